@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/export"
+	"stonks/internal/models"
+	"stonks/internal/web"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// openServer creates the application without starting the HTTP server or scheduler, so a
+// command uses the same services and the current database that the web application does
+func openServer() (*web.Server, error) {
+	server, err := web.NewServer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Wheeler: %w", err)
+	}
+	return server, nil
+}
+
+// runJob runs one of the background jobs and prints its message
+func runJob(name string) error {
+	server, err := openServer()
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	message, err := server.RunJob(name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(message)
+	return nil
+}
+
+func importCommand(args []string) error {
+	fs, opts := newFlagSet("import")
+	if err := opts.parse(fs, args, 2, 2); err != nil {
+		return err
+	}
+	importType, path := fs.Arg(0), fs.Arg(1)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	server, err := openServer()
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	batch, err := server.Import(importType, filepath.Base(path), file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d %s, skipped %d (batch %d)\n", batch.ImportedCount, importType, batch.SkippedCount, batch.ID)
+	return nil
+}
+
+func exportCommand(args []string) error {
+	fs, opts := newFlagSet("export")
+	format := fs.String("format", "zip", "zip (CSV files and JSON), ledger or beancount")
+	out := fs.String("out", "", "file to write, or - for standard output (default a dated file in the current directory)")
+	if err := opts.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var ledgerFormat export.LedgerFormat
+	if *format != "zip" {
+		var err error
+		if ledgerFormat, err = export.ParseLedgerFormat(*format); err != nil {
+			return err
+		}
+	}
+
+	dbName, err := database.GetCurrentDatabase()
+	if err != nil {
+		return err
+	}
+	db, err := openCurrentDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *out == "" {
+		base := strings.TrimSuffix(dbName, ".db")
+		if ledgerFormat == "" {
+			*out = fmt.Sprintf("%s_export_%s.zip", base, time.Now().Format("2006-01-02_15-04-05"))
+		} else {
+			*out = fmt.Sprintf("%s_%s.%s", base, time.Now().Format("2006-01-02"), ledgerFormat.FileExtension())
+		}
+	}
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	exporter := export.NewExporter(db.DB)
+	if ledgerFormat == "" {
+		if _, err := exporter.WriteZip(w, dbName); err != nil {
+			return err
+		}
+	} else {
+		doc, err := exporter.Collect(dbName)
+		if err != nil {
+			return err
+		}
+		accounts := export.LedgerAccountsFromConfig(models.NewConfigService(db.DB).GetValue)
+		if err := export.WriteLedger(w, doc, ledgerFormat, accounts); err != nil {
+			return err
+		}
+	}
+
+	if *out != "-" {
+		fmt.Printf("Exported %s to %s\n", dbName, *out)
+	}
+	return nil
+}
+
+func backupCommand(args []string) error {
+	fs, opts := newFlagSet("backup")
+	if err := opts.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	return runJob(web.JobBackup)
+}
+
+func migrateCommand(args []string) error {
+	fs, opts := newFlagSet("migrate")
+	all := fs.Bool("all", false, "migrate every database in the data directory, not just the current one")
+	if err := opts.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	names := []string{}
+	if *all {
+		var err error
+		if names, err = database.ListDatabases(); err != nil {
+			return err
+		}
+	} else {
+		name, err := database.GetCurrentDatabase()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		// Opening a database applies the schema and any pending migrations
+		db, err := database.NewDB(filepath.Join(database.DataDir(), name))
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", name, err)
+		}
+		var count int
+		var latest string
+		err = db.QueryRow(`SELECT COUNT(*), COALESCE(MAX(version), '') FROM schema_migrations`).Scan(&count, &latest)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read migrations of %s: %w", name, err)
+		}
+		fmt.Printf("%s: %d migrations applied, latest %s\n", name, count, latest)
+	}
+	return nil
+}
+
+func snapshotCommand(args []string) error {
+	fs, opts := newFlagSet("snapshot")
+	if err := opts.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	return runJob(web.JobMetricsSnapshot)
+}
+
+func pricesCommand(args []string) error {
+	fs, opts := newFlagSet("prices")
+	if err := opts.parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if fs.Arg(0) != "update" {
+		fs.Usage()
+		return errUsage
+	}
+	return runJob(web.JobPriceRefresh)
+}
+
+func reportCommand(args []string) error {
+	fs, opts := newFlagSet("report")
+	now := time.Now()
+	from := fs.String("from", now.AddDate(0, -11, 0).Format("2006-01"), "first month (YYYY-MM)")
+	to := fs.String("to", now.Format("2006-01"), "last month (YYYY-MM)")
+	format := fs.String("format", "text", "text or csv")
+	if err := opts.parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	if fs.Arg(0) != "monthly" || (*format != "text" && *format != "csv") {
+		fs.Usage()
+		return errUsage
+	}
+	for _, month := range []string{*from, *to} {
+		if _, err := time.Parse("2006-01", month); err != nil {
+			return fmt.Errorf("invalid month %q: use YYYY-MM", month)
+		}
+	}
+
+	server, err := openServer()
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	return writeMonthlyReport(os.Stdout, server.MonthlyReport(*from, *to), *format)
+}
+
+// writeMonthlyReport prints income by month with a total row, as an aligned table or CSV
+func writeMonthlyReport(w io.Writer, data web.MonthlyData, format string) error {
+	header := []string{"Month", "Puts", "Calls", "Cap Gains", "Dividends", "Total"}
+	var rows [][]string
+	var totals [5]float64
+	for i, total := range data.TotalsByMonth {
+		amounts := []float64{
+			data.PutsData.ByMonth[i].Amount,
+			data.CallsData.ByMonth[i].Amount,
+			data.CapGainsData.ByMonth[i].Amount,
+			data.DividendsData.ByMonth[i].Amount,
+			total.Amount,
+		}
+		rows = append(rows, monthlyReportRow(total.Month, amounts))
+		for j, amount := range amounts {
+			totals[j] += amount
+		}
+	}
+	rows = append(rows, monthlyReportRow("Total", totals[:]))
+
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, row := range append([][]string{header}, rows...) {
+		fmt.Fprintln(tw, strings.Join(row, "\t")+"\t")
+	}
+	return tw.Flush()
+}
+
+func monthlyReportRow(month string, amounts []float64) []string {
+	row := []string{month}
+	for _, amount := range amounts {
+		row = append(row, fmt.Sprintf("%.2f", amount))
+	}
+	return row
+}
+
+// openCurrentDatabase opens the current database, applying any pending migrations
+func openCurrentDatabase() (*database.DB, error) {
+	path, err := database.GetCurrentDatabasePath()
+	if err != nil {
+		return nil, err
+	}
+	return database.NewDB(path)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"stonks/internal/web"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	dataDir := t.TempDir()
+	csvPath := filepath.Join(t.TempDir(), "options.csv")
+	csv := "symbol,opened,closed,type,strike,expiration,premium,contracts,exit_price,commission\n" +
+		"VZ,2025-03-03,,Put,40,2025-04-17,0.85,2,,1.30\n"
+	if err := os.WriteFile(csvPath, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"migrate", "--data-dir", dataDir},
+		{"import", "--data-dir", dataDir, "options", csvPath},
+		{"backup", "--data-dir", dataDir},
+	} {
+		if err := run(args); err != nil {
+			t.Fatalf("wheeler %v failed: %v", args, err)
+		}
+	}
+	if err := run([]string{"import", "--data-dir", dataDir, "options"}); err != errUsage {
+		t.Errorf("Expected a usage error for a missing file, got %v", err)
+	}
+	if err := run([]string{"import", "--data-dir", dataDir, "bonds", csvPath}); err == nil {
+		t.Errorf("Expected an error for an unknown import type")
+	}
+
+	db, err := database.NewDB(filepath.Join(dataDir, "wheeler.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	options, err := models.NewOptionService(db.DB).GetAll()
+	if err != nil || len(options) != 1 || options[0].Symbol != "VZ" {
+		t.Errorf("Expected the imported VZ put, got %v (%v)", options, err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dataDir, "backups", "wheeler.*.db"))
+	if len(backups) != 1 {
+		t.Errorf("Expected one backup, got %v", backups)
+	}
+
+	// Settings also come from WHEELER_* variables, with flags taking precedence
+	backupDir := t.TempDir()
+	t.Setenv("WHEELER_DATA_DIR", dataDir)
+	t.Setenv("WHEELER_BACKUP_DIR", filepath.Join(dataDir, "ignored"))
+	if err := run([]string{"backup", "--backup-dir", backupDir}); err != nil {
+		t.Fatalf("wheeler backup --backup-dir failed: %v", err)
+	}
+	backups, _ = filepath.Glob(filepath.Join(backupDir, "wheeler.*.db"))
+	if len(backups) != 1 {
+		t.Errorf("Expected one backup in the backup directory, got %v", backups)
+	}
+}
+
+func TestWriteMonthlyReport(t *testing.T) {
+	data := web.MonthlyData{
+		PutsData:      web.MonthlyOptionData{ByMonth: []web.MonthlyChartData{{Month: "2025-03", Amount: 168.70}, {Month: "2025-04", Amount: 50}}},
+		CallsData:     web.MonthlyOptionData{ByMonth: []web.MonthlyChartData{{Month: "2025-03", Amount: 0}, {Month: "2025-04", Amount: 38.70}}},
+		CapGainsData:  web.MonthlyFinancialData{ByMonth: []web.MonthlyChartData{{Month: "2025-03", Amount: 0}, {Month: "2025-04", Amount: -20}}},
+		DividendsData: web.MonthlyFinancialData{ByMonth: []web.MonthlyChartData{{Month: "2025-03", Amount: 12.50}, {Month: "2025-04", Amount: 0}}},
+		TotalsByMonth: []web.MonthlyTotal{{Month: "2025-03", Amount: 181.20}, {Month: "2025-04", Amount: 68.70}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeMonthlyReport(&buf, data, "csv"); err != nil {
+		t.Fatalf("writeMonthlyReport failed: %v", err)
+	}
+	want := "Month,Puts,Calls,Cap Gains,Dividends,Total\n" +
+		"2025-03,168.70,0.00,0.00,12.50,181.20\n" +
+		"2025-04,50.00,38.70,-20.00,0.00,68.70\n" +
+		"Total,218.70,38.70,-20.00,12.50,249.90\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
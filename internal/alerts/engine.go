@@ -0,0 +1,232 @@
+// Package alerts evaluates user-defined alert rules against open positions and symbol
+// prices and records what they match in the alert inbox.
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"stonks/internal/models"
+	"time"
+)
+
+// Engine evaluates the enabled alert rules
+type Engine struct {
+	ruleService   *models.AlertRuleService
+	alertService  *models.AlertService
+	optionService *models.OptionService
+	symbolService *models.SymbolService
+}
+
+// NewEngine creates an engine reading positions and prices from the given services
+func NewEngine(ruleService *models.AlertRuleService, alertService *models.AlertService, optionService *models.OptionService, symbolService *models.SymbolService) *Engine {
+	return &Engine{
+		ruleService:   ruleService,
+		alertService:  alertService,
+		optionService: optionService,
+		symbolService: symbolService,
+	}
+}
+
+// match is one position or symbol that meets a rule
+type match struct {
+	subject string
+	symbol  string
+	message string
+}
+
+// Evaluate checks every enabled rule at now and returns the alerts that are new or have
+// come back from a snooze. Matches already in the inbox are refreshed but not returned.
+func (e *Engine) Evaluate(now time.Time) ([]*models.Alert, error) {
+	rules, err := e.ruleService.GetEnabled()
+	if err != nil {
+		return nil, err
+	}
+	options, err := e.optionService.GetOpen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open options: %w", err)
+	}
+	symbols, err := e.symbolService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[string]*models.Symbol, len(symbols))
+	for _, symbol := range symbols {
+		bySymbol[symbol.Symbol] = symbol
+	}
+
+	var raised []*models.Alert
+	for _, rule := range rules {
+		matches := matchRule(rule, options, bySymbol, now)
+		subjects := make(map[string]bool, len(matches))
+		for _, m := range matches {
+			subjects[m.subject] = true
+			alert, isNew, err := e.alertService.Raise(rule.ID, m.subject, m.symbol, m.message, now)
+			if err != nil {
+				return raised, err
+			}
+			if isNew {
+				raised = append(raised, alert)
+			}
+		}
+		if _, err := e.alertService.ClearResolved(rule.ID, subjects); err != nil {
+			return raised, err
+		}
+	}
+
+	log.Printf("[ALERTS] Evaluated %d rules against %d open options: %d alerts raised", len(rules), len(options), len(raised))
+	return raised, nil
+}
+
+// matchRule returns what a rule matches among the open options and symbols
+func matchRule(rule *models.AlertRule, options []*models.Option, symbols map[string]*models.Symbol, now time.Time) []match {
+	var matches []match
+	switch rule.Type {
+	case models.AlertRuleDTEBelow, models.AlertRuleITMPercent, models.AlertRuleProfitCaptured:
+		for _, option := range options {
+			if rule.Symbol != "" && option.Symbol != rule.Symbol {
+				continue
+			}
+			if message, ok := matchOption(rule, option, symbols[option.Symbol], now); ok {
+				matches = append(matches, match{
+					subject: fmt.Sprintf("option:%d", option.ID),
+					symbol:  option.Symbol,
+					message: message,
+				})
+			}
+		}
+
+	case models.AlertRulePriceAbove, models.AlertRulePriceBelow:
+		symbol := symbols[rule.Symbol]
+		if symbol == nil || symbol.Price <= 0 {
+			break
+		}
+		if rule.Type == models.AlertRulePriceAbove && symbol.Price >= rule.Threshold {
+			matches = append(matches, match{
+				subject: "symbol:" + symbol.Symbol,
+				symbol:  symbol.Symbol,
+				message: fmt.Sprintf("%s at $%.2f is at or above $%.2f", symbol.Symbol, symbol.Price, rule.Threshold),
+			})
+		}
+		if rule.Type == models.AlertRulePriceBelow && symbol.Price <= rule.Threshold {
+			matches = append(matches, match{
+				subject: "symbol:" + symbol.Symbol,
+				symbol:  symbol.Symbol,
+				message: fmt.Sprintf("%s at $%.2f is at or below $%.2f", symbol.Symbol, symbol.Price, rule.Threshold),
+			})
+		}
+
+	case models.AlertRuleExDividendCall:
+		calls := make(map[string]int)
+		var callSymbols []string
+		for _, option := range options {
+			if option.Type == "Call" && !option.Expiration.Before(startOfDay(now)) {
+				if calls[option.Symbol] == 0 {
+					callSymbols = append(callSymbols, option.Symbol)
+				}
+				calls[option.Symbol] += option.Contracts
+			}
+		}
+		sort.Strings(callSymbols)
+		for _, symbolName := range callSymbols {
+			contracts := calls[symbolName]
+			if rule.Symbol != "" && symbolName != rule.Symbol {
+				continue
+			}
+			symbol := symbols[symbolName]
+			if symbol == nil || symbol.ExDividendDate == nil {
+				continue
+			}
+			days := daysBetween(now, *symbol.ExDividendDate)
+			if days < 0 || float64(days) > rule.Threshold {
+				continue
+			}
+			exDate := symbol.ExDividendDate.Format("2006-01-02")
+			matches = append(matches, match{
+				subject: "exdiv:" + symbolName + ":" + exDate,
+				symbol:  symbolName,
+				message: fmt.Sprintf("%s goes ex-dividend %s (%s) with %d short call contract%s open",
+					symbolName, symbol.ExDividendDate.Format("01/02/2006"), dayCount(days), contracts, plural(contracts)),
+			})
+		}
+
+	case models.AlertRuleEarlyAssignment:
+		// Raised per call when the risk appears, even if the symbol's ex-date alert was already seen
+		for _, option := range options {
+			if rule.Symbol != "" && option.Symbol != rule.Symbol {
+				continue
+			}
+			risk := option.EarlyAssignmentRisk(symbols[option.Symbol], now)
+			if risk == nil || float64(daysBetween(now, risk.ExDividendDate)) > rule.Threshold {
+				continue
+			}
+			matches = append(matches, match{
+				subject: fmt.Sprintf("assignment:%d:%s", option.ID, risk.ExDividendDate.Format("2006-01-02")),
+				symbol:  option.Symbol,
+				message: fmt.Sprintf("%s $%.2f Call expiring %s is likely to be assigned early before the %s ex-date: $%.2f of extrinsic value left against a $%.2f dividend",
+					option.Symbol, option.Strike, option.Expiration.Format("01/02/2006"), risk.ExDividendDate.Format("01/02/2006"), risk.Extrinsic, risk.Dividend),
+			})
+		}
+	}
+	return matches
+}
+
+// matchOption checks an option rule against one open option
+func matchOption(rule *models.AlertRule, option *models.Option, symbol *models.Symbol, now time.Time) (string, bool) {
+	describe := fmt.Sprintf("%s $%.2f %s expiring %s", option.Symbol, option.Strike, option.Type, option.Expiration.Format("01/02/2006"))
+
+	switch rule.Type {
+	case models.AlertRuleDTEBelow:
+		// Same rounding as the options page: an option expiring tomorrow has 1 day left
+		dte := int(math.Ceil(option.Expiration.Sub(now).Hours() / 24))
+		if dte >= 0 && float64(dte) <= rule.Threshold {
+			if dte == 0 {
+				return describe + " expires today", true
+			}
+			return fmt.Sprintf("%s has %d day%s left", describe, dte, plural(dte)), true
+		}
+
+	case models.AlertRuleITMPercent:
+		if symbol == nil {
+			return "", false
+		}
+		itm := option.CalculatePercentITM(symbol.Price)
+		if itm > 0 && itm >= rule.Threshold {
+			return fmt.Sprintf("%s is %.1f%% in the money with %s at $%.2f", describe, itm, option.Symbol, symbol.Price), true
+		}
+
+	case models.AlertRuleProfitCaptured:
+		if !option.HasMark() {
+			return "", false
+		}
+		if captured := option.CalculatePercentOfProfit(); captured >= rule.Threshold {
+			return fmt.Sprintf("%s has captured %.0f%% of max profit at a mark of $%.2f", describe, captured, *option.CurrentPrice), true
+		}
+	}
+	return "", false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts calendar days from now to date, ignoring the time of day
+func daysBetween(now, date time.Time) int {
+	return int(startOfDay(date).Sub(startOfDay(now)).Hours() / 24)
+}
+
+// dayCount describes a number of days from now, such as "today" or "in 3 days"
+func dayCount(days int) string {
+	if days == 0 {
+		return "today"
+	}
+	return fmt.Sprintf("in %d day%s", days, plural(days))
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,154 @@
+package alerts
+
+import (
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEngineEvaluate(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	db := testDB.DB
+	rules := models.NewAlertRuleService(db)
+	alerts := models.NewAlertService(db)
+	options := models.NewOptionService(db)
+	symbols := models.NewSymbolService(db)
+	engine := NewEngine(rules, alerts, options, symbols)
+
+	now := time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC)
+	exDate := time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)
+	for _, symbol := range []string{"VZ", "KO"} {
+		if _, err := symbols.Create(symbol); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+	}
+	symbols.Update("VZ", 38, 0.6775, &exDate, nil)
+	symbols.Update("KO", 62, 0.485, nil, nil)
+
+	// A put 5% in the money expiring in 4 days, marked at a quarter of its premium (74% captured after commission), and a covered call
+	put, err := options.Create("VZ", "Put", now.AddDate(0, 0, -30), 40, now.AddDate(0, 0, 4), 1.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if err := options.UpdateCurrentPrice(put.ID, 0.25, now); err != nil {
+		t.Fatalf("Failed to mark put: %v", err)
+	}
+	if _, err := options.Create("VZ", "Call", now.AddDate(0, 0, -10), 42, now.AddDate(0, 0, 25), 0.50, 2); err != nil {
+		t.Fatalf("Failed to create call: %v", err)
+	}
+	// An in the money call with $0.20 of extrinsic value left, less than the $0.6775 dividend
+	itmCall, err := options.Create("VZ", "Call", now.AddDate(0, 0, -10), 37, now.AddDate(0, 0, 11), 1.30, 1)
+	if err != nil {
+		t.Fatalf("Failed to create ITM call: %v", err)
+	}
+	if err := options.UpdateCurrentPrice(itmCall.ID, 1.20, now); err != nil {
+		t.Fatalf("Failed to mark ITM call: %v", err)
+	}
+	if _, err := options.Create("KO", "Put", now.AddDate(0, 0, -10), 55, now.AddDate(0, 0, 40), 0.80, 1); err != nil {
+		t.Fatalf("Failed to create KO put: %v", err)
+	}
+
+	for _, rule := range []*models.AlertRule{
+		{Name: "Expiring", Type: models.AlertRuleDTEBelow, Threshold: 7, Enabled: true},
+		{Name: "Deep ITM", Type: models.AlertRuleITMPercent, Threshold: 5, Enabled: true},
+		{Name: "Take profit", Type: models.AlertRuleProfitCaptured, Threshold: 50, Enabled: true},
+		{Name: "KO breakout", Type: models.AlertRulePriceAbove, Symbol: "ko", Threshold: 60, Enabled: true},
+		{Name: "VZ support", Type: models.AlertRulePriceBelow, Symbol: "VZ", Threshold: 35, Enabled: true},
+		{Name: "Dividend capture", Type: models.AlertRuleExDividendCall, Threshold: 5, Enabled: true},
+		{Name: "Early assignment", Type: models.AlertRuleEarlyAssignment, Threshold: 5, Enabled: true},
+		{Name: "Disabled", Type: models.AlertRuleDTEBelow, Threshold: 60, Enabled: false},
+	} {
+		if _, err := rules.Create(rule); err != nil {
+			t.Fatalf("Failed to create rule %s: %v", rule.Name, err)
+		}
+	}
+	if _, err := rules.Create(&models.AlertRule{Name: "No symbol", Type: models.AlertRulePriceAbove, Threshold: 10}); err == nil {
+		t.Error("Expected price rule without a symbol to be rejected")
+	}
+
+	raised, err := engine.Evaluate(now)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	messages := make(map[string]string)
+	for _, alert := range raised {
+		messages[alert.RuleName] += alert.Message + "\n"
+	}
+	if len(raised) != 6 || messages["VZ support"] != "" {
+		t.Fatalf("Expected 6 alerts and none from VZ support, got %v", messages)
+	}
+	for _, tt := range []struct{ rule, want string }{
+		{"Expiring", "has 4 days left"},
+		{"Deep ITM", "5.3% in the money"},
+		{"Take profit", "captured 74% of max profit"},
+		{"KO breakout", "KO at $62.00 is at or above $60.00"},
+		{"Dividend capture", "VZ goes ex-dividend 01/09/2025 (in 3 days) with 3 short call contracts open"},
+		{"Early assignment", "VZ $37.00 Call expiring 01/17/2025 is likely to be assigned early before the 01/09/2025 ex-date: $0.20 of extrinsic value left against a $0.68 dividend"},
+	} {
+		if !strings.Contains(messages[tt.rule], tt.want) {
+			t.Errorf("Expected %s alerts to contain %q, got %q", tt.rule, tt.want, messages[tt.rule])
+		}
+	}
+
+	// A second pass raises nothing new
+	if raised, err := engine.Evaluate(now.Add(time.Hour)); err != nil || len(raised) != 0 {
+		t.Fatalf("Expected no new alerts on the second pass, got %d (%v)", len(raised), err)
+	}
+
+	inbox, err := alerts.GetAll(models.AlertStateNew)
+	if err != nil || len(inbox) != 6 {
+		t.Fatalf("Expected 6 new alerts, got %d (%v)", len(inbox), err)
+	}
+	byRule := make(map[string]*models.Alert)
+	for _, alert := range inbox {
+		byRule[alert.RuleName] = alert
+	}
+
+	// Acknowledged alerts stay quiet while the condition holds and clear once it does not
+	if err := alerts.Acknowledge(byRule["KO breakout"].ID); err != nil {
+		t.Fatalf("Acknowledge failed: %v", err)
+	}
+	// A snoozed alert comes back once the snooze passes
+	if err := alerts.Snooze(byRule["Expiring"].ID, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Snooze failed: %v", err)
+	}
+	if raised, _ := engine.Evaluate(now.Add(2 * time.Hour)); len(raised) != 0 {
+		t.Errorf("Expected acknowledged and snoozed alerts to stay quiet, got %d", len(raised))
+	}
+
+	symbols.Update("KO", 58, 0.485, nil, nil)
+	if _, err := engine.Evaluate(now.Add(3 * time.Hour)); err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if _, err := alerts.GetByID(byRule["KO breakout"].ID); err == nil {
+		t.Error("Expected the acknowledged KO alert to clear when the price fell back")
+	}
+	symbols.Update("KO", 61, 0.485, nil, nil)
+	raised, err = engine.Evaluate(now.Add(25 * time.Hour))
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(raised) != 2 {
+		t.Fatalf("Expected KO to cross again and the snooze to end, got %d alerts", len(raised))
+	}
+	if count, err := alerts.CountNew(); err != nil || count != 6 {
+		t.Errorf("Expected 6 new alerts, got %d (%v)", count, err)
+	}
+
+	// Deleting a rule removes its alerts
+	if err := rules.Delete(byRule["Take profit"].RuleID); err != nil {
+		t.Fatalf("Delete rule failed: %v", err)
+	}
+	if count, _ := alerts.CountNew(); count != 5 {
+		t.Errorf("Expected 5 new alerts after deleting a rule, got %d", count)
+	}
+}
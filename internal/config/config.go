@@ -0,0 +1,281 @@
+// Package config loads Wheeler's process settings: listen address, data and backup
+// directories, log level, default database and dev mode. Each comes from the first of a command-line
+// flag, a WHEELER_* environment variable, the config file and the built-in default.
+//
+// The config file is optional. It is read from --config or WHEELER_CONFIG, otherwise from
+// wheeler.toml, wheeler.yaml or wheeler.yml in the working directory when one exists. Only
+// flat key/value files are supported, which is all these settings need:
+//
+//	# wheeler.toml             # wheeler.yaml
+//	addr = ":8080"             addr: ":8080"
+//	data_dir = "/var/lib/wheeler"  data_dir: /var/lib/wheeler
+package config
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"strings"
+)
+
+// Log levels, from most to least verbose
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+	LogOff   = "off"
+)
+
+var logLevels = []string{LogDebug, LogInfo, LogWarn, LogError, LogOff}
+
+// defaultFiles are the config files looked for in the working directory
+var defaultFiles = []string{"wheeler.toml", "wheeler.yaml", "wheeler.yml"}
+
+// Config holds the process settings
+type Config struct {
+	Addr      string // Address the web server listens on
+	DataDir   string // Directory holding the databases
+	BackupDir string // Directory for database backups; blank means backups in the data directory
+	LogLevel  string
+	Database  string // Database to open when none has been selected yet
+	DevDir    string // Source checkout to read templates and static assets from, for development
+	File      string // Config file the settings were read from, if any
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	return Config{
+		Addr:     ":8080",
+		DataDir:  database.DefaultDataDir,
+		LogLevel: LogInfo,
+		Database: database.DefaultDatabase,
+	}
+}
+
+// setting names one setting in each source
+type setting struct {
+	key    string // Config file key
+	env    string
+	flag   string
+	usage  string
+	field  func(c *Config) *string
+	server bool // Only the web server has the flag
+}
+
+var settings = []setting{
+	{"addr", "WHEELER_ADDR", "addr", "address to listen on", func(c *Config) *string { return &c.Addr }, true},
+	{"data_dir", "WHEELER_DATA_DIR", "data-dir", "directory holding the databases", func(c *Config) *string { return &c.DataDir }, false},
+	{"backup_dir", "WHEELER_BACKUP_DIR", "backup-dir", "directory for database backups (default <data-dir>/backups)", func(c *Config) *string { return &c.BackupDir }, false},
+	{"log_level", "WHEELER_LOG_LEVEL", "log-level", "log level: " + strings.Join(logLevels, ", "), func(c *Config) *string { return &c.LogLevel }, false},
+	{"database", "WHEELER_DATABASE", "database", "database to open when none has been selected", func(c *Config) *string { return &c.Database }, false},
+	{"dev_dir", "WHEELER_DEV_DIR", "dev-dir", "dev mode: read templates, static files and example SQL from this source checkout on every request", func(c *Config) *string { return &c.DevDir }, true},
+}
+
+// Flags are the command-line flags for the settings, registered on a flag set
+type Flags struct {
+	fs     *flag.FlagSet
+	config *string
+	values map[string]*string
+}
+
+// AddFlags registers --config and a flag for every setting except the web server's; servers
+// add those with AddServerFlags
+func AddFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{
+		fs:     fs,
+		config: fs.String("config", "", "config file (default wheeler.toml or wheeler.yaml in the working directory, if present)"),
+		values: make(map[string]*string),
+	}
+	for _, s := range settings {
+		if !s.server {
+			f.values[s.flag] = fs.String(s.flag, "", s.usage)
+		}
+	}
+	return f
+}
+
+// AddServerFlags registers the web server's flags, --addr and --dev-dir
+func (f *Flags) AddServerFlags() {
+	for _, s := range settings {
+		if s.server {
+			f.values[s.flag] = f.fs.String(s.flag, "", s.usage)
+		}
+	}
+}
+
+// Load builds the settings once the flag set has been parsed, starting from defaults
+func (f *Flags) Load(defaults Config) (*Config, error) {
+	cfg := defaults
+
+	path := *f.config
+	if path == "" {
+		path = os.Getenv("WHEELER_CONFIG")
+	}
+	if path == "" {
+		for _, name := range defaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			*s.field(&cfg) = value
+		}
+	}
+
+	// Only flags given on the command line override the other sources
+	f.fs.Visit(func(fl *flag.Flag) {
+		for _, s := range settings {
+			if value, ok := f.values[s.flag]; ok && fl.Name == s.flag {
+				*s.field(&cfg) = *value
+			}
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile reads a flat TOML (key = value) or YAML (key: value) file, chosen by extension
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	separator := "="
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		separator = ":"
+	}
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, separator)
+		if !ok {
+			return fmt.Errorf("%s:%d: expected key %s value", path, lineNumber, separator)
+		}
+		key, value = strings.TrimSpace(key), unquote(strings.TrimSpace(value))
+
+		found := false
+		for _, s := range settings {
+			if s.key == key {
+				*s.field(c) = value
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, lineNumber, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	c.File = path
+	return nil
+}
+
+// stripComment removes a # comment that is not inside quotes
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Validate checks the log level and that the directories and database are set
+func (c *Config) Validate() error {
+	c.LogLevel = strings.ToLower(strings.TrimSpace(c.LogLevel))
+	valid := false
+	for _, level := range logLevels {
+		valid = valid || c.LogLevel == level
+	}
+	if !valid {
+		return fmt.Errorf("invalid log level %q: use %s", c.LogLevel, strings.Join(logLevels, ", "))
+	}
+	if c.DataDir == "" {
+		return fmt.Errorf("data directory is required")
+	}
+	if c.Database == "" || strings.ContainsAny(c.Database, `/\`) {
+		return fmt.Errorf("invalid database %q: use a file name in the data directory", c.Database)
+	}
+	return nil
+}
+
+// Apply points the database package at the configured directories and database and sets
+// the log output for the level
+func (c *Config) Apply() {
+	database.SetDataDir(c.DataDir)
+	database.SetBackupDir(c.BackupDir)
+	database.SetDefaultDatabase(c.Database)
+	log.SetOutput(LogWriter(c.LogLevel, os.Stderr))
+}
+
+// LogWriter filters log output by level. Wheeler's log lines carry no level, so warn keeps
+// lines that mention a warning, error or failure and error keeps those about errors and
+// failures; debug and info keep everything.
+func LogWriter(level string, w io.Writer) io.Writer {
+	switch level {
+	case LogOff:
+		return io.Discard
+	case LogWarn:
+		return &filterWriter{w: w, keywords: []string{"warn", "error", "fail"}}
+	case LogError:
+		return &filterWriter{w: w, keywords: []string{"error", "fail"}}
+	}
+	return w
+}
+
+// filterWriter passes on the log entries containing one of its keywords; the log package
+// writes each entry with a single call
+type filterWriter struct {
+	w        io.Writer
+	keywords []string
+}
+
+func (f *filterWriter) Write(p []byte) (int, error) {
+	entry := strings.ToLower(string(p))
+	for _, keyword := range f.keywords {
+		if strings.Contains(entry, keyword) {
+			return f.w.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadArgs(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := AddFlags(fs)
+	flags.AddServerFlags()
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Failed to parse %v: %v", args, err)
+	}
+	return flags.Load(Default())
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	toml := writeFile(t, "wheeler.toml", `# Wheeler settings
+addr = "127.0.0.1:9000"   # loopback only
+data_dir = '/var/lib/wheeler'
+backup_dir = /mnt/backups/#wheeler
+log_level = "WARN"
+`)
+	yaml := writeFile(t, "wheeler.yaml", `---
+addr: "127.0.0.1:9000"   # loopback only
+data_dir: /var/lib/wheeler
+backup_dir: "/mnt/backups/#wheeler"
+log_level: warn
+`)
+
+	for _, path := range []string{toml, yaml} {
+		cfg, err := loadArgs(t, "--config", path)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", path, err)
+		}
+		want := Config{
+			Addr:      "127.0.0.1:9000",
+			DataDir:   "/var/lib/wheeler",
+			BackupDir: "/mnt/backups/",
+			LogLevel:  LogWarn,
+			Database:  "wheeler.db",
+			File:      path,
+		}
+		if filepath.Ext(path) == ".yaml" {
+			want.BackupDir = "/mnt/backups/#wheeler"
+		}
+		if *cfg != want {
+			t.Errorf("Expected %+v from %s, got %+v", want, filepath.Base(path), *cfg)
+		}
+	}
+
+	for content, wantErr := range map[string]string{
+		"port = 8080\n":         `:1: unknown setting "port"`,
+		"addr = ':80'\nnope\n":  ":2: expected key = value",
+		"log_level = verbose\n": `invalid log level "verbose"`,
+		"database = ../x.db\n":  `invalid database "../x.db"`,
+	} {
+		if _, err := loadArgs(t, "--config", writeFile(t, "bad.toml", content)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Expected an error containing %q for %q, got %v", wantErr, content, err)
+		}
+	}
+	if _, err := loadArgs(t, "--config", filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Errorf("Expected an error for a missing config file")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "wheeler.toml", "addr = \":9000\"\ndata_dir = \"/from/file\"\ndatabase = \"file.db\"\n")
+	t.Setenv("WHEELER_CONFIG", path)
+	t.Setenv("WHEELER_DATA_DIR", "/from/env")
+	t.Setenv("WHEELER_DATABASE", "env.db")
+	t.Setenv("WHEELER_LOG_LEVEL", "")
+
+	cfg, err := loadArgs(t, "--database", "flag.db")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	// Flags beat environment variables, which beat the file, which beats the defaults
+	if cfg.Addr != ":9000" || cfg.DataDir != "/from/env" || cfg.Database != "flag.db" || cfg.LogLevel != LogInfo {
+		t.Errorf("Unexpected settings %+v", *cfg)
+	}
+	if cfg.File != path {
+		t.Errorf("Expected the file from WHEELER_CONFIG, got %q", cfg.File)
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(LogWriter(LogError, &buf), "", 0)
+	logger.Printf("[SERVER] Route registered: /")
+	logger.Printf("[BACKUP] Warning: old backups not pruned")
+	logger.Printf("[SCHEDULER] Job backup failed: disk full")
+	if buf.String() != "[SCHEDULER] Job backup failed: disk full\n" {
+		t.Errorf("Expected only the failure at error level, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.SetOutput(LogWriter(LogWarn, &buf))
+	logger.Printf("[SERVER] Route registered: /")
+	logger.Printf("[BACKUP] Warning: old backups not pruned")
+	if buf.String() != "[BACKUP] Warning: old backups not pruned\n" {
+		t.Errorf("Expected only the warning at warn level, got %q", buf.String())
+	}
+}
@@ -0,0 +1,84 @@
+// Package cusip validates CUSIP security identifiers.
+//
+// A CUSIP is nine characters: a six-character issuer code, a two-character issue
+// code and a check digit computed with the "double add double" (modulus 10) scheme.
+// US Treasury securities all use the 912 issuer prefix (e.g. 912797RT1 for a bill).
+package cusip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Length is the number of characters in a CUSIP including the check digit
+const Length = 9
+
+// TreasuryPrefix is the issuer prefix shared by US Treasury bills, notes and bonds
+const TreasuryPrefix = "912"
+
+// Normalize upper-cases and trims a CUSIP without validating it
+func Normalize(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+// CheckDigit computes the check digit for the first eight characters of a CUSIP
+func CheckDigit(base string) (byte, error) {
+	base = Normalize(base)
+	if len(base) != Length-1 {
+		return 0, fmt.Errorf("CUSIP base must be %d characters, got %d", Length-1, len(base))
+	}
+
+	sum := 0
+	for i := 0; i < len(base); i++ {
+		v, err := charValue(base[i])
+		if err != nil {
+			return 0, err
+		}
+		// Every second character is doubled
+		if i%2 == 1 {
+			v *= 2
+		}
+		sum += v/10 + v%10
+	}
+
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// Validate checks the length, character set and check digit of a CUSIP
+func Validate(s string) error {
+	s = Normalize(s)
+	if len(s) != Length {
+		return fmt.Errorf("CUSIP %q must be %d characters", s, Length)
+	}
+
+	want, err := CheckDigit(s[:Length-1])
+	if err != nil {
+		return fmt.Errorf("CUSIP %q: %w", s, err)
+	}
+	if s[Length-1] != want {
+		return fmt.Errorf("CUSIP %q has invalid check digit %c (expected %c)", s, s[Length-1], want)
+	}
+	return nil
+}
+
+// IsTreasury reports whether a CUSIP belongs to the US Treasury
+func IsTreasury(s string) bool {
+	return strings.HasPrefix(Normalize(s), TreasuryPrefix)
+}
+
+func charValue(c byte) (int, error) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), nil
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, nil
+	case c == '*':
+		return 36, nil
+	case c == '@':
+		return 37, nil
+	case c == '#':
+		return 38, nil
+	default:
+		return 0, fmt.Errorf("invalid character %q", c)
+	}
+}
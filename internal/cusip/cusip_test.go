@@ -0,0 +1,54 @@
+package cusip
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"037833100", // Apple
+		"38259P508", // Google (letter in issue code)
+		"912828U24", // Treasury note
+		"91282CJL6", // Treasury note
+		"912810TM0", // Treasury bond
+		" 912797rt6 ",
+	}
+	for _, s := range valid {
+		if err := Validate(s); err != nil {
+			t.Errorf("Validate(%q) returned error: %v", s, err)
+		}
+	}
+
+	invalid := []string{
+		"912797RT1",  // wrong check digit
+		"91282CJL",   // too short
+		"91282CJL60", // too long
+		"91282C-L6",  // invalid character
+		"",
+	}
+	for _, s := range invalid {
+		if err := Validate(s); err == nil {
+			t.Errorf("Validate(%q) expected error", s)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := map[string]byte{"03783310": '0', "38259P50": '8', "912797RT": '6', "9127975T": '0'}
+	for base, want := range tests {
+		got, err := CheckDigit(base)
+		if err != nil {
+			t.Fatalf("CheckDigit(%q) returned error: %v", base, err)
+		}
+		if got != want {
+			t.Errorf("CheckDigit(%q) = %c, want %c", base, got, want)
+		}
+	}
+}
+
+func TestIsTreasury(t *testing.T) {
+	if !IsTreasury("912797RT6") {
+		t.Error("Expected 912797RT6 to be a Treasury CUSIP")
+	}
+	if IsTreasury("037833100") {
+		t.Error("Expected 037833100 not to be a Treasury CUSIP")
+	}
+}
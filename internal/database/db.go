@@ -17,10 +17,62 @@ var schemaFS embed.FS
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// ExampleSQL is the example wheel strategy portfolio loaded by Generate Test Data
+//
+//go:embed wheel_strategy_example_clean.sql
+var ExampleSQL string
+
 type DB struct {
 	*sql.DB
 }
 
+// DefaultDataDir is where databases, backups and the current database marker are kept
+const DefaultDataDir = "./data"
+
+var dataDir = DefaultDataDir
+
+// SetDataDir changes the data directory; it must be called before any database is opened
+func SetDataDir(dir string) {
+	if dir == "" {
+		dir = DefaultDataDir
+	}
+	dataDir = dir
+}
+
+// DataDir returns the data directory
+func DataDir() string {
+	return dataDir
+}
+
+// DefaultDatabase is the database opened when none has been selected yet
+const DefaultDatabase = "wheeler.db"
+
+var (
+	backupDir       string
+	defaultDatabase = DefaultDatabase
+)
+
+// SetBackupDir changes where backups are kept; blank keeps them in the data directory
+func SetBackupDir(dir string) {
+	backupDir = dir
+}
+
+// BackupDir returns the backup directory
+func BackupDir() string {
+	if backupDir == "" {
+		return filepath.Join(dataDir, "backups")
+	}
+	return backupDir
+}
+
+// SetDefaultDatabase changes the database opened when currentdb has not been written yet
+func SetDefaultDatabase(name string) {
+	if name == "" {
+		name = DefaultDatabase
+	}
+	defaultDatabase = name
+}
+
 func NewDB(dataSourceName string) (*DB, error) {
 	// Add SQLite connection parameters for better reliability
 	connStr := dataSourceName + "?_busy_timeout=10000&_journal_mode=WAL&_foreign_keys=on"
@@ -104,22 +156,72 @@ func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
-// GetCurrentDatabase reads the current database filename from ./data/currentdb
+// OpenReadOnly opens an existing database for reading only: it is neither created nor
+// migrated, and SQLite rejects any write. A database behind the built-in migrations is
+// refused, since queries would not match its schema.
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_busy_timeout=10000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(4)
+
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if pending > 0 {
+		db.Close()
+		return nil, fmt.Errorf("database needs %d migrations; open it in Wheeler or run wheeler migrate --all", pending)
+	}
+
+	return &DB{DB: db}, nil
+}
+
+// pendingMigrations counts the built-in migrations not yet applied to db
+func pendingMigrations(db *sql.DB) (int, error) {
+	migrationFiles, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	pending := 0
+	for _, file := range migrationFiles {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+			continue
+		}
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?",
+			strings.TrimSuffix(file.Name(), ".sql")).Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to check migration %s: %w", file.Name(), err)
+		}
+		if count == 0 {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// GetCurrentDatabase reads the current database filename from currentdb in the data directory
 func GetCurrentDatabase() (string, error) {
 	// Ensure data directory exists
-	if err := os.MkdirAll("./data", 0755); err != nil {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	currentDBPath := "./data/currentdb"
+	currentDBPath := filepath.Join(dataDir, "currentdb")
 	
 	// Check if currentdb file exists
 	if _, err := os.Stat(currentDBPath); os.IsNotExist(err) {
-		// Create default currentdb file with wheeler.db
-		if err := os.WriteFile(currentDBPath, []byte("wheeler.db"), 0644); err != nil {
+		// Create default currentdb file with the default database
+		if err := os.WriteFile(currentDBPath, []byte(defaultDatabase), 0644); err != nil {
 			return "", fmt.Errorf("failed to create currentdb file: %w", err)
 		}
-		return "wheeler.db", nil
+		return defaultDatabase, nil
 	}
 
 	// Read the current database name
@@ -130,20 +232,20 @@ func GetCurrentDatabase() (string, error) {
 
 	dbName := strings.TrimSpace(string(data))
 	if dbName == "" {
-		dbName = "wheeler.db"
+		dbName = defaultDatabase
 	}
 
 	return dbName, nil
 }
 
-// SetCurrentDatabase writes the current database filename to ./data/currentdb
+// SetCurrentDatabase writes the current database filename to currentdb in the data directory
 func SetCurrentDatabase(dbName string) error {
 	// Ensure data directory exists
-	if err := os.MkdirAll("./data", 0755); err != nil {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	currentDBPath := "./data/currentdb"
+	currentDBPath := filepath.Join(dataDir, "currentdb")
 	if err := os.WriteFile(currentDBPath, []byte(dbName), 0644); err != nil {
 		return fmt.Errorf("failed to write currentdb file: %w", err)
 	}
@@ -158,13 +260,13 @@ func GetCurrentDatabasePath() (string, error) {
 		return "", err
 	}
 	
-	return filepath.Join("./data", dbName), nil
+	return filepath.Join(dataDir, dbName), nil
 }
 
 // CreateNewDatabase creates a new SQLite database in the data directory
 func CreateNewDatabase(name string) error {
 	// Ensure data directory exists
-	if err := os.MkdirAll("./data", 0755); err != nil {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
@@ -173,7 +275,7 @@ func CreateNewDatabase(name string) error {
 		name = name + ".db"
 	}
 
-	dbPath := filepath.Join("./data", name)
+	dbPath := filepath.Join(dataDir, name)
 	
 	// Check if database already exists
 	if _, err := os.Stat(dbPath); err == nil {
@@ -191,8 +293,6 @@ func CreateNewDatabase(name string) error {
 
 // ListDatabases returns a list of all .db files in the data directory
 func ListDatabases() ([]string, error) {
-	dataDir := "./data"
-	
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
@@ -2,6 +2,8 @@ package database
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -47,6 +49,16 @@ func TestMigrationSystem(t *testing.T) {
 			"treasuries",
 			"settings",
 			"metrics",
+			"import_batches",
+			"price_history",
+			"job_runs",
+			"api_cache",
+			"earnings_dates",
+			"watchlist",
+			"alert_rules",
+			"alerts",
+			"notification_channels",
+			"notification_deliveries",
 		}
 
 		for _, table := range expectedTables {
@@ -76,6 +88,18 @@ func TestMigrationSystem(t *testing.T) {
 			"idx_metrics_type",
 			"idx_options_unique",
 			"idx_dividends_unique",
+			"idx_import_batches_hash",
+			"idx_options_import_batch",
+			"idx_long_positions_import_batch",
+			"idx_dividends_import_batch",
+			"idx_treasuries_import_batch",
+			"idx_options_occ_symbol",
+			"idx_price_history_import_batch",
+			"idx_job_runs_job_started",
+			"idx_api_cache_expires",
+			"idx_earnings_dates_date",
+			"idx_alerts_state",
+			"idx_notification_deliveries_created",
 		}
 
 		for _, index := range expectedIndexes {
@@ -101,6 +125,28 @@ func TestMigrationSystem(t *testing.T) {
 		}
 	})
 
+	t.Run("options table has occ_symbol column", func(t *testing.T) {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('options') WHERE name='occ_symbol'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check for occ_symbol column: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected options.occ_symbol column to exist")
+		}
+	})
+
+	t.Run("options table has current_price_updated_at column", func(t *testing.T) {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('options') WHERE name='current_price_updated_at'").Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check for current_price_updated_at column: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected options.current_price_updated_at column to exist")
+		}
+	})
+
 	t.Run("migrations are idempotent", func(t *testing.T) {
 		// Run migrations again - should not fail
 		err := db.runMigrations()
@@ -114,8 +160,12 @@ func TestMigrationSystem(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to query schema_migrations: %v", err)
 		}
-		if count != 1 {
-			t.Errorf("Expected only 1 migration record after re-running migrations, got %d", count)
+		migrationFiles, err := migrationsFS.ReadDir("migrations")
+		if err != nil {
+			t.Fatalf("Failed to read migrations directory: %v", err)
+		}
+		if count != len(migrationFiles) {
+			t.Errorf("Expected %d migration records after re-running migrations, got %d", len(migrationFiles), count)
 		}
 	})
 }
@@ -151,3 +201,49 @@ func TestCurrentDatabaseManagement(t *testing.T) {
 		}
 	})
 }
+
+func TestOpenReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "portfolio.db")
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO symbols (symbol) VALUES ('VZ')"); err != nil {
+		t.Fatalf("Failed to insert symbol: %v", err)
+	}
+	db.Close()
+
+	ro, err := OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	var count int
+	if err := ro.QueryRow("SELECT COUNT(*) FROM symbols").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected to read one symbol, got %d (%v)", count, err)
+	}
+	if _, err := ro.Exec("INSERT INTO symbols (symbol) VALUES ('KO')"); err == nil {
+		t.Errorf("Expected writes to a read-only database to fail")
+	}
+	ro.Close()
+
+	// A database behind the built-in migrations is refused rather than migrated
+	db, err = NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)"); err != nil {
+		t.Fatalf("Failed to remove migration: %v", err)
+	}
+	db.Close()
+	if _, err := OpenReadOnly(dbPath); err == nil || !strings.Contains(err.Error(), "needs 1 migrations") {
+		t.Errorf("Expected a pending migration error, got %v", err)
+	}
+
+	missing := filepath.Join(filepath.Dir(dbPath), "missing.db")
+	if _, err := OpenReadOnly(missing); err == nil {
+		t.Errorf("Expected an error for a missing database")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Expected OpenReadOnly not to create databases")
+	}
+}
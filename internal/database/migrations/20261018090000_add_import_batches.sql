@@ -0,0 +1,32 @@
+-- ============================================================================
+-- IMPORT BATCHES
+-- ============================================================================
+-- Every CSV import runs in a single transaction and is recorded as a batch.
+-- Imported rows carry the batch ID so a bad import can be reverted as a unit.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS import_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    import_type TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    file_hash TEXT NOT NULL,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    imported_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reverted_at DATETIME
+);
+
+ALTER TABLE options ADD COLUMN import_batch_id INTEGER REFERENCES import_batches(id);
+ALTER TABLE long_positions ADD COLUMN import_batch_id INTEGER REFERENCES import_batches(id);
+ALTER TABLE dividends ADD COLUMN import_batch_id INTEGER REFERENCES import_batches(id);
+ALTER TABLE treasuries ADD COLUMN import_batch_id INTEGER REFERENCES import_batches(id);
+
+CREATE INDEX IF NOT EXISTS idx_import_batches_hash ON import_batches(file_hash);
+CREATE INDEX IF NOT EXISTS idx_options_import_batch ON options(import_batch_id);
+CREATE INDEX IF NOT EXISTS idx_long_positions_import_batch ON long_positions(import_batch_id);
+CREATE INDEX IF NOT EXISTS idx_dividends_import_batch ON dividends(import_batch_id);
+CREATE INDEX IF NOT EXISTS idx_treasuries_import_batch ON treasuries(import_batch_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018090000_add_import_batches');
@@ -0,0 +1,46 @@
+-- ============================================================================
+-- OCC OPTION SYMBOLS
+-- ============================================================================
+-- Stores the OCC symbol (e.g. AAPL250117P00150000) for each option so stored
+-- positions can be matched to broker exports and Polygon option tickers.
+-- The services set occ_symbol on every write; the triggers below keep it in
+-- sync for rows written by raw SQL such as the example wheel strategy data.
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN occ_symbol TEXT;
+
+UPDATE options
+SET occ_symbol = symbol
+    || substr(strftime('%Y', expiration), 3, 2) || strftime('%m%d', expiration)
+    || CASE type WHEN 'Put' THEN 'P' ELSE 'C' END
+    || printf('%08d', CAST(ROUND(strike * 1000) AS INTEGER))
+WHERE length(symbol) <= 6 AND strike > 0 AND strike < 100000;
+
+CREATE INDEX IF NOT EXISTS idx_options_occ_symbol ON options(occ_symbol);
+
+CREATE TRIGGER IF NOT EXISTS trg_options_occ_symbol_insert
+AFTER INSERT ON options
+WHEN NEW.occ_symbol IS NULL AND length(NEW.symbol) <= 6 AND NEW.strike > 0 AND NEW.strike < 100000
+BEGIN
+    UPDATE options
+    SET occ_symbol = NEW.symbol
+        || substr(strftime('%Y', NEW.expiration), 3, 2) || strftime('%m%d', NEW.expiration)
+        || CASE NEW.type WHEN 'Put' THEN 'P' ELSE 'C' END
+        || printf('%08d', CAST(ROUND(NEW.strike * 1000) AS INTEGER))
+    WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_options_occ_symbol_update
+AFTER UPDATE OF symbol, type, strike, expiration ON options
+WHEN length(NEW.symbol) <= 6 AND NEW.strike > 0 AND NEW.strike < 100000
+BEGIN
+    UPDATE options
+    SET occ_symbol = NEW.symbol
+        || substr(strftime('%Y', NEW.expiration), 3, 2) || strftime('%m%d', NEW.expiration)
+        || CASE NEW.type WHEN 'Put' THEN 'P' ELSE 'C' END
+        || printf('%08d', CAST(ROUND(NEW.strike * 1000) AS INTEGER))
+    WHERE id = NEW.id;
+END;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018100000_add_options_occ_symbol');
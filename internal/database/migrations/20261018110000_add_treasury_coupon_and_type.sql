@@ -0,0 +1,15 @@
+-- ============================================================================
+-- TREASURY COUPON RATE AND SECURITY TYPE
+-- ============================================================================
+-- TreasuryDirect and broker bond-position imports carry the coupon rate and
+-- whether a security is a bill, note, bond, TIPS or FRN. Both are optional so
+-- rows entered through the Wheeler CSV or the treasuries page stay valid.
+-- Existing rows are left unknown rather than guessed from their dates.
+-- ============================================================================
+
+ALTER TABLE treasuries ADD COLUMN coupon_rate REAL;
+ALTER TABLE treasuries ADD COLUMN security_type TEXT
+    CHECK (security_type IS NULL OR security_type IN ('Bill', 'Note', 'Bond', 'TIPS', 'FRN'));
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018110000_add_treasury_coupon_and_type');
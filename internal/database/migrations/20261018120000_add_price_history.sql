@@ -0,0 +1,28 @@
+-- ============================================================================
+-- PRICE HISTORY
+-- ============================================================================
+-- symbols.price only holds the latest close. price_history keeps one daily bar
+-- per symbol, filled from market data provider aggregates or CSV import, so
+-- trades can be charted against the price of the underlying.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS price_history (
+    symbol TEXT NOT NULL,
+    date DATE NOT NULL,
+    open REAL,
+    high REAL,
+    low REAL,
+    close REAL NOT NULL CHECK (close > 0),
+    volume REAL,
+    source TEXT NOT NULL DEFAULT 'manual',
+    import_batch_id INTEGER REFERENCES import_batches(id),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, date),
+    FOREIGN KEY (symbol) REFERENCES symbols(symbol) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_import_batch ON price_history(import_batch_id);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018120000_add_price_history');
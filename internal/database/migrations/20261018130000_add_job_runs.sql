@@ -0,0 +1,23 @@
+-- ============================================================================
+-- SCHEDULED JOB RUNS
+-- ============================================================================
+-- The background scheduler records every run of a job (price refresh, metrics
+-- snapshot, nightly backup) with its outcome so failures are visible on the
+-- jobs page instead of only in the server log.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job TEXT NOT NULL,
+    triggered_by TEXT NOT NULL DEFAULT 'schedule' CHECK (triggered_by IN ('schedule', 'manual')),
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'success', 'failed')),
+    message TEXT,
+    error TEXT,
+    started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job, started_at);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018130000_add_job_runs');
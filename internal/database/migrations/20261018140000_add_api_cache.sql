@@ -0,0 +1,19 @@
+-- ============================================================================
+-- MARKET DATA RESPONSE CACHE
+-- ============================================================================
+-- Responses from rate-limited market data APIs are cached here with an expiry
+-- so repeated page loads and bulk refreshes do not spend request quota on data
+-- that has not changed. Keys never include API keys.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS api_cache (
+    key TEXT PRIMARY KEY,
+    body BLOB NOT NULL,
+    fetched_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_cache_expires ON api_cache(expires_at);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018140000_add_api_cache');
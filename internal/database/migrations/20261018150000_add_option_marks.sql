@@ -0,0 +1,12 @@
+-- ============================================================================
+-- OPTION MARKS
+-- ============================================================================
+-- options.current_price holds the latest mark (bid/ask midpoint) for open
+-- options, written by the mark-to-market job. current_price_updated_at records
+-- when it was fetched so stale marks can be shown as such.
+-- ============================================================================
+
+ALTER TABLE options ADD COLUMN current_price_updated_at DATETIME;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018150000_add_option_marks');
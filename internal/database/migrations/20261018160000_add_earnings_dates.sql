@@ -0,0 +1,24 @@
+-- ============================================================================
+-- EARNINGS DATES
+-- ============================================================================
+-- Upcoming earnings announcements per symbol, entered by hand on the symbol
+-- modal or refreshed from the market data provider. Open options expiring
+-- after an earnings date are flagged so holding through earnings is a
+-- deliberate choice. Manual entries are never replaced by a provider refresh.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS earnings_dates (
+    symbol TEXT NOT NULL,
+    date DATE NOT NULL,
+    timing TEXT NOT NULL DEFAULT '' CHECK (timing IN ('', 'bmo', 'amc')),
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'provider')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, date),
+    FOREIGN KEY (symbol) REFERENCES symbols(symbol) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_earnings_dates_date ON earnings_dates(date);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018160000_add_earnings_dates');
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- WATCHLIST
+-- ============================================================================
+-- Tickers we want to wheel next, whether or not we hold a position. Each
+-- entry has its own symbols row so the price refresh jobs pick it up, plus
+-- an optional target entry price, target dividend yield and notes.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS watchlist (
+    symbol TEXT PRIMARY KEY,
+    target_price REAL CHECK (target_price IS NULL OR target_price > 0),
+    target_yield REAL CHECK (target_yield IS NULL OR target_yield > 0),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (symbol) REFERENCES symbols(symbol) ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018170000_add_watchlist');
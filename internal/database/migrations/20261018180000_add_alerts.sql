@@ -0,0 +1,43 @@
+-- ============================================================================
+-- ALERTS
+-- ============================================================================
+-- User-defined alert rules and the alerts they raise. A background job
+-- evaluates every enabled rule; each position or symbol that matches a rule
+-- keeps a single alert row keyed by rule and subject, so a condition that
+-- persists does not raise a new alert on every pass.
+--
+-- Alert states: new, acknowledged, snoozed. A snoozed alert returns to new
+-- once snoozed_until passes if its condition still holds. Acknowledged and
+-- snoozed alerts are removed when their condition clears, so the rule fires
+-- again the next time it matches; new alerts stay until seen.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    rule_type TEXT NOT NULL CHECK (rule_type IN ('dte_below', 'itm_percent', 'profit_captured', 'price_above', 'price_below', 'ex_dividend_call', 'early_assignment')),
+    symbol TEXT NOT NULL DEFAULT '',
+    threshold REAL NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rule_id INTEGER NOT NULL,
+    subject TEXT NOT NULL,
+    symbol TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'new' CHECK (state IN ('new', 'acknowledged', 'snoozed')),
+    snoozed_until DATETIME,
+    triggered_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    UNIQUE (rule_id, subject),
+    FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_state ON alerts(state);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018180000_add_alerts');
@@ -0,0 +1,37 @@
+-- ============================================================================
+-- NOTIFICATION CHANNELS
+-- ============================================================================
+-- Where new alerts are sent: a JSON webhook, SMTP email or a local file/log
+-- sink. Each channel keeps its type-specific settings (URL, SMTP host and
+-- recipients, file path) as a JSON object in config.
+--
+-- Every send to a channel, including test sends, is recorded in
+-- notification_deliveries with the number of attempts it took and the last
+-- error, so failed deliveries are visible from the settings page.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    channel_type TEXT NOT NULL CHECK (channel_type IN ('webhook', 'smtp', 'file')),
+    config TEXT NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    channel_id INTEGER NOT NULL,
+    subject TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created ON notification_deliveries(created_at);
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018190000_add_notifications');
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- TREASURY ISSUE DATE
+-- ============================================================================
+-- The issue date from TreasuryDirect and broker bond-position imports. A bill
+-- is told apart from a note or bond by its original term, issue to maturity,
+-- which the purchase date can't give for securities bought on the secondary
+-- market. Existing rows stay NULL.
+-- ============================================================================
+
+ALTER TABLE treasuries ADD COLUMN issue_date DATE;
+
+INSERT OR IGNORE INTO schema_migrations (version)
+VALUES ('20261018200000_add_treasury_issue_date');
@@ -81,6 +81,16 @@ CREATE TABLE IF NOT EXISTS metrics (
 INSERT OR IGNORE INTO settings (name, value, description)
 VALUES ('POLYGON_API_KEY', '', 'API key for Polygon.io stock market data integration');
 
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('POLYGON_PLAN', 'free', 'Polygon.io plan used to pace requests: free (5 per minute) or paid');
+
+-- Market data provider selection: 'polygon' or 'file' (CSV/JSON drop folder)
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('MARKET_DATA_PROVIDER', 'polygon', 'Market data provider: polygon or file');
+
+INSERT OR IGNORE INTO settings (name, value, description)
+VALUES ('MARKET_DATA_DIR', './data/marketdata', 'Drop folder of price CSV/JSON files read by the file provider');
+
 CREATE TABLE IF NOT EXISTS config (
     key         TEXT PRIMARY KEY,
     value       TEXT NOT NULL,
@@ -89,7 +99,27 @@ CREATE TABLE IF NOT EXISTS config (
 
 INSERT OR IGNORE INTO config (key, value, description) VALUES
     ('default_commission', '0.65', 'Default commission for new option positions'),
-    ('default_contracts',  '1',    'Default number of contracts for new options');
+    ('default_contracts',  '1',    'Default number of contracts for new options'),
+    ('ledger_account_cash',                 'Assets:Brokerage:Cash',           'Ledger/Beancount export: brokerage cash account'),
+    ('ledger_account_stocks',               'Assets:Brokerage:Stocks',         'Ledger/Beancount export: account holding stock lots'),
+    ('ledger_account_treasuries',           'Assets:Brokerage:Treasuries',     'Ledger/Beancount export: account holding treasuries'),
+    ('ledger_account_option_premium',       'Income:Options:Premium',          'Ledger/Beancount export: income account for option premium'),
+    ('ledger_account_option_buy_to_close',  'Expenses:Options:BuyToClose',     'Ledger/Beancount export: expense account for buy-to-close cost'),
+    ('ledger_account_commissions',          'Expenses:Brokerage:Commissions',  'Ledger/Beancount export: expense account for commissions'),
+    ('ledger_account_dividends',            'Income:Dividends',                'Ledger/Beancount export: income account for dividends'),
+    ('ledger_account_capital_gains',        'Income:CapitalGains',             'Ledger/Beancount export: income account for stock gains and losses'),
+    ('ledger_account_interest',             'Income:Interest:Treasuries',      'Ledger/Beancount export: income account for treasury interest'),
+    ('ledger_currency',                     'USD',                             'Ledger/Beancount export: currency commodity'),
+    ('schedule_timezone',                   'America/New_York',                'Scheduler: time zone the job schedules below are evaluated in'),
+    ('schedule_price_refresh',              '15 16 * * 1-5',                   'Scheduler: cron schedule (minute hour day month weekday) to refresh all symbol prices; blank disables'),
+    ('schedule_metrics_snapshot',           '30 16 * * *',                     'Scheduler: cron schedule to take the daily metrics snapshot; blank disables'),
+    ('schedule_backup',                     '0 2 * * *',                       'Scheduler: cron schedule to back up the current database; blank disables'),
+    ('schedule_option_marks',               '20 16 * * 1-5',                   'Scheduler: cron schedule to mark open options to market; blank disables'),
+    ('schedule_earnings_refresh',           '0 6 * * 1',                       'Scheduler: cron schedule to refresh upcoming earnings dates from the market data provider; blank disables'),
+    ('schedule_alert_check',                '*/15 * * * *',                    'Scheduler: cron schedule to evaluate alert rules; blank disables'),
+    ('schedule_daily_digest',               '0 18 * * 1-5',                    'Scheduler: cron schedule to send the daily digest to the notification channels; blank disables'),
+    ('digest_channel',                      '',                                'Daily digest: name of the notification channel to send the digest to; blank sends it to every enabled channel'),
+    ('profit_target_percent',               '50',                              'Options page: flag open options to consider closing once this percent of max profit is captured');
 
 -- Indexes for performance
 -- Note: Primary key columns automatically have indexes, so we don't need explicit indexes for:
@@ -0,0 +1,314 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"stonks/internal/models"
+	"strconv"
+	"time"
+)
+
+// The CSV writers below produce exactly the layouts accepted by the importers on
+// the Import page, so an exported file can be uploaded again unchanged.
+
+// OptionsCSVHeader is the header row accepted by the options importer
+var OptionsCSVHeader = []string{"symbol", "opened", "closed", "type", "strike", "expiration", "premium", "contracts", "exit_price", "commission"}
+
+// StocksCSVHeader is the header row of the stocks importer layout
+var StocksCSVHeader = []string{"Symbol", "Purchased", "Closed", "Shares", "BuyPrice", "ExitPrice"}
+
+// DividendsCSVHeader is the header row of the dividends importer layout
+var DividendsCSVHeader = []string{"Symbol", "Date Received", "Amount"}
+
+// TreasuriesCSVHeader is the header row of the treasuries importer layout
+var TreasuriesCSVHeader = []string{"CUSPID", "Purchased", "Maturity", "Amount", "Yield", "BuyPrice", "CurrentValue", "ExitPrice",
+	"CouponRate", "SecurityType", "IssueDate"}
+
+// SymbolsCSVHeader is the header row for symbols, which have no importer
+var SymbolsCSVHeader = []string{"symbol", "price", "dividend", "ex_dividend_date", "pe_ratio"}
+
+// MetricsCSVHeader is the header row for metrics, which have no importer
+var MetricsCSVHeader = []string{"created", "type", "value"}
+
+// ConfigCSVHeader is the header row for config settings, which have no importer
+var ConfigCSVHeader = []string{"key", "value", "description"}
+
+// ImportBatchesCSVHeader is the header row for the import history, which has no importer
+var ImportBatchesCSVHeader = []string{"id", "import_type", "filename", "file_hash", "row_count", "imported_count", "skipped_count", "created_at", "reverted_at"}
+
+// PriceHistoryCSVHeader is the header row accepted by the price history importer
+var PriceHistoryCSVHeader = []string{"symbol", "date", "open", "high", "low", "close", "volume"}
+
+// EarningsCSVHeader is the header row for earnings dates, which have no importer
+var EarningsCSVHeader = []string{"symbol", "date", "timing", "source"}
+
+// WatchlistCSVHeader is the header row for the watchlist, which has no importer
+var WatchlistCSVHeader = []string{"symbol", "target_price", "target_yield", "notes"}
+
+// AlertRulesCSVHeader is the header row for alert rules, which have no importer
+var AlertRulesCSVHeader = []string{"id", "name", "rule_type", "symbol", "threshold", "enabled"}
+
+// AlertsCSVHeader is the header row for alerts, which have no importer
+var AlertsCSVHeader = []string{"id", "rule_id", "subject", "symbol", "message", "state", "snoozed_until", "triggered_at", "last_seen_at"}
+
+// NotificationChannelsCSVHeader is the header row for notification channels, which have no
+// importer. The config column holds the channel's settings as JSON.
+var NotificationChannelsCSVHeader = []string{"id", "name", "channel_type", "config", "enabled"}
+
+const (
+	isoDate = "2006-01-02" // options and treasuries importers
+	usDate  = "1/2/2006"   // stocks and dividends importers
+)
+
+// WriteOptionsCSV writes options in the options importer layout
+func WriteOptionsCSV(w io.Writer, options []*models.Option) error {
+	rows := make([][]string, 0, len(options))
+	for _, o := range options {
+		rows = append(rows, []string{
+			o.Symbol,
+			o.Opened.Format(isoDate),
+			formatDatePtr(o.Closed, isoDate),
+			o.Type,
+			formatFloat(o.Strike),
+			o.Expiration.Format(isoDate),
+			formatFloat(o.Premium),
+			strconv.Itoa(o.Contracts),
+			formatFloatPtr(o.ExitPrice),
+			formatFloat(o.Commission),
+		})
+	}
+	return writeCSV(w, OptionsCSVHeader, rows)
+}
+
+// WriteStocksCSV writes long positions in the stocks importer layout, where shares are expressed in hundreds
+func WriteStocksCSV(w io.Writer, positions []*models.LongPosition) error {
+	rows := make([][]string, 0, len(positions))
+	for _, lp := range positions {
+		rows = append(rows, []string{
+			lp.Symbol,
+			lp.Opened.Format(usDate),
+			formatDatePtr(lp.Closed, usDate),
+			formatFloat(float64(lp.Shares) / 100),
+			formatFloat(lp.BuyPrice),
+			formatFloatPtr(lp.ExitPrice),
+		})
+	}
+	return writeCSV(w, StocksCSVHeader, rows)
+}
+
+// WriteDividendsCSV writes dividends in the dividends importer layout
+func WriteDividendsCSV(w io.Writer, dividends []*models.Dividend) error {
+	rows := make([][]string, 0, len(dividends))
+	for _, d := range dividends {
+		rows = append(rows, []string{
+			d.Symbol,
+			d.Received.Format(usDate),
+			formatFloat(d.Amount),
+		})
+	}
+	return writeCSV(w, DividendsCSVHeader, rows)
+}
+
+// WriteTreasuriesCSV writes treasuries in the treasuries importer layout
+func WriteTreasuriesCSV(w io.Writer, treasuries []*models.Treasury) error {
+	rows := make([][]string, 0, len(treasuries))
+	for _, t := range treasuries {
+		rows = append(rows, []string{
+			t.CUSPID,
+			t.Purchased.Format(isoDate),
+			t.Maturity.Format(isoDate),
+			formatFloat(t.Amount),
+			formatFloat(t.Yield),
+			formatFloat(t.BuyPrice),
+			formatFloatPtr(t.CurrentValue),
+			formatFloatPtr(t.ExitPrice),
+			formatFloatPtr(t.CouponRate),
+			t.GetSecurityType(),
+			formatDatePtr(t.IssueDate, isoDate),
+		})
+	}
+	return writeCSV(w, TreasuriesCSVHeader, rows)
+}
+
+// WriteSymbolsCSV writes the symbols table
+func WriteSymbolsCSV(w io.Writer, symbols []*models.Symbol) error {
+	rows := make([][]string, 0, len(symbols))
+	for _, s := range symbols {
+		rows = append(rows, []string{
+			s.Symbol,
+			formatFloat(s.Price),
+			formatFloat(s.Dividend),
+			formatDatePtr(s.ExDividendDate, isoDate),
+			formatFloatPtr(s.PERatio),
+		})
+	}
+	return writeCSV(w, SymbolsCSVHeader, rows)
+}
+
+// WriteMetricsCSV writes the metrics table
+func WriteMetricsCSV(w io.Writer, metrics []*models.Metric) error {
+	rows := make([][]string, 0, len(metrics))
+	for _, m := range metrics {
+		rows = append(rows, []string{
+			m.Created.Format(time.RFC3339),
+			string(m.Type),
+			formatFloat(m.Value),
+		})
+	}
+	return writeCSV(w, MetricsCSVHeader, rows)
+}
+
+// WriteConfigCSV writes the config table
+func WriteConfigCSV(w io.Writer, config []*models.ConfigSetting) error {
+	rows := make([][]string, 0, len(config))
+	for _, c := range config {
+		rows = append(rows, []string{c.Key, c.Value, c.Description})
+	}
+	return writeCSV(w, ConfigCSVHeader, rows)
+}
+
+// WriteImportBatchesCSV writes the import_batches table
+func WriteImportBatchesCSV(w io.Writer, batches []*models.ImportBatch) error {
+	rows := make([][]string, 0, len(batches))
+	for _, b := range batches {
+		rows = append(rows, []string{
+			strconv.Itoa(b.ID),
+			b.ImportType,
+			b.Filename,
+			b.FileHash,
+			strconv.Itoa(b.RowCount),
+			strconv.Itoa(b.ImportedCount),
+			strconv.Itoa(b.SkippedCount),
+			b.CreatedAt.Format(time.RFC3339),
+			formatDatePtr(b.RevertedAt, time.RFC3339),
+		})
+	}
+	return writeCSV(w, ImportBatchesCSVHeader, rows)
+}
+
+// WritePriceHistoryCSV writes daily prices in the price history importer layout
+func WritePriceHistoryCSV(w io.Writer, bars []*models.PriceBar) error {
+	rows := make([][]string, 0, len(bars))
+	for _, b := range bars {
+		rows = append(rows, []string{
+			b.Symbol,
+			b.Date.Format(isoDate),
+			formatFloatPtr(b.Open),
+			formatFloatPtr(b.High),
+			formatFloatPtr(b.Low),
+			formatFloat(b.Close),
+			formatFloatPtr(b.Volume),
+		})
+	}
+	return writeCSV(w, PriceHistoryCSVHeader, rows)
+}
+
+// WriteEarningsCSV writes the earnings_dates table
+func WriteEarningsCSV(w io.Writer, dates []*models.EarningsDate) error {
+	rows := make([][]string, 0, len(dates))
+	for _, e := range dates {
+		rows = append(rows, []string{e.Symbol, e.Date.Format(isoDate), e.Timing, e.Source})
+	}
+	return writeCSV(w, EarningsCSVHeader, rows)
+}
+
+// WriteWatchlistCSV writes the watchlist table
+func WriteWatchlistCSV(w io.Writer, items []*models.WatchlistItem) error {
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []string{
+			item.Symbol,
+			formatFloatPtr(item.TargetPrice),
+			formatFloatPtr(item.TargetYield),
+			item.Notes,
+		})
+	}
+	return writeCSV(w, WatchlistCSVHeader, rows)
+}
+
+// WriteAlertRulesCSV writes the alert_rules table
+func WriteAlertRulesCSV(w io.Writer, rules []*models.AlertRule) error {
+	rows := make([][]string, 0, len(rules))
+	for _, r := range rules {
+		rows = append(rows, []string{
+			strconv.Itoa(r.ID),
+			r.Name,
+			r.Type,
+			r.Symbol,
+			formatFloat(r.Threshold),
+			strconv.FormatBool(r.Enabled),
+		})
+	}
+	return writeCSV(w, AlertRulesCSVHeader, rows)
+}
+
+// WriteAlertsCSV writes the alerts table
+func WriteAlertsCSV(w io.Writer, alerts []*models.Alert) error {
+	rows := make([][]string, 0, len(alerts))
+	for _, a := range alerts {
+		rows = append(rows, []string{
+			strconv.Itoa(a.ID),
+			strconv.Itoa(a.RuleID),
+			a.Subject,
+			a.Symbol,
+			a.Message,
+			a.State,
+			formatDatePtr(a.SnoozedUntil, time.RFC3339),
+			a.TriggeredAt.Format(time.RFC3339),
+			a.LastSeenAt.Format(time.RFC3339),
+		})
+	}
+	return writeCSV(w, AlertsCSVHeader, rows)
+}
+
+// WriteNotificationChannelsCSV writes the notification_channels table. Callers redact the
+// channels first; the writer exports whatever config it is given.
+func WriteNotificationChannelsCSV(w io.Writer, channels []*models.NotificationChannel) error {
+	rows := make([][]string, 0, len(channels))
+	for _, c := range channels {
+		config, err := json.Marshal(c.Config)
+		if err != nil {
+			return fmt.Errorf("failed to encode config of channel %s: %w", c.Name, err)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(c.ID),
+			c.Name,
+			c.Type,
+			string(config),
+			strconv.FormatBool(c.Enabled),
+		})
+	}
+	return writeCSV(w, NotificationChannelsCSVHeader, rows)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	return nil
+}
+
+// formatFloat uses the shortest representation that parses back to the same value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatFloatPtr(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
+
+func formatDatePtr(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,368 @@
+// Package export writes the contents of a Wheeler database as a zip bundle of
+// importer-compatible CSV files plus a versioned JSON document.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"stonks/internal/models"
+	"time"
+)
+
+// DocumentFormat identifies Wheeler export documents
+const DocumentFormat = "wheeler-export"
+
+// DocumentVersion is incremented whenever the JSON document layout changes incompatibly
+const DocumentVersion = 1
+
+// File names inside the export bundle
+const (
+	JSONFileName          = "wheeler.json"
+	OptionsFileName       = "options.csv"
+	StocksFileName        = "stocks.csv"
+	DividendsFileName     = "dividends.csv"
+	TreasuriesFileName    = "treasuries.csv"
+	SymbolsFileName       = "symbols.csv"
+	MetricsFileName       = "metrics.csv"
+	ConfigFileName        = "config.csv"
+	ImportBatchesFileName = "import_batches.csv"
+	PriceHistoryFileName  = "price_history.csv"
+	EarningsFileName      = "earnings_dates.csv"
+	WatchlistFileName     = "watchlist.csv"
+	AlertRulesFileName    = "alert_rules.csv"
+	AlertsFileName        = "alerts.csv"
+	ChannelsFileName      = "notification_channels.csv"
+)
+
+// Document is the JSON representation of a full database export. The settings table is
+// deliberately left out because it holds the market data API key, and notification
+// channels are exported without their SMTP passwords: bundles get copied, shared and
+// archived, so they never carry credentials. The API cache, job runs and notification
+// deliveries are logs and caches rather than data, so they are left out too.
+type Document struct {
+	Format               string                        `json:"format"`
+	Version              int                           `json:"version"`
+	ExportedAt           time.Time                     `json:"exported_at"`
+	Database             string                        `json:"database"`
+	Symbols              []*models.Symbol              `json:"symbols"`
+	Options              []*models.Option              `json:"options"`
+	LongPositions        []*models.LongPosition        `json:"long_positions"`
+	Dividends            []*models.Dividend            `json:"dividends"`
+	Treasuries           []*models.Treasury            `json:"treasuries"`
+	Metrics              []*models.Metric              `json:"metrics"`
+	Config               []*models.ConfigSetting       `json:"config"`
+	ImportBatches        []*models.ImportBatch         `json:"import_batches"`
+	PriceHistory         []*models.PriceBar            `json:"price_history"`
+	EarningsDates        []*models.EarningsDate        `json:"earnings_dates"`
+	Watchlist            []*models.WatchlistItem       `json:"watchlist"`
+	AlertRules           []*models.AlertRule           `json:"alert_rules"`
+	Alerts               []*models.Alert               `json:"alerts"`
+	NotificationChannels []*models.NotificationChannel `json:"notification_channels"`
+}
+
+// Exporter reads every exportable table from a database
+type Exporter struct {
+	symbolService       *models.SymbolService
+	optionService       *models.OptionService
+	longPositionService *models.LongPositionService
+	dividendService     *models.DividendService
+	treasuryService     *models.TreasuryService
+	metricService       *models.MetricService
+	configService       *models.ConfigService
+	importBatchService  *models.ImportBatchService
+	priceHistoryService *models.PriceHistoryService
+	earningsService     *models.EarningsService
+	watchlistService    *models.WatchlistService
+	alertRuleService    *models.AlertRuleService
+	alertService        *models.AlertService
+	channelService      *models.NotificationChannelService
+}
+
+// NewExporter creates an exporter for the given database connection
+func NewExporter(db *sql.DB) *Exporter {
+	return &Exporter{
+		symbolService:       models.NewSymbolService(db),
+		optionService:       models.NewOptionService(db),
+		longPositionService: models.NewLongPositionService(db),
+		dividendService:     models.NewDividendService(db),
+		treasuryService:     models.NewTreasuryService(db),
+		metricService:       models.NewMetricService(db),
+		configService:       models.NewConfigService(db),
+		importBatchService:  models.NewImportBatchService(db),
+		priceHistoryService: models.NewPriceHistoryService(db),
+		earningsService:     models.NewEarningsService(db),
+		watchlistService:    models.NewWatchlistService(db),
+		alertRuleService:    models.NewAlertRuleService(db),
+		alertService:        models.NewAlertService(db),
+		channelService:      models.NewNotificationChannelService(db),
+	}
+}
+
+// Collect loads every table into a Document. Rows are sorted chronologically so
+// repeated exports of the same data are identical.
+func (e *Exporter) Collect(database string) (*Document, error) {
+	symbols, err := e.symbolService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export symbols: %w", err)
+	}
+
+	options, err := e.optionService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export options: %w", err)
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if !a.Opened.Equal(b.Opened) {
+			return a.Opened.Before(b.Opened)
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if !a.Expiration.Equal(b.Expiration) {
+			return a.Expiration.Before(b.Expiration)
+		}
+		if a.Strike != b.Strike {
+			return a.Strike < b.Strike
+		}
+		return a.Premium < b.Premium
+	})
+
+	positions, err := e.longPositionService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export long positions: %w", err)
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		if !a.Opened.Equal(b.Opened) {
+			return a.Opened.Before(b.Opened)
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Shares != b.Shares {
+			return a.Shares < b.Shares
+		}
+		return a.BuyPrice < b.BuyPrice
+	})
+
+	dividends, err := e.dividendService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export dividends: %w", err)
+	}
+	sort.SliceStable(dividends, func(i, j int) bool {
+		a, b := dividends[i], dividends[j]
+		if !a.Received.Equal(b.Received) {
+			return a.Received.Before(b.Received)
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Amount < b.Amount
+	})
+
+	treasuries, err := e.treasuryService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export treasuries: %w", err)
+	}
+	sort.SliceStable(treasuries, func(i, j int) bool {
+		a, b := treasuries[i], treasuries[j]
+		if !a.Purchased.Equal(b.Purchased) {
+			return a.Purchased.Before(b.Purchased)
+		}
+		return a.CUSPID < b.CUSPID
+	})
+
+	metrics, err := e.metricService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export metrics: %w", err)
+	}
+	sort.SliceStable(metrics, func(i, j int) bool {
+		a, b := metrics[i], metrics[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		return a.Type < b.Type
+	})
+
+	config, err := e.configService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export config: %w", err)
+	}
+
+	batches, err := e.importBatchService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export import batches: %w", err)
+	}
+	sort.SliceStable(batches, func(i, j int) bool {
+		return batches[i].ID < batches[j].ID
+	})
+
+	prices, err := e.priceHistoryService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export price history: %w", err)
+	}
+
+	earnings, err := e.earningsService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export earnings dates: %w", err)
+	}
+
+	watchlist, err := e.watchlistService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export watchlist: %w", err)
+	}
+
+	rules, err := e.alertRuleService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export alert rules: %w", err)
+	}
+
+	alerts, err := e.alertService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export alerts: %w", err)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].ID < alerts[j].ID
+	})
+
+	channels, err := e.channelService.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export notification channels: %w", err)
+	}
+	for i, channel := range channels {
+		channels[i] = channel.Redacted()
+	}
+
+	return &Document{
+		Format:               DocumentFormat,
+		Version:              DocumentVersion,
+		ExportedAt:           time.Now().UTC(),
+		Database:             database,
+		Symbols:              nonNil(symbols),
+		Options:              nonNil(options),
+		LongPositions:        nonNil(positions),
+		Dividends:            nonNil(dividends),
+		Treasuries:           nonNil(treasuries),
+		Metrics:              nonNil(metrics),
+		Config:               nonNil(config),
+		ImportBatches:        nonNil(batches),
+		PriceHistory:         nonNil(prices),
+		EarningsDates:        nonNil(earnings),
+		Watchlist:            nonNil(watchlist),
+		AlertRules:           nonNil(rules),
+		Alerts:               nonNil(alerts),
+		NotificationChannels: nonNil(channels),
+	}, nil
+}
+
+// WriteZip collects the database and writes the CSV files and JSON document as a zip archive
+func (e *Exporter) WriteZip(w io.Writer, database string) (*Document, error) {
+	doc, err := e.Collect(database)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteBundle(w, doc); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[EXPORT] Exported %s: %d options, %d long positions, %d dividends, %d treasuries, %d symbols, %d metrics, %d config settings, %d import batches, "+
+		"%d daily prices, %d earnings dates, %d watchlist entries, %d alert rules, %d alerts, %d notification channels",
+		database, len(doc.Options), len(doc.LongPositions), len(doc.Dividends), len(doc.Treasuries), len(doc.Symbols), len(doc.Metrics),
+		len(doc.Config), len(doc.ImportBatches), len(doc.PriceHistory), len(doc.EarningsDates), len(doc.Watchlist), len(doc.AlertRules),
+		len(doc.Alerts), len(doc.NotificationChannels))
+	return doc, nil
+}
+
+// WriteBundle writes a collected document as a zip archive
+func WriteBundle(w io.Writer, doc *Document) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{JSONFileName, func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(doc)
+		}},
+		{OptionsFileName, func(w io.Writer) error { return WriteOptionsCSV(w, doc.Options) }},
+		{StocksFileName, func(w io.Writer) error { return WriteStocksCSV(w, doc.LongPositions) }},
+		{DividendsFileName, func(w io.Writer) error { return WriteDividendsCSV(w, doc.Dividends) }},
+		{TreasuriesFileName, func(w io.Writer) error { return WriteTreasuriesCSV(w, doc.Treasuries) }},
+		{SymbolsFileName, func(w io.Writer) error { return WriteSymbolsCSV(w, doc.Symbols) }},
+		{MetricsFileName, func(w io.Writer) error { return WriteMetricsCSV(w, doc.Metrics) }},
+		{ConfigFileName, func(w io.Writer) error { return WriteConfigCSV(w, doc.Config) }},
+		{ImportBatchesFileName, func(w io.Writer) error { return WriteImportBatchesCSV(w, doc.ImportBatches) }},
+		{PriceHistoryFileName, func(w io.Writer) error { return WritePriceHistoryCSV(w, doc.PriceHistory) }},
+		{EarningsFileName, func(w io.Writer) error { return WriteEarningsCSV(w, doc.EarningsDates) }},
+		{WatchlistFileName, func(w io.Writer) error { return WriteWatchlistCSV(w, doc.Watchlist) }},
+		{AlertRulesFileName, func(w io.Writer) error { return WriteAlertRulesCSV(w, doc.AlertRules) }},
+		{AlertsFileName, func(w io.Writer) error { return WriteAlertsCSV(w, doc.Alerts) }},
+		{ChannelsFileName, func(w io.Writer) error { return WriteNotificationChannelsCSV(w, doc.NotificationChannels) }},
+	}
+
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: doc.ExportedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to export: %w", file.name, err)
+		}
+		if err := file.write(fw); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish export archive: %w", err)
+	}
+	return nil
+}
+
+// ReadDocument reads the JSON document from an export bundle and checks its format and version
+func ReadDocument(bundle []byte) (*Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export archive: %w", err)
+	}
+
+	f, err := zr.Open(JSONFileName)
+	if err != nil {
+		return nil, fmt.Errorf("export archive has no %s: %w", JSONFileName, err)
+	}
+	defer f.Close()
+
+	var doc Document
+	if err := json.NewDecoder(f).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", JSONFileName, err)
+	}
+	if doc.Format != DocumentFormat {
+		return nil, fmt.Errorf("unexpected export format %q", doc.Format)
+	}
+	if doc.Version > DocumentVersion {
+		return nil, fmt.Errorf("export version %d is newer than supported version %d", doc.Version, DocumentVersion)
+	}
+
+	return &doc, nil
+}
+
+// nonNil keeps empty tables as [] rather than null in the JSON document
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,362 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LedgerFormat selects the plain-text accounting syntax written by WriteLedger
+type LedgerFormat string
+
+const (
+	FormatLedger    LedgerFormat = "ledger"
+	FormatBeancount LedgerFormat = "beancount"
+)
+
+// ParseLedgerFormat validates a format name from a request
+func ParseLedgerFormat(name string) (LedgerFormat, error) {
+	switch LedgerFormat(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatLedger, "":
+		return FormatLedger, nil
+	case FormatBeancount:
+		return FormatBeancount, nil
+	default:
+		return "", fmt.Errorf("unknown ledger format %q (expected ledger or beancount)", name)
+	}
+}
+
+// FileExtension returns the conventional file extension for the format
+func (f LedgerFormat) FileExtension() string {
+	if f == FormatBeancount {
+		return "beancount"
+	}
+	return "ledger"
+}
+
+// LedgerAccounts names the accounts that Wheeler activity is posted to
+type LedgerAccounts struct {
+	Cash             string
+	Stocks           string
+	Treasuries       string
+	OptionPremium    string
+	OptionBuyToClose string
+	Commissions      string
+	Dividends        string
+	CapitalGains     string
+	Interest         string
+	Currency         string
+}
+
+// Config keys holding the account names; see the config table seed in schema.sql
+const (
+	ConfigLedgerCash             = "ledger_account_cash"
+	ConfigLedgerStocks           = "ledger_account_stocks"
+	ConfigLedgerTreasuries       = "ledger_account_treasuries"
+	ConfigLedgerOptionPremium    = "ledger_account_option_premium"
+	ConfigLedgerOptionBuyToClose = "ledger_account_option_buy_to_close"
+	ConfigLedgerCommissions      = "ledger_account_commissions"
+	ConfigLedgerDividends        = "ledger_account_dividends"
+	ConfigLedgerCapitalGains     = "ledger_account_capital_gains"
+	ConfigLedgerInterest         = "ledger_account_interest"
+	ConfigLedgerCurrency         = "ledger_currency"
+)
+
+// DefaultLedgerAccounts returns the account names used when the config table has none
+func DefaultLedgerAccounts() LedgerAccounts {
+	return LedgerAccounts{
+		Cash:             "Assets:Brokerage:Cash",
+		Stocks:           "Assets:Brokerage:Stocks",
+		Treasuries:       "Assets:Brokerage:Treasuries",
+		OptionPremium:    "Income:Options:Premium",
+		OptionBuyToClose: "Expenses:Options:BuyToClose",
+		Commissions:      "Expenses:Brokerage:Commissions",
+		Dividends:        "Income:Dividends",
+		CapitalGains:     "Income:CapitalGains",
+		Interest:         "Income:Interest:Treasuries",
+		Currency:         "USD",
+	}
+}
+
+// LedgerAccountsFromConfig reads account names through lookup, falling back to the defaults
+func LedgerAccountsFromConfig(lookup func(key, defaultVal string) string) LedgerAccounts {
+	d := DefaultLedgerAccounts()
+	return LedgerAccounts{
+		Cash:             lookup(ConfigLedgerCash, d.Cash),
+		Stocks:           lookup(ConfigLedgerStocks, d.Stocks),
+		Treasuries:       lookup(ConfigLedgerTreasuries, d.Treasuries),
+		OptionPremium:    lookup(ConfigLedgerOptionPremium, d.OptionPremium),
+		OptionBuyToClose: lookup(ConfigLedgerOptionBuyToClose, d.OptionBuyToClose),
+		Commissions:      lookup(ConfigLedgerCommissions, d.Commissions),
+		Dividends:        lookup(ConfigLedgerDividends, d.Dividends),
+		CapitalGains:     lookup(ConfigLedgerCapitalGains, d.CapitalGains),
+		Interest:         lookup(ConfigLedgerInterest, d.Interest),
+		Currency:         lookup(ConfigLedgerCurrency, d.Currency),
+	}
+}
+
+func (a LedgerAccounts) all() []string {
+	return []string{a.Cash, a.Stocks, a.Treasuries, a.OptionPremium, a.OptionBuyToClose,
+		a.Commissions, a.Dividends, a.CapitalGains, a.Interest}
+}
+
+// LedgerTransaction is one balanced journal entry
+type LedgerTransaction struct {
+	Date      time.Time
+	Narration string
+	Source    string // e.g. "option:12", written as metadata so entries can be traced back
+	Postings  []LedgerPosting
+}
+
+// LedgerPosting moves Cents of the account currency, or Units of a stock lot held at Cost
+type LedgerPosting struct {
+	Account string
+	Cents   int64
+	Units   int
+	Symbol  string
+	Cost    float64  // per-share cost basis of the lot
+	Price   *float64 // per-share sale price when reducing a lot
+}
+
+// weightCents is the posting's contribution to the transaction balance
+func (p LedgerPosting) weightCents() int64 {
+	if p.Symbol != "" {
+		return toCents(float64(p.Units) * p.Cost)
+	}
+	return p.Cents
+}
+
+// BuildLedgerTransactions turns the document into balanced transactions sorted by date.
+// Treasuries without an exit price are redeemed at face value once they matured before the export.
+func BuildLedgerTransactions(doc *Document, accounts LedgerAccounts) []LedgerTransaction {
+	var txns []LedgerTransaction
+
+	for _, o := range doc.Options {
+		contract := fmt.Sprintf("%d %s %s %s %s", o.Contracts, o.Symbol, o.Expiration.Format(isoDate), formatFloat(o.Strike), o.Type)
+		gross := toCents(o.Premium * float64(o.Contracts) * 100)
+		commission := toCents(o.Commission)
+
+		open := LedgerTransaction{
+			Date:      o.Opened,
+			Narration: "Sell to open " + contract,
+			Source:    fmt.Sprintf("option:%d", o.ID),
+			Postings: []LedgerPosting{
+				{Account: accounts.Cash, Cents: gross - commission},
+				{Account: accounts.Commissions, Cents: commission},
+				{Account: accounts.OptionPremium, Cents: -gross},
+			},
+		}
+		if commission == 0 {
+			open.Postings = []LedgerPosting{open.Postings[0], open.Postings[2]}
+		}
+		txns = append(txns, open)
+
+		if o.Closed != nil && o.ExitPrice != nil && *o.ExitPrice > 0 {
+			cost := toCents(*o.ExitPrice * float64(o.Contracts) * 100)
+			txns = append(txns, LedgerTransaction{
+				Date:      *o.Closed,
+				Narration: "Buy to close " + contract,
+				Source:    fmt.Sprintf("option:%d", o.ID),
+				Postings: []LedgerPosting{
+					{Account: accounts.OptionBuyToClose, Cents: cost},
+					{Account: accounts.Cash, Cents: -cost},
+				},
+			})
+		}
+	}
+
+	for _, lp := range doc.LongPositions {
+		basis := toCents(float64(lp.Shares) * lp.BuyPrice)
+		txns = append(txns, LedgerTransaction{
+			Date:      lp.Opened,
+			Narration: fmt.Sprintf("Buy %d %s", lp.Shares, lp.Symbol),
+			Source:    fmt.Sprintf("long_position:%d", lp.ID),
+			Postings: []LedgerPosting{
+				{Account: accounts.Stocks, Units: lp.Shares, Symbol: lp.Symbol, Cost: lp.BuyPrice},
+				{Account: accounts.Cash, Cents: -basis},
+			},
+		})
+
+		if lp.Closed != nil && lp.ExitPrice != nil {
+			proceeds := toCents(float64(lp.Shares) * *lp.ExitPrice)
+			txns = append(txns, LedgerTransaction{
+				Date:      *lp.Closed,
+				Narration: fmt.Sprintf("Sell %d %s", lp.Shares, lp.Symbol),
+				Source:    fmt.Sprintf("long_position:%d", lp.ID),
+				Postings: []LedgerPosting{
+					{Account: accounts.Cash, Cents: proceeds},
+					{Account: accounts.Stocks, Units: -lp.Shares, Symbol: lp.Symbol, Cost: lp.BuyPrice, Price: lp.ExitPrice},
+					{Account: accounts.CapitalGains, Cents: basis - proceeds},
+				},
+			})
+		}
+	}
+
+	for _, d := range doc.Dividends {
+		amount := toCents(d.Amount)
+		txns = append(txns, LedgerTransaction{
+			Date:      d.Received,
+			Narration: "Dividend " + d.Symbol,
+			Source:    fmt.Sprintf("dividend:%d", d.ID),
+			Postings: []LedgerPosting{
+				{Account: accounts.Cash, Cents: amount},
+				{Account: accounts.Dividends, Cents: -amount},
+			},
+		})
+	}
+
+	for _, t := range doc.Treasuries {
+		cost := toCents(t.BuyPrice)
+		txns = append(txns, LedgerTransaction{
+			Date:      t.Purchased,
+			Narration: "Buy treasury " + t.CUSPID,
+			Source:    "treasury:" + t.CUSPID,
+			Postings: []LedgerPosting{
+				{Account: accounts.Treasuries, Cents: cost},
+				{Account: accounts.Cash, Cents: -cost},
+			},
+		})
+
+		if redeemed, proceeds, narration, ok := treasuryRedemption(t, doc.ExportedAt); ok {
+			txns = append(txns, LedgerTransaction{
+				Date:      redeemed,
+				Narration: narration + " treasury " + t.CUSPID,
+				Source:    "treasury:" + t.CUSPID,
+				Postings: []LedgerPosting{
+					{Account: accounts.Cash, Cents: proceeds},
+					{Account: accounts.Treasuries, Cents: -cost},
+					{Account: accounts.Interest, Cents: cost - proceeds},
+				},
+			})
+		}
+	}
+
+	sort.SliceStable(txns, func(i, j int) bool {
+		return txns[i].Date.Before(txns[j].Date)
+	})
+	return txns
+}
+
+// treasuryRedemption returns when and for how much a treasury left the account. Sold
+// treasuries have no sale date, so the date the exit price was recorded stands in for it.
+func treasuryRedemption(t *models.Treasury, asOf time.Time) (time.Time, int64, string, bool) {
+	if t.ExitPrice != nil {
+		date := t.UpdatedAt
+		if t.Maturity.Before(date) || date.IsZero() {
+			date = t.Maturity
+		}
+		return date, toCents(*t.ExitPrice), "Sell", true
+	}
+	if !t.Maturity.After(asOf) {
+		return t.Maturity, toCents(t.Amount), "Redeem", true
+	}
+	return time.Time{}, 0, "", false
+}
+
+// WriteLedger writes the document as Ledger or Beancount transactions
+func WriteLedger(w io.Writer, doc *Document, format LedgerFormat, accounts LedgerAccounts) error {
+	txns := BuildLedgerTransactions(doc, accounts)
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "; Wheeler export of %s on %s\n", doc.Database, doc.ExportedAt.Format(isoDate))
+	if format == FormatBeancount {
+		writeBeancountHeader(bw, txns, accounts)
+	}
+
+	for _, txn := range txns {
+		if err := checkBalanced(txn); err != nil {
+			return err
+		}
+		bw.WriteString("\n")
+		if format == FormatBeancount {
+			fmt.Fprintf(bw, "%s * %s\n", txn.Date.Format(isoDate), strconv.Quote(txn.Narration))
+			fmt.Fprintf(bw, "  wheeler_source: %s\n", strconv.Quote(txn.Source))
+		} else {
+			fmt.Fprintf(bw, "%s %s\n", txn.Date.Format("2006/01/02"), txn.Narration)
+			fmt.Fprintf(bw, "    ; wheeler-source: %s\n", txn.Source)
+		}
+		for _, p := range txn.Postings {
+			fmt.Fprintf(bw, "    %-38s  %s\n", p.Account, formatPostingAmount(p, accounts.Currency))
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// writeBeancountHeader opens every account and commodity used, as Beancount requires
+func writeBeancountHeader(bw *bufio.Writer, txns []LedgerTransaction, accounts LedgerAccounts) {
+	if len(txns) == 0 {
+		return
+	}
+	opened := txns[0].Date.Format(isoDate)
+
+	fmt.Fprintf(bw, "\noption \"operating_currency\" %s\n\n", strconv.Quote(accounts.Currency))
+	seen := map[string]bool{}
+	for _, account := range accounts.all() {
+		if !seen[account] {
+			seen[account] = true
+			fmt.Fprintf(bw, "%s open %s\n", opened, account)
+		}
+	}
+
+	var symbols []string
+	seen = map[string]bool{}
+	for _, txn := range txns {
+		for _, p := range txn.Postings {
+			if p.Symbol != "" && !seen[p.Symbol] {
+				seen[p.Symbol] = true
+				symbols = append(symbols, p.Symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		fmt.Fprintf(bw, "%s commodity %s\n", opened, symbol)
+	}
+}
+
+func checkBalanced(txn LedgerTransaction) error {
+	var total int64
+	for _, p := range txn.Postings {
+		total += p.weightCents()
+	}
+	// Lot costs with fractional cents may leave a residual of a cent, within both tools' tolerance
+	if total > 1 || total < -1 {
+		return fmt.Errorf("transaction %q on %s does not balance (off by %s)",
+			txn.Narration, txn.Date.Format(isoDate), formatCents(total))
+	}
+	return nil
+}
+
+func formatPostingAmount(p LedgerPosting, currency string) string {
+	if p.Symbol == "" {
+		return formatCents(p.Cents) + " " + currency
+	}
+	amount := fmt.Sprintf("%d %s {%s %s}", p.Units, p.Symbol, formatFloat(p.Cost), currency)
+	if p.Price != nil {
+		amount += fmt.Sprintf(" @ %s %s", formatFloat(*p.Price), currency)
+	}
+	return amount
+}
+
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"stonks/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ledgerTestDocument() *Document {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	ptrTime := func(t time.Time) *time.Time { return &t }
+	ptrFloat := func(f float64) *float64 { return &f }
+
+	return &Document{
+		Database:   "books.db",
+		ExportedAt: day(2025, 10, 1),
+		Options: []*models.Option{
+			{ID: 1, Symbol: "VZ", Type: "Put", Opened: day(2025, 1, 6), Closed: ptrTime(day(2025, 1, 24)),
+				Strike: 42.5, Expiration: day(2025, 2, 21), Premium: 1.15, Contracts: 2, ExitPrice: ptrFloat(0.2), Commission: 2.6},
+			{ID: 2, Symbol: "VZ", Type: "Call", Opened: day(2025, 2, 3), Strike: 47, Expiration: day(2025, 3, 21),
+				Premium: 0.85, Contracts: 1},
+		},
+		LongPositions: []*models.LongPosition{
+			{ID: 3, Symbol: "VZ", Opened: day(2024, 6, 2), Closed: ptrTime(day(2024, 12, 20)), Shares: 100,
+				BuyPrice: 38.125, ExitPrice: ptrFloat(44)},
+		},
+		Dividends: []*models.Dividend{
+			{ID: 4, Symbol: "VZ", Received: day(2025, 3, 14), Amount: 152.33},
+		},
+		Treasuries: []*models.Treasury{
+			{CUSPID: "912797RT1", Purchased: day(2025, 1, 2), Maturity: day(2025, 7, 3), Amount: 10000, BuyPrice: 9786.12},
+			{CUSPID: "912797ZZ9", Purchased: day(2025, 9, 2), Maturity: day(2026, 3, 3), Amount: 5000, BuyPrice: 4890},
+		},
+	}
+}
+
+func TestBuildLedgerTransactions(t *testing.T) {
+	txns := BuildLedgerTransactions(ledgerTestDocument(), DefaultLedgerAccounts())
+
+	// 2 option opens, 1 buy to close, stock buy and sell, dividend, 2 treasury buys, 1 redemption
+	if len(txns) != 9 {
+		t.Fatalf("Expected 9 transactions, got %d", len(txns))
+	}
+	for i, txn := range txns {
+		if err := checkBalanced(txn); err != nil {
+			t.Errorf("Transaction %d: %v", i, err)
+		}
+		if i > 0 && txn.Date.Before(txns[i-1].Date) {
+			t.Errorf("Transactions are not sorted by date at %d", i)
+		}
+	}
+}
+
+func TestWriteLedger(t *testing.T) {
+	tests := []struct {
+		format LedgerFormat
+		want   []string
+	}{
+		{FormatLedger, []string{
+			"2025/01/06 Sell to open 2 VZ 2025-02-21 42.5 Put\n" +
+				"    ; wheeler-source: option:1\n" +
+				"    Assets:Brokerage:Cash                   227.40 USD\n" +
+				"    Expenses:Brokerage:Commissions          2.60 USD\n" +
+				"    Income:Options:Premium                  -230.00 USD\n",
+			"2025/01/24 Buy to close 2 VZ 2025-02-21 42.5 Put\n",
+			"    Assets:Brokerage:Stocks                 -100 VZ {38.125 USD} @ 44 USD\n" +
+				"    Income:CapitalGains                     -587.50 USD\n",
+			"2025/07/03 Redeem treasury 912797RT1\n",
+		}},
+		{FormatBeancount, []string{
+			"option \"operating_currency\" \"USD\"\n",
+			"2024-06-02 open Assets:Brokerage:Cash\n",
+			"2024-06-02 commodity VZ\n",
+			"2025-03-14 * \"Dividend VZ\"\n" +
+				"  wheeler_source: \"dividend:4\"\n" +
+				"    Assets:Brokerage:Cash                   152.33 USD\n" +
+				"    Income:Dividends                        -152.33 USD\n",
+			"    Income:Interest:Treasuries              -213.88 USD\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteLedger(&buf, ledgerTestDocument(), tt.format, DefaultLedgerAccounts()); err != nil {
+				t.Fatalf("WriteLedger failed: %v", err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("Output missing:\n%s\ngot:\n%s", want, out)
+				}
+			}
+			// The call opened in February has no closing trade and the second treasury has not matured
+			if strings.Contains(out, "Buy to close 1 VZ") || strings.Count(out, "treasury 912797ZZ9") != 1 {
+				t.Errorf("Unexpected closing transaction in output:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestParseLedgerFormat(t *testing.T) {
+	if f, err := ParseLedgerFormat(" Beancount "); err != nil || f != FormatBeancount {
+		t.Errorf("Expected beancount, got %q (%v)", f, err)
+	}
+	if f, err := ParseLedgerFormat(""); err != nil || f != FormatLedger {
+		t.Errorf("Expected ledger default, got %q (%v)", f, err)
+	}
+	if _, err := ParseLedgerFormat("qif"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,70 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarWrite(t *testing.T) {
+	cal := &Calendar{
+		Name: "Wheeler",
+		Events: []Event{{
+			UID:          "option-12@wheeler",
+			Date:         time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC),
+			Summary:      "VZ $40.00 Put expires",
+			Description:  "1 contract, $0.80 premium; strike 40,\nopened 02/03/2025",
+			Categories:   []string{"Expiration", "VZ"},
+			LastModified: time.Date(2025, 2, 3, 14, 30, 0, 0, time.UTC),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Wheeler\r\n",
+		"UID:option-12@wheeler\r\n",
+		"DTSTAMP:20250301T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20250321\r\nDTEND;VALUE=DATE:20250322\r\n",
+		"SUMMARY:VZ $40.00 Put expires\r\n",
+		`DESCRIPTION:1 contract\, $0.80 premium\; strike 40\,\nopened 02/03/2025` + "\r\n",
+		"CATEGORIES:Expiration,VZ\r\n",
+		"LAST-MODIFIED:20250203T143000Z\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	summary := strings.Repeat("é", 60) // 120 octets
+	cal := &Calendar{Events: []Event{{UID: "x", Date: time.Now(), Summary: summary}}}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf, time.Now()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line %d is %d octets: %q", i, len(line), line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	if !strings.Contains(unfolded.String(), "\nSUMMARY:"+summary+"\n") {
+		t.Errorf("Expected the folded summary to unfold to the original, got %q", unfolded.String())
+	}
+}
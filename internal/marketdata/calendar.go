@@ -0,0 +1,84 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"time"
+)
+
+// CalendarService keeps stored earnings dates current from the market data provider
+type CalendarService struct {
+	marketDataService *Service
+	earningsService   *models.EarningsService
+}
+
+// NewCalendarService creates a calendar service reading earnings through the market data service
+func NewCalendarService(marketDataService *Service, earningsService *models.EarningsService) *CalendarService {
+	return &CalendarService{
+		marketDataService: marketDataService,
+		earningsService:   earningsService,
+	}
+}
+
+// RefreshEarnings replaces each symbol's upcoming provider earnings dates. Symbols with a
+// manually entered date are skipped, and a symbol the provider cannot answer for is
+// counted as failed; only cancellation of ctx returns an error.
+func (s *CalendarService) RefreshEarnings(ctx context.Context, symbols []string) (updated, failed int, err error) {
+	p, err := s.marketDataService.Provider()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	log.Printf("[MARKET DATA] Refreshing earnings dates for %d symbols from %s", len(symbols), p.Name())
+
+	today := time.Now()
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return updated, failed, err
+		}
+
+		earnings, err := p.GetEarnings(ctx, symbol, today)
+		if err != nil {
+			if ctx.Err() != nil {
+				return updated, failed, ctx.Err()
+			}
+			log.Printf("[MARKET DATA] Failed to get earnings for %s: %v", symbol, err)
+			failed++
+			continue
+		}
+
+		dates, err := earningsDates(earnings)
+		if err != nil {
+			log.Printf("[MARKET DATA] Failed to read earnings for %s: %v", symbol, err)
+			failed++
+			continue
+		}
+		replaced, err := s.earningsService.ReplaceProviderDates(symbol, dates, today)
+		if err != nil {
+			log.Printf("[MARKET DATA] Failed to store earnings for %s: %v", symbol, err)
+			failed++
+			continue
+		}
+		if replaced {
+			updated++
+		}
+	}
+
+	log.Printf("[MARKET DATA] Earnings refresh complete: %d updated, %d failed", updated, failed)
+	return updated, failed, nil
+}
+
+// earningsDates converts provider earnings to stored dates
+func earningsDates(earnings []*Earnings) ([]*models.EarningsDate, error) {
+	dates := make([]*models.EarningsDate, 0, len(earnings))
+	for _, e := range earnings {
+		date, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid earnings date %q", e.Date)
+		}
+		dates = append(dates, &models.EarningsDate{Symbol: e.Symbol, Date: date, Timing: e.Timing})
+	}
+	return dates, nil
+}
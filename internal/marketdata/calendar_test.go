@@ -0,0 +1,82 @@
+package marketdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"testing"
+	"time"
+)
+
+func TestRefreshEarnings(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	symbolService := models.NewSymbolService(testDB.DB)
+	settingService := models.NewSettingService(testDB.DB)
+	earningsService := models.NewEarningsService(testDB.DB)
+	service := NewService(symbolService, settingService, models.NewPriceHistoryService(testDB.DB))
+	calendar := NewCalendarService(service, earningsService)
+
+	for _, symbol := range []string{"VZ", "KO", "T"} {
+		if _, err := symbolService.Create(symbol); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+	}
+
+	today := time.Now()
+	soon := today.AddDate(0, 0, 10).Format("2006-01-02")
+	later := today.AddDate(0, 0, 100).Format("2006-01-02")
+	dir := t.TempDir()
+	content := "symbol,date,timing\nVZ," + soon + ",bmo\nVZ," + later + ",bmo\nKO," + soon + ",amc\n"
+	if err := os.WriteFile(filepath.Join(dir, "earnings.csv"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write earnings: %v", err)
+	}
+	settingService.SetValue(SettingProvider, ProviderFile, "")
+	settingService.SetValue(SettingDirectory, dir, "")
+
+	// KO has a date entered by hand, which the refresh keeps
+	manual := today.AddDate(0, 0, 20)
+	if err := earningsService.SetNext("KO", &manual, "", today); err != nil {
+		t.Fatalf("SetNext failed: %v", err)
+	}
+
+	updated, failed, err := calendar.RefreshEarnings(context.Background(), []string{"VZ", "KO", "T"})
+	if err != nil {
+		t.Fatalf("RefreshEarnings failed: %v", err)
+	}
+	// T has no dates in the drop folder, which is not a failure
+	if updated != 2 || failed != 0 {
+		t.Errorf("Expected 2 updated and 0 failed, got %d and %d", updated, failed)
+	}
+
+	next, err := earningsService.GetNextBySymbol(today)
+	if err != nil {
+		t.Fatalf("GetNextBySymbol failed: %v", err)
+	}
+	if vz := next["VZ"]; vz == nil || vz.Date.Format("2006-01-02") != soon || vz.Timing != models.EarningsBeforeOpen || vz.Source != models.EarningsSourceProvider {
+		t.Errorf("Unexpected VZ earnings %+v", next["VZ"])
+	}
+	if ko := next["KO"]; ko == nil || ko.Source != models.EarningsSourceManual {
+		t.Errorf("Expected KO to keep its manual date, got %+v", next["KO"])
+	}
+	if _, ok := next["T"]; ok {
+		t.Errorf("Expected no earnings for T")
+	}
+
+	// Contracts expiring after the announcement are flagged
+	contracts := []*ChainContract{
+		{OptionSnapshot: &OptionSnapshot{Underlying: "VZ", Expiration: today.AddDate(0, 0, 5).Format("2006-01-02")}},
+		{OptionSnapshot: &OptionSnapshot{Underlying: "VZ", Expiration: today.AddDate(0, 0, 30).Format("2006-01-02")}},
+		{OptionSnapshot: &OptionSnapshot{Underlying: "T", Expiration: today.AddDate(0, 0, 30).Format("2006-01-02")}},
+	}
+	FlagEarnings(contracts, next)
+	if contracts[0].Earnings != nil || contracts[1].Earnings == nil || contracts[2].Earnings != nil {
+		t.Errorf("Expected only the VZ contract expiring after earnings to be flagged")
+	}
+}
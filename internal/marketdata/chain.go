@@ -0,0 +1,213 @@
+package marketdata
+
+import (
+	"math"
+	"sort"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// ChainContract is a contract from an option chain with the figures used to pick a trade
+type ChainContract struct {
+	*OptionSnapshot
+	DTE        int     `json:"dte"`
+	Premium    float64 `json:"premium"`
+	Collateral float64 `json:"collateral"`
+	AROI       float64 `json:"aroi"`
+	PercentOTM float64 `json:"percent_otm"`
+	// Earnings is the underlying's next earnings when it falls before expiration
+	Earnings *models.EarningsDate `json:"earnings,omitempty"`
+}
+
+// NewChainContract works out days to expiration, the premium collected at the mid,
+// annualized return on collateral and how far out of the money the strike is.
+// underlyingPrice is used when the snapshot does not carry one.
+func NewChainContract(snapshot *OptionSnapshot, underlyingPrice float64, now time.Time) *ChainContract {
+	snap := *snapshot
+	if snap.UnderlyingPrice == 0 {
+		snap.UnderlyingPrice = underlyingPrice
+	}
+
+	c := &ChainContract{
+		OptionSnapshot: &snap,
+		Premium:        snap.Mid(),
+		Collateral:     snap.Strike * 100,
+	}
+
+	today := truncateDay(now)
+	expiration, err := time.Parse("2006-01-02", snap.Expiration)
+	if err == nil {
+		c.DTE = int(math.Round(expiration.Sub(today).Hours() / 24))
+
+		// Selling one contract at the mid and holding to expiration, as Wheeler records trades
+		option := &models.Option{
+			Symbol:     snap.Underlying,
+			Type:       optionType(snap.ContractType),
+			Opened:     today,
+			Closed:     &expiration,
+			Strike:     snap.Strike,
+			Expiration: expiration,
+			Premium:    c.Premium,
+			Contracts:  1,
+		}
+		c.AROI = option.CalculateAROI()
+	}
+
+	if snap.UnderlyingPrice > 0 {
+		if strings.EqualFold(snap.ContractType, "call") {
+			c.PercentOTM = (snap.Strike - snap.UnderlyingPrice) / snap.UnderlyingPrice * 100
+		} else {
+			c.PercentOTM = (snap.UnderlyingPrice - snap.Strike) / snap.UnderlyingPrice * 100
+		}
+	}
+	return c
+}
+
+// FlagEarnings sets Earnings on each contract whose underlying reports before the contract
+// expires, given each symbol's next earnings date
+func FlagEarnings(contracts []*ChainContract, next map[string]*models.EarningsDate) {
+	for _, c := range contracts {
+		earnings, ok := next[c.Underlying]
+		if !ok {
+			continue
+		}
+		expiration, err := time.Parse("2006-01-02", c.Expiration)
+		if err == nil && earnings.FallsBefore(expiration) {
+			c.Earnings = earnings
+		}
+	}
+}
+
+// optionType maps a provider contract type to the Put/Call naming used by stored options
+func optionType(contractType string) string {
+	if strings.EqualFold(contractType, "call") {
+		return "Call"
+	}
+	return "Put"
+}
+
+// ScreenCriteria selects contracts worth trading. Zero values leave a bound open;
+// delta bounds apply to the absolute delta so the same numbers work for puts and calls.
+type ScreenCriteria struct {
+	MinDTE          int     `json:"min_dte"`
+	MaxDTE          int     `json:"max_dte"`
+	MinAROI         float64 `json:"min_aroi"`
+	MinDelta        float64 `json:"min_delta"`
+	MaxDelta        float64 `json:"max_delta"`
+	MinOpenInterest float64 `json:"min_open_interest"`
+	MinOTM          float64 `json:"min_otm"`
+	MaxOTM          float64 `json:"max_otm"`
+	MinBid          float64 `json:"min_bid"`
+}
+
+// Matches reports whether a contract meets every criterion
+func (sc ScreenCriteria) Matches(c *ChainContract) bool {
+	delta := math.Abs(c.Delta)
+	switch {
+	case sc.MinDTE > 0 && c.DTE < sc.MinDTE:
+		return false
+	case sc.MaxDTE > 0 && c.DTE > sc.MaxDTE:
+		return false
+	case sc.MinAROI > 0 && c.AROI < sc.MinAROI:
+		return false
+	case sc.MinDelta > 0 && delta < sc.MinDelta:
+		return false
+	case sc.MaxDelta > 0 && delta > sc.MaxDelta:
+		return false
+	case sc.MinOpenInterest > 0 && c.OpenInterest < sc.MinOpenInterest:
+		return false
+	case sc.MinOTM != 0 && c.PercentOTM < sc.MinOTM:
+		return false
+	case sc.MaxOTM != 0 && c.PercentOTM > sc.MaxOTM:
+		return false
+	case sc.MinBid > 0 && c.Bid < sc.MinBid:
+		return false
+	}
+	return true
+}
+
+// ChainFilter returns the provider filter covering the criteria's expiration window,
+// so contracts outside it are not fetched at all
+func (sc ScreenCriteria) ChainFilter(contractType string, now time.Time) ChainFilter {
+	filter := ChainFilter{ContractType: contractType}
+	if sc.MinDTE > 0 {
+		filter.ExpirationFrom = now.AddDate(0, 0, sc.MinDTE)
+	}
+	if sc.MaxDTE > 0 {
+		filter.ExpirationTo = now.AddDate(0, 0, sc.MaxDTE)
+	}
+	return filter
+}
+
+// Sort keys accepted by SortChainContracts
+const (
+	SortByAROI         = "aroi"
+	SortByPercentOTM   = "otm"
+	SortByDelta        = "delta"
+	SortByIV           = "iv"
+	SortByOpenInterest = "oi"
+	SortByDTE          = "dte"
+	SortByStrike       = "strike"
+	SortByPremium      = "premium"
+	SortBySymbol       = "symbol"
+	SortByExpiration   = "expiration"
+)
+
+// SortChainContracts orders contracts by key, defaulting to AROI. Ties fall back to
+// symbol, expiration and strike so the order is stable between requests.
+func SortChainContracts(contracts []*ChainContract, key string, descending bool) {
+	sort.SliceStable(contracts, func(i, j int) bool {
+		a, b := contracts[i], contracts[j]
+		if c := compareChainContracts(a, b, key); c != 0 {
+			if descending {
+				return c > 0
+			}
+			return c < 0
+		}
+		for _, tieBreak := range []string{SortBySymbol, SortByExpiration, SortByStrike} {
+			if c := compareChainContracts(a, b, tieBreak); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// compareChainContracts returns -1, 0 or 1 as a sorts before, with or after b on key
+func compareChainContracts(a, b *ChainContract, key string) int {
+	switch key {
+	case SortBySymbol:
+		return strings.Compare(a.Underlying, b.Underlying)
+	case SortByExpiration:
+		return strings.Compare(a.Expiration, b.Expiration)
+	}
+
+	value := func(c *ChainContract) float64 {
+		switch key {
+		case SortByPercentOTM:
+			return c.PercentOTM
+		case SortByDelta:
+			return math.Abs(c.Delta)
+		case SortByIV:
+			return c.ImpliedVolatility
+		case SortByOpenInterest:
+			return c.OpenInterest
+		case SortByDTE:
+			return float64(c.DTE)
+		case SortByStrike:
+			return c.Strike
+		case SortByPremium:
+			return c.Premium
+		}
+		return c.AROI
+	}
+	va, vb := value(a), value(b)
+	switch {
+	case va < vb:
+		return -1
+	case va > vb:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package marketdata
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewChainContract(t *testing.T) {
+	now := time.Date(2025, 3, 1, 15, 30, 0, 0, time.UTC)
+
+	put := NewChainContract(&OptionSnapshot{
+		Underlying:   "VZ",
+		ContractType: "put",
+		Strike:       40,
+		Expiration:   "2025-03-31",
+		Bid:          0.95,
+		Ask:          1.05,
+		Delta:        -0.22,
+	}, 42, now)
+
+	if put.DTE != 30 || put.Premium != 1 || put.Collateral != 4000 {
+		t.Errorf("Unexpected DTE, premium or collateral: %+v", put)
+	}
+	// $100 on $4,000 of collateral for 30 days, annualized over 365.25 days
+	if math.Abs(put.AROI-30.4375) > 0.0001 {
+		t.Errorf("Expected AROI 30.4375, got %v", put.AROI)
+	}
+	if math.Abs(put.PercentOTM-4.7619) > 0.0001 || put.UnderlyingPrice != 42 {
+		t.Errorf("Expected put 4.76%% OTM using the stored price, got %v at %v", put.PercentOTM, put.UnderlyingPrice)
+	}
+
+	call := NewChainContract(&OptionSnapshot{ContractType: "call", Strike: 44, Expiration: "2025-03-31", Last: 0.5, UnderlyingPrice: 40}, 42, now)
+	if call.Premium != 0.5 || math.Abs(call.PercentOTM-10) > 0.0001 {
+		t.Errorf("Expected call 10%% OTM priced at the last trade, got %+v", call)
+	}
+}
+
+func TestScreenCriteriaAndSorting(t *testing.T) {
+	contracts := []*ChainContract{
+		{OptionSnapshot: &OptionSnapshot{Underlying: "VZ", Strike: 40, Delta: -0.20, OpenInterest: 500, Bid: 0.5}, DTE: 30, AROI: 18, PercentOTM: 5},
+		{OptionSnapshot: &OptionSnapshot{Underlying: "KO", Strike: 60, Delta: -0.45, OpenInterest: 900, Bid: 1.2}, DTE: 30, AROI: 35, PercentOTM: 1},
+		{OptionSnapshot: &OptionSnapshot{Underlying: "T", Strike: 25, Delta: -0.25, OpenInterest: 20, Bid: 0.3}, DTE: 30, AROI: 22, PercentOTM: 6},
+		{OptionSnapshot: &OptionSnapshot{Underlying: "PFE", Strike: 24, Delta: -0.18, OpenInterest: 800, Bid: 0.2}, DTE: 60, AROI: 25, PercentOTM: 8},
+	}
+
+	criteria := ScreenCriteria{MaxDTE: 45, MaxDelta: 0.30, MinOpenInterest: 100, MinOTM: 2}
+	var matched []*ChainContract
+	for _, c := range contracts {
+		if criteria.Matches(c) {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) != 1 || matched[0].Underlying != "VZ" {
+		t.Fatalf("Expected only VZ to pass the screen, got %d contracts", len(matched))
+	}
+
+	SortChainContracts(contracts, SortByAROI, true)
+	if contracts[0].Underlying != "KO" || contracts[3].Underlying != "VZ" {
+		t.Errorf("Expected highest AROI first, got %s..%s", contracts[0].Underlying, contracts[3].Underlying)
+	}
+	SortChainContracts(contracts, SortByDelta, false)
+	if contracts[0].Underlying != "PFE" {
+		t.Errorf("Expected lowest absolute delta first, got %s", contracts[0].Underlying)
+	}
+	SortChainContracts(contracts, SortBySymbol, false)
+	if contracts[0].Underlying != "KO" || contracts[3].Underlying != "VZ" {
+		t.Errorf("Expected alphabetical order, got %s..%s", contracts[0].Underlying, contracts[3].Underlying)
+	}
+
+	filter := ScreenCriteria{MinDTE: 7, MaxDTE: 45}.ChainFilter("put", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if filter.ExpirationFrom.Format("2006-01-02") != "2025-03-08" || filter.ExpirationTo.Format("2006-01-02") != "2025-04-15" {
+		t.Errorf("Unexpected expiration window %+v", filter)
+	}
+}
+
+func TestFileProviderOptionChain(t *testing.T) {
+	dir := writeDropFiles(t, map[string]string{
+		"options.csv": "occ_symbol,bid,ask,delta,open_interest\n" +
+			"VZ250321P00040000,0.95,1.05,-0.22,500\n" +
+			"VZ250418P00038000,0.60,0.70,-0.15,300\n" +
+			"VZ250321C00044000,0.40,0.50,0.20,200\n" +
+			"KO250321P00060000,1.10,1.20,-0.30,900\n",
+	})
+	p := NewFileProvider(dir)
+
+	chain, err := p.GetOptionChain(context.Background(), "vz", ChainFilter{
+		ContractType: "put",
+		ExpirationTo: time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("GetOptionChain failed: %v", err)
+	}
+	if len(chain) != 1 || chain[0].OCCSymbol != "VZ250321P00040000" || chain[0].Strike != 40 {
+		t.Fatalf("Expected the March VZ put only, got %+v", chain)
+	}
+
+	if _, err := p.GetOptionChain(context.Background(), "T", ChainFilter{}); err == nil {
+		t.Error("Expected error for an underlying with no contracts")
+	}
+}
@@ -0,0 +1,548 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"stonks/internal/occ"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileProvider serves market data from CSV and JSON files in a drop folder.
+//
+// CSV files are matched by name: quotes*.csv or prices*.csv hold price bars,
+// dividends*.csv dividends, tickers*.csv or details*.csv ticker details,
+// earnings*.csv earnings dates and options*.csv option snapshots. Columns are
+// matched by header using the JSON field names of Quote, Dividend, TickerDetails,
+// Earnings and OptionSnapshot. A JSON file holds an object with "quotes",
+// "dividends", "tickers", "earnings" and "options" arrays.
+// The folder is re-read on every request so dropped files take effect at once.
+type FileProvider struct {
+	dir string
+}
+
+// NewFileProvider creates a provider reading from dir
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Directory returns the drop folder the provider reads from
+func (p *FileProvider) Directory() string {
+	return p.dir
+}
+
+// Name returns the provider name
+func (p *FileProvider) Name() string {
+	return ProviderFile
+}
+
+// TestConnection checks that the drop folder exists and its files parse
+func (p *FileProvider) TestConnection(ctx context.Context) error {
+	_, err := p.load()
+	return err
+}
+
+// GetQuote returns the most recent price bar for a symbol
+func (p *FileProvider) GetQuote(ctx context.Context, symbol string) (*Quote, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := data.quotesFor(symbol)
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no price for %s in %s", normalizeSymbol(symbol), p.dir)
+	}
+
+	latest := *quotes[len(quotes)-1]
+	if latest.PreviousClose == 0 && len(quotes) > 1 {
+		latest.PreviousClose = quotes[len(quotes)-2].Price
+	}
+	return &latest, nil
+}
+
+// GetPreviousClose returns the most recent price bar for a symbol. The drop folder
+// has no intraday data, so the last recorded price is the previous close.
+func (p *FileProvider) GetPreviousClose(ctx context.Context, symbol string) (*Quote, error) {
+	return p.GetQuote(ctx, symbol)
+}
+
+// GetDailyBars returns the dated price bars for a symbol between from and to inclusive
+func (p *FileProvider) GetDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]*Quote, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var bars []*Quote
+	var previous float64
+	for _, q := range data.quotesFor(symbol) {
+		if q.Date.IsZero() {
+			continue
+		}
+		bar := *q
+		if bar.PreviousClose == 0 {
+			bar.PreviousClose = previous
+		}
+		previous = q.Price
+		if bar.Date.Before(from) || bar.Date.After(to) {
+			continue
+		}
+		bars = append(bars, &bar)
+	}
+	return bars, nil
+}
+
+// GetDividends returns up to limit dividends for a symbol, newest ex-date first
+func (p *FileProvider) GetDividends(ctx context.Context, symbol string, limit int) ([]*Dividend, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = normalizeSymbol(symbol)
+	var result []*Dividend
+	for _, div := range data.Dividends {
+		if normalizeSymbol(div.Symbol) == symbol {
+			result = append(result, div)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ExDividendDate > result[j].ExDividendDate
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// GetTickerDetails returns reference data for a symbol
+func (p *FileProvider) GetTickerDetails(ctx context.Context, symbol string) (*TickerDetails, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = normalizeSymbol(symbol)
+	for _, details := range data.Tickers {
+		if normalizeSymbol(details.Symbol) == symbol {
+			return details, nil
+		}
+	}
+	return nil, fmt.Errorf("no ticker details for %s in %s", symbol, p.dir)
+}
+
+// GetEarnings returns a symbol's earnings dates on or after from, soonest first
+func (p *FileProvider) GetEarnings(ctx context.Context, symbol string, from time.Time) ([]*Earnings, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = normalizeSymbol(symbol)
+	day := truncateDay(from).Format("2006-01-02")
+	var result []*Earnings
+	for _, e := range data.Earnings {
+		if normalizeSymbol(e.Symbol) == symbol && e.Date >= day {
+			result = append(result, e)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result, nil
+}
+
+// GetOptionSnapshot returns the snapshot for an OCC symbol
+func (p *FileProvider) GetOptionSnapshot(ctx context.Context, underlying, occSymbol string) (*OptionSnapshot, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	key := normalizeOCC(occSymbol)
+	for _, snapshot := range data.Options {
+		if normalizeOCC(snapshot.OCCSymbol) == key {
+			return snapshot, nil
+		}
+	}
+	return nil, fmt.Errorf("no option snapshot for %s in %s", key, p.dir)
+}
+
+// GetOptionChain returns the underlying's option snapshots in the drop folder matching filter
+func (p *FileProvider) GetOptionChain(ctx context.Context, underlying string, filter ChainFilter) ([]*OptionSnapshot, error) {
+	data, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := normalizeSymbol(underlying)
+	var chain []*OptionSnapshot
+	for _, snapshot := range data.Options {
+		if normalizeSymbol(snapshot.Underlying) == symbol && filter.Matches(snapshot) {
+			chain = append(chain, snapshot)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no option chain for %s in %s", symbol, p.dir)
+	}
+	return chain, nil
+}
+
+// fileData is the merged content of the drop folder, also the JSON file layout
+type fileData struct {
+	Quotes    []*Quote          `json:"quotes"`
+	Dividends []*Dividend       `json:"dividends"`
+	Tickers   []*TickerDetails  `json:"tickers"`
+	Earnings  []*Earnings       `json:"earnings"`
+	Options   []*OptionSnapshot `json:"options"`
+}
+
+// quotesFor returns the quotes for a symbol in date order
+func (d *fileData) quotesFor(symbol string) []*Quote {
+	symbol = normalizeSymbol(symbol)
+	var quotes []*Quote
+	for _, q := range d.Quotes {
+		if normalizeSymbol(q.Symbol) == symbol {
+			quotes = append(quotes, q)
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Date.Before(quotes[j].Date)
+	})
+	return quotes
+}
+
+// load reads every CSV and JSON file in the drop folder
+func (p *FileProvider) load() (*fileData, error) {
+	if p.dir == "" {
+		return nil, fmt.Errorf("market data directory not configured")
+	}
+
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read market data directory: %w", err)
+	}
+
+	data := &fileData{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(p.dir, name)
+
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json":
+			if err := loadJSONFile(path, data); err != nil {
+				return nil, err
+			}
+		case ".csv":
+			if err := loadCSVFile(path, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return data, nil
+}
+
+func loadJSONFile(path string, data *fileData) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	// Quote dates are written as plain YYYY-MM-DD in drop files
+	var file struct {
+		fileData
+		Quotes []struct {
+			Quote
+			Date string `json:"date"`
+		} `json:"quotes"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	for _, fq := range file.Quotes {
+		q := fq.Quote
+		q.Symbol = normalizeSymbol(q.Symbol)
+		if fq.Date != "" {
+			date, err := time.Parse("2006-01-02", fq.Date)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: invalid date %q for %s", filepath.Base(path), fq.Date, q.Symbol)
+			}
+			q.Date = date
+		}
+		data.Quotes = append(data.Quotes, &q)
+	}
+	data.Dividends = append(data.Dividends, file.Dividends...)
+	data.Tickers = append(data.Tickers, file.Tickers...)
+	data.Earnings = append(data.Earnings, file.Earnings...)
+	data.Options = append(data.Options, file.Options...)
+	return nil
+}
+
+func loadCSVFile(path string, data *fileData) error {
+	base := strings.ToLower(filepath.Base(path))
+	kind := ""
+	for _, prefix := range []string{"quote", "price", "dividend", "ticker", "detail", "earning", "option"} {
+		if strings.HasPrefix(base, prefix) {
+			kind = prefix
+			break
+		}
+	}
+	if kind == "" {
+		// Unrecognised files are left alone so the folder can hold notes or other exports
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("failed to read %s line %d: %w", filepath.Base(path), line, err)
+		}
+
+		row := csvRow{columns: columns, record: record}
+		if row.text("symbol", "ticker", "occ_symbol", "underlying") == "" {
+			continue
+		}
+
+		switch kind {
+		case "quote", "price":
+			q, err := row.quote()
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+			}
+			data.Quotes = append(data.Quotes, q)
+		case "dividend":
+			d, err := row.dividend()
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+			}
+			data.Dividends = append(data.Dividends, d)
+		case "ticker", "detail":
+			t, err := row.tickerDetails()
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+			}
+			data.Tickers = append(data.Tickers, t)
+		case "earning":
+			e, err := row.earnings()
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+			}
+			data.Earnings = append(data.Earnings, e)
+		case "option":
+			o, err := row.optionSnapshot()
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", filepath.Base(path), line, err)
+			}
+			data.Options = append(data.Options, o)
+		}
+	}
+
+	return nil
+}
+
+// csvRow reads named columns from a CSV record
+type csvRow struct {
+	columns map[string]int
+	record  []string
+	err     error
+}
+
+// text returns the first non-empty value among the named columns
+func (r *csvRow) text(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.columns[name]; ok && i < len(r.record) {
+			if v := strings.TrimSpace(r.record[i]); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// number parses the first non-empty named column, recording the first error
+func (r *csvRow) number(names ...string) float64 {
+	v := strings.NewReplacer("$", "", ",", "").Replace(r.text(names...))
+	if v == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid number %q for %s", v, names[0])
+	}
+	return f
+}
+
+func (r *csvRow) quote() (*Quote, error) {
+	q := &Quote{
+		Symbol:        normalizeSymbol(r.text("symbol", "ticker")),
+		Price:         r.number("price", "close"),
+		Open:          r.number("open"),
+		High:          r.number("high"),
+		Low:           r.number("low"),
+		PreviousClose: r.number("previous_close"),
+		Volume:        r.number("volume"),
+	}
+	if date := r.text("date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", date)
+		}
+		q.Date = parsed
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if q.Price <= 0 {
+		return nil, fmt.Errorf("price for %s must be positive", q.Symbol)
+	}
+	return q, nil
+}
+
+func (r *csvRow) dividend() (*Dividend, error) {
+	d := &Dividend{
+		Symbol:          normalizeSymbol(r.text("symbol", "ticker")),
+		CashAmount:      r.number("cash_amount", "amount"),
+		DeclarationDate: r.text("declaration_date"),
+		ExDividendDate:  r.text("ex_dividend_date"),
+		PayDate:         r.text("pay_date"),
+		RecordDate:      r.text("record_date"),
+		DividendType:    r.text("dividend_type"),
+		Frequency:       int(r.number("frequency")),
+	}
+	return d, r.err
+}
+
+func (r *csvRow) tickerDetails() (*TickerDetails, error) {
+	active := strings.ToLower(r.text("active"))
+	t := &TickerDetails{
+		Symbol:      normalizeSymbol(r.text("symbol", "ticker")),
+		Name:        r.text("name"),
+		Market:      r.text("market"),
+		Type:        r.text("type"),
+		Active:      active == "" || active == "true" || active == "1" || active == "yes",
+		Currency:    r.text("currency"),
+		Description: r.text("description"),
+		Homepage:    r.text("homepage"),
+		MarketCap:   r.number("market_cap"),
+		Employees:   int(r.number("employees")),
+	}
+	return t, r.err
+}
+
+func (r *csvRow) earnings() (*Earnings, error) {
+	confirmed := strings.ToLower(r.text("confirmed"))
+	e := &Earnings{
+		Symbol:    normalizeSymbol(r.text("symbol", "ticker")),
+		Date:      r.text("date", "earnings_date"),
+		Timing:    r.text("timing", "time"),
+		Confirmed: confirmed == "true" || confirmed == "1" || confirmed == "yes",
+	}
+	if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+		return nil, fmt.Errorf("invalid earnings date %q for %s", e.Date, e.Symbol)
+	}
+	return e, nil
+}
+
+func (r *csvRow) optionSnapshot() (*OptionSnapshot, error) {
+	o := &OptionSnapshot{
+		OCCSymbol:         normalizeOCC(r.text("occ_symbol", "ticker", "symbol")),
+		Underlying:        normalizeSymbol(r.text("underlying")),
+		ContractType:      r.text("contract_type", "type"),
+		Strike:            r.number("strike"),
+		Expiration:        r.text("expiration"),
+		Bid:               r.number("bid"),
+		Ask:               r.number("ask"),
+		Last:              r.number("last"),
+		ImpliedVolatility: r.number("implied_volatility", "iv"),
+		Delta:             r.number("delta"),
+		Gamma:             r.number("gamma"),
+		Theta:             r.number("theta"),
+		Vega:              r.number("vega"),
+		OpenInterest:      r.number("open_interest"),
+		Volume:            r.number("volume"),
+		UnderlyingPrice:   r.number("underlying_price"),
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// Contract terms can be left out of the file as the OCC symbol encodes them
+	if contract, err := occ.Parse(o.OCCSymbol); err == nil {
+		if o.Underlying == "" {
+			o.Underlying = contract.Underlying
+		}
+		if o.ContractType == "" {
+			o.ContractType = contract.Type
+		}
+		if o.Strike == 0 {
+			o.Strike = contract.Strike
+		}
+		if o.Expiration == "" {
+			o.Expiration = contract.Expiration.Format("2006-01-02")
+		}
+	} else if o.OCCSymbol == "" && o.ContractType != "" {
+		// Chain exports often list contract terms without the OCC symbol
+		if expiration, err := time.Parse("2006-01-02", o.Expiration); err == nil {
+			if symbol, err := occ.Format(o.Underlying, optionType(o.ContractType), expiration, o.Strike); err == nil {
+				o.OCCSymbol = symbol
+			}
+		}
+	}
+	return o, nil
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// normalizeOCC reduces padded, O:-prefixed and bare OCC symbols to one form
+func normalizeOCC(symbol string) string {
+	if contract, err := occ.Parse(symbol); err == nil {
+		if s := contract.String(); s != "" {
+			return s
+		}
+	}
+	return strings.TrimPrefix(normalizeSymbol(symbol), occ.PolygonPrefix)
+}
@@ -0,0 +1,145 @@
+package marketdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeDropFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestFileProviderQuotes(t *testing.T) {
+	dir := writeDropFiles(t, map[string]string{
+		"quotes.csv": "\ufeffSymbol,Date,Close,High,Low,Volume\n" +
+			"vz,2025-01-03,40.10,40.50,39.80,1000\n" +
+			"VZ,2025-01-02,39.75,40.00,39.50,900\n" +
+			",,,,,\n",
+		"snapshot.json": `{"quotes":[{"symbol":"KO","date":"2025-01-03","price":62.25}]}`,
+		"README.csv":    "not,market,data\n",
+	})
+	p := NewFileProvider(dir)
+	ctx := context.Background()
+
+	quote, err := p.GetPreviousClose(ctx, "VZ")
+	if err != nil {
+		t.Fatalf("GetPreviousClose failed: %v", err)
+	}
+	if quote.Price != 40.10 || quote.PreviousClose != 39.75 || quote.Date.Format("2006-01-02") != "2025-01-03" {
+		t.Errorf("Unexpected VZ quote: %+v", quote)
+	}
+
+	quote, err = p.GetQuote(ctx, "ko")
+	if err != nil {
+		t.Fatalf("GetQuote from JSON failed: %v", err)
+	}
+	if quote.Price != 62.25 {
+		t.Errorf("Expected KO at 62.25, got %v", quote.Price)
+	}
+
+	if _, err := p.GetQuote(ctx, "T"); err == nil {
+		t.Error("Expected error for symbol missing from the drop folder")
+	}
+	if err := p.TestConnection(ctx); err != nil {
+		t.Errorf("TestConnection failed: %v", err)
+	}
+}
+
+func TestFileProviderDividendsAndDetails(t *testing.T) {
+	dir := writeDropFiles(t, map[string]string{
+		"dividends.csv": "symbol,cash_amount,ex_dividend_date,pay_date,frequency\n" +
+			"VZ,0.665,2024-10-10,2024-11-01,4\n" +
+			"VZ,0.6775,2025-01-10,2025-02-03,4\n" +
+			"KO,0.485,2024-11-29,2024-12-16,4\n",
+		"tickers.csv": "symbol,name,market_cap,active\nVZ,Verizon Communications,170000000000,true\n",
+	})
+	p := NewFileProvider(dir)
+	ctx := context.Background()
+
+	dividends, err := p.GetDividends(ctx, "VZ", 1)
+	if err != nil {
+		t.Fatalf("GetDividends failed: %v", err)
+	}
+	if len(dividends) != 1 || dividends[0].CashAmount != 0.6775 || dividends[0].Frequency != 4 {
+		t.Errorf("Expected the newest VZ dividend, got %+v", dividends)
+	}
+
+	details, err := p.GetTickerDetails(ctx, "VZ")
+	if err != nil {
+		t.Fatalf("GetTickerDetails failed: %v", err)
+	}
+	if details.Name != "Verizon Communications" || !details.Active || details.MarketCap != 170e9 {
+		t.Errorf("Unexpected ticker details: %+v", details)
+	}
+}
+
+func TestFileProviderEarnings(t *testing.T) {
+	dir := writeDropFiles(t, map[string]string{
+		"earnings.csv": "symbol,date,timing,confirmed\n" +
+			"VZ,2025-04-22,bmo,true\n" +
+			"VZ,2025-01-24,bmo,true\n" +
+			"VZ,2024-10-22,bmo,true\n",
+		"calendar.json": `{"earnings":[{"symbol":"KO","date":"2025-02-11","timing":"bmo"}]}`,
+	})
+	p := NewFileProvider(dir)
+	ctx := context.Background()
+
+	earnings, err := p.GetEarnings(ctx, "vz", time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetEarnings failed: %v", err)
+	}
+	if len(earnings) != 2 || earnings[0].Date != "2025-01-24" || !earnings[0].Confirmed || earnings[0].Timing != "bmo" {
+		t.Errorf("Expected the two upcoming VZ dates soonest first, got %+v", earnings)
+	}
+
+	earnings, err = p.GetEarnings(ctx, "KO", time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(earnings) != 1 {
+		t.Errorf("Expected KO earnings from JSON, got %+v (%v)", earnings, err)
+	}
+
+	bad := writeDropFiles(t, map[string]string{"earnings.csv": "symbol,date\nVZ,next week\n"})
+	if _, err := NewFileProvider(bad).GetEarnings(ctx, "VZ", time.Now()); err == nil {
+		t.Error("Expected error for an invalid earnings date")
+	}
+}
+
+func TestFileProviderOptionSnapshot(t *testing.T) {
+	dir := writeDropFiles(t, map[string]string{
+		"options.csv": "occ_symbol,bid,ask,delta,implied_volatility\nVZ    250221P00042500,0.45,0.55,-0.31,0.22\n",
+	})
+	p := NewFileProvider(dir)
+
+	snapshot, err := p.GetOptionSnapshot(context.Background(), "VZ", "O:VZ250221P00042500")
+	if err != nil {
+		t.Fatalf("GetOptionSnapshot failed: %v", err)
+	}
+	if snapshot.Underlying != "VZ" || snapshot.ContractType != "Put" || snapshot.Strike != 42.5 ||
+		snapshot.Expiration != "2025-02-21" || snapshot.Mid() != 0.5 || snapshot.Delta != -0.31 {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestFileProviderErrors(t *testing.T) {
+	ctx := context.Background()
+
+	if err := NewFileProvider(filepath.Join(t.TempDir(), "missing")).TestConnection(ctx); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+
+	dir := writeDropFiles(t, map[string]string{"prices.csv": "symbol,price\nVZ,abc\n"})
+	err := NewFileProvider(dir).TestConnection(ctx)
+	if err == nil || !strings.Contains(err.Error(), "prices.csv line 2") {
+		t.Errorf("Expected line-numbered parse error, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"time"
+)
+
+// MarkService marks open options to market so their unrealized profit can be shown
+type MarkService struct {
+	marketDataService *Service
+	optionService     *models.OptionService
+}
+
+// NewMarkService creates a mark service reading quotes through the market data service
+func NewMarkService(marketDataService *Service, optionService *models.OptionService) *MarkService {
+	return &MarkService{
+		marketDataService: marketDataService,
+		optionService:     optionService,
+	}
+}
+
+// MarkOpenOptions fetches the mid of every open, unexpired option and stores it as the
+// option's current price. Positions sharing a contract are quoted once. A contract that
+// cannot be quoted is counted as failed and keeps its previous mark; only cancellation
+// of ctx returns an error.
+func (s *MarkService) MarkOpenOptions(ctx context.Context) (marked, failed int, err error) {
+	options, err := s.optionService.GetOpen()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get open options: %w", err)
+	}
+
+	log.Printf("[MARKET DATA] Marking %d open options to market", len(options))
+
+	today := truncateDay(time.Now())
+	marks := make(map[string]float64)
+	for _, option := range options {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[MARKET DATA] Option marks cancelled: %d marked, %d failed", marked, failed)
+			return marked, failed, err
+		}
+		if option.Expiration.Before(today) {
+			continue
+		}
+
+		occSymbol := option.GetOCCSymbol()
+		mark, ok := marks[occSymbol]
+		if !ok {
+			mark, err = s.fetchMark(ctx, option)
+			if err != nil {
+				if ctx.Err() != nil {
+					log.Printf("[MARKET DATA] Option marks cancelled: %d marked, %d failed", marked, failed)
+					return marked, failed, ctx.Err()
+				}
+				log.Printf("[MARKET DATA] Failed to mark %s: %v", occSymbol, err)
+				failed++
+				continue
+			}
+			marks[occSymbol] = mark
+		}
+
+		if err := s.optionService.UpdateCurrentPrice(option.ID, mark, time.Now()); err != nil {
+			log.Printf("[MARKET DATA] Failed to store mark for option %d: %v", option.ID, err)
+			failed++
+			continue
+		}
+		marked++
+	}
+
+	log.Printf("[MARKET DATA] Option marks complete: %d marked, %d failed", marked, failed)
+	return marked, failed, nil
+}
+
+// fetchMark returns the option's mid, falling back to the last trade
+func (s *MarkService) fetchMark(ctx context.Context, option *models.Option) (float64, error) {
+	snapshot, err := s.marketDataService.FetchOptionSnapshot(ctx, option)
+	if err != nil {
+		return 0, err
+	}
+	mark := snapshot.Mid()
+	if mark <= 0 {
+		return 0, fmt.Errorf("no bid, ask or last price for %s", option.GetOCCSymbol())
+	}
+	return mark, nil
+}
@@ -0,0 +1,98 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"testing"
+	"time"
+)
+
+func TestMarkOpenOptions(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	symbolService := models.NewSymbolService(testDB.DB)
+	settingService := models.NewSettingService(testDB.DB)
+	optionService := models.NewOptionService(testDB.DB)
+	service := NewService(symbolService, settingService, models.NewPriceHistoryService(testDB.DB))
+	marks := NewMarkService(service, optionService)
+
+	if _, err := symbolService.Create("VZ"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	today := truncateDay(time.Now())
+	expiration := today.AddDate(0, 0, 30)
+	quoted, err := optionService.Create("VZ", "Put", today.AddDate(0, 0, -10), 40, expiration, 1.00, 2)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+	unquoted, err := optionService.Create("VZ", "Put", today.AddDate(0, 0, -10), 35, expiration, 0.50, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+	closed, err := optionService.Create("VZ", "Put", today.AddDate(0, 0, -10), 40, expiration, 1.20, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+	if err := optionService.CloseByID(closed.ID, today, 0.10); err != nil {
+		t.Fatalf("Failed to close option: %v", err)
+	}
+
+	dir := t.TempDir()
+	content := fmt.Sprintf("occ_symbol,bid,ask\n%s,0.25,0.35\n", quoted.GetOCCSymbol())
+	if err := os.WriteFile(filepath.Join(dir, "options.csv"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write options: %v", err)
+	}
+	settingService.SetValue(SettingProvider, ProviderFile, "")
+	settingService.SetValue(SettingDirectory, dir, "")
+
+	// The closed position shares the quoted contract but is not open
+	marked, failed, err := marks.MarkOpenOptions(context.Background())
+	if err != nil {
+		t.Fatalf("MarkOpenOptions failed: %v", err)
+	}
+	if marked != 1 || failed != 1 {
+		t.Errorf("Expected 1 marked and 1 failed, got %d marked and %d failed", marked, failed)
+	}
+
+	option, err := optionService.GetByID(quoted.ID)
+	if err != nil {
+		t.Fatalf("Failed to get option: %v", err)
+	}
+	if option.CurrentPrice == nil || *option.CurrentPrice != 0.30 {
+		t.Fatalf("Expected mark 0.30, got %v", option.CurrentPrice)
+	}
+	if option.CurrentPriceUpdatedAt == nil || time.Since(*option.CurrentPriceUpdatedAt) > time.Minute {
+		t.Errorf("Expected a recent mark timestamp, got %v", option.CurrentPriceUpdatedAt)
+	}
+	// 2 contracts sold at 1.00 and worth 0.30: $140 of $200 less $1.30 commission
+	if percent := option.CalculatePercentOfProfit(); percent < 69 || percent > 70 {
+		t.Errorf("Expected about 69.35%% of max profit, got %.2f", percent)
+	}
+	if !option.ShouldConsiderClosing(50) || option.ShouldConsiderClosing(75) {
+		t.Errorf("Expected to consider closing at a 50%% target but not at 75%%")
+	}
+
+	option, err = optionService.GetByID(unquoted.ID)
+	if err != nil {
+		t.Fatalf("Failed to get option: %v", err)
+	}
+	if option.CurrentPrice != nil || option.HasMark() {
+		t.Errorf("Expected no mark for the unquoted option, got %v", *option.CurrentPrice)
+	}
+
+	option, err = optionService.GetByID(closed.ID)
+	if err != nil {
+		t.Fatalf("Failed to get option: %v", err)
+	}
+	if option.CurrentPrice != nil {
+		t.Errorf("Expected closed option to be left unmarked, got %v", *option.CurrentPrice)
+	}
+}
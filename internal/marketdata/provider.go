@@ -0,0 +1,171 @@
+// Package marketdata defines the MarketDataProvider interface Wheeler uses for
+// quotes, dividends, ticker details, earnings dates and option snapshots, along with the Service
+// that applies provider data to stored symbols.
+//
+// Polygon.io is one implementation (see the polygon package). FileProvider reads
+// prices from a local drop folder so Wheeler can run offline or under test.
+package marketdata
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Provider names accepted by the MARKET_DATA_PROVIDER setting
+const (
+	ProviderPolygon = "polygon"
+	ProviderFile    = "file"
+)
+
+// Settings that control provider selection
+const (
+	SettingProvider  = "MARKET_DATA_PROVIDER"
+	SettingDirectory = "MARKET_DATA_DIR"
+)
+
+// DefaultProvider is used when MARKET_DATA_PROVIDER is unset
+const DefaultProvider = ProviderPolygon
+
+// DefaultDirectory is the drop folder read by the file provider when MARKET_DATA_DIR is unset
+const DefaultDirectory = "./data/marketdata"
+
+// MarketDataProvider is a source of market data. Providers with request limits pace their
+// own calls, blocking each method until a request is allowed or ctx is done.
+type MarketDataProvider interface {
+	// Name returns the provider name as stored in MARKET_DATA_PROVIDER
+	Name() string
+	// GetQuote returns the latest available quote for a symbol
+	GetQuote(ctx context.Context, symbol string) (*Quote, error)
+	// GetPreviousClose returns the most recent completed trading day for a symbol
+	GetPreviousClose(ctx context.Context, symbol string) (*Quote, error)
+	// GetDailyBars returns daily price bars for a symbol between from and to inclusive, oldest first
+	GetDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]*Quote, error)
+	// GetDividends returns up to limit recent dividends for a symbol, newest first
+	GetDividends(ctx context.Context, symbol string, limit int) ([]*Dividend, error)
+	// GetTickerDetails returns reference data about a symbol
+	GetTickerDetails(ctx context.Context, symbol string) (*TickerDetails, error)
+	// GetEarnings returns a symbol's scheduled earnings announcements on or after from, soonest first
+	GetEarnings(ctx context.Context, symbol string, from time.Time) ([]*Earnings, error)
+	// GetOptionSnapshot returns market data for an option contract by OCC symbol
+	GetOptionSnapshot(ctx context.Context, underlying, occSymbol string) (*OptionSnapshot, error)
+	// GetOptionChain returns snapshots of the underlying's listed contracts matching filter
+	GetOptionChain(ctx context.Context, underlying string, filter ChainFilter) ([]*OptionSnapshot, error)
+	// TestConnection checks that the provider is configured and reachable
+	TestConnection(ctx context.Context) error
+}
+
+// Quote represents a price bar for a symbol
+type Quote struct {
+	Symbol        string    `json:"symbol"`
+	Price         float64   `json:"price"`
+	Open          float64   `json:"open"`
+	High          float64   `json:"high"`
+	Low           float64   `json:"low"`
+	PreviousClose float64   `json:"previous_close"`
+	Volume        float64   `json:"volume"`
+	Date          time.Time `json:"date"`
+}
+
+// Dividend represents a declared dividend for a symbol
+type Dividend struct {
+	Symbol          string  `json:"symbol"`
+	CashAmount      float64 `json:"cash_amount"`
+	DeclarationDate string  `json:"declaration_date"`
+	ExDividendDate  string  `json:"ex_dividend_date"`
+	PayDate         string  `json:"pay_date"`
+	RecordDate      string  `json:"record_date"`
+	DividendType    string  `json:"dividend_type"`
+	Frequency       int     `json:"frequency"`
+}
+
+// TickerDetails represents reference information about a symbol
+type TickerDetails struct {
+	Symbol      string  `json:"symbol"`
+	Name        string  `json:"name"`
+	Market      string  `json:"market"`
+	Type        string  `json:"type"`
+	Active      bool    `json:"active"`
+	Currency    string  `json:"currency"`
+	Description string  `json:"description"`
+	Homepage    string  `json:"homepage"`
+	MarketCap   float64 `json:"market_cap"`
+	Employees   int     `json:"employees"`
+}
+
+// Earnings represents a scheduled earnings announcement
+type Earnings struct {
+	Symbol    string `json:"symbol"`
+	Date      string `json:"date"`
+	Timing    string `json:"timing"` // bmo, amc or blank when unknown
+	Confirmed bool   `json:"confirmed"`
+}
+
+// OptionSnapshot represents market data for a single option contract
+type OptionSnapshot struct {
+	OCCSymbol         string  `json:"occ_symbol"`
+	Underlying        string  `json:"underlying"`
+	ContractType      string  `json:"contract_type"`
+	Strike            float64 `json:"strike"`
+	Expiration        string  `json:"expiration"`
+	Bid               float64 `json:"bid"`
+	Ask               float64 `json:"ask"`
+	Last              float64 `json:"last"`
+	ImpliedVolatility float64 `json:"implied_volatility"`
+	Delta             float64 `json:"delta"`
+	Gamma             float64 `json:"gamma"`
+	Theta             float64 `json:"theta"`
+	Vega              float64 `json:"vega"`
+	OpenInterest      float64 `json:"open_interest"`
+	Volume            float64 `json:"volume"`
+	UnderlyingPrice   float64 `json:"underlying_price"`
+}
+
+// ChainFilter narrows an option chain request. Zero values leave a bound open.
+type ChainFilter struct {
+	ContractType   string    `json:"contract_type"` // put or call; empty for both
+	ExpirationFrom time.Time `json:"expiration_from"`
+	ExpirationTo   time.Time `json:"expiration_to"`
+	StrikeMin      float64   `json:"strike_min"`
+	StrikeMax      float64   `json:"strike_max"`
+}
+
+// Matches reports whether a contract falls within the filter
+func (f ChainFilter) Matches(o *OptionSnapshot) bool {
+	if f.ContractType != "" && !strings.EqualFold(o.ContractType, f.ContractType) {
+		return false
+	}
+	if f.StrikeMin > 0 && o.Strike < f.StrikeMin {
+		return false
+	}
+	if f.StrikeMax > 0 && o.Strike > f.StrikeMax {
+		return false
+	}
+	if f.ExpirationFrom.IsZero() && f.ExpirationTo.IsZero() {
+		return true
+	}
+	expiration, err := time.Parse("2006-01-02", o.Expiration)
+	if err != nil {
+		return false
+	}
+	if !f.ExpirationFrom.IsZero() && expiration.Before(truncateDay(f.ExpirationFrom)) {
+		return false
+	}
+	if !f.ExpirationTo.IsZero() && expiration.After(truncateDay(f.ExpirationTo)) {
+		return false
+	}
+	return true
+}
+
+// truncateDay drops the time of day, keeping the calendar date
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Mid returns the bid/ask midpoint, falling back to the last trade
+func (o *OptionSnapshot) Mid() float64 {
+	if o.Bid > 0 && o.Ask > 0 {
+		return (o.Bid + o.Ask) / 2
+	}
+	return o.Last
+}
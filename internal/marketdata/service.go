@@ -0,0 +1,362 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// Service applies market data from the configured provider to Wheeler's symbols
+type Service struct {
+	symbolService       *models.SymbolService
+	settingService      *models.SettingService
+	priceHistoryService *models.PriceHistoryService
+	providers           map[string]MarketDataProvider
+}
+
+// NewService creates a market data service. The file provider is always available;
+// network providers such as Polygon are passed in by the caller.
+func NewService(symbolService *models.SymbolService, settingService *models.SettingService, priceHistoryService *models.PriceHistoryService, providers ...MarketDataProvider) *Service {
+	s := &Service{
+		symbolService:       symbolService,
+		settingService:      settingService,
+		priceHistoryService: priceHistoryService,
+		providers:           make(map[string]MarketDataProvider),
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// ProviderName returns the provider selected in settings
+func (s *Service) ProviderName() string {
+	name := strings.ToLower(strings.TrimSpace(s.settingService.GetValue(SettingProvider)))
+	if name == "" {
+		return DefaultProvider
+	}
+	return name
+}
+
+// ProviderNames returns the names of all selectable providers
+func (s *Service) ProviderNames() []string {
+	names := []string{ProviderFile}
+	for name := range s.providers {
+		if name != ProviderFile {
+			names = append(names, name)
+		}
+	}
+	// Keep the default first so it appears first in the settings page
+	for i, name := range names {
+		if name == DefaultProvider {
+			names[0], names[i] = names[i], names[0]
+		}
+	}
+	return names
+}
+
+// Directory returns the drop folder used by the file provider
+func (s *Service) Directory() string {
+	return s.settingService.GetValueWithDefault(SettingDirectory, DefaultDirectory)
+}
+
+// Provider returns the provider selected in settings
+func (s *Service) Provider() (MarketDataProvider, error) {
+	name := s.ProviderName()
+	if name == ProviderFile {
+		return NewFileProvider(s.Directory()), nil
+	}
+	if p, ok := s.providers[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown market data provider %q", name)
+}
+
+// UpdateSymbolPrice updates a single symbol's price from the previous close
+func (s *Service) UpdateSymbolPrice(ctx context.Context, symbol string) error {
+	p, err := s.Provider()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[MARKET DATA] Updating price for symbol %s from %s", symbol, p.Name())
+
+	quote, err := p.GetPreviousClose(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get quote for %s: %w", symbol, err)
+	}
+
+	currentSymbol, err := s.symbolService.GetBySymbol(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get current symbol data: %w", err)
+	}
+
+	_, err = s.symbolService.Update(
+		symbol,
+		quote.Price,
+		currentSymbol.Dividend,
+		currentSymbol.ExDividendDate,
+		currentSymbol.PERatio,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update symbol price: %w", err)
+	}
+
+	// Keep the close in the price history when the provider dates its bars
+	if !quote.Date.IsZero() {
+		if err := s.priceHistoryService.Upsert(priceBar(symbol, quote)); err != nil {
+			log.Printf("[MARKET DATA] Warning: failed to record price history for %s: %v", symbol, err)
+		}
+	}
+
+	log.Printf("[MARKET DATA] Updated %s price to $%.2f", symbol, quote.Price)
+	return nil
+}
+
+// BackfillPriceHistory stores daily bars for a symbol between from and to and returns how many were saved
+func (s *Service) BackfillPriceHistory(ctx context.Context, symbol string, from, to time.Time) (int, error) {
+	p, err := s.Provider()
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[MARKET DATA] Backfilling %s price history from %s to %s using %s",
+		symbol, from.Format("2006-01-02"), to.Format("2006-01-02"), p.Name())
+
+	bars, err := p.GetDailyBars(ctx, symbol, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily bars for %s: %w", symbol, err)
+	}
+
+	saved := 0
+	for _, bar := range bars {
+		if bar.Price <= 0 || bar.Date.IsZero() {
+			continue
+		}
+		if err := s.priceHistoryService.Upsert(priceBar(symbol, bar)); err != nil {
+			return saved, err
+		}
+		saved++
+	}
+
+	log.Printf("[MARKET DATA] Saved %d daily bars for %s", saved, symbol)
+	return saved, nil
+}
+
+// priceBar converts a provider quote into a stored price history bar
+func priceBar(symbol string, q *Quote) *models.PriceBar {
+	optional := func(v float64) *float64 {
+		if v == 0 {
+			return nil
+		}
+		return &v
+	}
+	return &models.PriceBar{
+		Symbol: symbol,
+		Date:   q.Date,
+		Open:   optional(q.Open),
+		High:   optional(q.High),
+		Low:    optional(q.Low),
+		Close:  q.Price,
+		Volume: optional(q.Volume),
+		Source: models.PriceSourceProvider,
+	}
+}
+
+// UpdateAllSymbolPrices updates prices for all symbols in the database
+// Uses prioritized order: active positions first, inactive symbols last
+func (s *Service) UpdateAllSymbolPrices(ctx context.Context) error {
+	symbols, err := s.symbolService.GetPrioritizedSymbols()
+	if err != nil {
+		return fmt.Errorf("failed to get prioritized symbols: %w", err)
+	}
+
+	_, _, err = s.UpdateSymbolPrices(ctx, symbols, nil)
+	return err
+}
+
+// UpdateSymbolPrices updates each symbol in order, reporting every outcome to onResult
+// when it is not nil. It stops early, returning ctx.Err(), if ctx is cancelled.
+func (s *Service) UpdateSymbolPrices(ctx context.Context, symbols []string, onResult func(symbol string, err error)) (updated, failed int, err error) {
+	log.Printf("[MARKET DATA] Starting bulk price update for %d symbols", len(symbols))
+
+	// The provider paces its own requests, so no delay is needed between symbols
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[MARKET DATA] Bulk price update cancelled: %d updated, %d failed", updated, failed)
+			return updated, failed, err
+		}
+
+		err := s.UpdateSymbolPrice(ctx, symbol)
+		if err != nil {
+			// A cancelled request is not the symbol's fault
+			if ctx.Err() != nil {
+				log.Printf("[MARKET DATA] Bulk price update cancelled: %d updated, %d failed", updated, failed)
+				return updated, failed, ctx.Err()
+			}
+			log.Printf("[MARKET DATA] Failed to update %s: %v", symbol, err)
+			failed++
+		} else {
+			updated++
+		}
+		if onResult != nil {
+			onResult(symbol, err)
+		}
+	}
+
+	log.Printf("[MARKET DATA] Bulk price update complete: %d updated, %d failed", updated, failed)
+	return updated, failed, nil
+}
+
+// FetchSymbolDetails gets ticker details and the latest price for a symbol
+func (s *Service) FetchSymbolDetails(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	p, err := s.Provider()
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := p.GetTickerDetails(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticker details: %w", err)
+	}
+
+	quote, err := p.GetPreviousClose(ctx, symbol)
+	if err != nil {
+		log.Printf("[MARKET DATA] Warning: failed to get current price for %s: %v", symbol, err)
+		// Continue without current price
+	}
+
+	info := &SymbolInfo{TickerDetails: *details}
+	if quote != nil {
+		info.CurrentPrice = quote.Price
+		info.PreviousClose = quote.PreviousClose
+		info.High = quote.High
+		info.Low = quote.Low
+		info.Volume = quote.Volume
+	}
+
+	return info, nil
+}
+
+// FetchDividendHistory gets recent dividend history for a symbol
+func (s *Service) FetchDividendHistory(ctx context.Context, symbol string, limit int) ([]*Dividend, error) {
+	p, err := s.Provider()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+
+	dividends, err := p.GetDividends(ctx, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dividends: %w", err)
+	}
+	return dividends, nil
+}
+
+// FetchOptionSnapshot gets the market snapshot for a stored option using its OCC symbol
+func (s *Service) FetchOptionSnapshot(ctx context.Context, option *models.Option) (*OptionSnapshot, error) {
+	occSymbol := option.GetOCCSymbol()
+	if occSymbol == "" {
+		return nil, fmt.Errorf("option %d has no OCC symbol", option.ID)
+	}
+
+	p, err := s.Provider()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[MARKET DATA] Fetching option snapshot for %s from %s", occSymbol, p.Name())
+
+	snapshot, err := p.GetOptionSnapshot(ctx, option.Symbol, occSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get option snapshot for %s: %w", occSymbol, err)
+	}
+	return snapshot, nil
+}
+
+// FetchOptionChain gets the symbol's option chain matching filter, with return figures for each contract
+func (s *Service) FetchOptionChain(ctx context.Context, symbol string, filter ChainFilter) ([]*ChainContract, error) {
+	p, err := s.Provider()
+	if err != nil {
+		return nil, err
+	}
+
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	log.Printf("[MARKET DATA] Fetching option chain for %s from %s", symbol, p.Name())
+
+	snapshots, err := p.GetOptionChain(ctx, symbol, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get option chain for %s: %w", symbol, err)
+	}
+
+	// Snapshots usually carry the underlying price; the stored price covers those that do not
+	var storedPrice float64
+	if stored, err := s.symbolService.GetBySymbol(symbol); err == nil {
+		storedPrice = stored.Price
+	}
+
+	now := time.Now()
+	contracts := make([]*ChainContract, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		contracts = append(contracts, NewChainContract(snapshot, storedPrice, now))
+	}
+	return contracts, nil
+}
+
+// ScreenOptionChains fetches each symbol's chain and keeps the contracts meeting criteria.
+// A symbol whose chain cannot be fetched is reported in failures rather than stopping the
+// screen; only cancellation of ctx returns an error.
+func (s *Service) ScreenOptionChains(ctx context.Context, symbols []string, contractType string, criteria ScreenCriteria) ([]*ChainContract, map[string]string, error) {
+	filter := criteria.ChainFilter(contractType, time.Now())
+	failures := make(map[string]string)
+	var matches []*ChainContract
+
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		contracts, err := s.FetchOptionChain(ctx, symbol, filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+			log.Printf("[MARKET DATA] Skipping %s in screen: %v", symbol, err)
+			failures[symbol] = err.Error()
+			continue
+		}
+		for _, contract := range contracts {
+			if criteria.Matches(contract) {
+				matches = append(matches, contract)
+			}
+		}
+	}
+
+	log.Printf("[MARKET DATA] Screened %d symbols: %d contracts matched, %d symbols failed", len(symbols), len(matches), len(failures))
+	return matches, failures, nil
+}
+
+// TestConnection checks the selected provider
+func (s *Service) TestConnection(ctx context.Context) error {
+	p, err := s.Provider()
+	if err != nil {
+		return err
+	}
+	return p.TestConnection(ctx)
+}
+
+// SymbolInfo combines ticker details with the latest price
+type SymbolInfo struct {
+	TickerDetails
+	CurrentPrice  float64 `json:"current_price"`
+	PreviousClose float64 `json:"previous_close"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	Volume        float64 `json:"volume"`
+}
@@ -0,0 +1,59 @@
+package marketdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestServiceSelectsProviderFromSettings(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	symbolService := models.NewSymbolService(testDB.DB)
+	settingService := models.NewSettingService(testDB.DB)
+	priceHistoryService := models.NewPriceHistoryService(testDB.DB)
+	service := NewService(symbolService, settingService, priceHistoryService)
+
+	// Polygon is the default but was not registered with this service
+	if name := service.ProviderName(); name != ProviderPolygon {
+		t.Errorf("Expected default provider polygon, got %s", name)
+	}
+	if _, err := service.Provider(); err == nil {
+		t.Error("Expected error for unregistered provider")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "quotes.csv"), []byte("symbol,price\nVZ,41.37\n"), 0644); err != nil {
+		t.Fatalf("Failed to write quotes: %v", err)
+	}
+	if err := settingService.SetValue(SettingProvider, ProviderFile, ""); err != nil {
+		t.Fatalf("Failed to select file provider: %v", err)
+	}
+	if err := settingService.SetValue(SettingDirectory, dir, ""); err != nil {
+		t.Fatalf("Failed to set directory: %v", err)
+	}
+
+	if _, err := symbolService.Create("VZ"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	if err := service.UpdateSymbolPrice(context.Background(), "VZ"); err != nil {
+		t.Fatalf("UpdateSymbolPrice failed: %v", err)
+	}
+
+	symbol, err := symbolService.GetBySymbol("VZ")
+	if err != nil {
+		t.Fatalf("Failed to get symbol: %v", err)
+	}
+	if symbol.Price != 41.37 {
+		t.Errorf("Expected VZ price 41.37 from the drop folder, got %v", symbol.Price)
+	}
+}
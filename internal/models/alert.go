@@ -0,0 +1,440 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert rule types
+const (
+	AlertRuleDTEBelow        = "dte_below"        // open option with N or fewer days to expiration
+	AlertRuleITMPercent      = "itm_percent"      // open option in the money by at least X percent
+	AlertRuleProfitCaptured  = "profit_captured"  // marked option that has captured Y percent of max profit
+	AlertRulePriceAbove      = "price_above"      // symbol price at or above a level
+	AlertRulePriceBelow      = "price_below"      // symbol price at or below a level
+	AlertRuleExDividendCall  = "ex_dividend_call" // ex-dividend within N days on a symbol with a short call
+	AlertRuleEarlyAssignment = "early_assignment" // short call likely assigned early for an ex-dividend date within N days
+)
+
+// AlertRuleTypes lists every rule type in the order offered to the user
+var AlertRuleTypes = []string{
+	AlertRuleDTEBelow,
+	AlertRuleITMPercent,
+	AlertRuleProfitCaptured,
+	AlertRulePriceAbove,
+	AlertRulePriceBelow,
+	AlertRuleExDividendCall,
+	AlertRuleEarlyAssignment,
+}
+
+// Alert states
+const (
+	AlertStateNew          = "new"
+	AlertStateAcknowledged = "acknowledged"
+	AlertStateSnoozed      = "snoozed"
+)
+
+// AlertRule is a user-defined condition checked against open positions and prices
+type AlertRule struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"rule_type"`
+	Symbol    string    `json:"symbol"` // Blank matches every symbol
+	Threshold float64   `json:"threshold"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the rule type and threshold, and that price rules name a symbol
+func (r *AlertRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Type {
+	case AlertRuleDTEBelow, AlertRuleITMPercent, AlertRuleExDividendCall, AlertRuleEarlyAssignment:
+		if r.Threshold < 0 {
+			return fmt.Errorf("threshold cannot be negative")
+		}
+	case AlertRuleProfitCaptured:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("profit threshold must be greater than zero")
+		}
+	case AlertRulePriceAbove, AlertRulePriceBelow:
+		if strings.TrimSpace(r.Symbol) == "" {
+			return fmt.Errorf("price alerts need a symbol")
+		}
+		if r.Threshold <= 0 {
+			return fmt.Errorf("price level must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("invalid rule type %q", r.Type)
+	}
+	return nil
+}
+
+// Describe returns the rule's condition in words, such as "DTE at or below 7"
+func (r *AlertRule) Describe() string {
+	var condition string
+	switch r.Type {
+	case AlertRuleDTEBelow:
+		condition = fmt.Sprintf("DTE at or below %g", r.Threshold)
+	case AlertRuleITMPercent:
+		condition = fmt.Sprintf("In the money by %g%% or more", r.Threshold)
+	case AlertRuleProfitCaptured:
+		condition = fmt.Sprintf("%g%% or more of max profit captured", r.Threshold)
+	case AlertRulePriceAbove:
+		condition = fmt.Sprintf("Price at or above $%.2f", r.Threshold)
+	case AlertRulePriceBelow:
+		condition = fmt.Sprintf("Price at or below $%.2f", r.Threshold)
+	case AlertRuleExDividendCall:
+		condition = fmt.Sprintf("Ex-dividend within %g days with a short call", r.Threshold)
+	case AlertRuleEarlyAssignment:
+		condition = fmt.Sprintf("Short call at risk of early assignment for an ex-dividend within %g days", r.Threshold)
+	default:
+		condition = r.Type
+	}
+	if r.Symbol != "" {
+		return r.Symbol + ": " + condition
+	}
+	return condition
+}
+
+// Alert is a rule match on one position or symbol
+type Alert struct {
+	ID           int        `json:"id"`
+	RuleID       int        `json:"rule_id"`
+	RuleName     string     `json:"rule_name"`
+	RuleType     string     `json:"rule_type"`
+	Subject      string     `json:"subject"` // What matched, such as option:12 or symbol:VZ
+	Symbol       string     `json:"symbol"`
+	Message      string     `json:"message"`
+	State        string     `json:"state"`
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+	TriggeredAt  time.Time  `json:"triggered_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+}
+
+type AlertRuleService struct {
+	db DBTX
+}
+
+func NewAlertRuleService(db *sql.DB) *AlertRuleService {
+	return &AlertRuleService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *AlertRuleService) WithTx(tx *sql.Tx) *AlertRuleService {
+	return &AlertRuleService{db: tx}
+}
+
+const alertRuleColumns = `id, name, rule_type, symbol, threshold, enabled, created_at, updated_at`
+
+func scanAlertRule(row interface{ Scan(...interface{}) error }) (*AlertRule, error) {
+	var rule AlertRule
+	if err := row.Scan(&rule.ID, &rule.Name, &rule.Type, &rule.Symbol, &rule.Threshold, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// normalize trims the rule's text fields and upper-cases its symbol
+func (r *AlertRule) normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Type = strings.TrimSpace(strings.ToLower(r.Type))
+	r.Symbol = strings.TrimSpace(strings.ToUpper(r.Symbol))
+}
+
+// Create validates and stores a new rule
+func (s *AlertRuleService) Create(rule *AlertRule) (*AlertRule, error) {
+	rule.normalize()
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO alert_rules (name, rule_type, symbol, threshold, enabled) VALUES (?, ?, ?, ?, ?) RETURNING ` + alertRuleColumns
+	created, err := scanAlertRule(s.db.QueryRow(query, rule.Name, rule.Type, rule.Symbol, rule.Threshold, rule.Enabled))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return created, nil
+}
+
+// Update validates and replaces a rule
+func (s *AlertRuleService) Update(rule *AlertRule) (*AlertRule, error) {
+	rule.normalize()
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE alert_rules SET name = ?, rule_type = ?, symbol = ?, threshold = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? RETURNING ` + alertRuleColumns
+	updated, err := scanAlertRule(s.db.QueryRow(query, rule.Name, rule.Type, rule.Symbol, rule.Threshold, rule.Enabled, rule.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert rule not found")
+		}
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete removes a rule and every alert it raised
+func (s *AlertRuleService) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+	return nil
+}
+
+// GetByID returns one rule
+func (s *AlertRuleService) GetByID(id int) (*AlertRule, error) {
+	rule, err := scanAlertRule(s.db.QueryRow(`SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert rule not found")
+		}
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// GetAll returns every rule in the order they were created
+func (s *AlertRuleService) GetAll() ([]*AlertRule, error) {
+	return s.query(`SELECT ` + alertRuleColumns + ` FROM alert_rules ORDER BY id`)
+}
+
+// GetEnabled returns the rules the alert check evaluates
+func (s *AlertRuleService) GetEnabled() ([]*AlertRule, error) {
+	return s.query(`SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE enabled = 1 ORDER BY id`)
+}
+
+func (s *AlertRuleService) query(query string, args ...interface{}) ([]*AlertRule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+type AlertService struct {
+	db DBTX
+}
+
+func NewAlertService(db *sql.DB) *AlertService {
+	return &AlertService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *AlertService) WithTx(tx *sql.Tx) *AlertService {
+	return &AlertService{db: tx}
+}
+
+const alertSelect = `SELECT a.id, a.rule_id, r.name, r.rule_type, a.subject, a.symbol, a.message, a.state,
+			  a.snoozed_until, a.triggered_at, a.last_seen_at
+			  FROM alerts a JOIN alert_rules r ON r.id = a.rule_id`
+
+func scanAlert(row interface{ Scan(...interface{}) error }) (*Alert, error) {
+	var alert Alert
+	err := row.Scan(&alert.ID, &alert.RuleID, &alert.RuleName, &alert.RuleType, &alert.Subject, &alert.Symbol, &alert.Message,
+		&alert.State, &alert.SnoozedUntil, &alert.TriggeredAt, &alert.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Raise records that a rule matches subject at now. A subject that already has an alert
+// keeps it, with the message refreshed; a snoozed alert whose snooze has passed returns
+// to new. raised reports whether the alert is new or came back from a snooze.
+func (s *AlertService) Raise(ruleID int, subject, symbol, message string, now time.Time) (alert *Alert, raised bool, err error) {
+	var id int
+	var state string
+	var snoozedUntil *time.Time
+	err = s.db.QueryRow(`SELECT id, state, snoozed_until FROM alerts WHERE rule_id = ? AND subject = ?`, ruleID, subject).
+		Scan(&id, &state, &snoozedUntil)
+
+	switch {
+	case err == sql.ErrNoRows:
+		err = s.db.QueryRow(`INSERT INTO alerts (rule_id, subject, symbol, message, state, triggered_at, last_seen_at)
+				  VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+			ruleID, subject, symbol, message, AlertStateNew, now, now).Scan(&id)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to create alert: %w", err)
+		}
+		raised = true
+
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get alert: %w", err)
+
+	case state == AlertStateSnoozed && (snoozedUntil == nil || !snoozedUntil.After(now)):
+		_, err = s.db.Exec(`UPDATE alerts SET message = ?, state = ?, snoozed_until = NULL, triggered_at = ?, last_seen_at = ? WHERE id = ?`,
+			message, AlertStateNew, now, now, id)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to wake snoozed alert: %w", err)
+		}
+		raised = true
+
+	default:
+		if _, err = s.db.Exec(`UPDATE alerts SET message = ?, last_seen_at = ? WHERE id = ?`, message, now, id); err != nil {
+			return nil, false, fmt.Errorf("failed to update alert: %w", err)
+		}
+	}
+
+	alert, err = s.GetByID(id)
+	return alert, raised, err
+}
+
+// ClearResolved removes a rule's acknowledged and snoozed alerts whose subject no longer
+// matches, so the rule can fire again. New alerts stay until the user has seen them.
+func (s *AlertService) ClearResolved(ruleID int, matching map[string]bool) (int, error) {
+	rows, err := s.db.Query(`SELECT id, subject FROM alerts WHERE rule_id = ? AND state != ?`, ruleID, AlertStateNew)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get alerts for rule %d: %w", ruleID, err)
+	}
+	var resolved []int
+	for rows.Next() {
+		var id int
+		var subject string
+		if err := rows.Scan(&id, &subject); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		if !matching[subject] {
+			resolved = append(resolved, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	for _, id := range resolved {
+		if _, err := s.db.Exec(`DELETE FROM alerts WHERE id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to clear alert %d: %w", id, err)
+		}
+	}
+	return len(resolved), nil
+}
+
+// Acknowledge marks an alert as seen
+func (s *AlertService) Acknowledge(id int) error {
+	return s.setState(id, AlertStateAcknowledged, nil)
+}
+
+// Snooze hides an alert until the given time
+func (s *AlertService) Snooze(id int, until time.Time) error {
+	return s.setState(id, AlertStateSnoozed, &until)
+}
+
+func (s *AlertService) setState(id int, state string, snoozedUntil *time.Time) error {
+	result, err := s.db.Exec(`UPDATE alerts SET state = ?, snoozed_until = ? WHERE id = ?`, state, snoozedUntil, id)
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert not found")
+	}
+	return nil
+}
+
+// Delete dismisses an alert; it is raised again if its rule still matches on the next check
+func (s *AlertService) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM alerts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("alert not found")
+	}
+	return nil
+}
+
+// GetByID returns one alert
+func (s *AlertService) GetByID(id int) (*Alert, error) {
+	alert, err := scanAlert(s.db.QueryRow(alertSelect+` WHERE a.id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("alert not found")
+		}
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+	return alert, nil
+}
+
+// GetAll returns alerts in the given states, or every alert when none are given,
+// new alerts first and then most recently triggered first
+func (s *AlertService) GetAll(states ...string) ([]*Alert, error) {
+	query := alertSelect
+	args := make([]interface{}, 0, len(states))
+	if len(states) > 0 {
+		query += ` WHERE a.state IN (?` + strings.Repeat(`, ?`, len(states)-1) + `)`
+		for _, state := range states {
+			args = append(args, state)
+		}
+	}
+	query += ` ORDER BY CASE a.state WHEN 'new' THEN 0 WHEN 'snoozed' THEN 1 ELSE 2 END, a.triggered_at DESC, a.id DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []*Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CountNew returns how many alerts have not been acknowledged or snoozed
+func (s *AlertService) CountNew() (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM alerts WHERE state = ?`, AlertStateNew).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count new alerts: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// APICacheStats summarizes the response cache for the settings page
+type APICacheStats struct {
+	Entries int `json:"entries"`
+	Live    int `json:"live"`
+	Bytes   int `json:"bytes"`
+}
+
+// APICacheService stores market data API responses until they expire
+type APICacheService struct {
+	db *sql.DB
+}
+
+func NewAPICacheService(db *sql.DB) *APICacheService {
+	return &APICacheService{db: db}
+}
+
+// Get returns a cached response body if it has not expired
+func (s *APICacheService) Get(key string) ([]byte, bool) {
+	var body []byte
+	err := s.db.QueryRow(`SELECT body FROM api_cache WHERE key = ? AND expires_at > ?`, key, time.Now().UTC()).Scan(&body)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// Set stores a response body for ttl and drops expired entries
+func (s *APICacheService) Set(key string, body []byte, ttl time.Duration) error {
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`INSERT INTO api_cache (key, body, fetched_at, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET body = excluded.body, fetched_at = excluded.fetched_at, expires_at = excluded.expires_at`,
+		key, body, now, now.Add(ttl))
+	if err != nil {
+		return fmt.Errorf("failed to cache response for %s: %w", key, err)
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM api_cache WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("failed to delete expired cache entries: %w", err)
+	}
+	return nil
+}
+
+// Clear removes every cached response
+func (s *APICacheService) Clear() (int, error) {
+	result, err := s.db.Exec(`DELETE FROM api_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear response cache: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// Stats returns the number and size of cached responses
+func (s *APICacheService) Stats() (*APICacheStats, error) {
+	var stats APICacheStats
+	err := s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(expires_at > ?), 0), COALESCE(SUM(LENGTH(body)), 0) FROM api_cache`,
+		time.Now().UTC()).Scan(&stats.Entries, &stats.Live, &stats.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response cache stats: %w", err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestAPICacheService_Expiry(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	cache := NewAPICacheService(testDB.DB)
+	if err := cache.Set("/v2/aggs/ticker/VZ/prev", []byte(`{"status":"OK"}`), time.Hour); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := cache.Set("/v2/last/nbbo/VZ", []byte(`{"status":"OK"}`), -time.Second); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if body, ok := cache.Get("/v2/aggs/ticker/VZ/prev"); !ok || string(body) != `{"status":"OK"}` {
+		t.Errorf("Expected a fresh entry, got %q, %v", body, ok)
+	}
+	if _, ok := cache.Get("/v2/last/nbbo/VZ"); ok {
+		t.Errorf("Expected the expired entry to be missed")
+	}
+
+	stats, err := cache.Stats()
+	if err != nil || stats.Live != 1 {
+		t.Errorf("Expected 1 live entry, got %+v (%v)", stats, err)
+	}
+
+	cleared, err := cache.Clear()
+	if err != nil || cleared != 1 {
+		t.Errorf("Expected to clear 1 entry, got %d (%v)", cleared, err)
+	}
+}
@@ -8,13 +8,18 @@ import (
 )
 
 type DividendService struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewDividendService(db *sql.DB) *DividendService {
 	return &DividendService{db: db}
 }
 
+// WithTx returns a copy of the service bound to the given transaction
+func (s *DividendService) WithTx(tx *sql.Tx) *DividendService {
+	return &DividendService{db: tx}
+}
+
 func (s *DividendService) Create(symbol string, received time.Time, amount float64) (*Dividend, error) {
 	if amount <= 0 {
 		return nil, fmt.Errorf("dividend amount must be positive")
@@ -35,6 +40,27 @@ func (s *DividendService) Create(symbol string, received time.Time, amount float
 	return &dividend, nil
 }
 
+// CreateImported inserts a dividend as part of an import batch
+func (s *DividendService) CreateImported(symbol string, received time.Time, amount float64, batchID int) (*Dividend, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("dividend amount must be positive")
+	}
+
+	query := `INSERT INTO dividends (symbol, received, amount, import_batch_id)
+			  VALUES (?, ?, ?, ?)
+			  RETURNING id, symbol, received, amount, created_at`
+
+	var dividend Dividend
+	err := s.db.QueryRow(query, symbol, received, amount, batchID).Scan(
+		&dividend.ID, &dividend.Symbol, &dividend.Received, &dividend.Amount, &dividend.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dividend: %w", err)
+	}
+
+	return &dividend, nil
+}
+
 func (s *DividendService) GetBySymbol(symbol string) ([]*Dividend, error) {
 	query := `SELECT id, symbol, received, amount, created_at 
 			  FROM dividends WHERE symbol = ? ORDER BY received DESC`
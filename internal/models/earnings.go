@@ -0,0 +1,215 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Earnings date sources
+const (
+	EarningsSourceManual   = "manual"
+	EarningsSourceProvider = "provider"
+)
+
+// Earnings announcement timings; blank when the time of day is not known
+const (
+	EarningsBeforeOpen = "bmo"
+	EarningsAfterClose = "amc"
+)
+
+// EarningsDate is a scheduled earnings announcement for a symbol
+type EarningsDate struct {
+	Symbol    string    `json:"symbol"`
+	Date      time.Time `json:"date"`
+	Timing    string    `json:"timing"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FallsBefore reports whether the announcement comes before an option expiring on
+// expiration stops trading. Earnings after the close on expiration day do not count.
+func (e *EarningsDate) FallsBefore(expiration time.Time) bool {
+	date, expires := priceDate(e.Date), priceDate(expiration)
+	if date.Equal(expires) {
+		return e.Timing != EarningsAfterClose
+	}
+	return date.Before(expires)
+}
+
+// NormalizeEarningsTiming maps the many spellings of an announcement time to bmo, amc or blank
+func NormalizeEarningsTiming(timing string) string {
+	switch strings.ToLower(strings.TrimSpace(timing)) {
+	case "bmo", "before open", "before market open", "pre-market", "premarket":
+		return EarningsBeforeOpen
+	case "amc", "after close", "after market close", "post-market", "postmarket":
+		return EarningsAfterClose
+	}
+	return ""
+}
+
+type EarningsService struct {
+	db DBTX
+}
+
+func NewEarningsService(db *sql.DB) *EarningsService {
+	return &EarningsService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *EarningsService) WithTx(tx *sql.Tx) *EarningsService {
+	return &EarningsService{db: tx}
+}
+
+// SetNext records date as a symbol's next earnings, entered by hand. Every other upcoming
+// date for the symbol, from either source, is removed since the user has said which is next.
+// A nil date clears the symbol's upcoming earnings.
+func (s *EarningsService) SetNext(symbol string, date *time.Time, timing string, today time.Time) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM earnings_dates WHERE symbol = ? AND date >= ?`, symbol, priceDate(today)); err != nil {
+		return fmt.Errorf("failed to clear upcoming earnings for %s: %w", symbol, err)
+	}
+	if date == nil {
+		return nil
+	}
+
+	query := `INSERT INTO earnings_dates (symbol, date, timing, source)
+			  VALUES (?, ?, ?, ?)
+			  ON CONFLICT(symbol, date) DO UPDATE SET
+			      timing = excluded.timing,
+			      source = excluded.source,
+			      updated_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, symbol, priceDate(*date), NormalizeEarningsTiming(timing), EarningsSourceManual); err != nil {
+		return fmt.Errorf("failed to save earnings for %s on %s: %w", symbol, date.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// ReplaceProviderDates swaps a symbol's provider dates from today on for dates, so a
+// rescheduled announcement does not linger. Symbols with an upcoming manual date are
+// left alone and ReplaceProviderDates reports false.
+func (s *EarningsService) ReplaceProviderDates(symbol string, dates []*EarningsDate, today time.Time) (bool, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	from := priceDate(today)
+
+	var manual int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM earnings_dates WHERE symbol = ? AND date >= ? AND source = ?`,
+		symbol, from, EarningsSourceManual).Scan(&manual)
+	if err != nil {
+		return false, fmt.Errorf("failed to check manual earnings for %s: %w", symbol, err)
+	}
+	if manual > 0 {
+		return false, nil
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM earnings_dates WHERE symbol = ? AND date >= ? AND source = ?`,
+		symbol, from, EarningsSourceProvider); err != nil {
+		return false, fmt.Errorf("failed to clear provider earnings for %s: %w", symbol, err)
+	}
+
+	query := `INSERT INTO earnings_dates (symbol, date, timing, source)
+			  VALUES (?, ?, ?, ?)
+			  ON CONFLICT(symbol, date) DO UPDATE SET
+			      timing = excluded.timing,
+			      source = excluded.source,
+			      updated_at = CURRENT_TIMESTAMP`
+	for _, e := range dates {
+		if priceDate(e.Date).Before(from) {
+			continue
+		}
+		if _, err := s.db.Exec(query, symbol, priceDate(e.Date), NormalizeEarningsTiming(e.Timing), EarningsSourceProvider); err != nil {
+			return false, fmt.Errorf("failed to save earnings for %s on %s: %w", symbol, e.Date.Format("2006-01-02"), err)
+		}
+	}
+	return true, nil
+}
+
+// Delete removes one earnings date
+func (s *EarningsService) Delete(symbol string, date time.Time) error {
+	result, err := s.db.Exec(`DELETE FROM earnings_dates WHERE symbol = ? AND date = ?`, strings.ToUpper(symbol), priceDate(date))
+	if err != nil {
+		return fmt.Errorf("failed to delete earnings date: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("earnings date not found")
+	}
+	return nil
+}
+
+// GetUpcoming returns earnings dates on or after from, soonest first. An empty symbol returns every symbol's dates.
+func (s *EarningsService) GetUpcoming(symbol string, from time.Time) ([]*EarningsDate, error) {
+	query := `SELECT symbol, date, timing, source, created_at, updated_at
+			  FROM earnings_dates WHERE date >= ?`
+	args := []interface{}{priceDate(from)}
+	if symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, strings.ToUpper(symbol))
+	}
+	query += ` ORDER BY date, symbol`
+
+	return s.query(query, args...)
+}
+
+// GetAll returns every stored earnings date, past and upcoming, by date and then symbol
+func (s *EarningsService) GetAll() ([]*EarningsDate, error) {
+	return s.query(`SELECT symbol, date, timing, source, created_at, updated_at
+			  FROM earnings_dates ORDER BY date, symbol`)
+}
+
+func (s *EarningsService) query(query string, args ...interface{}) ([]*EarningsDate, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earnings dates: %w", err)
+	}
+	defer rows.Close()
+
+	var dates []*EarningsDate
+	for rows.Next() {
+		var e EarningsDate
+		if err := rows.Scan(&e.Symbol, &e.Date, &e.Timing, &e.Source, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan earnings date: %w", err)
+		}
+		dates = append(dates, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating earnings dates: %w", err)
+	}
+
+	return dates, nil
+}
+
+// GetNextBySymbol returns each symbol's soonest earnings date on or after from
+func (s *EarningsService) GetNextBySymbol(from time.Time) (map[string]*EarningsDate, error) {
+	dates, err := s.GetUpcoming("", from)
+	if err != nil {
+		return nil, err
+	}
+
+	next := make(map[string]*EarningsDate)
+	for _, e := range dates {
+		if _, ok := next[e.Symbol]; !ok {
+			next[e.Symbol] = e
+		}
+	}
+	return next, nil
+}
+
+// GetNext returns a symbol's soonest earnings date on or after from, or nil if none is known
+func (s *EarningsService) GetNext(symbol string, from time.Time) (*EarningsDate, error) {
+	dates, err := s.GetUpcoming(symbol, from)
+	if err != nil || len(dates) == 0 {
+		return nil, err
+	}
+	return dates[0], nil
+}
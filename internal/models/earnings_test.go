@@ -0,0 +1,104 @@
+package models
+
+import (
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestEarningsService_ManualAndProviderDates(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	for _, symbol := range []string{"VZ", "KO"} {
+		if _, err := NewSymbolService(testDB.DB).Create(symbol); err != nil {
+			t.Fatalf("Failed to create symbol: %v", err)
+		}
+	}
+	service := NewEarningsService(testDB.DB)
+	today := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	// Past dates are ignored and a second refresh replaces the first
+	refreshed, err := service.ReplaceProviderDates("VZ", []*EarningsDate{
+		{Date: time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC), Timing: "Before Open"},
+	}, today)
+	if err != nil || !refreshed {
+		t.Fatalf("ReplaceProviderDates failed: %v, %v", refreshed, err)
+	}
+	if _, err := service.ReplaceProviderDates("VZ", []*EarningsDate{
+		{Date: time.Date(2025, 1, 24, 0, 0, 0, 0, time.UTC), Timing: "amc"},
+	}, today); err != nil {
+		t.Fatalf("Second ReplaceProviderDates failed: %v", err)
+	}
+
+	next, err := service.GetNext("VZ", today)
+	if err != nil || next == nil {
+		t.Fatalf("Expected a next earnings date, got %v, %v", next, err)
+	}
+	if next.Date.Format("2006-01-02") != "2025-01-24" || next.Timing != EarningsAfterClose || next.Source != EarningsSourceProvider {
+		t.Errorf("Expected the rescheduled provider date after the close, got %+v", next)
+	}
+
+	// A manual date replaces the upcoming dates and is kept on refresh
+	manual := time.Date(2025, 1, 23, 0, 0, 0, 0, time.UTC)
+	if err := service.SetNext("vz", &manual, "", today); err != nil {
+		t.Fatalf("SetNext failed: %v", err)
+	}
+	refreshed, err = service.ReplaceProviderDates("VZ", []*EarningsDate{{Date: time.Date(2025, 1, 28, 0, 0, 0, 0, time.UTC)}}, today)
+	if err != nil || refreshed {
+		t.Fatalf("Expected refresh to leave the manual date alone, got %v, %v", refreshed, err)
+	}
+	dates, err := service.GetUpcoming("VZ", today)
+	if err != nil || len(dates) != 1 || !dates[0].Date.Equal(manual) || dates[0].Source != EarningsSourceManual {
+		t.Fatalf("Expected only the manual date, got %v (%v)", dates, err)
+	}
+
+	kept := time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC)
+	if err := service.SetNext("KO", &kept, "bmo", today); err != nil {
+		t.Fatalf("SetNext failed: %v", err)
+	}
+	bySymbol, err := service.GetNextBySymbol(today)
+	if err != nil || len(bySymbol) != 2 || !bySymbol["KO"].Date.Equal(kept) {
+		t.Fatalf("Expected next dates for VZ and KO, got %v (%v)", bySymbol, err)
+	}
+
+	if err := service.SetNext("VZ", nil, "", today); err != nil {
+		t.Fatalf("Clearing earnings failed: %v", err)
+	}
+	if next, err := service.GetNext("VZ", today); err != nil || next != nil {
+		t.Errorf("Expected VZ earnings to be cleared, got %v, %v", next, err)
+	}
+	if err := service.Delete("KO", kept); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if err := service.Delete("KO", kept); err == nil {
+		t.Error("Expected an error deleting a missing date")
+	}
+}
+
+func TestEarningsDate_FallsBefore(t *testing.T) {
+	expiration := time.Date(2025, 1, 24, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date   time.Time
+		timing string
+		want   bool
+	}{
+		{time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC), "", true},
+		{expiration, EarningsBeforeOpen, true},
+		{expiration, "", true},
+		{expiration, EarningsAfterClose, false},
+		{time.Date(2025, 1, 28, 0, 0, 0, 0, time.UTC), EarningsBeforeOpen, false},
+	}
+	for _, tt := range tests {
+		e := &EarningsDate{Date: tt.date, Timing: tt.timing}
+		if got := e.FallsBefore(expiration); got != tt.want {
+			t.Errorf("FallsBefore for %s %q = %v, want %v", tt.date.Format("2006-01-02"), tt.timing, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Import types recorded on import batches
+const (
+	ImportTypeOptions    = "options"
+	ImportTypeStocks     = "stocks"
+	ImportTypeDividends  = "dividends"
+	ImportTypeTreasuries = "treasuries"
+
+	ImportTypeTreasuryDirect = "treasurydirect"
+	ImportTypeBondPositions  = "bond_positions"
+	ImportTypePriceHistory   = "price_history"
+)
+
+// importBatchTables lists every table whose rows carry an import_batch_id
+var importBatchTables = []string{"options", "long_positions", "dividends", "treasuries", "price_history"}
+
+type ImportBatch struct {
+	ID            int        `json:"id"`
+	ImportType    string     `json:"import_type"`
+	Filename      string     `json:"filename"`
+	FileHash      string     `json:"file_hash"`
+	RowCount      int        `json:"row_count"`
+	ImportedCount int        `json:"imported_count"`
+	SkippedCount  int        `json:"skipped_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RevertedAt    *time.Time `json:"reverted_at"`
+}
+
+// IsReverted returns true if the batch has been reverted
+func (b *ImportBatch) IsReverted() bool {
+	return b.RevertedAt != nil
+}
+
+// ShortHash returns the first 12 characters of the file hash for display
+func (b *ImportBatch) ShortHash() string {
+	if len(b.FileHash) > 12 {
+		return b.FileHash[:12]
+	}
+	return b.FileHash
+}
+
+// ImportFunc imports rows inside the batch transaction and reports how many were imported and skipped
+type ImportFunc func(tx *sql.Tx, batchID int) (importedCount int, skippedCount int, err error)
+
+type ImportBatchService struct {
+	db *sql.DB
+}
+
+func NewImportBatchService(db *sql.DB) *ImportBatchService {
+	return &ImportBatchService{db: db}
+}
+
+// HashContent returns the hex-encoded SHA-256 hash of an import file
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Run records a new import batch and executes importFn in the same transaction.
+// Either every row and the batch record are committed, or nothing is.
+func (s *ImportBatchService) Run(importType, filename string, content []byte, importFn ImportFunc) (*ImportBatch, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var batchID int
+	err = tx.QueryRow(`INSERT INTO import_batches (import_type, filename, file_hash) VALUES (?, ?, ?) RETURNING id`,
+		importType, filename, HashContent(content)).Scan(&batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import batch: %w", err)
+	}
+
+	importedCount, skippedCount, err := importFn(tx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE import_batches SET row_count = ?, imported_count = ?, skipped_count = ? WHERE id = ?
+			  RETURNING id, import_type, filename, file_hash, row_count, imported_count, skipped_count, created_at, reverted_at`
+
+	var batch ImportBatch
+	err = tx.QueryRow(query, importedCount+skippedCount, importedCount, skippedCount, batchID).Scan(
+		&batch.ID, &batch.ImportType, &batch.Filename, &batch.FileHash, &batch.RowCount,
+		&batch.ImportedCount, &batch.SkippedCount, &batch.CreatedAt, &batch.RevertedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize import batch: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	log.Printf("[IMPORT BATCH] Committed batch %d (%s, %s): %d imported, %d skipped",
+		batch.ID, batch.ImportType, batch.Filename, batch.ImportedCount, batch.SkippedCount)
+	return &batch, nil
+}
+
+func (s *ImportBatchService) GetAll() ([]*ImportBatch, error) {
+	query := `SELECT id, import_type, filename, file_hash, row_count, imported_count, skipped_count, created_at, reverted_at
+			  FROM import_batches ORDER BY created_at DESC, id DESC`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []*ImportBatch
+	for rows.Next() {
+		var batch ImportBatch
+		if err := rows.Scan(&batch.ID, &batch.ImportType, &batch.Filename, &batch.FileHash, &batch.RowCount,
+			&batch.ImportedCount, &batch.SkippedCount, &batch.CreatedAt, &batch.RevertedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan import batch: %w", err)
+		}
+		batches = append(batches, &batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating import batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+func (s *ImportBatchService) GetByID(id int) (*ImportBatch, error) {
+	query := `SELECT id, import_type, filename, file_hash, row_count, imported_count, skipped_count, created_at, reverted_at
+			  FROM import_batches WHERE id = ?`
+
+	var batch ImportBatch
+	err := s.db.QueryRow(query, id).Scan(&batch.ID, &batch.ImportType, &batch.Filename, &batch.FileHash, &batch.RowCount,
+		&batch.ImportedCount, &batch.SkippedCount, &batch.CreatedAt, &batch.RevertedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("import batch not found")
+		}
+		return nil, fmt.Errorf("failed to get import batch: %w", err)
+	}
+
+	return &batch, nil
+}
+
+// Revert deletes every row created by the batch in a single transaction and marks the batch as reverted.
+// It returns the number of rows removed.
+func (s *ImportBatchService) Revert(id int) (int, error) {
+	batch, err := s.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if batch.IsReverted() {
+		return 0, fmt.Errorf("import batch %d was already reverted", id)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin revert transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed := 0
+	for _, table := range importBatchTables {
+		result, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE import_batch_id = ?`, table), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to revert %s for batch %d: %w", table, id, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		removed += int(rowsAffected)
+	}
+
+	if _, err := tx.Exec(`UPDATE import_batches SET reverted_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to mark import batch %d as reverted: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit revert: %w", err)
+	}
+
+	log.Printf("[IMPORT BATCH] Reverted batch %d: %d rows removed", id, removed)
+	return removed, nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupImportBatchTestDB(t *testing.T) *database.DB {
+	// Transactions need every query on the same database, so use a file instead of :memory:
+	testDB, err := database.NewDB(filepath.Join(t.TempDir(), "import_batch_test.db"))
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	if _, err := NewSymbolService(testDB.DB).Create("AAPL"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	return testDB
+}
+
+func TestImportBatchService_RunCommitsAllRows(t *testing.T) {
+	testDB := setupImportBatchTestDB(t)
+	batchService := NewImportBatchService(testDB.DB)
+	dividendService := NewDividendService(testDB.DB)
+
+	received := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	batch, err := batchService.Run(ImportTypeDividends, "dividends.csv", []byte("Symbol,Date,Amount"), func(tx *sql.Tx, batchID int) (int, int, error) {
+		txDividends := dividendService.WithTx(tx)
+		for i := 0; i < 3; i++ {
+			if _, err := txDividends.CreateImported("AAPL", received.AddDate(0, i, 0), 25.0, batchID); err != nil {
+				return 0, 0, err
+			}
+		}
+		return 3, 1, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if batch.ImportedCount != 3 || batch.SkippedCount != 1 || batch.RowCount != 4 {
+		t.Errorf("Unexpected batch counts: imported=%d skipped=%d rows=%d", batch.ImportedCount, batch.SkippedCount, batch.RowCount)
+	}
+	if batch.FileHash != HashContent([]byte("Symbol,Date,Amount")) {
+		t.Errorf("Expected file hash to be recorded, got %q", batch.FileHash)
+	}
+
+	dividends, err := dividendService.GetBySymbol("AAPL")
+	if err != nil {
+		t.Fatalf("Failed to get dividends: %v", err)
+	}
+	if len(dividends) != 3 {
+		t.Errorf("Expected 3 dividends after commit, got %d", len(dividends))
+	}
+}
+
+func TestImportBatchService_RunRollsBackOnError(t *testing.T) {
+	testDB := setupImportBatchTestDB(t)
+	batchService := NewImportBatchService(testDB.DB)
+	dividendService := NewDividendService(testDB.DB)
+
+	received := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	_, err := batchService.Run(ImportTypeDividends, "bad.csv", []byte("bad"), func(tx *sql.Tx, batchID int) (int, int, error) {
+		txDividends := dividendService.WithTx(tx)
+		if _, err := txDividends.CreateImported("AAPL", received, 25.0, batchID); err != nil {
+			return 0, 0, err
+		}
+		return 1, 0, fmt.Errorf("error parsing row 3")
+	})
+	if err == nil {
+		t.Fatal("Expected Run to return the import error")
+	}
+
+	dividends, err := dividendService.GetBySymbol("AAPL")
+	if err != nil {
+		t.Fatalf("Failed to get dividends: %v", err)
+	}
+	if len(dividends) != 0 {
+		t.Errorf("Expected no dividends after rollback, got %d", len(dividends))
+	}
+
+	batches, err := batchService.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get import batches: %v", err)
+	}
+	if len(batches) != 0 {
+		t.Errorf("Expected no import batches after rollback, got %d", len(batches))
+	}
+}
+
+func TestImportBatchService_Revert(t *testing.T) {
+	testDB := setupImportBatchTestDB(t)
+	batchService := NewImportBatchService(testDB.DB)
+	optionService := NewOptionService(testDB.DB)
+	positionService := NewLongPositionService(testDB.DB)
+
+	// A manually entered option must survive the revert
+	opened := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := optionService.Create("AAPL", "Put", opened, 150.0, opened.AddDate(0, 1, 0), 2.50, 1); err != nil {
+		t.Fatalf("Failed to create manual option: %v", err)
+	}
+
+	batch, err := batchService.Run(ImportTypeOptions, "options.csv", []byte("options"), func(tx *sql.Tx, batchID int) (int, int, error) {
+		closed := opened.AddDate(0, 0, 10)
+		exitPrice := 0.50
+		option := &Option{
+			Symbol: "AAPL", Type: "Call", Opened: opened, Closed: &closed, Strike: 170.0,
+			Expiration: opened.AddDate(0, 1, 0), Premium: 1.25, Contracts: 1, ExitPrice: &exitPrice, Commission: 0.65,
+		}
+		if _, err := optionService.WithTx(tx).CreateImported(option, batchID); err != nil {
+			return 0, 0, err
+		}
+		if _, err := positionService.WithTx(tx).CreateImported(&LongPosition{Symbol: "AAPL", Opened: opened, Shares: 100, BuyPrice: 160.0}, batchID); err != nil {
+			return 0, 0, err
+		}
+		return 2, 0, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	removed, err := batchService.Revert(batch.ID)
+	if err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 rows removed, got %d", removed)
+	}
+
+	options, err := optionService.GetBySymbol("AAPL")
+	if err != nil {
+		t.Fatalf("Failed to get options: %v", err)
+	}
+	if len(options) != 1 || options[0].Type != "Put" {
+		t.Errorf("Expected only the manual option to remain, got %d options", len(options))
+	}
+
+	positions, err := positionService.GetBySymbol("AAPL")
+	if err != nil {
+		t.Fatalf("Failed to get long positions: %v", err)
+	}
+	if len(positions) != 0 {
+		t.Errorf("Expected imported long position to be removed, got %d", len(positions))
+	}
+
+	reverted, err := batchService.GetByID(batch.ID)
+	if err != nil {
+		t.Fatalf("Failed to get import batch: %v", err)
+	}
+	if !reverted.IsReverted() {
+		t.Error("Expected batch to be marked as reverted")
+	}
+
+	if _, err := batchService.Revert(batch.ID); err == nil {
+		t.Error("Expected reverting the same batch twice to fail")
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Job run statuses
+const (
+	JobStatusRunning = "running"
+	JobStatusSuccess = "success"
+	JobStatusFailed  = "failed"
+)
+
+// What started a job run
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// jobRunsKept is how many runs of each job are kept in the history
+const jobRunsKept = 50
+
+// JobRun is one execution of a scheduled job
+type JobRun struct {
+	ID          int        `json:"id"`
+	Job         string     `json:"job"`
+	TriggeredBy string     `json:"triggered_by"`
+	Status      string     `json:"status"`
+	Message     string     `json:"message"`
+	Error       string     `json:"error"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// Duration returns how long the run took, or zero while it is still running
+func (r *JobRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Second)
+}
+
+type JobRunService struct {
+	db *sql.DB
+}
+
+func NewJobRunService(db *sql.DB) *JobRunService {
+	return &JobRunService{db: db}
+}
+
+// Start records that a job has started and returns the run ID
+func (s *JobRunService) Start(job, triggeredBy string) (int, error) {
+	var id int
+	err := s.db.QueryRow(`INSERT INTO job_runs (job, triggered_by, status, started_at) VALUES (?, ?, ?, ?) RETURNING id`,
+		job, triggeredBy, JobStatusRunning, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record start of job %s: %w", job, err)
+	}
+	return id, nil
+}
+
+// Finish records the outcome of a run and trims the job's history
+func (s *JobRunService) Finish(id int, message string, runErr error) error {
+	status := JobStatusSuccess
+	var errText sql.NullString
+	if runErr != nil {
+		status = JobStatusFailed
+		errText = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	var job string
+	err := s.db.QueryRow(`UPDATE job_runs SET status = ?, message = ?, error = ?, finished_at = ? WHERE id = ? RETURNING job`,
+		status, message, errText, time.Now().UTC(), id).Scan(&job)
+	if err != nil {
+		return fmt.Errorf("failed to record result of job run %d: %w", id, err)
+	}
+
+	_, err = s.db.Exec(`DELETE FROM job_runs WHERE job = ? AND id NOT IN
+		(SELECT id FROM job_runs WHERE job = ? ORDER BY started_at DESC, id DESC LIMIT ?)`, job, job, jobRunsKept)
+	if err != nil {
+		return fmt.Errorf("failed to trim history of job %s: %w", job, err)
+	}
+	return nil
+}
+
+// FailInterrupted marks runs left running by a previous process as failed
+func (s *JobRunService) FailInterrupted() (int, error) {
+	result, err := s.db.Exec(`UPDATE job_runs SET status = ?, error = 'interrupted by server shutdown', finished_at = started_at
+		WHERE status = ?`, JobStatusFailed, JobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark interrupted job runs: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// GetRecent returns the latest runs of a job, newest first
+func (s *JobRunService) GetRecent(job string, limit int) ([]*JobRun, error) {
+	rows, err := s.db.Query(`SELECT id, job, triggered_by, status, COALESCE(message, ''), COALESCE(error, ''), started_at, finished_at
+		FROM job_runs WHERE job = ? ORDER BY started_at DESC, id DESC LIMIT ?`, job, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runs of job %s: %w", job, err)
+	}
+	defer rows.Close()
+
+	var runs []*JobRun
+	for rows.Next() {
+		var run JobRun
+		if err := rows.Scan(&run.ID, &run.Job, &run.TriggeredBy, &run.Status, &run.Message, &run.Error,
+			&run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// GetLastError returns the most recent failed run of a job, or nil if it has never failed
+func (s *JobRunService) GetLastError(job string) (*JobRun, error) {
+	var run JobRun
+	err := s.db.QueryRow(`SELECT id, job, triggered_by, status, COALESCE(message, ''), COALESCE(error, ''), started_at, finished_at
+		FROM job_runs WHERE job = ? AND status = ? ORDER BY started_at DESC, id DESC LIMIT 1`, job, JobStatusFailed).Scan(
+		&run.ID, &run.Job, &run.TriggeredBy, &run.Status, &run.Message, &run.Error, &run.StartedAt, &run.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last error of job %s: %w", job, err)
+	}
+	return &run, nil
+}
@@ -7,13 +7,18 @@ import (
 )
 
 type LongPositionService struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewLongPositionService(db *sql.DB) *LongPositionService {
 	return &LongPositionService{db: db}
 }
 
+// WithTx returns a copy of the service bound to the given transaction
+func (s *LongPositionService) WithTx(tx *sql.Tx) *LongPositionService {
+	return &LongPositionService{db: tx}
+}
+
 func (s *LongPositionService) Create(symbol string, opened time.Time, shares int, buyPrice float64) (*LongPosition, error) {
 	query := `INSERT INTO long_positions (symbol, opened, shares, buy_price) 
 			  VALUES (?, ?, ?, ?) 
@@ -31,6 +36,24 @@ func (s *LongPositionService) Create(symbol string, opened time.Time, shares int
 	return &position, nil
 }
 
+// CreateImported inserts a fully specified long position, including exit details, as part of an import batch
+func (s *LongPositionService) CreateImported(lp *LongPosition, batchID int) (*LongPosition, error) {
+	query := `INSERT INTO long_positions (symbol, opened, closed, shares, buy_price, exit_price, import_batch_id)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)
+			  RETURNING id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at`
+
+	var position LongPosition
+	err := s.db.QueryRow(query, lp.Symbol, lp.Opened, lp.Closed, lp.Shares, lp.BuyPrice, lp.ExitPrice, batchID).Scan(
+		&position.ID, &position.Symbol, &position.Opened, &position.Closed, &position.Shares,
+		&position.BuyPrice, &position.ExitPrice, &position.CreatedAt, &position.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create long position: %w", err)
+	}
+
+	return &position, nil
+}
+
 func (s *LongPositionService) GetBySymbol(symbol string) ([]*LongPosition, error) {
 	query := `SELECT id, symbol, opened, closed, shares, buy_price, exit_price, created_at, updated_at 
 			  FROM long_positions WHERE symbol = ? ORDER BY opened DESC`
@@ -0,0 +1,362 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification channel types
+const (
+	ChannelWebhook = "webhook" // JSON POST to a URL; Slack, Discord and ntfy compatible
+	ChannelSMTP    = "smtp"    // email through an SMTP server
+	ChannelFile    = "file"    // append to a local file, or only the server log when no path is set
+)
+
+// NotificationChannelTypes lists every channel type in the order offered to the user
+var NotificationChannelTypes = []string{ChannelWebhook, ChannelSMTP, ChannelFile}
+
+// Delivery statuses
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// NotificationChannel is a destination new alerts are sent to. Config holds the
+// type-specific settings: url and format for webhooks; host, port, username, password,
+// from and to for SMTP; path for file sinks.
+type NotificationChannel struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Type      string            `json:"channel_type"`
+	Config    map[string]string `json:"config"`
+	Enabled   bool              `json:"enabled"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Setting returns a trimmed config value, or "" when it is not set
+func (c *NotificationChannel) Setting(key string) string {
+	return strings.TrimSpace(c.Config[key])
+}
+
+// Recipients returns the SMTP channel's comma-separated to addresses
+func (c *NotificationChannel) Recipients() []string {
+	var recipients []string
+	for _, address := range strings.Split(c.Setting("to"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			recipients = append(recipients, address)
+		}
+	}
+	return recipients
+}
+
+// Validate checks the channel type and that its required settings are present
+func (c *NotificationChannel) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch c.Type {
+	case ChannelWebhook:
+		target, err := url.Parse(c.Setting("url"))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return fmt.Errorf("webhook needs an http or https url")
+		}
+		switch c.Setting("format") {
+		case "", "json", "ntfy":
+		default:
+			return fmt.Errorf("invalid webhook format %q", c.Setting("format"))
+		}
+	case ChannelSMTP:
+		if c.Setting("host") == "" {
+			return fmt.Errorf("smtp host is required")
+		}
+		if port := c.Setting("port"); port != "" {
+			if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+				return fmt.Errorf("invalid smtp port %q", port)
+			}
+		}
+		if c.Setting("from") == "" {
+			return fmt.Errorf("from address is required")
+		}
+		if len(c.Recipients()) == 0 {
+			return fmt.Errorf("at least one recipient is required")
+		}
+	case ChannelFile:
+	default:
+		return fmt.Errorf("invalid channel type %q", c.Type)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the channel without its SMTP password, for API responses
+// and exports
+func (c *NotificationChannel) Redacted() *NotificationChannel {
+	copied := *c
+	copied.Config = make(map[string]string, len(c.Config))
+	for key, value := range c.Config {
+		if key != "password" {
+			copied.Config[key] = value
+		}
+	}
+	return &copied
+}
+
+// Describe returns where the channel delivers, such as a webhook URL or email recipients
+func (c *NotificationChannel) Describe() string {
+	switch c.Type {
+	case ChannelWebhook:
+		if target, err := url.Parse(c.Setting("url")); err == nil {
+			return "POST to " + target.Scheme + "://" + target.Host + target.Path
+		}
+	case ChannelSMTP:
+		return "Email to " + strings.Join(c.Recipients(), ", ") + " via " + c.Setting("host")
+	case ChannelFile:
+		if path := c.Setting("path"); path != "" {
+			return "Append to " + path
+		}
+		return "Server log"
+	}
+	return c.Type
+}
+
+// normalize trims the channel's name and type and drops blank config values
+func (c *NotificationChannel) normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Type = strings.TrimSpace(strings.ToLower(c.Type))
+	config := make(map[string]string, len(c.Config))
+	for key, value := range c.Config {
+		if value = strings.TrimSpace(value); value != "" {
+			config[key] = value
+		}
+	}
+	c.Config = config
+}
+
+// NotificationDelivery records one send to a channel, including its retries
+type NotificationDelivery struct {
+	ID          int       `json:"id"`
+	ChannelID   int       `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	Subject     string    `json:"subject"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type NotificationChannelService struct {
+	db DBTX
+}
+
+func NewNotificationChannelService(db *sql.DB) *NotificationChannelService {
+	return &NotificationChannelService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *NotificationChannelService) WithTx(tx *sql.Tx) *NotificationChannelService {
+	return &NotificationChannelService{db: tx}
+}
+
+const notificationChannelColumns = `id, name, channel_type, config, enabled, created_at, updated_at`
+
+func scanNotificationChannel(row interface{ Scan(...interface{}) error }) (*NotificationChannel, error) {
+	var channel NotificationChannel
+	var config string
+	if err := row.Scan(&channel.ID, &channel.Name, &channel.Type, &config, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(config), &channel.Config); err != nil {
+		return nil, fmt.Errorf("invalid config for channel %d: %w", channel.ID, err)
+	}
+	if channel.Config == nil {
+		channel.Config = map[string]string{}
+	}
+	return &channel, nil
+}
+
+// Create validates and stores a new channel
+func (s *NotificationChannelService) Create(channel *NotificationChannel) (*NotificationChannel, error) {
+	channel.normalize()
+	if err := channel.Validate(); err != nil {
+		return nil, err
+	}
+	config, err := json.Marshal(channel.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode channel config: %w", err)
+	}
+
+	query := `INSERT INTO notification_channels (name, channel_type, config, enabled) VALUES (?, ?, ?, ?) RETURNING ` + notificationChannelColumns
+	created, err := scanNotificationChannel(s.db.QueryRow(query, channel.Name, channel.Type, string(config), channel.Enabled))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	return created, nil
+}
+
+// Update validates and replaces a channel
+func (s *NotificationChannelService) Update(channel *NotificationChannel) (*NotificationChannel, error) {
+	channel.normalize()
+	if err := channel.Validate(); err != nil {
+		return nil, err
+	}
+	config, err := json.Marshal(channel.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode channel config: %w", err)
+	}
+
+	query := `UPDATE notification_channels SET name = ?, channel_type = ?, config = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? RETURNING ` + notificationChannelColumns
+	updated, err := scanNotificationChannel(s.db.QueryRow(query, channel.Name, channel.Type, string(config), channel.Enabled, channel.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("notification channel not found")
+		}
+		return nil, fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete removes a channel and its delivery log
+func (s *NotificationChannelService) Delete(id int) error {
+	result, err := s.db.Exec(`DELETE FROM notification_channels WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification channel not found")
+	}
+	return nil
+}
+
+// GetByID returns one channel
+func (s *NotificationChannelService) GetByID(id int) (*NotificationChannel, error) {
+	channel, err := scanNotificationChannel(s.db.QueryRow(`SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("notification channel not found")
+		}
+		return nil, fmt.Errorf("failed to get notification channel: %w", err)
+	}
+	return channel, nil
+}
+
+// GetAll returns every channel in the order they were created
+func (s *NotificationChannelService) GetAll() ([]*NotificationChannel, error) {
+	return s.query(`SELECT ` + notificationChannelColumns + ` FROM notification_channels ORDER BY id`)
+}
+
+// GetEnabled returns the channels new alerts are sent to
+func (s *NotificationChannelService) GetEnabled() ([]*NotificationChannel, error) {
+	return s.query(`SELECT ` + notificationChannelColumns + ` FROM notification_channels WHERE enabled = 1 ORDER BY id`)
+}
+
+func (s *NotificationChannelService) query(query string, args ...interface{}) ([]*NotificationChannel, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*NotificationChannel
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification channels: %w", err)
+	}
+
+	return channels, nil
+}
+
+type NotificationDeliveryService struct {
+	db DBTX
+}
+
+func NewNotificationDeliveryService(db *sql.DB) *NotificationDeliveryService {
+	return &NotificationDeliveryService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *NotificationDeliveryService) WithTx(tx *sql.Tx) *NotificationDeliveryService {
+	return &NotificationDeliveryService{db: tx}
+}
+
+// Record logs the outcome of a send to a channel; a nil sendErr records a successful delivery
+func (s *NotificationDeliveryService) Record(channelID int, subject string, attempts int, sendErr error, at time.Time) (*NotificationDelivery, error) {
+	status, message := DeliverySent, ""
+	if sendErr != nil {
+		status, message = DeliveryFailed, sendErr.Error()
+	}
+
+	var id int
+	err := s.db.QueryRow(`INSERT INTO notification_deliveries (channel_id, subject, status, attempts, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id`, channelID, subject, status, attempts, message, at.UTC()).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return s.GetByID(id)
+}
+
+const notificationDeliveryQuery = `
+	SELECT d.id, d.channel_id, c.name, d.subject, d.status, d.attempts, d.error, d.created_at
+	FROM notification_deliveries d
+	JOIN notification_channels c ON c.id = d.channel_id`
+
+func scanNotificationDelivery(row interface{ Scan(...interface{}) error }) (*NotificationDelivery, error) {
+	var delivery NotificationDelivery
+	if err := row.Scan(&delivery.ID, &delivery.ChannelID, &delivery.ChannelName, &delivery.Subject,
+		&delivery.Status, &delivery.Attempts, &delivery.Error, &delivery.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetByID returns one delivery
+func (s *NotificationDeliveryService) GetByID(id int) (*NotificationDelivery, error) {
+	delivery, err := scanNotificationDelivery(s.db.QueryRow(notificationDeliveryQuery+` WHERE d.id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("notification delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get notification delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// GetRecent returns the latest deliveries across all channels, newest first
+func (s *NotificationDeliveryService) GetRecent(limit int) ([]*NotificationDelivery, error) {
+	rows, err := s.db.Query(notificationDeliveryQuery+` ORDER BY d.created_at DESC, d.id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*NotificationDelivery
+	for rows.Next() {
+		delivery, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating notification deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
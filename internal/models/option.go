@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"stonks/internal/occ"
 	"time"
 )
 
@@ -11,13 +12,27 @@ import (
 const OptionCommissionPerContract = 0.65
 
 type OptionService struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewOptionService(db *sql.DB) *OptionService {
 	return &OptionService{db: db}
 }
 
+// WithTx returns a copy of the service bound to the given transaction
+func (s *OptionService) WithTx(tx *sql.Tx) *OptionService {
+	return &OptionService{db: tx}
+}
+
+// occSymbolFor returns the OCC symbol to store for a contract, or nil if it cannot be expressed as one
+func occSymbolFor(symbol, optionType string, expiration time.Time, strike float64) *string {
+	occSymbol, err := occ.Format(symbol, optionType, expiration, strike)
+	if err != nil {
+		return nil
+	}
+	return &occSymbol
+}
+
 func (s *OptionService) Create(symbol, optionType string, opened time.Time, strike float64, expiration time.Time, premium float64, contracts int) (*Option, error) {
 	// Automatically calculate opening commission: $0.65 per contract
 	openingCommission := OptionCommissionPerContract * float64(contracts)
@@ -29,15 +44,38 @@ func (s *OptionService) CreateWithCommission(symbol, optionType string, opened t
 		return nil, fmt.Errorf("option type must be 'Put' or 'Call'")
 	}
 
-	query := `INSERT INTO options (symbol, type, opened, strike, expiration, premium, contracts, commission) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
-			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at`
+	query := `INSERT INTO options (symbol, type, opened, strike, expiration, premium, contracts, commission, occ_symbol) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) 
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at`
 
 	var option Option
-	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission).Scan(
+	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission, occSymbolFor(symbol, optionType, expiration, strike)).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed, &option.Strike,
 		&option.Expiration, &option.Premium, &option.Contracts, &option.ExitPrice, &option.Commission,
-		&option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create option: %w", err)
+	}
+
+	return &option, nil
+}
+
+// CreateImported inserts a fully specified option, including exit details, as part of an import batch
+func (s *OptionService) CreateImported(o *Option, batchID int) (*Option, error) {
+	if o.Type != "Put" && o.Type != "Call" {
+		return nil, fmt.Errorf("option type must be 'Put' or 'Call'")
+	}
+
+	query := `INSERT INTO options (symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, occ_symbol, import_batch_id)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at`
+
+	var option Option
+	err := s.db.QueryRow(query, o.Symbol, o.Type, o.Opened, o.Closed, o.Strike, o.Expiration, o.Premium, o.Contracts, o.ExitPrice, o.Commission, occSymbolFor(o.Symbol, o.Type, o.Expiration, o.Strike), batchID).Scan(
+		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed, &option.Strike,
+		&option.Expiration, &option.Premium, &option.Contracts, &option.ExitPrice, &option.Commission,
+		&option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create option: %w", err)
@@ -47,7 +85,7 @@ func (s *OptionService) CreateWithCommission(symbol, optionType string, opened t
 }
 
 func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at 
 			  FROM options WHERE symbol = ? ORDER BY expiration DESC, opened DESC`
 
 	rows, err := s.db.Query(query, symbol)
@@ -61,7 +99,41 @@ func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan option: %w", err)
+		}
+		options = append(options, &option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating options: %w", err)
+	}
+
+	return options, nil
+}
+
+// GetByOCCSymbol retrieves all options for a contract by its OCC symbol (with or without the Polygon "O:" prefix)
+func (s *OptionService) GetByOCCSymbol(occSymbol string) ([]*Option, error) {
+	contract, err := occ.Parse(occSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at 
+			  FROM options WHERE occ_symbol = ? ORDER BY opened DESC`
+
+	rows, err := s.db.Query(query, contract.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options by OCC symbol: %w", err)
+	}
+	defer rows.Close()
+
+	var options []*Option
+	for rows.Next() {
+		var option Option
+		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
+			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -75,7 +147,7 @@ func (s *OptionService) GetBySymbol(symbol string) ([]*Option, error) {
 }
 
 func (s *OptionService) GetAll() ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at 
 			  FROM options ORDER BY expiration DESC, opened DESC`
 
 	rows, err := s.db.Query(query)
@@ -89,7 +161,7 @@ func (s *OptionService) GetAll() ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -103,7 +175,7 @@ func (s *OptionService) GetAll() ([]*Option, error) {
 }
 
 func (s *OptionService) GetOpen() ([]*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at 
 			  FROM options WHERE closed IS NULL ORDER BY expiration ASC`
 
 	rows, err := s.db.Query(query)
@@ -117,7 +189,7 @@ func (s *OptionService) GetOpen() ([]*Option, error) {
 		var option Option
 		if err := rows.Scan(&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 			&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt); err != nil {
+			&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan option: %w", err)
 		}
 		options = append(options, &option)
@@ -176,14 +248,14 @@ func (s *OptionService) Delete(symbol, optionType string, opened time.Time, stri
 
 // GetByID retrieves an option by its ID
 func (s *OptionService) GetByID(id int) (*Option, error) {
-	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at 
+	query := `SELECT id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at 
 			  FROM options WHERE id = ?`
 
 	var option Option
 	err := s.db.QueryRow(query, id).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 		&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -202,15 +274,15 @@ func (s *OptionService) UpdateByID(id int, symbol, optionType string, opened tim
 	}
 
 	query := `UPDATE options 
-			  SET symbol = ?, type = ?, opened = ?, strike = ?, expiration = ?, premium = ?, contracts = ?, commission = ?, closed = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
+			  SET symbol = ?, type = ?, opened = ?, strike = ?, expiration = ?, premium = ?, contracts = ?, commission = ?, closed = ?, exit_price = ?, occ_symbol = ?, updated_at = CURRENT_TIMESTAMP 
 			  WHERE id = ? 
-			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, created_at, updated_at`
+			  RETURNING id, symbol, type, opened, closed, strike, expiration, premium, contracts, exit_price, commission, current_price, current_price_updated_at, occ_symbol, created_at, updated_at`
 
 	var option Option
-	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission, closed, exitPrice, id).Scan(
+	err := s.db.QueryRow(query, symbol, optionType, opened, strike, expiration, premium, contracts, commission, closed, exitPrice, occSymbolFor(symbol, optionType, expiration, strike), id).Scan(
 		&option.ID, &option.Symbol, &option.Type, &option.Opened, &option.Closed,
 		&option.Strike, &option.Expiration, &option.Premium, &option.Contracts,
-		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CreatedAt, &option.UpdatedAt,
+		&option.ExitPrice, &option.Commission, &option.CurrentPrice, &option.CurrentPriceUpdatedAt, &option.OCCSymbol, &option.CreatedAt, &option.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -274,6 +346,26 @@ func (s *OptionService) CloseByID(id int, closed time.Time, exitPrice float64) e
 	return nil
 }
 
+// UpdateCurrentPrice records an option's mark and when it was taken
+func (s *OptionService) UpdateCurrentPrice(id int, price float64, at time.Time) error {
+	query := `UPDATE options SET current_price = ?, current_price_updated_at = ? WHERE id = ?`
+	result, err := s.db.Exec(query, price, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to update option mark: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("option not found")
+	}
+
+	return nil
+}
+
 func (s *OptionService) DeleteBySymbol(symbol string) error {
 	query := `DELETE FROM options WHERE symbol = ?`
 	result, err := s.db.Exec(query, symbol)
@@ -304,9 +396,11 @@ type OptionSummary struct {
 // OpenPositionData represents an open option position with additional calculated fields
 type OpenPositionData struct {
 	*Option
-	DaysToExpiration int       `json:"days_to_expiration"`
-	Status           string    `json:"status"`
-	EntryDate        time.Time `json:"entry_date"`
+	DaysToExpiration int             `json:"days_to_expiration"`
+	Status           string          `json:"status"`
+	EntryDate        time.Time       `json:"entry_date"`
+	Earnings         *EarningsDate   `json:"earnings,omitempty"`        // Upcoming earnings before expiration, when flagged
+	AssignmentRisk   *AssignmentRisk `json:"assignment_risk,omitempty"` // Likely early assignment before an ex-dividend date, when flagged
 }
 
 // GetOptionsSummaryBySymbol returns options summary data grouped by symbol
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"math"
+	"path/filepath"
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestOptionService_OCCSymbol(t *testing.T) {
+	testDB, err := database.NewDB(filepath.Join(t.TempDir(), "option_test.db"))
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	if _, err := NewSymbolService(testDB.DB).Create("VZ"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	optionService := NewOptionService(testDB.DB)
+
+	opened := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	expiration := time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)
+
+	t.Run("create stores OCC symbol", func(t *testing.T) {
+		option, err := optionService.Create("VZ", "Call", opened, 42.5, expiration, 0.85, 1)
+		if err != nil {
+			t.Fatalf("Failed to create option: %v", err)
+		}
+		if option.OCCSymbol == nil || *option.OCCSymbol != "VZ250321C00042500" {
+			t.Errorf("Expected OCC symbol VZ250321C00042500, got %v", option.OCCSymbol)
+		}
+
+		options, err := optionService.GetByOCCSymbol("O:VZ250321C00042500")
+		if err != nil {
+			t.Fatalf("GetByOCCSymbol failed: %v", err)
+		}
+		if len(options) != 1 || options[0].ID != option.ID {
+			t.Errorf("Expected to find option %d by OCC symbol, got %d options", option.ID, len(options))
+		}
+	})
+
+	t.Run("update refreshes OCC symbol", func(t *testing.T) {
+		option, err := optionService.Create("VZ", "Put", opened, 40, expiration, 0.60, 1)
+		if err != nil {
+			t.Fatalf("Failed to create option: %v", err)
+		}
+		updated, err := optionService.UpdateByID(option.ID, "VZ", "Put", opened, 39, expiration, 0.60, 1, option.Commission, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to update option: %v", err)
+		}
+		if updated.GetOCCSymbol() != "VZ250321P00039000" {
+			t.Errorf("Expected OCC symbol VZ250321P00039000, got %s", updated.GetOCCSymbol())
+		}
+	})
+
+	t.Run("raw SQL inserts match the Go formatter", func(t *testing.T) {
+		// The example data scripts insert options directly; the trigger must produce the same symbol as occ.Format
+		_, err := testDB.Exec(`INSERT INTO options (symbol, type, opened, expiration, strike, premium, contracts, commission)
+			VALUES ('VZ', 'Put', '2025-02-03', '2025-04-17', 41.5, 0.75, 1, 0.65)`)
+		if err != nil {
+			t.Fatalf("Failed to insert option: %v", err)
+		}
+
+		options, err := optionService.GetByOCCSymbol("VZ250417P00041500")
+		if err != nil {
+			t.Fatalf("GetByOCCSymbol failed: %v", err)
+		}
+		if len(options) != 1 {
+			t.Fatalf("Expected 1 option for raw SQL insert, got %d", len(options))
+		}
+	})
+}
+
+func TestOption_EarlyAssignmentRisk(t *testing.T) {
+	now := time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC)
+	exDate := time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC)
+	symbol := &Symbol{Symbol: "VZ", Price: 42, Dividend: 0.6775, ExDividendDate: &exDate}
+	mark := func(price float64) *float64 { return &price }
+
+	tests := []struct {
+		name      string
+		option    *Option
+		wantRisk  bool
+		extrinsic float64
+	}{
+		// $2.00 intrinsic in a $2.25 mark leaves $0.25 of time value, less than the dividend
+		{"deep ITM call", &Option{Type: "Call", Strike: 40, Expiration: now.AddDate(0, 0, 11), CurrentPrice: mark(2.25)}, true, 0.25},
+		{"extrinsic above dividend", &Option{Type: "Call", Strike: 40, Expiration: now.AddDate(0, 0, 11), CurrentPrice: mark(2.90)}, false, 0},
+		{"out of the money", &Option{Type: "Call", Strike: 43, Expiration: now.AddDate(0, 0, 11), CurrentPrice: mark(0.10)}, false, 0},
+		{"expires before ex-date", &Option{Type: "Call", Strike: 40, Expiration: now.AddDate(0, 0, 1), CurrentPrice: mark(2.01)}, false, 0},
+		{"no mark", &Option{Type: "Call", Strike: 40, Expiration: now.AddDate(0, 0, 11)}, false, 0},
+		{"put", &Option{Type: "Put", Strike: 45, Expiration: now.AddDate(0, 0, 11), CurrentPrice: mark(3.05)}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := tt.option.EarlyAssignmentRisk(symbol, now)
+			if (risk != nil) != tt.wantRisk {
+				t.Fatalf("Expected risk %v, got %+v", tt.wantRisk, risk)
+			}
+			if risk != nil && (math.Abs(risk.Extrinsic-tt.extrinsic) > 0.001 || !risk.ExDividendDate.Equal(exDate) || risk.Dividend != 0.6775) {
+				t.Errorf("Unexpected risk %+v", risk)
+			}
+		})
+	}
+
+	// On the ex-date the early exercise has already happened
+	if risk := tests[0].option.EarlyAssignmentRisk(symbol, exDate.Add(10*time.Hour)); risk != nil {
+		t.Errorf("Expected no risk on the ex-date, got %+v", risk)
+	}
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Price history sources
+const (
+	PriceSourceManual   = "manual"
+	PriceSourceImport   = "import"
+	PriceSourceProvider = "provider"
+)
+
+// PriceBar is one day of price history for a symbol
+type PriceBar struct {
+	Symbol    string    `json:"symbol"`
+	Date      time.Time `json:"date"`
+	Open      *float64  `json:"open"`
+	High      *float64  `json:"high"`
+	Low       *float64  `json:"low"`
+	Close     float64   `json:"close"`
+	Volume    *float64  `json:"volume"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PriceHistoryService struct {
+	db DBTX
+}
+
+func NewPriceHistoryService(db *sql.DB) *PriceHistoryService {
+	return &PriceHistoryService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *PriceHistoryService) WithTx(tx *sql.Tx) *PriceHistoryService {
+	return &PriceHistoryService{db: tx}
+}
+
+// priceDate truncates a timestamp to its calendar date so there is one bar per day
+func priceDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Upsert stores a daily bar, replacing any existing bar for the same symbol and date
+func (s *PriceHistoryService) Upsert(bar *PriceBar) error {
+	symbol, source, err := validatePriceBar(bar)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO price_history (symbol, date, open, high, low, close, volume, source)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(symbol, date) DO UPDATE SET
+			      open = excluded.open,
+			      high = excluded.high,
+			      low = excluded.low,
+			      close = excluded.close,
+			      volume = excluded.volume,
+			      source = excluded.source,
+			      import_batch_id = NULL,
+			      updated_at = CURRENT_TIMESTAMP`
+
+	_, err = s.db.Exec(query, symbol, priceDate(bar.Date), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume, source)
+	if err != nil {
+		return fmt.Errorf("failed to save price for %s on %s: %w", symbol, bar.Date.Format("2006-01-02"), err)
+	}
+	return nil
+}
+
+// CreateImported stores a daily bar as part of an import batch. A bar already stored for
+// the same symbol and date is kept as it is, so reverting the batch can only remove bars
+// the batch added; created is false when the bar was skipped for that reason.
+func (s *PriceHistoryService) CreateImported(bar *PriceBar, batchID int) (created bool, err error) {
+	symbol, source, err := validatePriceBar(bar)
+	if err != nil {
+		return false, err
+	}
+
+	query := `INSERT INTO price_history (symbol, date, open, high, low, close, volume, source, import_batch_id)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(symbol, date) DO NOTHING`
+
+	result, err := s.db.Exec(query, symbol, priceDate(bar.Date), bar.Open, bar.High, bar.Low, bar.Close, bar.Volume, source, batchID)
+	if err != nil {
+		return false, fmt.Errorf("failed to save price for %s on %s: %w", symbol, bar.Date.Format("2006-01-02"), err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check saved price for %s: %w", symbol, err)
+	}
+	return rows > 0, nil
+}
+
+// validatePriceBar checks a bar and returns its normalized symbol and source
+func validatePriceBar(bar *PriceBar) (string, string, error) {
+	if bar.Close <= 0 {
+		return "", "", fmt.Errorf("close price must be positive")
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(bar.Symbol))
+	if symbol == "" {
+		return "", "", fmt.Errorf("symbol is required")
+	}
+	source := bar.Source
+	if source == "" {
+		source = PriceSourceManual
+	}
+	return symbol, source, nil
+}
+
+// GetBySymbol returns the daily bars for a symbol between from and to inclusive, oldest first.
+// A zero from or to leaves that end of the range open.
+func (s *PriceHistoryService) GetBySymbol(symbol string, from, to time.Time) ([]*PriceBar, error) {
+	query := `SELECT symbol, date, open, high, low, close, volume, source, created_at, updated_at
+			  FROM price_history WHERE symbol = ?`
+	args := []interface{}{symbol}
+	if !from.IsZero() {
+		query += ` AND date >= ?`
+		args = append(args, priceDate(from))
+	}
+	if !to.IsZero() {
+		query += ` AND date <= ?`
+		args = append(args, priceDate(to))
+	}
+	query += ` ORDER BY date`
+
+	return s.query(query, args...)
+}
+
+// GetAll returns every symbol's daily bars, by symbol and then oldest first
+func (s *PriceHistoryService) GetAll() ([]*PriceBar, error) {
+	return s.query(`SELECT symbol, date, open, high, low, close, volume, source, created_at, updated_at
+			  FROM price_history ORDER BY symbol, date`)
+}
+
+func (s *PriceHistoryService) query(query string, args ...interface{}) ([]*PriceBar, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	var bars []*PriceBar
+	for rows.Next() {
+		var bar PriceBar
+		if err := rows.Scan(&bar.Symbol, &bar.Date, &bar.Open, &bar.High, &bar.Low, &bar.Close,
+			&bar.Volume, &bar.Source, &bar.CreatedAt, &bar.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price bar: %w", err)
+		}
+		bars = append(bars, &bar)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price history: %w", err)
+	}
+
+	return bars, nil
+}
+
+// GetLatestDate returns the date of the most recent bar for a symbol, or nil if there is none
+func (s *PriceHistoryService) GetLatestDate(symbol string) (*time.Time, error) {
+	var latest time.Time
+	err := s.db.QueryRow(`SELECT date FROM price_history WHERE symbol = ? ORDER BY date DESC LIMIT 1`, symbol).Scan(&latest)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest price date: %w", err)
+	}
+	return &latest, nil
+}
+
+// Count returns the number of bars stored for a symbol
+func (s *PriceHistoryService) Count(symbol string) (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM price_history WHERE symbol = ?`, symbol).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count price history: %w", err)
+	}
+	return count, nil
+}
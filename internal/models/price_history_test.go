@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestPriceHistoryService_UpsertAndRange(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	if _, err := NewSymbolService(testDB.DB).Create("VZ"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	service := NewPriceHistoryService(testDB.DB)
+
+	if latest, err := service.GetLatestDate("VZ"); err != nil || latest != nil {
+		t.Fatalf("Expected no latest date before any bars, got %v, %v", latest, err)
+	}
+
+	eastern := time.FixedZone("EST", -5*60*60)
+	for i, close := range []float64{40.10, 40.55, 41.02} {
+		bar := &PriceBar{Symbol: "VZ", Date: time.Date(2025, 3, 3+i, 0, 0, 0, 0, eastern), Close: close, Source: PriceSourceProvider}
+		if err := service.Upsert(bar); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	// A second bar for the same day replaces the first
+	if err := service.Upsert(&PriceBar{Symbol: "VZ", Date: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), Close: 41.37}); err != nil {
+		t.Fatalf("Upsert of existing day failed: %v", err)
+	}
+
+	count, err := service.Count("VZ")
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 bars, got %d (%v)", count, err)
+	}
+
+	bars, err := service.GetBySymbol("VZ", time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil {
+		t.Fatalf("GetBySymbol failed: %v", err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 bars from March 4, got %d", len(bars))
+	}
+	if got := bars[0].Date.Format("2006-01-02"); got != "2025-03-04" {
+		t.Errorf("Expected the wall date to be kept, got %s", got)
+	}
+	if bars[1].Close != 41.37 || bars[1].Source != PriceSourceManual {
+		t.Errorf("Expected the replaced bar to close at 41.37 from manual, got %.2f from %s", bars[1].Close, bars[1].Source)
+	}
+
+	latest, err := service.GetLatestDate("VZ")
+	if err != nil || latest == nil || latest.Format("2006-01-02") != "2025-03-05" {
+		t.Errorf("Expected latest date 2025-03-05, got %v (%v)", latest, err)
+	}
+	if all, err := service.GetAll(); err != nil || len(all) != 3 || all[0].Date.Format("2006-01-02") != "2025-03-03" {
+		t.Errorf("Expected all 3 bars oldest first, got %v (%v)", all, err)
+	}
+}
+
+func TestPriceHistoryService_CreateImportedKeepsExistingBars(t *testing.T) {
+	testDB := setupImportBatchTestDB(t)
+	service := NewPriceHistoryService(testDB.DB)
+	batchService := NewImportBatchService(testDB.DB)
+
+	// A provider bar for March 3 is already stored
+	march3 := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	if err := service.Upsert(&PriceBar{Symbol: "AAPL", Date: march3, Close: 240.00, Source: PriceSourceProvider}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	batch, err := batchService.Run(ImportTypePriceHistory, "AAPL.csv", []byte("prices"), func(tx *sql.Tx, batchID int) (int, int, error) {
+		imported, skipped := 0, 0
+		for i, close := range []float64{239.50, 241.25} {
+			created, err := service.WithTx(tx).CreateImported(&PriceBar{Symbol: "AAPL", Date: march3.AddDate(0, 0, i), Close: close, Source: PriceSourceImport}, batchID)
+			if err != nil {
+				return 0, 0, err
+			}
+			if created {
+				imported++
+			} else {
+				skipped++
+			}
+		}
+		return imported, skipped, nil
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if batch.ImportedCount != 1 || batch.SkippedCount != 1 {
+		t.Errorf("Expected 1 imported and 1 skipped, got %d and %d", batch.ImportedCount, batch.SkippedCount)
+	}
+
+	if _, err := batchService.Revert(batch.ID); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	bars, err := service.GetBySymbol("AAPL", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetBySymbol failed: %v", err)
+	}
+	if len(bars) != 1 || bars[0].Close != 240.00 || bars[0].Source != PriceSourceProvider {
+		t.Fatalf("Expected only the provider bar to survive the revert, got %d bars", len(bars))
+	}
+}
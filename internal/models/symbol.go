@@ -93,8 +93,22 @@ type Option struct {
 	ExitPrice    *float64   `json:"exit_price"`
 	Commission   float64    `json:"commission"`
 	CurrentPrice *float64   `json:"current_price"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	// CurrentPriceUpdatedAt is when CurrentPrice was last marked to market
+	CurrentPriceUpdatedAt *time.Time `json:"current_price_updated_at"`
+	OCCSymbol             *string    `json:"occ_symbol"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// GetOCCSymbol returns the stored OCC symbol, or one computed from the contract fields if none is stored
+func (o *Option) GetOCCSymbol() string {
+	if o.OCCSymbol != nil && *o.OCCSymbol != "" {
+		return *o.OCCSymbol
+	}
+	if occSymbol := occSymbolFor(o.Symbol, o.Type, o.Expiration, o.Strike); occSymbol != nil {
+		return *occSymbol
+	}
+	return ""
 }
 
 func (o *Option) CalculatePercentOTM(currentPrice float64) float64 {
@@ -113,6 +127,72 @@ func (o *Option) CalculatePercentOTM(currentPrice float64) float64 {
 	return 0
 }
 
+// CalculatePercentITM returns how far the option is in the money as a percentage of the
+// current price, measured like CalculatePercentOTM, or zero when it is not in the money
+func (o *Option) CalculatePercentITM(currentPrice float64) float64 {
+	if currentPrice <= 0 {
+		return 0
+	}
+	if o.Type == "Put" && currentPrice < o.Strike {
+		return (o.Strike - currentPrice) / currentPrice * 100
+	}
+	if o.Type == "Call" && currentPrice > o.Strike {
+		return (currentPrice - o.Strike) / currentPrice * 100
+	}
+	return 0
+}
+
+// CalculateExtrinsic returns the time value left in the option's mark with the underlying at
+// currentPrice: the mark less intrinsic value, never below zero
+func (o *Option) CalculateExtrinsic(currentPrice float64) float64 {
+	if !o.HasMark() {
+		return 0
+	}
+	intrinsic := 0.0
+	if o.Type == "Put" && currentPrice < o.Strike {
+		intrinsic = o.Strike - currentPrice
+	}
+	if o.Type == "Call" && currentPrice > o.Strike {
+		intrinsic = currentPrice - o.Strike
+	}
+	return math.Max(*o.CurrentPrice-intrinsic, 0)
+}
+
+// AssignmentRisk describes a short call likely to be exercised early to collect a dividend
+type AssignmentRisk struct {
+	ExDividendDate time.Time `json:"ex_dividend_date"`
+	Dividend       float64   `json:"dividend"`  // Per share
+	Extrinsic      float64   `json:"extrinsic"` // Per share, from the option's mark
+}
+
+// EarlyAssignmentRisk checks an open short call against its symbol's next dividend. A call
+// holder gives up the remaining extrinsic value by exercising, so once the call is in the
+// money with less extrinsic value left than the dividend, exercising the day before the
+// ex-date pays. Returns nil when there is no such risk, including for calls without a mark
+// and calls that expire before the ex-date.
+func (o *Option) EarlyAssignmentRisk(symbol *Symbol, now time.Time) *AssignmentRisk {
+	if o.Type != "Call" || !o.IsOpen() || !o.HasMark() || symbol == nil || symbol.ExDividendDate == nil {
+		return nil
+	}
+	if symbol.Dividend <= 0 || symbol.Price <= o.Strike {
+		return nil
+	}
+	exDate := *symbol.ExDividendDate
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !exDate.After(today) || exDate.After(o.Expiration) {
+		return nil
+	}
+	extrinsic := o.CalculateExtrinsic(symbol.Price)
+	if extrinsic >= symbol.Dividend {
+		return nil
+	}
+	return &AssignmentRisk{
+		ExDividendDate: exDate,
+		Dividend:       symbol.Dividend,
+		Extrinsic:      extrinsic,
+	}
+}
+
 func (o *Option) CalculateDTE() int {
 	if o.Expiration.Before(o.Opened) {
 		return 0
@@ -142,15 +222,39 @@ func (o *Option) CalculateTotalProfit() float64 {
 	return profit - o.Commission // Subtract commission for accurate net profit
 }
 
+// CalculatePercentOfProfit returns the profit as a percent of the premium collected.
+// Open positions with a mark are measured as if bought back at the mark.
 func (o *Option) CalculatePercentOfProfit() float64 {
 	if o.Premium == 0 {
 		return 0
 	}
 	maxProfit := o.Premium * float64(o.Contracts) * 100
 	actualProfit := o.CalculateTotalProfit()
+	if o.HasMark() {
+		actualProfit = o.CalculateUnrealizedProfit()
+	}
 	return (actualProfit / maxProfit) * 100
 }
 
+// HasMark reports whether the option is open and has been marked to market
+func (o *Option) HasMark() bool {
+	return o.Closed == nil && o.ExitPrice == nil && o.CurrentPrice != nil
+}
+
+// CalculateUnrealizedProfit returns the profit if the option were bought back at its current mark
+func (o *Option) CalculateUnrealizedProfit() float64 {
+	if o.CurrentPrice == nil {
+		return o.CalculateTotalProfit()
+	}
+	profit := math.Floor((o.Premium - *o.CurrentPrice) * float64(o.Contracts) * 100)
+	return profit - o.Commission
+}
+
+// ShouldConsiderClosing reports whether an open option has captured at least targetPercent of its max profit
+func (o *Option) ShouldConsiderClosing(targetPercent float64) bool {
+	return o.HasMark() && targetPercent > 0 && o.CalculatePercentOfProfit() >= targetPercent
+}
+
 func (o *Option) CalculatePercentOfTime() float64 {
 	totalDays := o.Expiration.Sub(o.Opened).Hours() / 24
 	if totalDays <= 0 {
@@ -299,13 +403,18 @@ type Dividend struct {
 }
 
 type SymbolService struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewSymbolService(db *sql.DB) *SymbolService {
 	return &SymbolService{db: db}
 }
 
+// WithTx returns a copy of the service bound to the given transaction
+func (s *SymbolService) WithTx(tx *sql.Tx) *SymbolService {
+	return &SymbolService{db: tx}
+}
+
 func (s *SymbolService) Create(symbol string) (*Symbol, error) {
 	symbol = strings.TrimSpace(strings.ToUpper(symbol))
 	if symbol == "" {
@@ -471,7 +580,8 @@ func (s *SymbolService) GetDistinctSymbols() ([]string, error) {
 // GetPrioritizedSymbols returns symbols ordered by importance:
 // 1. Symbols with open long positions (highest priority)
 // 2. Symbols with open options positions
-// 3. All other symbols in the database (lowest priority)
+// 3. Symbols on the watchlist
+// 4. All other symbols in the database (lowest priority)
 func (s *SymbolService) GetPrioritizedSymbols() ([]string, error) {
 	query := `
 		WITH symbol_priorities AS (
@@ -489,8 +599,14 @@ func (s *SymbolService) GetPrioritizedSymbols() ([]string, error) {
 			
 			UNION
 			
-			-- Priority 3: All other symbols (from any table)
-			SELECT DISTINCT symbol, 3 as priority
+			-- Priority 3: Symbols on the watchlist
+			SELECT symbol, 3 as priority
+			FROM watchlist
+			
+			UNION
+			
+			-- Priority 4: All other symbols (from any table)
+			SELECT DISTINCT symbol, 4 as priority
 			FROM (
 				SELECT symbol FROM symbols
 				UNION
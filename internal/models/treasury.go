@@ -4,9 +4,38 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 )
 
+// Treasury security types stored in treasuries.security_type
+const (
+	TreasuryTypeBill = "Bill"
+	TreasuryTypeNote = "Note"
+	TreasuryTypeBond = "Bond"
+	TreasuryTypeTIPS = "TIPS"
+	TreasuryTypeFRN  = "FRN"
+)
+
+// ParseTreasurySecurityType recognizes a security type from a type column or a
+// description such as "26-Week Bill" or "UNITED STATES TREAS NTS 4.250% 11/15/34"
+func ParseTreasurySecurityType(description string) (string, bool) {
+	d := " " + strings.ToUpper(description) + " "
+	switch {
+	case strings.Contains(d, "TIPS") || strings.Contains(d, "INFLATION"):
+		return TreasuryTypeTIPS, true
+	case strings.Contains(d, "FRN") || strings.Contains(d, "FLOATING"):
+		return TreasuryTypeFRN, true
+	case strings.Contains(d, "BILL") || strings.Contains(d, "-WEEK") || strings.Contains(d, " BIL ") || strings.Contains(d, " BILS "):
+		return TreasuryTypeBill, true
+	case strings.Contains(d, "NOTE") || strings.Contains(d, " NTS ") || strings.Contains(d, " NT "):
+		return TreasuryTypeNote, true
+	case strings.Contains(d, "BOND") || strings.Contains(d, " BDS ") || strings.Contains(d, " BD "):
+		return TreasuryTypeBond, true
+	}
+	return "", false
+}
+
 type Treasury struct {
 	CUSPID       string     `json:"cuspid"`
 	Purchased    time.Time  `json:"purchased"`
@@ -16,6 +45,9 @@ type Treasury struct {
 	BuyPrice     float64    `json:"buy_price"`
 	CurrentValue *float64   `json:"current_value"`
 	ExitPrice    *float64   `json:"exit_price"`
+	CouponRate   *float64   `json:"coupon_rate"`
+	SecurityType *string    `json:"security_type"`
+	IssueDate    *time.Time `json:"issue_date"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
@@ -70,6 +102,27 @@ func (t *Treasury) GetExitPrice() float64 {
 	return *t.ExitPrice
 }
 
+// GetSecurityType returns the security type (Bill, Note, ...), or an empty string if unknown
+func (t *Treasury) GetSecurityType() string {
+	if t.SecurityType == nil {
+		return ""
+	}
+	return *t.SecurityType
+}
+
+// GetCouponRate returns the coupon rate as a float64, or 0.0 if nil
+func (t *Treasury) GetCouponRate() float64 {
+	if t.CouponRate == nil {
+		return 0.0
+	}
+	return *t.CouponRate
+}
+
+// HasCouponRate returns true if coupon rate is set
+func (t *Treasury) HasCouponRate() bool {
+	return t.CouponRate != nil
+}
+
 // HasCurrentValue returns true if current value is set
 func (t *Treasury) HasCurrentValue() bool {
 	return t.CurrentValue != nil
@@ -91,13 +144,18 @@ func (t *Treasury) CalculateDaysRemaining() int {
 }
 
 type TreasuryService struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewTreasuryService(db *sql.DB) *TreasuryService {
 	return &TreasuryService{db: db}
 }
 
+// WithTx returns a copy of the service bound to the given transaction
+func (s *TreasuryService) WithTx(tx *sql.Tx) *TreasuryService {
+	return &TreasuryService{db: tx}
+}
+
 func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, amount, yield, buyPrice float64) (*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] Create: Starting creation for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] Create: Parameters - Purchased=%v, Maturity=%v, Amount=%.2f, Yield=%.3f, BuyPrice=%.2f", 
@@ -110,7 +168,7 @@ func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, a
 
 	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price) 
 			  VALUES (?, ?, ?, ?, ?, ?) 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] Create: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] Create: SQL = %s", query)
@@ -118,7 +176,7 @@ func (s *TreasuryService) Create(cuspid string, purchased, maturity time.Time, a
 	var treasury Treasury
 	err := s.db.QueryRow(query, cuspid, purchased, maturity, amount, yield, buyPrice).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
 		&treasury.CreatedAt, &treasury.UpdatedAt,
 	)
 	if err != nil {
@@ -158,7 +216,7 @@ func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Tim
 
 	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price) 
 			  VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] CreateFull: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] CreateFull: SQL = %s", query)
@@ -166,7 +224,7 @@ func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Tim
 	var treasury Treasury
 	err := s.db.QueryRow(query, cuspid, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
 		&treasury.CreatedAt, &treasury.UpdatedAt,
 	)
 	if err != nil {
@@ -183,10 +241,37 @@ func (s *TreasuryService) CreateFull(cuspid string, purchased, maturity time.Tim
 	return &treasury, nil
 }
 
+// CreateImported inserts a fully specified treasury as part of an import batch
+func (s *TreasuryService) CreateImported(t *Treasury, batchID int) (*Treasury, error) {
+	log.Printf("[TREASURY SERVICE] CreateImported: Starting creation for CUSPID=%s in batch %d", t.CUSPID, batchID)
+
+	if t.CUSPID == "" {
+		return nil, fmt.Errorf("CUSPID cannot be empty")
+	}
+
+	query := `INSERT INTO treasuries (cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, import_batch_id)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at`
+
+	var treasury Treasury
+	err := s.db.QueryRow(query, t.CUSPID, t.Purchased, t.Maturity, t.Amount, t.Yield, t.BuyPrice, t.CurrentValue, t.ExitPrice, t.CouponRate, t.SecurityType, t.IssueDate, batchID).Scan(
+		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
+		&treasury.CreatedAt, &treasury.UpdatedAt,
+	)
+	if err != nil {
+		log.Printf("[TREASURY SERVICE] CreateImported: ERROR - SQL execution failed for CUSPID=%s: %v", t.CUSPID, err)
+		return nil, fmt.Errorf("failed to create treasury: %w", err)
+	}
+
+	log.Printf("[TREASURY SERVICE] CreateImported: Successfully created treasury for CUSPID=%s", t.CUSPID)
+	return &treasury, nil
+}
+
 func (s *TreasuryService) GetAll() ([]*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] GetAll: Starting to retrieve all treasuries")
 	
-	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at 
+	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at 
 			  FROM treasuries ORDER BY maturity DESC, purchased DESC`
 	
 	log.Printf("[TREASURY SERVICE] GetAll: Executing SQL query")
@@ -204,7 +289,7 @@ func (s *TreasuryService) GetAll() ([]*Treasury, error) {
 	for rows.Next() {
 		var treasury Treasury
 		if err := rows.Scan(&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-			&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+			&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
 			&treasury.CreatedAt, &treasury.UpdatedAt); err != nil {
 			log.Printf("[TREASURY SERVICE] GetAll: ERROR - Failed to scan row %d: %v", rowCount, err)
 			return nil, fmt.Errorf("failed to scan treasury: %w", err)
@@ -246,7 +331,7 @@ func (s *TreasuryService) GetTotalOpenValue() (float64, error) {
 func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 	log.Printf("[TREASURY SERVICE] GetByCUSPID: Starting to retrieve treasury for CUSPID=%s", cuspid)
 	
-	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at 
+	query := `SELECT cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at 
 			  FROM treasuries WHERE cuspid = ?`
 	
 	log.Printf("[TREASURY SERVICE] GetByCUSPID: Executing SQL query for CUSPID=%s", cuspid)
@@ -254,7 +339,7 @@ func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 	
 	var treasury Treasury
 	err := s.db.QueryRow(query, cuspid).Scan(&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity,
-		&treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
 		&treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -275,12 +360,12 @@ func (s *TreasuryService) GetByCUSPID(cuspid string) (*Treasury, error) {
 func (s *TreasuryService) Update(cuspid string, currentValue, exitPrice *float64) (*Treasury, error) {
 	query := `UPDATE treasuries SET current_value = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
 			  WHERE cuspid = ? 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at`
 	
 	var treasury Treasury
 	err := s.db.QueryRow(query, currentValue, exitPrice, cuspid).Scan(&treasury.CUSPID, &treasury.Purchased,
 		&treasury.Maturity, &treasury.Amount, &treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue,
-		&treasury.ExitPrice, &treasury.CreatedAt, &treasury.UpdatedAt)
+		&treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate, &treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("treasury not found")
@@ -309,7 +394,7 @@ func (s *TreasuryService) UpdateFull(cuspid string, purchased, maturity time.Tim
 	
 	query := `UPDATE treasuries SET purchased = ?, maturity = ?, amount = ?, yield = ?, buy_price = ?, current_value = ?, exit_price = ?, updated_at = CURRENT_TIMESTAMP 
 			  WHERE cuspid = ? 
-			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, created_at, updated_at`
+			  RETURNING cuspid, purchased, maturity, amount, yield, buy_price, current_value, exit_price, coupon_rate, security_type, issue_date, created_at, updated_at`
 	
 	log.Printf("[TREASURY SERVICE] UpdateFull: Executing SQL query for CUSPID=%s", cuspid)
 	log.Printf("[TREASURY SERVICE] UpdateFull: SQL = %s", query)
@@ -317,7 +402,7 @@ func (s *TreasuryService) UpdateFull(cuspid string, purchased, maturity time.Tim
 	var treasury Treasury
 	err := s.db.QueryRow(query, purchased, maturity, amount, yield, buyPrice, currentValue, exitPrice, cuspid).Scan(
 		&treasury.CUSPID, &treasury.Purchased, &treasury.Maturity, &treasury.Amount,
-		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice,
+		&treasury.Yield, &treasury.BuyPrice, &treasury.CurrentValue, &treasury.ExitPrice, &treasury.CouponRate, &treasury.SecurityType, &treasury.IssueDate,
 		&treasury.CreatedAt, &treasury.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
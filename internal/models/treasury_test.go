@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestParseTreasurySecurityType(t *testing.T) {
+	tests := map[string]string{
+		"26-Week Bill": TreasuryTypeBill,
+		"52-Week":      TreasuryTypeBill,
+		"UNITED STATES TREAS BILS 0.000% 03/20/25": TreasuryTypeBill,
+		"Note": TreasuryTypeNote,
+		"UNITED STATES TREAS NTS 4.250% 11/15/34": TreasuryTypeNote,
+		"US TREASURY BOND 4.000% 11/15/52":        TreasuryTypeBond,
+		"10-Year TIPS":                            TreasuryTypeTIPS,
+		"TREASURY INFLATION INDEXED NOTE":         TreasuryTypeTIPS,
+		"2-Year FRN":                              TreasuryTypeFRN,
+	}
+	for description, want := range tests {
+		got, ok := ParseTreasurySecurityType(description)
+		if !ok || got != want {
+			t.Errorf("ParseTreasurySecurityType(%q) = %q, %v; want %q", description, got, ok, want)
+		}
+	}
+
+	for _, description := range []string{"", "Fixed Income", "Cash"} {
+		if got, ok := ParseTreasurySecurityType(description); ok {
+			t.Errorf("ParseTreasurySecurityType(%q) = %q, expected no match", description, got)
+		}
+	}
+}
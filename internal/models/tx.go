@@ -0,0 +1,10 @@
+package models
+
+import "database/sql"
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx so services can run inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// WatchlistItem is a ticker we want to wheel next, with the symbol's latest price and dividend
+type WatchlistItem struct {
+	Symbol         string    `json:"symbol"`
+	TargetPrice    *float64  `json:"target_price"`
+	TargetYield    *float64  `json:"target_yield"`
+	Notes          string    `json:"notes"`
+	Price          float64   `json:"price"`
+	Dividend       float64   `json:"dividend"`
+	PriceUpdatedAt time.Time `json:"price_updated_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Yield calculates the annualized dividend yield percentage at the current price
+func (w *WatchlistItem) Yield() float64 {
+	if w.Price == 0 {
+		return 0
+	}
+	return (w.Dividend * 4) / w.Price * 100
+}
+
+// TargetYieldPrice returns the price at which the dividend reaches the target yield,
+// or nil without a target yield or dividend
+func (w *WatchlistItem) TargetYieldPrice() *float64 {
+	if w.TargetYield == nil || *w.TargetYield <= 0 || w.Dividend <= 0 {
+		return nil
+	}
+	price := (w.Dividend * 4) / (*w.TargetYield / 100)
+	return &price
+}
+
+// EntryPrice returns the highest price that meets every target set, or nil without targets
+func (w *WatchlistItem) EntryPrice() *float64 {
+	entry := w.TargetPrice
+	if yieldPrice := w.TargetYieldPrice(); yieldPrice != nil && (entry == nil || *yieldPrice < *entry) {
+		entry = yieldPrice
+	}
+	return entry
+}
+
+// DistanceToTarget returns how far the price must fall, as a percentage of the current
+// price, to reach the entry price. It is zero or negative once the price is at or below it.
+func (w *WatchlistItem) DistanceToTarget() *float64 {
+	entry := w.EntryPrice()
+	if entry == nil || w.Price <= 0 {
+		return nil
+	}
+	distance := math.Round((w.Price-*entry)/w.Price*10000) / 100
+	return &distance
+}
+
+// AtTarget reports whether the current price meets every target set
+func (w *WatchlistItem) AtTarget() bool {
+	distance := w.DistanceToTarget()
+	return distance != nil && *distance <= 0
+}
+
+type WatchlistService struct {
+	db DBTX
+}
+
+func NewWatchlistService(db *sql.DB) *WatchlistService {
+	return &WatchlistService{db: db}
+}
+
+// WithTx returns a copy of the service bound to the given transaction
+func (s *WatchlistService) WithTx(tx *sql.Tx) *WatchlistService {
+	return &WatchlistService{db: tx}
+}
+
+const watchlistSelect = `SELECT w.symbol, w.target_price, w.target_yield, w.notes, s.price, s.dividend,
+			  s.updated_at, w.created_at, w.updated_at
+			  FROM watchlist w JOIN symbols s ON s.symbol = w.symbol`
+
+func scanWatchlistItem(row interface{ Scan(...interface{}) error }) (*WatchlistItem, error) {
+	var item WatchlistItem
+	err := row.Scan(&item.Symbol, &item.TargetPrice, &item.TargetYield, &item.Notes, &item.Price, &item.Dividend,
+		&item.PriceUpdatedAt, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Add puts a symbol on the watchlist, creating the symbol if needed so the price refresh
+// jobs pick it up. Adding a symbol already on the watchlist replaces its targets and notes.
+func (s *WatchlistService) Add(symbol string, targetPrice, targetYield *float64, notes string) (*WatchlistItem, error) {
+	symbol = strings.TrimSpace(strings.ToUpper(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol cannot be empty")
+	}
+
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO symbols (symbol) VALUES (?)`, symbol); err != nil {
+		return nil, fmt.Errorf("failed to create symbol: %w", err)
+	}
+
+	query := `INSERT INTO watchlist (symbol, target_price, target_yield, notes)
+			  VALUES (?, ?, ?, ?)
+			  ON CONFLICT(symbol) DO UPDATE SET
+			      target_price = excluded.target_price,
+			      target_yield = excluded.target_yield,
+			      notes = excluded.notes,
+			      updated_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, symbol, targetPrice, targetYield, strings.TrimSpace(notes)); err != nil {
+		return nil, fmt.Errorf("failed to add %s to watchlist: %w", symbol, err)
+	}
+
+	return s.GetBySymbol(symbol)
+}
+
+// Update changes the targets and notes of a symbol already on the watchlist
+func (s *WatchlistService) Update(symbol string, targetPrice, targetYield *float64, notes string) (*WatchlistItem, error) {
+	symbol = strings.TrimSpace(strings.ToUpper(symbol))
+
+	query := `UPDATE watchlist SET target_price = ?, target_yield = ?, notes = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?`
+	result, err := s.db.Exec(query, targetPrice, targetYield, strings.TrimSpace(notes), symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update watchlist entry: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("watchlist entry not found")
+	}
+
+	return s.GetBySymbol(symbol)
+}
+
+// Delete takes a symbol off the watchlist. The symbol itself and its price history are kept.
+func (s *WatchlistService) Delete(symbol string) error {
+	result, err := s.db.Exec(`DELETE FROM watchlist WHERE symbol = ?`, strings.TrimSpace(strings.ToUpper(symbol)))
+	if err != nil {
+		return fmt.Errorf("failed to delete watchlist entry: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("watchlist entry not found")
+	}
+	return nil
+}
+
+// GetBySymbol returns one watchlist entry
+func (s *WatchlistService) GetBySymbol(symbol string) (*WatchlistItem, error) {
+	item, err := scanWatchlistItem(s.db.QueryRow(watchlistSelect+` WHERE w.symbol = ?`, strings.TrimSpace(strings.ToUpper(symbol))))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("watchlist entry not found")
+		}
+		return nil, fmt.Errorf("failed to get watchlist entry: %w", err)
+	}
+	return item, nil
+}
+
+// GetAll returns the whole watchlist ordered by symbol
+func (s *WatchlistService) GetAll() ([]*WatchlistItem, error) {
+	rows, err := s.db.Query(watchlistSelect + ` ORDER BY w.symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*WatchlistItem
+	for rows.Next() {
+		item, err := scanWatchlistItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist entry: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watchlist: %w", err)
+	}
+
+	return items, nil
+}
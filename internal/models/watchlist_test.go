@@ -0,0 +1,109 @@
+package models
+
+import (
+	"stonks/internal/database"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestWatchlistService(t *testing.T) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer testDB.Close()
+
+	service := NewWatchlistService(testDB.DB)
+	symbolService := NewSymbolService(testDB.DB)
+
+	// Adding a new ticker creates its symbol so price updates reach it
+	targetPrice, targetYield := 38.0, 7.0
+	item, err := service.Add(" vz ", &targetPrice, &targetYield, " Wheel after earnings ")
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if item.Symbol != "VZ" || item.Notes != "Wheel after earnings" || *item.TargetPrice != 38 {
+		t.Errorf("Unexpected watchlist entry: %+v", item)
+	}
+	if _, err := symbolService.GetBySymbol("VZ"); err != nil {
+		t.Fatalf("Expected VZ symbol to be created: %v", err)
+	}
+
+	if _, err := symbolService.Update("VZ", 40, 0.665, nil, nil); err != nil {
+		t.Fatalf("Failed to price VZ: %v", err)
+	}
+	item, err = service.GetBySymbol("VZ")
+	if err != nil {
+		t.Fatalf("GetBySymbol failed: %v", err)
+	}
+	// A 7% yield needs $38.00; the lower of the two targets is the entry price
+	if yield := item.Yield(); yield < 6.64 || yield > 6.66 {
+		t.Errorf("Expected 6.65%% yield, got %.2f", yield)
+	}
+	if entry := item.EntryPrice(); entry == nil || *entry != 38 {
+		t.Errorf("Expected $38 entry, got %v", entry)
+	}
+	if distance := item.DistanceToTarget(); distance == nil || *distance != 5 || item.AtTarget() {
+		t.Errorf("Expected 5%% above target, got %v", distance)
+	}
+
+	// Adding again replaces the targets
+	item, err = service.Add("VZ", nil, &targetYield, "")
+	if err != nil || item.TargetPrice != nil || item.Notes != "" {
+		t.Fatalf("Expected targets to be replaced, got %+v (%v)", item, err)
+	}
+	if entry := item.EntryPrice(); entry == nil || *entry != 38 {
+		t.Errorf("Expected $38 entry from the yield target alone, got %v", entry)
+	}
+
+	higher := 42.0
+	item, err = service.Update("VZ", &higher, nil, "At target")
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if distance := item.DistanceToTarget(); distance == nil || *distance != -5 || !item.AtTarget() {
+		t.Errorf("Expected 5%% below target, got %v", distance)
+	}
+	if _, err := service.Update("KO", nil, nil, ""); err == nil {
+		t.Error("Expected error updating a symbol not on the watchlist")
+	}
+
+	// Without targets there is no entry price
+	if _, err := service.Add("KO", nil, nil, ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	items, err := service.GetAll()
+	if err != nil || len(items) != 2 || items[0].Symbol != "KO" || items[0].DistanceToTarget() != nil {
+		t.Fatalf("Unexpected watchlist: %v (%v)", items, err)
+	}
+
+	// Watchlist symbols are priced after open positions and before everything else
+	if _, err := symbolService.Create("T"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	if _, err := NewOptionService(testDB.DB).Create("T", "Put", time.Now(), 25, time.Now().AddDate(0, 1, 0), 0.5, 1); err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+	if _, err := symbolService.Create("MO"); err != nil {
+		t.Fatalf("Failed to create symbol: %v", err)
+	}
+	prioritized, err := symbolService.GetPrioritizedSymbols()
+	if err != nil {
+		t.Fatalf("GetPrioritizedSymbols failed: %v", err)
+	}
+	if len(prioritized) != 4 || prioritized[0] != "T" || prioritized[1] != "KO" || prioritized[2] != "VZ" || prioritized[3] != "MO" {
+		t.Errorf("Expected T, KO, VZ, MO, got %v", prioritized)
+	}
+
+	if err := service.Delete("KO"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := service.Delete("KO"); err == nil {
+		t.Error("Expected error deleting a symbol twice")
+	}
+	if _, err := symbolService.GetBySymbol("KO"); err != nil {
+		t.Errorf("Expected KO symbol to be kept: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"stonks/internal/models"
+	"time"
+)
+
+// fileSender writes messages to the server log and, when a path is set, appends them to
+// that file as one JSON object per line
+type fileSender struct {
+	name string
+	path string
+}
+
+func newFileSender(channel *models.NotificationChannel) *fileSender {
+	return &fileSender{name: channel.Name, path: channel.Setting("path")}
+}
+
+// fileEntry is one line of a file sink
+type fileEntry struct {
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+func (s *fileSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("[NOTIFY] %s: %s: %s", s.name, msg.Subject, msg.Body)
+	if s.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(fileEntry{Time: time.Now().UTC(), Subject: msg.Subject, Body: msg.Body})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,147 @@
+// Package notify delivers alerts to the configured notification channels: JSON webhooks,
+// SMTP email and a local file/log sink. Failed sends are retried and every delivery is
+// recorded in the delivery log.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// Message is what a channel delivers. Body is plain text; channels that can show HTML
+// send the optional HTML rendering alongside it.
+type Message struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Sender delivers a message to one channel
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender builds the sender for a channel's type and settings
+func NewSender(channel *models.NotificationChannel) (Sender, error) {
+	if err := channel.Validate(); err != nil {
+		return nil, err
+	}
+	switch channel.Type {
+	case models.ChannelWebhook:
+		return newWebhookSender(channel), nil
+	case models.ChannelSMTP:
+		return newSMTPSender(channel), nil
+	case models.ChannelFile:
+		return newFileSender(channel), nil
+	}
+	return nil, fmt.Errorf("invalid channel type %q", channel.Type)
+}
+
+// Defaults for retrying failed sends; the delay doubles after each attempt
+const (
+	defaultAttempts   = 3
+	defaultRetryDelay = 2 * time.Second
+)
+
+// Notifier sends messages to channels, retrying failures and logging every delivery
+type Notifier struct {
+	channelService  *models.NotificationChannelService
+	deliveryService *models.NotificationDeliveryService
+	attempts        int
+	retryDelay      time.Duration
+}
+
+// NewNotifier creates a notifier for the channels stored in the given services
+func NewNotifier(channelService *models.NotificationChannelService, deliveryService *models.NotificationDeliveryService) *Notifier {
+	return &Notifier{
+		channelService:  channelService,
+		deliveryService: deliveryService,
+		attempts:        defaultAttempts,
+		retryDelay:      defaultRetryDelay,
+	}
+}
+
+// Deliver sends a message to one channel and records the outcome. A failed send is
+// reported in the returned delivery's status; the error is only for failing to record it.
+func (n *Notifier) Deliver(ctx context.Context, channel *models.NotificationChannel, msg Message) (*models.NotificationDelivery, error) {
+	attempts, err := n.send(ctx, channel, msg)
+	if err != nil {
+		log.Printf("[NOTIFY] Delivery of %q to %s failed after %d attempts: %v", msg.Subject, channel.Name, attempts, err)
+	} else {
+		log.Printf("[NOTIFY] Delivered %q to %s", msg.Subject, channel.Name)
+	}
+	return n.deliveryService.Record(channel.ID, msg.Subject, attempts, err, time.Now())
+}
+
+// send tries a channel until it succeeds, the attempts run out or ctx is done, and
+// returns how many attempts were made
+func (n *Notifier) send(ctx context.Context, channel *models.NotificationChannel, msg Message) (int, error) {
+	sender, err := NewSender(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	delay := n.retryDelay
+	for attempt := 1; ; attempt++ {
+		err = sender.Send(ctx, msg)
+		if err == nil || attempt >= n.attempts {
+			return attempt, err
+		}
+		log.Printf("[NOTIFY] Attempt %d to %s failed, retrying in %s: %v", attempt, channel.Name, delay, err)
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Broadcast sends a message to every enabled channel and returns the deliveries
+func (n *Notifier) Broadcast(ctx context.Context, msg Message) ([]*models.NotificationDelivery, error) {
+	channels, err := n.channelService.GetEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*models.NotificationDelivery
+	for _, channel := range channels {
+		delivery, err := n.Deliver(ctx, channel, msg)
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// AlertsMessage summarizes newly raised alerts: one alert is sent as it is, several are
+// listed one per line
+func AlertsMessage(alerts []*models.Alert) Message {
+	if len(alerts) == 1 {
+		return Message{
+			Subject: "Wheeler alert: " + alerts[0].RuleName,
+			Body:    alerts[0].Message,
+		}
+	}
+	lines := make([]string, len(alerts))
+	for i, alert := range alerts {
+		lines[i] = fmt.Sprintf("- %s: %s", alert.RuleName, alert.Message)
+	}
+	return Message{
+		Subject: fmt.Sprintf("Wheeler: %d new alerts", len(alerts)),
+		Body:    strings.Join(lines, "\n"),
+	}
+}
+
+// TestMessage is sent by a channel's test button
+func TestMessage(channel *models.NotificationChannel) Message {
+	return Message{
+		Subject: "Wheeler test notification",
+		Body:    fmt.Sprintf("This is a test from Wheeler. Alerts will be delivered to %s (%s).", channel.Name, channel.Describe()),
+	}
+}
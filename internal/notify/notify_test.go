@@ -0,0 +1,350 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestNotifier(t *testing.T) (*Notifier, *models.NotificationChannelService) {
+	testDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+
+	channels := models.NewNotificationChannelService(testDB.DB)
+	notifier := NewNotifier(channels, models.NewNotificationDeliveryService(testDB.DB))
+	notifier.retryDelay = time.Millisecond
+	return notifier, channels
+}
+
+// startSMTPServer runs a minimal SMTP server that accepts every message and returns its
+// address and the DATA of each message received
+func startSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+				reply("220 localhost test SMTP")
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.Fields(line + " x")[0])
+					switch command {
+					case "EHLO", "HELO":
+						reply("250 localhost")
+					case "MAIL", "RCPT", "RSET", "NOOP":
+						reply("250 OK")
+					case "DATA":
+						reply("354 End data with <CR><LF>.<CR><LF>")
+						var data strings.Builder
+						for {
+							line, err := reader.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						messages <- data.String()
+						reply("250 OK")
+					case "QUIT":
+						reply("221 Bye")
+						return
+					default:
+						reply("502 Command not implemented")
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestWebhookRetries(t *testing.T) {
+	notifier, channels := newTestNotifier(t)
+
+	var mu sync.Mutex
+	var payloads []map[string]string
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	channel, err := channels.Create(&models.NotificationChannel{
+		Name: "Slack", Type: models.ChannelWebhook, Config: map[string]string{"url": server.URL}, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	delivery, err := notifier.Deliver(context.Background(), channel, Message{Subject: "Wheeler alert: Expiring", Body: "VZ put has 3 days left"})
+	if err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	if delivery.Status != models.DeliverySent || delivery.Attempts != 2 || delivery.ChannelName != "Slack" {
+		t.Errorf("Expected a sent delivery after 2 attempts, got %+v", delivery)
+	}
+	if len(payloads) != 1 || payloads[0]["text"] != "*Wheeler alert: Expiring*\nVZ put has 3 days left" ||
+		payloads[0]["content"] != "**Wheeler alert: Expiring**\nVZ put has 3 days left" || payloads[0]["body"] != "VZ put has 3 days left" {
+		t.Errorf("Unexpected webhook payloads %v", payloads)
+	}
+
+	// A channel that keeps failing is logged as failed with the last error
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+	channel.Config["url"] = failing.URL
+	delivery, err = notifier.Deliver(context.Background(), channel, Message{Subject: "Test", Body: "Body"})
+	if err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != defaultAttempts || !strings.Contains(delivery.Error, "401") {
+		t.Errorf("Expected a failed delivery after %d attempts, got %+v", defaultAttempts, delivery)
+	}
+
+	recent, err := notifier.deliveryService.GetRecent(10)
+	if err != nil || len(recent) != 2 || recent[0].Status != models.DeliveryFailed {
+		t.Errorf("Expected the failed delivery first in the log, got %d (%v)", len(recent), err)
+	}
+}
+
+func TestNtfyWebhook(t *testing.T) {
+	var title, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(&models.NotificationChannel{
+		Name: "ntfy", Type: models.ChannelWebhook, Config: map[string]string{"url": server.URL + "/wheeler", "format": "ntfy"},
+	})
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	if err := sender.Send(context.Background(), Message{Subject: "Wheeler alert", Body: "KO at $62.00"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if title != "Wheeler alert" || body != "KO at $62.00" {
+		t.Errorf("Expected title and plain body, got %q %q", title, body)
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	addr, messages := startSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	sender, err := NewSender(&models.NotificationChannel{
+		Name: "Email", Type: models.ChannelSMTP,
+		Config: map[string]string{"host": host, "port": port, "from": "wheeler@example.com", "to": "me@example.com, spouse@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("NewSender failed: %v", err)
+	}
+	if err := sender.Send(context.Background(), Message{Subject: "Wheeler: 2 new alerts", Body: "- Expiring: VZ\n- Breakout: KO"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	select {
+	case data := <-messages:
+		for _, want := range []string{
+			"From: wheeler@example.com\r\n",
+			"To: me@example.com, spouse@example.com\r\n",
+			"Subject: Wheeler: 2 new alerts\r\n",
+			"\r\n\r\n- Expiring: VZ\r\n- Breakout: KO\r\n",
+		} {
+			if !strings.Contains(data, want) {
+				t.Errorf("Expected email to contain %q, got %q", want, data)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for email")
+	}
+}
+
+func TestSMTPSenderTimeout(t *testing.T) {
+	// A server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sender := newSMTPSender(&models.NotificationChannel{
+		Name: "Email", Type: models.ChannelSMTP,
+		Config: map[string]string{"host": host, "port": port, "from": "wheeler@example.com", "to": "me@example.com"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err = sender.Send(ctx, Message{Subject: "Wheeler", Body: "Hello"})
+	if err == nil {
+		t.Fatal("Expected Send to fail when the server never responds")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Expected Send to give up when ctx expired, took %s", elapsed)
+	}
+
+	sender.timeout = 100 * time.Millisecond
+	if err := sender.Send(context.Background(), Message{Subject: "Wheeler", Body: "Hello"}); err == nil {
+		t.Fatal("Expected Send to fail after its timeout")
+	}
+}
+
+func TestSMTPSenderHTML(t *testing.T) {
+	sender := newSMTPSender(&models.NotificationChannel{
+		Name: "Email", Type: models.ChannelSMTP,
+		Config: map[string]string{"host": "localhost", "from": "wheeler@example.com", "to": "me@example.com"},
+	})
+	html := "<p>" + strings.Repeat("Premium earned ", 20) + "</p>"
+	data := sender.buildMessage(Message{Subject: "Wheeler digest", Body: "Premium MTD: $120.00", HTML: html}, time.Now())
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to parse email: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected a multipart/alternative email, got %q", msg.Header.Get("Content-Type"))
+	}
+
+	var got []string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		content, _ := io.ReadAll(part) // NextPart decodes quoted-printable
+		got = append(got, part.Header.Get("Content-Type")+": "+string(content))
+	}
+	want := []string{
+		"text/plain; charset=UTF-8: Premium MTD: $120.00",
+		"text/html; charset=UTF-8: " + html,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected parts %q, got %q", want, got)
+	}
+	body := string(data[bytes.Index(data, []byte("\r\n\r\n")):])
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("Expected body lines of at most 76 characters, got %d: %q", len(line), line)
+		}
+	}
+}
+
+func TestBroadcastToFileSink(t *testing.T) {
+	notifier, channels := newTestNotifier(t)
+	path := filepath.Join(t.TempDir(), "alerts.log")
+
+	for _, channel := range []*models.NotificationChannel{
+		{Name: "Log", Type: models.ChannelFile, Config: map[string]string{"path": path}, Enabled: true},
+		{Name: "Server log", Type: models.ChannelFile, Enabled: true},
+		{Name: "Off", Type: models.ChannelFile, Config: map[string]string{"path": path}, Enabled: false},
+	} {
+		if _, err := channels.Create(channel); err != nil {
+			t.Fatalf("Failed to create channel %s: %v", channel.Name, err)
+		}
+	}
+	if _, err := channels.Create(&models.NotificationChannel{Name: "No URL", Type: models.ChannelWebhook}); err == nil {
+		t.Error("Expected a webhook without a url to be rejected")
+	}
+
+	msg := AlertsMessage([]*models.Alert{
+		{RuleName: "Expiring", Message: "VZ put has 3 days left"},
+		{RuleName: "Breakout", Message: "KO at $62.00 is at or above $60.00"},
+	})
+	deliveries, err := notifier.Broadcast(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected deliveries to the 2 enabled channels, got %d", len(deliveries))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read sink: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var entry fileEntry
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &entry) != nil {
+		t.Fatalf("Expected one JSON line in the sink, got %q", data)
+	}
+	if entry.Subject != "Wheeler: 2 new alerts" || entry.Body != "- Expiring: VZ put has 3 days left\n- Breakout: KO at $62.00 is at or above $60.00" {
+		t.Errorf("Unexpected sink entry %+v", entry)
+	}
+}
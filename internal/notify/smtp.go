@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// defaultSMTPPort is the mail submission port, used when a channel does not set one
+const defaultSMTPPort = "587"
+
+// smtpSender emails messages through an SMTP server. The connection is upgraded with
+// STARTTLS when the server offers it, and PLAIN auth is used when a username is set.
+type smtpSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+	timeout  time.Duration
+}
+
+func newSMTPSender(channel *models.NotificationChannel) *smtpSender {
+	port := channel.Setting("port")
+	if port == "" {
+		port = defaultSMTPPort
+	}
+	return &smtpSender{
+		addr:     net.JoinHostPort(channel.Setting("host"), port),
+		host:     channel.Setting("host"),
+		username: channel.Setting("username"),
+		password: channel.Config["password"],
+		from:     channel.Setting("from"),
+		to:       channel.Recipients(),
+		timeout:  15 * time.Second,
+	}
+}
+
+// Send delivers msg within the sender's timeout, or sooner if ctx is done first
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", s.addr, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+	// Cancelling ctx unblocks whatever read or write is in progress
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := s.send(conn, msg); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return fmt.Errorf("failed to send email via %s: %w", s.addr, err)
+	}
+	return nil
+}
+
+// send runs the SMTP exchange that smtp.SendMail would, over a connection Send has dialed
+func (s *smtpSender) send(conn net.Conn, msg Message) error {
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(s.buildMessage(msg, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage formats an email with CRLF line endings: plain text, or a
+// multipart/alternative text and HTML email when the message has an HTML rendering
+func (s *smtpSender) buildMessage(msg Message, date time.Time) []byte {
+	headers := []string{
+		"From: " + s.from,
+		"To: " + strings.Join(s.to, ", "),
+		"Subject: " + strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+	}
+	if msg.HTML == "" {
+		headers = append(headers, "Content-Type: text/plain; charset=UTF-8")
+		return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + crlf(msg.Body) + "\r\n")
+	}
+
+	// HTML lines can be longer than SMTP allows, so both parts are quoted-printable
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Body},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		// Writes to a bytes.Buffer cannot fail
+		w, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(crlf(part.content)))
+		qp.Close()
+	}
+	parts.Close()
+
+	headers = append(headers, "Content-Type: multipart/alternative; boundary="+parts.Boundary())
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body.String())
+}
+
+// crlf converts line endings to CRLF
+func crlf(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// webhookSender POSTs messages to a URL. The default json format sends a payload that
+// Slack (text), Discord (content) and generic receivers (subject, body, optional html) all understand;
+// the ntfy format posts the body as plain text with the subject in the Title header.
+type webhookSender struct {
+	url        string
+	format     string
+	httpClient *http.Client
+}
+
+func newWebhookSender(channel *models.NotificationChannel) *webhookSender {
+	return &webhookSender{
+		url:    channel.Setting("url"),
+		format: channel.Setting("format"),
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// webhookPayload is the json format's request body
+type webhookPayload struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Text    string `json:"text"`    // Slack
+	Content string `json:"content"` // Discord
+	HTML    string `json:"html,omitempty"`
+}
+
+func (s *webhookSender) Send(ctx context.Context, msg Message) error {
+	var body []byte
+	contentType := "application/json"
+	if s.format == "ntfy" {
+		body = []byte(msg.Body)
+		contentType = "text/plain; charset=utf-8"
+	} else {
+		var err error
+		body, err = json.Marshal(webhookPayload{
+			Subject: msg.Subject,
+			Body:    msg.Body,
+			Text:    "*" + msg.Subject + "*\n" + msg.Body,
+			Content: "**" + msg.Subject + "**\n" + msg.Body,
+			HTML:    msg.HTML,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.format == "ntfy" {
+		req.Header.Set("Title", msg.Subject)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package occ parses and formats OCC option symbols such as AAPL250117P00150000.
+//
+// An OCC symbol is the underlying root, a YYMMDD expiration, a P or C flag and the
+// strike multiplied by 1000 padded to eight digits. Brokers sometimes pad the root
+// to six characters with spaces and Polygon prefixes option tickers with "O:";
+// Parse accepts all of these forms.
+package occ
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolygonPrefix is prepended to OCC symbols in Polygon option tickers
+const PolygonPrefix = "O:"
+
+const (
+	maxRootLength = 6
+	dateLength    = 6
+	strikeLength  = 8
+	suffixLength  = dateLength + 1 + strikeLength
+	maxStrike     = 99999.999
+)
+
+// Contract is a single option contract identified by an OCC symbol
+type Contract struct {
+	Underlying string    `json:"underlying"`
+	Expiration time.Time `json:"expiration"`
+	Type       string    `json:"type"` // "Put" or "Call", matching options.type
+	Strike     float64   `json:"strike"`
+}
+
+// Parse parses an OCC symbol, with or without the Polygon "O:" prefix or space padding
+func Parse(symbol string) (*Contract, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(symbol)), PolygonPrefix)
+	if len(s) <= suffixLength {
+		return nil, fmt.Errorf("invalid OCC symbol %q: too short", symbol)
+	}
+
+	root := strings.TrimSpace(s[:len(s)-suffixLength])
+	suffix := s[len(s)-suffixLength:]
+	if root == "" || len(root) > maxRootLength || strings.ContainsAny(root, " \t") {
+		return nil, fmt.Errorf("invalid OCC symbol %q: bad underlying %q", symbol, root)
+	}
+
+	expiration, err := time.Parse("060102", suffix[:dateLength])
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCC symbol %q: bad expiration: %w", symbol, err)
+	}
+
+	var optionType string
+	switch suffix[dateLength] {
+	case 'P':
+		optionType = "Put"
+	case 'C':
+		optionType = "Call"
+	default:
+		return nil, fmt.Errorf("invalid OCC symbol %q: type must be P or C", symbol)
+	}
+
+	strikeDigits := suffix[dateLength+1:]
+	for _, r := range strikeDigits {
+		if r < '0' || r > '9' {
+			return nil, fmt.Errorf("invalid OCC symbol %q: bad strike %q", symbol, strikeDigits)
+		}
+	}
+	strikeThousandths, err := strconv.Atoi(strikeDigits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCC symbol %q: bad strike: %w", symbol, err)
+	}
+	if strikeThousandths == 0 {
+		return nil, fmt.Errorf("invalid OCC symbol %q: strike must be positive", symbol)
+	}
+
+	return &Contract{
+		Underlying: root,
+		Expiration: expiration,
+		Type:       optionType,
+		Strike:     float64(strikeThousandths) / 1000,
+	}, nil
+}
+
+// Format builds the OCC symbol for a contract, e.g. Format("AAPL", "Put", 2025-01-17, 150) = "AAPL250117P00150000"
+func Format(underlying, optionType string, expiration time.Time, strike float64) (string, error) {
+	root := strings.TrimSpace(strings.ToUpper(underlying))
+	if root == "" || len(root) > maxRootLength || strings.ContainsAny(root, " \t") {
+		return "", fmt.Errorf("invalid underlying %q for OCC symbol", underlying)
+	}
+
+	var flag string
+	switch optionType {
+	case "Put":
+		flag = "P"
+	case "Call":
+		flag = "C"
+	default:
+		return "", fmt.Errorf("option type must be 'Put' or 'Call', got '%s'", optionType)
+	}
+
+	if strike <= 0 || strike > maxStrike {
+		return "", fmt.Errorf("strike %.3f out of range for OCC symbol", strike)
+	}
+
+	thousandths := int64(math.Round(strike * 1000))
+	return fmt.Sprintf("%s%s%s%0*d", root, expiration.Format("060102"), flag, strikeLength, thousandths), nil
+}
+
+// String returns the OCC symbol for the contract, or an empty string if it cannot be formatted
+func (c *Contract) String() string {
+	symbol, err := Format(c.Underlying, c.Type, c.Expiration, c.Strike)
+	if err != nil {
+		return ""
+	}
+	return symbol
+}
+
+// PolygonTicker returns the Polygon option ticker (O:-prefixed) for an OCC symbol
+func PolygonTicker(occSymbol string) string {
+	s := strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(occSymbol)), PolygonPrefix)
+	return PolygonPrefix + strings.ReplaceAll(s, " ", "")
+}
@@ -0,0 +1,122 @@
+package occ
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		symbol     string
+		underlying string
+		expiration string
+		optionType string
+		strike     float64
+	}{
+		{"plain put", "AAPL250117P00150000", "AAPL", "2025-01-17", "Put", 150},
+		{"polygon prefix", "O:AAPL250117C00150000", "AAPL", "2025-01-17", "Call", 150},
+		{"space padded root", "VZ    250321C00042500", "VZ", "2025-03-21", "Call", 42.5},
+		{"fractional strike", "F250620P00011125", "F", "2025-06-20", "Put", 11.125},
+		{"six character root", "GOOGL1251219C01250000", "GOOGL1", "2025-12-19", "Call", 1250},
+		{"lower case", "o:spy251031p00500000", "SPY", "2025-10-31", "Put", 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.symbol)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.symbol, err)
+			}
+			if c.Underlying != tt.underlying {
+				t.Errorf("Expected underlying %s, got %s", tt.underlying, c.Underlying)
+			}
+			if got := c.Expiration.Format("2006-01-02"); got != tt.expiration {
+				t.Errorf("Expected expiration %s, got %s", tt.expiration, got)
+			}
+			if c.Type != tt.optionType {
+				t.Errorf("Expected type %s, got %s", tt.optionType, c.Type)
+			}
+			if c.Strike != tt.strike {
+				t.Errorf("Expected strike %.3f, got %.3f", tt.strike, c.Strike)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"AAPL",
+		"250117P00150000",
+		"AAPL251317P00150000",
+		"AAPL250117X00150000",
+		"AAPL250117P0015000A",
+		"AAPL250117P00000000",
+		"TOOLONG250117P00150000",
+	}
+
+	for _, symbol := range invalid {
+		if _, err := Parse(symbol); err == nil {
+			t.Errorf("Expected Parse(%q) to fail", symbol)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	expiration := time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)
+
+	symbol, err := Format("aapl", "Put", expiration, 150)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if symbol != "AAPL250117P00150000" {
+		t.Errorf("Expected AAPL250117P00150000, got %s", symbol)
+	}
+
+	// Floating point strikes must round to the nearest tenth of a cent
+	symbol, err = Format("VZ", "Call", expiration, 42.49999999)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if symbol != "VZ250117C00042500" {
+		t.Errorf("Expected VZ250117C00042500, got %s", symbol)
+	}
+
+	if _, err := Format("AAPL", "Straddle", expiration, 150); err == nil {
+		t.Error("Expected invalid option type to fail")
+	}
+	if _, err := Format("AAPL", "Put", expiration, 0); err == nil {
+		t.Error("Expected zero strike to fail")
+	}
+	if _, err := Format("TOOLONG", "Put", expiration, 150); err == nil {
+		t.Error("Expected long underlying to fail")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, symbol := range []string{"AAPL250117P00150000", "KO260116C00062500", "T251219P00027000"} {
+		c, err := Parse(symbol)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", symbol, err)
+		}
+		if got := c.String(); got != symbol {
+			t.Errorf("Round trip of %s produced %s", symbol, got)
+		}
+	}
+}
+
+func TestPolygonTicker(t *testing.T) {
+	tests := map[string]string{
+		"AAPL250117P00150000":   "O:AAPL250117P00150000",
+		"O:AAPL250117P00150000": "O:AAPL250117P00150000",
+		"VZ    250321C00042500": "O:VZ250321C00042500",
+		" o:vz250321c00042500 ": "O:VZ250321C00042500",
+	}
+
+	for input, expected := range tests {
+		if got := PolygonTicker(input); got != expected {
+			t.Errorf("PolygonTicker(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
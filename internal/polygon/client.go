@@ -2,21 +2,26 @@ package polygon
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
+	"stonks/internal/occ"
+	"strconv"
 	"time"
 )
 
-// Client represents a Polygon.io API client
+// Client represents a Polygon.io API client. Requests are paced by a rate
+// limiter shared across clients and, when a cache is set, served from it while fresh.
 type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	limiter    *RateLimiter
+	cache      Cache
 }
 
-// NewClient creates a new Polygon.io API client
+// NewClient creates a new Polygon.io API client using the shared rate limiter
 func NewClient(apiKey string) *Client {
 	return &Client{
 		apiKey:  apiKey,
@@ -24,9 +29,16 @@ func NewClient(apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		limiter: sharedLimiter,
 	}
 }
 
+// WithCache returns the client after setting the cache responses are stored in
+func (c *Client) WithCache(cache Cache) *Client {
+	c.cache = cache
+	return c
+}
+
 // StockQuote represents a stock quote response from Polygon.io
 type StockQuote struct {
 	Status string `json:"status"`
@@ -91,19 +103,30 @@ type Branding struct {
 }
 
 type OptionSnapshot struct {
-	Status  string `json:"status"`
-	Results struct {
-		BreakEvenPrice   float64 `json:"break_even_price"`
-		Day              DayData `json:"day"`
-		Details          OptionDetails `json:"details"`
-		Greeks           Greeks `json:"greeks"`
-		ImpliedVolatility float64 `json:"implied_volatility"`
-		LastQuote        Quote `json:"last_quote"`
-		LastTrade        Trade `json:"last_trade"`
-		OpenInterest     float64 `json:"open_interest"`
-		UnderlyingAsset  UnderlyingAsset `json:"underlying_asset"`
-	} `json:"results"`
-	RequestID string `json:"request_id"`
+	Status    string               `json:"status"`
+	Results   OptionSnapshotResult `json:"results"`
+	RequestID string               `json:"request_id"`
+}
+
+// OptionSnapshotResult is the market data for one option contract
+type OptionSnapshotResult struct {
+	BreakEvenPrice    float64         `json:"break_even_price"`
+	Day               DayData         `json:"day"`
+	Details           OptionDetails   `json:"details"`
+	Greeks            Greeks          `json:"greeks"`
+	ImpliedVolatility float64         `json:"implied_volatility"`
+	LastQuote         Quote           `json:"last_quote"`
+	LastTrade         Trade           `json:"last_trade"`
+	OpenInterest      float64         `json:"open_interest"`
+	UnderlyingAsset   UnderlyingAsset `json:"underlying_asset"`
+}
+
+// OptionChain is one page of contract snapshots for an underlying
+type OptionChain struct {
+	Status    string                 `json:"status"`
+	Results   []OptionSnapshotResult `json:"results"`
+	NextURL   string                 `json:"next_url"`
+	RequestID string                 `json:"request_id"`
 }
 
 type DayData struct {
@@ -177,271 +200,264 @@ type DividendData struct {
 	RequestID string `json:"request_id"`
 }
 
+// EarningsData is a page of scheduled earnings announcements from the Benzinga partner feed
+type EarningsData struct {
+	Status  string `json:"status"`
+	Results []struct {
+		Ticker     string `json:"ticker"`
+		Date       string `json:"date"`
+		Time       string `json:"time"`
+		DateStatus string `json:"date_status"`
+	} `json:"results"`
+	NextURL   string `json:"next_url"`
+	RequestID string `json:"request_id"`
+}
+
 // GetLastQuote fetches the last quote for a stock symbol
 func (c *Client) GetLastQuote(ctx context.Context, symbol string) (*StockQuote, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("polygon API key not configured")
-	}
-
 	endpoint := fmt.Sprintf("/v2/last/nbbo/%s", url.PathEscape(symbol))
-	url := fmt.Sprintf("%s%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, fmt.Errorf("unauthorized: invalid or missing Polygon API key (status 401)")
-		} else if resp.StatusCode == http.StatusForbidden {
-			return nil, fmt.Errorf("forbidden: API key may not have access to this endpoint (status 403)")
-		}
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
 
 	var quote StockQuote
-	if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if quote.Status != "OK" {
-		return nil, fmt.Errorf("API returned status: %s", quote.Status)
+	err := c.getJSON(ctx, endpoint, nil, lastQuoteTTL, &quote, func() error {
+		if quote.Status != "OK" {
+			return fmt.Errorf("API returned status: %s", quote.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return &quote, nil
 }
 
 // GetPreviousClose gets the previous trading day's close price for a symbol
 func (c *Client) GetPreviousClose(ctx context.Context, symbol string) (*StockQuote, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("polygon API key not configured")
-	}
-
 	endpoint := fmt.Sprintf("/v2/aggs/ticker/%s/prev", url.PathEscape(symbol))
-	url := fmt.Sprintf("%s%s?adjusted=true&apikey=%s", c.baseURL, endpoint, c.apiKey)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, fmt.Errorf("unauthorized: invalid or missing Polygon API key (status 401)")
-		} else if resp.StatusCode == http.StatusForbidden {
-			return nil, fmt.Errorf("forbidden: API key may not have access to this endpoint (status 403)")
-		}
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
+	params := url.Values{"adjusted": {"true"}}
 
 	var result struct {
-		Status string `json:"status"`
-		Results []struct {
-			Symbol        string  `json:"T"`
-			Volume        float64   `json:"v"`
-			VolumeWeighted float64 `json:"vw"`
-			Open          float64 `json:"o"`
-			Close         float64 `json:"c"`
-			High          float64 `json:"h"`
-			Low           float64 `json:"l"`
-			Timestamp     int64   `json:"t"`
-			Transactions  int     `json:"n"`
-		} `json:"results"`
-		RequestID string `json:"request_id"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if result.Status != "OK" || len(result.Results) == 0 {
-		return nil, fmt.Errorf("API returned status: %s or no results", result.Status)
+		Status    string         `json:"status"`
+		Results   []AggregateBar `json:"results"`
+		Ticker    string         `json:"ticker"`
+		RequestID string         `json:"request_id"`
+	}
+	err := c.getJSON(ctx, endpoint, params, previousCloseTTL, &result, func() error {
+		if result.Status != "OK" || len(result.Results) == 0 {
+			return fmt.Errorf("API returned status: %s or no results", result.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to StockQuote format
+	bar := result.Results[0]
 	quote := &StockQuote{
-		Status: result.Status,
+		Status:    result.Status,
 		RequestID: result.RequestID,
 	}
-	quote.Results.Symbol = result.Results[0].Symbol
-	quote.Results.Price = result.Results[0].Close
-	quote.Results.High = result.Results[0].High
-	quote.Results.Low = result.Results[0].Low
-	quote.Results.Open = result.Results[0].Open
-	quote.Results.Volume = result.Results[0].Volume
-	quote.Results.Timestamp = result.Results[0].Timestamp
+	quote.Results.Symbol = bar.Symbol
+	quote.Results.Price = bar.Close
+	quote.Results.High = bar.High
+	quote.Results.Low = bar.Low
+	quote.Results.Open = bar.Open
+	quote.Results.Volume = bar.Volume
+	quote.Results.Timestamp = bar.Timestamp
 
 	return quote, nil
 }
 
-// GetTickerDetails fetches detailed information about a ticker
-func (c *Client) GetTickerDetails(ctx context.Context, symbol string) (*TickerDetails, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("polygon API key not configured")
-	}
+// AggregateBar is one bar from the aggregates endpoint
+type AggregateBar struct {
+	Symbol         string  `json:"T"`
+	Volume         float64 `json:"v"`
+	VolumeWeighted float64 `json:"vw"`
+	Open           float64 `json:"o"`
+	Close          float64 `json:"c"`
+	High           float64 `json:"h"`
+	Low            float64 `json:"l"`
+	Timestamp      int64   `json:"t"`
+	Transactions   int     `json:"n"`
+}
 
-	endpoint := fmt.Sprintf("/v3/reference/tickers/%s", url.PathEscape(symbol))
-	url := fmt.Sprintf("%s%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
+// GetDailyAggregates gets daily bars for a symbol between from and to inclusive
+func (c *Client) GetDailyAggregates(ctx context.Context, symbol string, from, to time.Time) ([]AggregateBar, error) {
+	endpoint := fmt.Sprintf("/v2/aggs/ticker/%s/range/1/day/%s/%s", url.PathEscape(symbol),
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	params := url.Values{"adjusted": {"true"}, "sort": {"asc"}, "limit": {"50000"}}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Completed history does not change; a range reaching today gains a bar each day
+	ttl := historicalBarsTTL
+	if !to.Before(time.Now().AddDate(0, 0, -1)) {
+		ttl = previousCloseTTL
 	}
 
-	resp, err := c.httpClient.Do(req)
+	var result struct {
+		Status    string         `json:"status"`
+		Results   []AggregateBar `json:"results"`
+		RequestID string         `json:"request_id"`
+	}
+	err := c.getJSON(ctx, endpoint, params, ttl, &result, func() error {
+		// Delayed plans report DELAYED rather than OK but still return bars
+		if result.Status != "OK" && result.Status != "DELAYED" {
+			return fmt.Errorf("API returned status: %s", result.Status)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
+	return result.Results, nil
+}
 
-	var details TickerDetails
-	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+// GetTickerDetails fetches detailed information about a ticker
+func (c *Client) GetTickerDetails(ctx context.Context, symbol string) (*TickerDetails, error) {
+	endpoint := fmt.Sprintf("/v3/reference/tickers/%s", url.PathEscape(symbol))
 
-	if details.Status != "OK" {
-		return nil, fmt.Errorf("API returned status: %s", details.Status)
+	var details TickerDetails
+	err := c.getJSON(ctx, endpoint, nil, tickerDetailsTTL, &details, func() error {
+		if details.Status != "OK" {
+			return fmt.Errorf("API returned status: %s", details.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return &details, nil
 }
 
 // GetDividends fetches dividend information for a symbol
 func (c *Client) GetDividends(ctx context.Context, symbol string, limit int) (*DividendData, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("polygon API key not configured")
-	}
-
 	if limit <= 0 {
 		limit = 10
 	}
 
-	endpoint := fmt.Sprintf("/v3/reference/dividends")
 	params := url.Values{}
 	params.Set("ticker", symbol)
-	params.Set("limit", fmt.Sprintf("%d", limit))
-	params.Set("apikey", c.apiKey)
-
-	url := fmt.Sprintf("%s%s?%s", c.baseURL, endpoint, params.Encode())
+	params.Set("limit", strconv.Itoa(limit))
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	var dividends DividendData
+	err := c.getJSON(ctx, "/v3/reference/dividends", params, dividendsTTL, &dividends, func() error {
+		if dividends.Status != "OK" {
+			return fmt.Errorf("API returned status: %s", dividends.Status)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return &dividends, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// GetEarnings fetches a symbol's earnings announcements on or after from, soonest first
+func (c *Client) GetEarnings(ctx context.Context, symbol string, from time.Time) (*EarningsData, error) {
+	params := url.Values{}
+	params.Set("ticker", symbol)
+	params.Set("date.gte", from.Format("2006-01-02"))
+	params.Set("sort", "date.asc")
+	params.Set("limit", "10")
+
+	var earnings EarningsData
+	err := c.getJSON(ctx, "/benzinga/v1/earnings", params, earningsTTL, &earnings, func() error {
+		if earnings.Status != "OK" {
+			return fmt.Errorf("API returned status: %s", earnings.Status)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
-
-	var dividends DividendData
-	if err := json.NewDecoder(resp.Body).Decode(&dividends); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, err
 	}
-
-	if dividends.Status != "OK" {
-		return nil, fmt.Errorf("API returned status: %s", dividends.Status)
-	}
-
-	return &dividends, nil
+	return &earnings, nil
 }
 
-// IsValidAPIKey tests if the API key is valid by making a simple request
+// IsValidAPIKey tests if the API key is valid by making a simple request. It is never cached.
 func (c *Client) IsValidAPIKey(ctx context.Context) error {
-	if c.apiKey == "" {
-		return fmt.Errorf("polygon API key not configured")
-	}
-
 	// Test with a simple request to get market status
-	endpoint := "/v1/marketstatus/now"
-	url := fmt.Sprintf("%s%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	status, _, err := c.do(ctx, "/v1/marketstatus/now", nil)
 	if err != nil {
-		return fmt.Errorf("failed to create test request: %w", err)
+		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to execute test request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusUnauthorized {
+	if status == http.StatusUnauthorized {
 		return fmt.Errorf("invalid or expired Polygon.io API key")
 	}
-	
-	if resp.StatusCode == http.StatusForbidden {
+
+	if status == http.StatusForbidden {
 		return fmt.Errorf("API key does not have permission to access Polygon.io endpoints")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API test request failed with status %d", resp.StatusCode)
+	if status != http.StatusOK {
+		return fmt.Errorf("API test request failed with status %d", status)
 	}
 
 	return nil
 }
 
 func (c *Client) GetOptionSnapshot(ctx context.Context, underlyingAsset, optionContract string) (*OptionSnapshot, error) {
-	if c.apiKey == "" {
-		return nil, fmt.Errorf("polygon API key not configured")
-	}
+	// Accept bare OCC symbols as well as Polygon "O:" tickers
+	optionContract = occ.PolygonTicker(optionContract)
 
-	endpoint := fmt.Sprintf("/v3/snapshot/options/%s/%s", 
-		url.PathEscape(underlyingAsset), 
+	endpoint := fmt.Sprintf("/v3/snapshot/options/%s/%s",
+		url.PathEscape(underlyingAsset),
 		url.PathEscape(optionContract))
-	url := fmt.Sprintf("%s%s?apikey=%s", c.baseURL, endpoint, c.apiKey)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
+	var snapshot OptionSnapshot
+	err := c.getJSON(ctx, endpoint, nil, optionSnapshotTTL, &snapshot, func() error {
+		if snapshot.Status != "OK" {
+			return fmt.Errorf("API returned status: %s", snapshot.Status)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &snapshot, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, fmt.Errorf("unauthorized: invalid or missing Polygon API key (status 401)")
-		} else if resp.StatusCode == http.StatusForbidden {
-			return nil, fmt.Errorf("forbidden: API key may not have access to this endpoint (status 403)")
+// maxChainPages caps how many pages of contracts GetOptionChain follows
+const maxChainPages = 8
+
+// GetOptionChain fetches snapshots of every contract on an underlying matching params
+// (contract_type, expiration_date.gte, strike_price.lte and so on), following pagination
+func (c *Client) GetOptionChain(ctx context.Context, underlyingAsset string, params url.Values) ([]OptionSnapshotResult, error) {
+	endpoint := fmt.Sprintf("/v3/snapshot/options/%s", url.PathEscape(underlyingAsset))
+
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Set("limit", "250")
+
+	var results []OptionSnapshotResult
+	for page := 0; page < maxChainPages; page++ {
+		var chain OptionChain
+		err := c.getJSON(ctx, endpoint, query, optionSnapshotTTL, &chain, func() error {
+			if chain.Status != "OK" {
+				return fmt.Errorf("API returned status: %s", chain.Status)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
+		results = append(results, chain.Results...)
 
-	var snapshot OptionSnapshot
-	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if snapshot.Status != "OK" {
-		return nil, fmt.Errorf("API returned status: %s", snapshot.Status)
+		if chain.NextURL == "" {
+			return results, nil
+		}
+		next, err := url.Parse(chain.NextURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse next page URL: %w", err)
+		}
+		// The next page is addressed by its cursor; the key is added back by do
+		query = next.Query()
+		query.Del("apiKey")
+		query.Del("apikey")
 	}
 
-	return &snapshot, nil
-}
\ No newline at end of file
+	log.Printf("[POLYGON] Option chain for %s stopped after %d pages", underlyingAsset, maxChainPages)
+	return results, nil
+}
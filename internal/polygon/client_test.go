@@ -0,0 +1,176 @@
+package polygon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryCache is an in-process Cache for tests
+type memoryCache map[string][]byte
+
+func (m memoryCache) Get(key string) ([]byte, bool) {
+	body, ok := m[key]
+	return body, ok
+}
+
+func (m memoryCache) Set(key string, body []byte, ttl time.Duration) error {
+	m[key] = body
+	return nil
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-key")
+	client.baseURL = server.URL
+	client.limiter = NewRateLimiter(PaidRequestsPerMinute)
+	return client
+}
+
+func TestClientRetriesAfterRateLimit(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","ticker":"VZ","results":[{"T":"VZ","c":41.37,"t":1741122000000}]}`))
+	})
+
+	quote, err := client.GetPreviousClose(context.Background(), "VZ")
+	if err != nil {
+		t.Fatalf("GetPreviousClose failed: %v", err)
+	}
+	if quote.Results.Price != 41.37 || calls != 3 {
+		t.Errorf("Expected 41.37 after 3 calls, got %v after %d", quote.Results.Price, calls)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := client.GetTickerDetails(context.Background(), "VZ"); err == nil {
+		t.Fatal("Expected an error while rate limited")
+	}
+	if calls != maxRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", maxRetries+1, calls)
+	}
+}
+
+func TestClientServesFreshResponsesFromCache(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("apikey") != "test-key" {
+			t.Errorf("Expected the API key on the request")
+		}
+		if r.URL.Query().Get("ticker") == "BAD" {
+			w.Write([]byte(`{"status":"ERROR"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"OK","results":[{"ticker":"VZ","cash_amount":0.6775}]}`))
+	})
+	cache := memoryCache{}
+	client.WithCache(cache)
+
+	for i := 0; i < 3; i++ {
+		dividends, err := client.GetDividends(context.Background(), "VZ", 5)
+		if err != nil || len(dividends.Results) != 1 {
+			t.Fatalf("GetDividends failed: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected repeated requests to be served from cache, got %d calls", calls)
+	}
+	for key := range cache {
+		if key != "/v3/reference/dividends?limit=5&ticker=VZ" {
+			t.Errorf("Unexpected cache key %q; keys must not include the API key", key)
+		}
+	}
+
+	// Responses that fail validation are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetDividends(context.Background(), "BAD", 5); err == nil {
+			t.Errorf("Expected an error for status ERROR")
+		}
+	}
+	if calls != 3 || len(cache) != 1 {
+		t.Errorf("Expected rejected responses to be refetched and not cached, got %d calls and %d entries", calls, len(cache))
+	}
+}
+
+func TestClientFollowsOptionChainPages(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/snapshot/options/VZ" || r.URL.Query().Get("apikey") != "test-key" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			if r.URL.Query().Get("contract_type") != "put" {
+				t.Errorf("Expected contract_type filter on the first page, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"status":"OK","next_url":"` + server.URL + `/v3/snapshot/options/VZ?cursor=abc","results":[` +
+				`{"details":{"ticker":"O:VZ250321P00040000","contract_type":"put","strike_price":40},"underlying_asset":{"ticker":"VZ","price":41.37}}]}`))
+			return
+		}
+		w.Write([]byte(`{"status":"OK","results":[` +
+			`{"details":{"ticker":"O:VZ250321P00039000","contract_type":"put","strike_price":39},"underlying_asset":{"ticker":"VZ","price":41.37}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-key")
+	client.baseURL = server.URL
+	client.limiter = NewRateLimiter(PaidRequestsPerMinute)
+
+	results, err := client.GetOptionChain(context.Background(), "VZ", url.Values{"contract_type": {"put"}})
+	if err != nil {
+		t.Fatalf("GetOptionChain failed: %v", err)
+	}
+	if len(results) != 2 || results[1].Details.StrikePrice != 39 {
+		t.Fatalf("Expected both pages of contracts, got %+v", results)
+	}
+
+	snapshot := convertOptionSnapshot(&results[0])
+	if snapshot.OCCSymbol != "VZ250321P00040000" || snapshot.UnderlyingPrice != 41.37 {
+		t.Errorf("Unexpected converted snapshot %+v", snapshot)
+	}
+}
+
+func TestClientGetEarnings(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/benzinga/v1/earnings" || r.URL.Query().Get("ticker") != "VZ" || r.URL.Query().Get("date.gte") != "2025-01-06" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"status":"OK","results":[{"ticker":"VZ","date":"2025-01-24","time":"07:00:00","date_status":"confirmed"},` +
+			`{"ticker":"VZ","date":"2025-04-22","time":"16:05:00","date_status":"projected"}]}`))
+	})
+
+	earnings, err := client.GetEarnings(context.Background(), "VZ", time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetEarnings failed: %v", err)
+	}
+	if len(earnings.Results) != 2 || earnings.Results[0].Date != "2025-01-24" {
+		t.Fatalf("Unexpected earnings %+v", earnings)
+	}
+	for clock, want := range map[string]string{"07:00:00": "bmo", "16:05:00": "amc", "12:00:00": "", "": ""} {
+		if got := earningsTiming(clock); got != want {
+			t.Errorf("earningsTiming(%q) = %q, want %q", clock, got, want)
+		}
+	}
+}
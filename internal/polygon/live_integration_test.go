@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"stonks/internal/database"
+	"stonks/internal/marketdata"
 	"stonks/internal/models"
 	"strings"
 	"testing"
@@ -50,7 +51,7 @@ func TestLivePolygonIntegration(t *testing.T) {
 	// Create Polygon client and service
 	client := NewClient(apiKey)
 	symbolService := models.NewSymbolService(dbWrapper.DB)
-	service := NewService(symbolService, settingService)
+	service := marketdata.NewService(symbolService, settingService, models.NewPriceHistoryService(dbWrapper.DB), NewProvider(settingService, models.NewAPICacheService(dbWrapper.DB)))
 
 	// Run tests with generous timeout for API calls
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -0,0 +1,361 @@
+package polygon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"stonks/internal/marketdata"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings controlling request pacing
+const (
+	SettingPlan              = "POLYGON_PLAN"
+	SettingRequestsPerMinute = "POLYGON_REQUESTS_PER_MINUTE"
+)
+
+// Provider implements marketdata.MarketDataProvider using Polygon.io
+type Provider struct {
+	settingService *models.SettingService
+	cache          Cache
+}
+
+// NewProvider creates a Polygon provider reading the API key and plan from settings
+// and caching responses in cache
+func NewProvider(settingService *models.SettingService, cache Cache) *Provider {
+	return &Provider{settingService: settingService, cache: cache}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return marketdata.ProviderPolygon
+}
+
+// RequestsPerMinute returns the request limit from POLYGON_REQUESTS_PER_MINUTE, or the plan's default
+func (p *Provider) RequestsPerMinute() int {
+	if value := strings.TrimSpace(p.settingService.GetValue(SettingRequestsPerMinute)); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("[POLYGON] Ignoring invalid %s %q", SettingRequestsPerMinute, value)
+	}
+	return PlanRequestsPerMinute(p.settingService.GetValue(SettingPlan))
+}
+
+// getClient returns a Polygon client with the current API key
+func (p *Provider) getClient() (*Client, error) {
+	apiKey := p.settingService.GetValue("POLYGON_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("Polygon API key not configured - please set your API key in Settings")
+	}
+
+	log.Printf("[POLYGON] Using API key: %s", maskKey(apiKey))
+
+	// Settings may have changed since the last request
+	sharedLimiter.SetLimit(p.RequestsPerMinute())
+
+	client := NewClient(apiKey)
+	if p.cache != nil {
+		client.WithCache(p.cache)
+	}
+	return client, nil
+}
+
+// GetQuote returns the last quote for a symbol
+func (p *Provider) GetQuote(ctx context.Context, symbol string) (*marketdata.Quote, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	quote, err := client.GetLastQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return convertQuote(symbol, quote), nil
+}
+
+// GetPreviousClose returns the previous trading day's bar for a symbol
+func (p *Provider) GetPreviousClose(ctx context.Context, symbol string) (*marketdata.Quote, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	quote, err := client.GetPreviousClose(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	return convertQuote(symbol, quote), nil
+}
+
+// GetDailyBars returns daily aggregates for a symbol
+func (p *Provider) GetDailyBars(ctx context.Context, symbol string, from, to time.Time) ([]*marketdata.Quote, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	bars, err := client.GetDailyAggregates(ctx, symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]*marketdata.Quote, 0, len(bars))
+	for i, bar := range bars {
+		q := &marketdata.Quote{
+			Symbol: strings.ToUpper(symbol),
+			Price:  bar.Close,
+			Open:   bar.Open,
+			High:   bar.High,
+			Low:    bar.Low,
+			Volume: bar.Volume,
+			// Daily bars are stamped at midnight Eastern; keep the trading date
+			Date: time.UnixMilli(bar.Timestamp).In(marketLocation()),
+		}
+		if i > 0 {
+			q.PreviousClose = bars[i-1].Close
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// GetDividends returns recent dividends for a symbol
+func (p *Provider) GetDividends(ctx context.Context, symbol string, limit int) ([]*marketdata.Dividend, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	dividends, err := client.GetDividends(ctx, symbol, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*marketdata.Dividend
+	for _, div := range dividends.Results {
+		result = append(result, &marketdata.Dividend{
+			Symbol:          div.Ticker,
+			CashAmount:      div.CashAmount,
+			DeclarationDate: div.DeclarationDate,
+			ExDividendDate:  div.ExDividendDate,
+			PayDate:         div.PayDate,
+			RecordDate:      div.RecordDate,
+			DividendType:    div.DividendType,
+			Frequency:       div.Frequency,
+		})
+	}
+	return result, nil
+}
+
+// GetTickerDetails returns reference data for a symbol
+func (p *Provider) GetTickerDetails(ctx context.Context, symbol string) (*marketdata.TickerDetails, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	details, err := client.GetTickerDetails(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return &marketdata.TickerDetails{
+		Symbol:      details.Results.Symbol,
+		Name:        details.Results.Name,
+		Market:      details.Results.Market,
+		Type:        details.Results.Type,
+		Active:      details.Results.Active,
+		Currency:    details.Results.CurrencyName,
+		Description: details.Results.Description,
+		Homepage:    details.Results.HomepageURL,
+		MarketCap:   details.Results.MarketCap,
+		Employees:   details.Results.TotalEmployees,
+	}, nil
+}
+
+// GetEarnings returns a symbol's upcoming earnings announcements
+func (p *Provider) GetEarnings(ctx context.Context, symbol string, from time.Time) ([]*marketdata.Earnings, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	earnings, err := client.GetEarnings(ctx, symbol, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*marketdata.Earnings
+	for _, e := range earnings.Results {
+		result = append(result, &marketdata.Earnings{
+			Symbol:    e.Ticker,
+			Date:      e.Date,
+			Timing:    earningsTiming(e.Time),
+			Confirmed: strings.EqualFold(e.DateStatus, "confirmed"),
+		})
+	}
+	return result, nil
+}
+
+// GetOptionSnapshot returns the snapshot for an option contract
+func (p *Provider) GetOptionSnapshot(ctx context.Context, underlying, occSymbol string) (*marketdata.OptionSnapshot, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	snapshot, err := client.GetOptionSnapshot(ctx, underlying, occSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertOptionSnapshot(&snapshot.Results), nil
+}
+
+// GetOptionChain returns snapshots of the underlying's contracts matching filter
+func (p *Provider) GetOptionChain(ctx context.Context, underlying string, filter marketdata.ChainFilter) ([]*marketdata.OptionSnapshot, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Polygon client: %w", err)
+	}
+
+	params := url.Values{}
+	if filter.ContractType != "" {
+		params.Set("contract_type", strings.ToLower(filter.ContractType))
+	}
+	if !filter.ExpirationFrom.IsZero() {
+		params.Set("expiration_date.gte", filter.ExpirationFrom.Format("2006-01-02"))
+	}
+	if !filter.ExpirationTo.IsZero() {
+		params.Set("expiration_date.lte", filter.ExpirationTo.Format("2006-01-02"))
+	}
+	if filter.StrikeMin > 0 {
+		params.Set("strike_price.gte", strconv.FormatFloat(filter.StrikeMin, 'f', -1, 64))
+	}
+	if filter.StrikeMax > 0 {
+		params.Set("strike_price.lte", strconv.FormatFloat(filter.StrikeMax, 'f', -1, 64))
+	}
+
+	results, err := client.GetOptionChain(ctx, underlying, params)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]*marketdata.OptionSnapshot, 0, len(results))
+	for i := range results {
+		chain = append(chain, convertOptionSnapshot(&results[i]))
+	}
+	return chain, nil
+}
+
+// TestConnection validates the API key and connection
+func (p *Provider) TestConnection(ctx context.Context) error {
+	client, err := p.getClient()
+	if err != nil {
+		return err
+	}
+
+	return client.IsValidAPIKey(ctx)
+}
+
+// GetAPIKeyStatus returns information about the current API key configuration
+func (p *Provider) GetAPIKeyStatus() *APIKeyStatus {
+	apiKey := p.settingService.GetValue("POLYGON_API_KEY")
+
+	status := &APIKeyStatus{
+		Configured:        apiKey != "",
+		RequestsPerMinute: p.RequestsPerMinute(),
+	}
+	if status.Configured {
+		status.Masked = maskKey(apiKey)
+	}
+
+	return status
+}
+
+// APIKeyStatus represents the status of the Polygon API key
+type APIKeyStatus struct {
+	Configured bool   `json:"configured"`
+	Masked     string `json:"masked"`
+	Valid      bool   `json:"valid"`
+	Error      string `json:"error,omitempty"`
+	// RequestsPerMinute is the pace requests are limited to
+	RequestsPerMinute int `json:"requests_per_minute"`
+}
+
+// marketLocation returns US Eastern time, falling back to a fixed offset without tzdata
+func marketLocation() *time.Location {
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		return loc
+	}
+	return time.FixedZone("EST", -5*60*60)
+}
+
+// earningsTiming maps an announcement time in Eastern time to bmo or amc, or blank during the session
+func earningsTiming(clock string) string {
+	if len(clock) < 5 {
+		return ""
+	}
+	switch hhmm := clock[:5]; {
+	case hhmm < "09:30":
+		return "bmo"
+	case hhmm >= "16:00":
+		return "amc"
+	}
+	return ""
+}
+
+// maskKey shows the first and last 3 characters of an API key
+func maskKey(apiKey string) string {
+	if len(apiKey) > 6 {
+		return apiKey[:3] + "..." + apiKey[len(apiKey)-3:]
+	}
+	return strings.Repeat("*", len(apiKey))
+}
+
+func convertQuote(symbol string, quote *StockQuote) *marketdata.Quote {
+	q := &marketdata.Quote{
+		Symbol:        quote.Results.Symbol,
+		Price:         quote.Results.Price,
+		Open:          quote.Results.Open,
+		High:          quote.Results.High,
+		Low:           quote.Results.Low,
+		PreviousClose: quote.Results.PreviousClose,
+		Volume:        quote.Results.Volume,
+	}
+	if q.Symbol == "" {
+		q.Symbol = strings.ToUpper(symbol)
+	}
+	if quote.Results.Timestamp > 0 {
+		q.Date = time.UnixMilli(quote.Results.Timestamp).In(marketLocation())
+	}
+	return q
+}
+
+// convertOptionSnapshot maps a Polygon contract snapshot to the provider-neutral form
+func convertOptionSnapshot(r *OptionSnapshotResult) *marketdata.OptionSnapshot {
+	return &marketdata.OptionSnapshot{
+		OCCSymbol:         strings.TrimPrefix(r.Details.Ticker, "O:"),
+		Underlying:        r.UnderlyingAsset.Ticker,
+		ContractType:      r.Details.ContractType,
+		Strike:            r.Details.StrikePrice,
+		Expiration:        r.Details.ExpirationDate,
+		Bid:               r.LastQuote.Bid,
+		Ask:               r.LastQuote.Ask,
+		Last:              r.LastTrade.Price,
+		ImpliedVolatility: r.ImpliedVolatility,
+		Delta:             r.Greeks.Delta,
+		Gamma:             r.Greeks.Gamma,
+		Theta:             r.Greeks.Theta,
+		Vega:              r.Greeks.Vega,
+		OpenInterest:      r.OpenInterest,
+		Volume:            r.Day.Volume,
+		UnderlyingPrice:   r.UnderlyingAsset.Price,
+	}
+}
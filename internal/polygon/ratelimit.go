@@ -0,0 +1,127 @@
+package polygon
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Polygon plans accepted by the POLYGON_PLAN setting
+const (
+	PlanFree = "free"
+	PlanPaid = "paid"
+)
+
+// Request limits for each plan. The free plan allows 5 calls per minute; paid plans
+// are unlimited but Polygon asks clients to stay under about 100 requests per second.
+const (
+	FreeRequestsPerMinute = 5
+	PaidRequestsPerMinute = 6000
+)
+
+// RateLimiter is a token bucket shared by every client using the same API key
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// sharedLimiter paces every Polygon client in the process, so page loads,
+// bulk refreshes and scheduled jobs draw from the same quota
+var sharedLimiter = NewRateLimiter(FreeRequestsPerMinute)
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute requests
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimit(requestsPerMinute)
+	l.tokens = l.burst
+	return l
+}
+
+// PlanRequestsPerMinute returns the request limit for a plan name, defaulting to the free plan
+func PlanRequestsPerMinute(plan string) int {
+	if strings.EqualFold(strings.TrimSpace(plan), PlanPaid) {
+		return PaidRequestsPerMinute
+	}
+	return FreeRequestsPerMinute
+}
+
+// SetLimit changes the allowed requests per minute, keeping tokens already earned
+func (l *RateLimiter) SetLimit(requestsPerMinute int) {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = FreeRequestsPerMinute
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = float64(requestsPerMinute) / 60
+	// A burst of one spaces free-plan calls 12 seconds apart so no 60-second
+	// window ever sees more than 5; faster plans may burst for a second
+	l.burst = 1
+	if l.rate > 1 {
+		l.burst = l.rate
+	}
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// RequestsPerMinute returns the current limit
+func (l *RateLimiter) RequestsPerMinute() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.rate*60 + 0.5)
+}
+
+// Wait blocks until a request may be made or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff empties the bucket so every caller waits after the server rejects a request
+func (l *RateLimiter) Backoff() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens = 0
+}
+
+// reserve takes a token if one is available, otherwise returns how long until one is
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
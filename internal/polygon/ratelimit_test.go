@@ -0,0 +1,48 @@
+package polygon
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	// 1200 per minute is one every 50ms with a burst of 20
+	limiter := NewRateLimiter(1200)
+
+	start := time.Now()
+	for i := 0; i < 22; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	// The burst is free; the last two requests wait about 50ms each
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected about 100ms for 22 requests, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	limiter := NewRateLimiter(FreeRequestsPerMinute)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("First request should not wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected the free plan to block the second request past the deadline")
+	}
+}
+
+func TestPlanRequestsPerMinute(t *testing.T) {
+	if got := PlanRequestsPerMinute("free"); got != FreeRequestsPerMinute {
+		t.Errorf("free plan = %d", got)
+	}
+	if got := PlanRequestsPerMinute(" Paid "); got != PaidRequestsPerMinute {
+		t.Errorf("paid plan = %d", got)
+	}
+	if got := PlanRequestsPerMinute(""); got != FreeRequestsPerMinute {
+		t.Errorf("unset plan = %d, expected the free limit", got)
+	}
+}
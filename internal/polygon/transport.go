@@ -0,0 +1,152 @@
+package polygon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Cache stores raw API responses between requests
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, body []byte, ttl time.Duration) error
+}
+
+// How long each kind of response stays fresh in the cache
+const (
+	lastQuoteTTL      = time.Minute
+	previousCloseTTL  = time.Hour
+	historicalBarsTTL = 7 * 24 * time.Hour
+	tickerDetailsTTL  = 7 * 24 * time.Hour
+	dividendsTTL      = 24 * time.Hour
+	earningsTTL       = 24 * time.Hour
+	optionSnapshotTTL = 5 * time.Minute
+)
+
+// maxRetries is how many times a request rejected with 429 is retried
+const maxRetries = 4
+
+// retryBaseDelay is the first backoff after a 429 without Retry-After; it doubles on each retry
+var retryBaseDelay = 2 * time.Second
+
+// do sends a rate-limited GET request, retrying with backoff while Polygon answers 429
+func (c *Client) do(ctx context.Context, endpoint string, params url.Values) (int, []byte, error) {
+	if c.apiKey == "" {
+		return 0, nil, fmt.Errorf("polygon API key not configured")
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Set("apikey", c.apiKey)
+	requestURL := fmt.Sprintf("%s%s?%s", c.baseURL, endpoint, query.Encode())
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return 0, nil, fmt.Errorf("waiting for rate limiter: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+			return resp.StatusCode, body, nil
+		}
+
+		delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
+		log.Printf("[POLYGON] Rate limited on %s, retry %d of %d in %s", endpoint, attempt+1, maxRetries, delay)
+		c.limiter.Backoff()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, nil, fmt.Errorf("waiting to retry after rate limit: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// getJSON decodes the response for endpoint into out. A fresh cached response is used
+// when there is one; new responses are cached only once check accepts them.
+func (c *Client) getJSON(ctx context.Context, endpoint string, params url.Values, ttl time.Duration, out interface{}, check func() error) error {
+	key := endpoint
+	if len(params) > 0 {
+		key += "?" + params.Encode()
+	}
+
+	useCache := c.cache != nil && ttl > 0
+	if useCache {
+		if body, ok := c.cache.Get(key); ok {
+			if json.Unmarshal(body, out) == nil && check() == nil {
+				log.Printf("[POLYGON] Cache hit for %s", key)
+				return nil
+			}
+		}
+	}
+
+	status, body, err := c.do(ctx, endpoint, params)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return statusError(status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := check(); err != nil {
+		return err
+	}
+
+	if useCache {
+		if err := c.cache.Set(key, body, ttl); err != nil {
+			log.Printf("[POLYGON] Warning: %v", err)
+		}
+	}
+	return nil
+}
+
+// statusError describes a non-200 response
+func statusError(status int) error {
+	switch status {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("unauthorized: invalid or missing Polygon API key (status 401)")
+	case http.StatusForbidden:
+		return fmt.Errorf("forbidden: API key may not have access to this endpoint (status 403)")
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited by Polygon after %d retries (status 429)", maxRetries)
+	}
+	return fmt.Errorf("API request failed with status %d", status)
+}
+
+// retryDelay honours a Retry-After header in seconds, otherwise backs off exponentially
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	delay := retryBaseDelay << uint(attempt)
+	if delay > time.Minute {
+		delay = time.Minute
+	}
+	return delay
+}
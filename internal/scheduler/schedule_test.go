@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	eastern := time.FixedZone("EST", -5*60*60)
+	// Friday 2025-03-07 16:20
+	from := time.Date(2025, 3, 7, 16, 20, 0, 0, eastern)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"15 16 * * 1-5", time.Date(2025, 3, 10, 16, 15, 0, 0, eastern)},
+		{"30 16 * * *", time.Date(2025, 3, 7, 16, 30, 0, 0, eastern)},
+		{"0 2 * * *", time.Date(2025, 3, 8, 2, 0, 0, 0, eastern)},
+		{"*/15 * * * *", time.Date(2025, 3, 7, 16, 30, 0, 0, eastern)},
+		{"5/20 9-10 * * *", time.Date(2025, 3, 8, 9, 5, 0, 0, eastern)},
+		{"0 0 1 * *", time.Date(2025, 4, 1, 0, 0, 0, 0, eastern)},
+		{"0 12 15 * 0", time.Date(2025, 3, 9, 12, 0, 0, 0, eastern)},
+		{"0 9 * * 7", time.Date(2025, 3, 9, 9, 0, 0, 0, eastern)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, eastern)},
+		{"@daily", time.Date(2025, 3, 8, 0, 0, 0, 0, eastern)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidSchedules(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "0 0 * 13 *", "0 0 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, expected an error", spec)
+		}
+	}
+}
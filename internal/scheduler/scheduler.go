@@ -0,0 +1,431 @@
+// Package scheduler runs Wheeler's background jobs, such as the after-close price
+// refresh and the nightly backup, on cron schedules stored in the config table.
+//
+// Schedules are re-read from config on every tick, so editing them on the config
+// page takes effect without a restart. Every run is recorded in job_runs.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stonks/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigTimezone is the config key naming the time zone schedules are evaluated in
+const ConfigTimezone = "schedule_timezone"
+
+// DefaultTimezone is used when schedule_timezone is unset; jobs are planned around market hours
+const DefaultTimezone = "America/New_York"
+
+// tickInterval is how often due jobs are checked
+const tickInterval = 30 * time.Second
+
+// JobFunc does a job's work and returns a short summary for the run history
+type JobFunc func(ctx context.Context) (string, error)
+
+// Job is a unit of background work run on the cron schedule stored under ConfigKey
+type Job struct {
+	Name        string
+	Title       string
+	Description string
+	ConfigKey   string
+	Run         JobFunc
+}
+
+// JobStatus describes a job for the jobs page
+type JobStatus struct {
+	Job           *Job
+	Schedule      string
+	ScheduleError string
+	NextRun       *time.Time
+	Running       bool
+	LastRun       *models.JobRun
+	LastError     *models.JobRun
+	Recent        []*models.JobRun
+}
+
+// Enabled reports whether the job has a valid schedule
+func (js *JobStatus) Enabled() bool {
+	return js.Schedule != "" && js.ScheduleError == ""
+}
+
+// Scheduler runs registered jobs when their schedules come due
+type Scheduler struct {
+	mu      sync.Mutex
+	config  *models.ConfigService
+	runs    *models.JobRunService
+	guard   sync.Locker
+	jobs    []*Job
+	planned map[string]plannedRun
+	running map[string]bool
+	cancels map[string]context.CancelFunc
+	halted  bool // Set by CancelRunning until ResumeRunning
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// plannedRun caches a job's parsed schedule until its config changes
+type plannedRun struct {
+	key      string
+	schedule *Schedule
+	next     time.Time
+}
+
+// New creates a scheduler reading schedules from config and recording runs in runs
+func New(config *models.ConfigService, runs *models.JobRunService) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		config:  config,
+		runs:    runs,
+		planned: make(map[string]plannedRun),
+		running: make(map[string]bool),
+		cancels: make(map[string]context.CancelFunc),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Register adds a job to the scheduler
+func (s *Scheduler) Register(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// SetServices points the scheduler at another database after the current database is switched
+func (s *Scheduler) SetServices(config *models.ConfigService, runs *models.JobRunService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+	s.runs = runs
+	s.planned = make(map[string]plannedRun)
+}
+
+// SetGuard makes the scheduler hold guard while it reads schedules and runs jobs, so the
+// services cannot be switched underneath it. It must be called before Start. Call
+// CancelRunning before taking the other side of the guard so a long job gives it up early,
+// and ResumeRunning once it is held.
+func (s *Scheduler) SetGuard(guard sync.Locker) {
+	s.guard = guard
+}
+
+// lock takes the guard, if any, and returns the function that releases it
+func (s *Scheduler) lock() func() {
+	if s.guard == nil {
+		return func() {}
+	}
+	s.guard.Lock()
+	return s.guard.Unlock
+}
+
+// Start checks for due jobs in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	runs := s.runs
+	s.mu.Unlock()
+
+	if n, err := runs.FailInterrupted(); err != nil {
+		log.Printf("[SCHEDULER] Warning: %v", err)
+	} else if n > 0 {
+		log.Printf("[SCHEDULER] Marked %d interrupted job runs as failed", n)
+	}
+
+	log.Printf("[SCHEDULER] Starting with %d jobs", len(s.jobs))
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		s.tick(time.Now())
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.tick(now)
+			}
+		}
+	}()
+}
+
+// Stop cancels running jobs and waits for them to finish
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	log.Printf("[SCHEDULER] Stopped")
+}
+
+// CancelRunning cancels the context of every running job without stopping the scheduler.
+// Jobs that get the guard before ResumeRunning start cancelled too, so none can slip in
+// ahead of the other side of the guard. Jobs still waiting for it run normally once they
+// get it after ResumeRunning.
+func (s *Scheduler) CancelRunning() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.halted = true
+	for name, cancel := range s.cancels {
+		log.Printf("[SCHEDULER] Cancelling running job %s", name)
+		cancel()
+	}
+}
+
+// ResumeRunning lets jobs run again after CancelRunning
+func (s *Scheduler) ResumeRunning() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.halted = false
+}
+
+// RunNow starts a job immediately in the background
+func (s *Scheduler) RunNow(name string) error {
+	job := s.job(name)
+	if job == nil {
+		return fmt.Errorf("unknown job %q", name)
+	}
+	if !s.launch(job, models.JobTriggerManual) {
+		return fmt.Errorf("job %s is already running", name)
+	}
+	return nil
+}
+
+// Status returns every job with its schedule, next run and recent history
+func (s *Scheduler) Status(historyLimit int) ([]*JobStatus, error) {
+	s.mu.Lock()
+	jobs := append([]*Job(nil), s.jobs...)
+	config, runs := s.config, s.runs
+	s.mu.Unlock()
+
+	loc := s.location(config)
+	var statuses []*JobStatus
+	for _, job := range jobs {
+		status := &JobStatus{
+			Job:      job,
+			Schedule: strings.TrimSpace(config.GetValue(job.ConfigKey, "")),
+		}
+		if status.Schedule != "" {
+			if schedule, err := Parse(status.Schedule); err != nil {
+				status.ScheduleError = err.Error()
+			} else {
+				next := schedule.Next(time.Now().In(loc))
+				status.NextRun = &next
+			}
+		}
+
+		s.mu.Lock()
+		status.Running = s.running[job.Name]
+		s.mu.Unlock()
+
+		recent, err := runs.GetRecent(job.Name, historyLimit)
+		if err != nil {
+			return nil, err
+		}
+		status.Recent = recent
+		if len(recent) > 0 {
+			status.LastRun = recent[0]
+		}
+		if status.LastError, err = runs.GetLastError(job.Name); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// tick launches every job whose next run is at or before now
+func (s *Scheduler) tick(now time.Time) {
+	defer s.lock()()
+
+	s.mu.Lock()
+	jobs := append([]*Job(nil), s.jobs...)
+	config := s.config
+	s.mu.Unlock()
+
+	loc := s.location(config)
+	now = now.In(loc)
+
+	for _, job := range jobs {
+		spec := strings.TrimSpace(config.GetValue(job.ConfigKey, ""))
+		key := spec + "|" + loc.String()
+
+		s.mu.Lock()
+		plan, ok := s.planned[job.Name]
+		s.mu.Unlock()
+
+		if spec == "" {
+			s.forget(job.Name)
+			continue
+		}
+		if !ok || plan.key != key {
+			schedule, err := Parse(spec)
+			if err != nil {
+				log.Printf("[SCHEDULER] Job %s has an invalid schedule: %v", job.Name, err)
+				s.remember(job.Name, plannedRun{key: key})
+				continue
+			}
+			plan = plannedRun{key: key, schedule: schedule, next: schedule.Next(now)}
+			s.remember(job.Name, plan)
+			log.Printf("[SCHEDULER] Job %s scheduled %q, next run %s", job.Name, spec, plan.next.Format("2006-01-02 15:04 MST"))
+			continue
+		}
+		if plan.schedule == nil || now.Before(plan.next) {
+			continue
+		}
+
+		plan.next = plan.schedule.Next(now)
+		s.remember(job.Name, plan)
+		if !s.launch(job, models.JobTriggerSchedule) {
+			log.Printf("[SCHEDULER] Skipping %s, previous run still in progress", job.Name)
+		}
+	}
+}
+
+// launch runs a job in the background unless it is already running
+func (s *Scheduler) launch(job *Job, triggeredBy string) bool {
+	if !s.claim(job) {
+		return false
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(job)
+		s.execute(job, triggeredBy)
+	}()
+	return true
+}
+
+// Run runs a job to completion as a manual run and returns its result; the command line
+// uses it to run jobs without starting the scheduler
+func (s *Scheduler) Run(name string) (string, error) {
+	job := s.job(name)
+	if job == nil {
+		return "", fmt.Errorf("unknown job %q", name)
+	}
+	if !s.claim(job) {
+		return "", fmt.Errorf("job %s is already running", name)
+	}
+	defer s.release(job)
+	return s.execute(job, models.JobTriggerManual)
+}
+
+// claim marks a job as running, or returns false if it is already running
+func (s *Scheduler) claim(job *Job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[job.Name] {
+		return false
+	}
+	s.running[job.Name] = true
+	return true
+}
+
+func (s *Scheduler) release(job *Job) {
+	s.mu.Lock()
+	delete(s.running, job.Name)
+	s.mu.Unlock()
+}
+
+// execute runs a job and records the outcome, recovering from panics so one job cannot stop the scheduler
+func (s *Scheduler) execute(job *Job, triggeredBy string) (string, error) {
+	defer s.lock()()
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.mu.Lock()
+	runs := s.runs
+	s.cancels[job.Name] = cancel
+	if s.halted {
+		// CancelRunning ran between taking the guard and registering the cancel
+		cancel()
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, job.Name)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	log.Printf("[SCHEDULER] Running job %s (%s)", job.Name, triggeredBy)
+	started := time.Now()
+
+	runID, err := runs.Start(job.Name, triggeredBy)
+	if err != nil {
+		log.Printf("[SCHEDULER] Warning: %v", err)
+	}
+
+	var message string
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		message, err = job.Run(ctx)
+	}()
+
+	if err != nil {
+		log.Printf("[SCHEDULER] Job %s failed after %s: %v", job.Name, time.Since(started).Round(time.Millisecond), err)
+	} else {
+		log.Printf("[SCHEDULER] Job %s finished in %s: %s", job.Name, time.Since(started).Round(time.Millisecond), message)
+	}
+
+	if runID > 0 {
+		if finishErr := runs.Finish(runID, message, err); finishErr != nil {
+			log.Printf("[SCHEDULER] Warning: %v", finishErr)
+		}
+	}
+	return message, err
+}
+
+// location returns the configured schedule time zone
+func (s *Scheduler) location(config *models.ConfigService) *time.Location {
+	name := config.GetValue(ConfigTimezone, DefaultTimezone)
+	if name == "" {
+		name = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// HasJob reports whether a job with the given name is registered
+func (s *Scheduler) HasJob(name string) bool {
+	return s.job(name) != nil
+}
+
+func (s *Scheduler) job(name string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) remember(name string, plan plannedRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.planned[name] = plan
+}
+
+func (s *Scheduler) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.planned, name)
+}
@@ -0,0 +1,278 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"stonks/internal/database"
+	"stonks/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupSchedulerTestDB(t *testing.T) (*models.ConfigService, *models.JobRunService) {
+	testDB, err := database.NewDB(filepath.Join(t.TempDir(), "scheduler_test.db"))
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	t.Cleanup(func() { testDB.Close() })
+	return models.NewConfigService(testDB.DB), models.NewJobRunService(testDB.DB)
+}
+
+func TestSchedulerRunsDueJobsAndRecordsHistory(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	ran := make(chan struct{}, 10)
+	s.Register(&Job{Name: "nightly", ConfigKey: "schedule_backup", Run: func(ctx context.Context) (string, error) {
+		ran <- struct{}{}
+		return "done", nil
+	}})
+	s.Register(&Job{Name: "broken", ConfigKey: "schedule_metrics_snapshot", Run: func(ctx context.Context) (string, error) {
+		ran <- struct{}{}
+		return "", errors.New("no metrics today")
+	}})
+	if _, err := config.Set("schedule_backup", "0 2 * * *"); err != nil {
+		t.Fatalf("Failed to set schedule: %v", err)
+	}
+	if _, err := config.Set("schedule_metrics_snapshot", "30 16 * * *"); err != nil {
+		t.Fatalf("Failed to set schedule: %v", err)
+	}
+
+	loc, _ := time.LoadLocation(DefaultTimezone)
+	// The first tick only plans the next run
+	s.tick(time.Date(2025, 3, 7, 1, 59, 0, 0, loc))
+	s.tick(time.Date(2025, 3, 7, 2, 0, 30, 0, loc))
+	waitForRuns(t, ran, 1)
+
+	s.tick(time.Date(2025, 3, 7, 16, 30, 0, 0, loc))
+	waitForRuns(t, ran, 1)
+	s.wg.Wait()
+
+	nightly, err := runs.GetRecent("nightly", 10)
+	if err != nil || len(nightly) != 1 {
+		t.Fatalf("Expected 1 nightly run, got %d (%v)", len(nightly), err)
+	}
+	if nightly[0].Status != models.JobStatusSuccess || nightly[0].Message != "done" || nightly[0].TriggeredBy != models.JobTriggerSchedule {
+		t.Errorf("Unexpected nightly run: %+v", nightly[0])
+	}
+
+	statuses, err := s.Status(10)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	broken := statuses[1]
+	if broken.LastError == nil || broken.LastError.Error != "no metrics today" {
+		t.Errorf("Expected the failed run's error to be kept, got %+v", broken.LastError)
+	}
+	if !broken.Enabled() || broken.NextRun == nil {
+		t.Errorf("Expected the broken job to stay scheduled")
+	}
+
+	// Clearing the schedule disables the job
+	if _, err := config.Set("schedule_backup", ""); err != nil {
+		t.Fatalf("Failed to clear schedule: %v", err)
+	}
+	s.tick(time.Date(2025, 3, 8, 2, 0, 0, 0, loc))
+	s.wg.Wait()
+	select {
+	case <-ran:
+		t.Errorf("Disabled job should not run")
+	default:
+	}
+}
+
+func TestSchedulerRunNow(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	release := make(chan struct{})
+	s.Register(&Job{Name: "slow", ConfigKey: "schedule_backup", Run: func(ctx context.Context) (string, error) {
+		<-release
+		return "finished", nil
+	}})
+
+	if err := s.RunNow("missing"); err == nil {
+		t.Errorf("Expected an error for an unknown job")
+	}
+	if err := s.RunNow("slow"); err != nil {
+		t.Fatalf("RunNow failed: %v", err)
+	}
+	if err := s.RunNow("slow"); err == nil {
+		t.Errorf("Expected an error while the job is already running")
+	}
+	close(release)
+	s.wg.Wait()
+
+	recent, err := runs.GetRecent("slow", 10)
+	if err != nil || len(recent) != 1 || recent[0].TriggeredBy != models.JobTriggerManual || recent[0].Status != models.JobStatusSuccess {
+		t.Errorf("Expected one successful manual run, got %v (%v)", recent, err)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	s.Register(&Job{Name: "ok", ConfigKey: "schedule_backup", Run: func(ctx context.Context) (string, error) {
+		return "done", nil
+	}})
+	s.Register(&Job{Name: "broken", ConfigKey: "schedule_backup", Run: func(ctx context.Context) (string, error) {
+		return "", errors.New("provider down")
+	}})
+
+	if message, err := s.Run("ok"); err != nil || message != "done" {
+		t.Errorf("Expected Run to return the job's message, got %q (%v)", message, err)
+	}
+	if _, err := s.Run("broken"); err == nil || err.Error() != "provider down" {
+		t.Errorf("Expected Run to return the job's error, got %v", err)
+	}
+	if _, err := s.Run("missing"); err == nil {
+		t.Errorf("Expected an error for an unknown job")
+	}
+
+	recent, err := runs.GetRecent("broken", 10)
+	if err != nil || len(recent) != 1 || recent[0].Status != models.JobStatusFailed {
+		t.Errorf("Expected the failed run to be recorded, got %v (%v)", recent, err)
+	}
+}
+
+func TestSchedulerGuard(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	var guard sync.RWMutex
+	s.SetGuard(guard.RLocker())
+	ran := make(chan struct{}, 1)
+	s.Register(&Job{Name: "backup", ConfigKey: "schedule_backup", Run: func(ctx context.Context) (string, error) {
+		ran <- struct{}{}
+		return "done", nil
+	}})
+
+	// A job started while the database is being switched waits for the switch, then
+	// records its run with the new services
+	guard.Lock()
+	if err := s.RunNow("backup"); err != nil {
+		t.Fatalf("RunNow failed: %v", err)
+	}
+	select {
+	case <-ran:
+		t.Fatalf("Expected the job to wait for the guard")
+	case <-time.After(100 * time.Millisecond):
+	}
+	newConfig, newRuns := setupSchedulerTestDB(t)
+	s.SetServices(newConfig, newRuns)
+	guard.Unlock()
+	waitForRuns(t, ran, 1)
+
+	if err := waitForFinishedRun(newRuns, "backup"); err != nil {
+		t.Fatalf("Expected the run in the new database: %v", err)
+	}
+	if recent, _ := runs.GetRecent("backup", 10); len(recent) != 0 {
+		t.Errorf("Expected no runs in the old database, got %v", recent)
+	}
+}
+
+func TestSchedulerCancelRunning(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	var guard sync.RWMutex
+	s.SetGuard(guard.RLocker())
+	started := make(chan struct{}, 1)
+	s.Register(&Job{Name: "price_refresh", ConfigKey: "schedule_price_refresh", Run: func(ctx context.Context) (string, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return "", ctx.Err()
+	}})
+
+	if err := s.RunNow("price_refresh"); err != nil {
+		t.Fatalf("RunNow failed: %v", err)
+	}
+	waitForRuns(t, started, 1)
+
+	// Switching databases cancels the long job rather than waiting for it, and the
+	// scheduler keeps running jobs afterwards
+	s.CancelRunning()
+	locked := make(chan struct{})
+	go func() {
+		guard.Lock()
+		s.ResumeRunning()
+		close(locked)
+		guard.Unlock()
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the cancelled job to release the guard")
+	}
+
+	// The cancelled run is released just after the guard
+	err := s.RunNow("price_refresh")
+	for deadline := time.Now().Add(5 * time.Second); err != nil && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		err = s.RunNow("price_refresh")
+	}
+	if err != nil {
+		t.Fatalf("RunNow after cancelling failed: %v", err)
+	}
+	waitForRuns(t, started, 1)
+}
+
+func TestSchedulerCancelRunningHaltsNewRuns(t *testing.T) {
+	config, runs := setupSchedulerTestDB(t)
+	s := New(config, runs)
+	defer s.Stop()
+
+	cancelled := make(chan bool, 1)
+	s.Register(&Job{Name: "price_refresh", ConfigKey: "schedule_price_refresh", Run: func(ctx context.Context) (string, error) {
+		cancelled <- ctx.Err() != nil
+		return "", nil
+	}})
+
+	// A job that gets the guard after CancelRunning, before the switch takes the other
+	// side of it, starts cancelled
+	s.CancelRunning()
+	if _, err := s.Run("price_refresh"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !<-cancelled {
+		t.Errorf("Expected a job started after CancelRunning to be cancelled")
+	}
+
+	s.ResumeRunning()
+	if _, err := s.Run("price_refresh"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if <-cancelled {
+		t.Errorf("Expected a job started after ResumeRunning to run normally")
+	}
+}
+
+// waitForFinishedRun polls until the job's latest run has finished
+func waitForFinishedRun(runs *models.JobRunService, name string) error {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if recent, err := runs.GetRecent(name, 1); err == nil && len(recent) == 1 && recent[0].Status == models.JobStatusSuccess {
+			return nil
+		}
+	}
+	return errors.New("timed out waiting for the run to finish")
+}
+
+func waitForRuns(t *testing.T, ran chan struct{}, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ran:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for job run %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSnooze is how long an alert is snoozed when no duration is given
+const defaultSnooze = 24 * time.Hour
+
+// alertRuleTypeOptions describes each rule type for the rule form
+var alertRuleTypeOptions = []AlertRuleTypeOption{
+	{Value: models.AlertRuleDTEBelow, Label: "Days to expiration at or below", ThresholdLabel: "Days"},
+	{Value: models.AlertRuleITMPercent, Label: "Option in the money by", ThresholdLabel: "% ITM"},
+	{Value: models.AlertRuleProfitCaptured, Label: "Profit captured at or above", ThresholdLabel: "% of max"},
+	{Value: models.AlertRulePriceAbove, Label: "Symbol price at or above", ThresholdLabel: "Price"},
+	{Value: models.AlertRulePriceBelow, Label: "Symbol price at or below", ThresholdLabel: "Price"},
+	{Value: models.AlertRuleExDividendCall, Label: "Ex-dividend with a short call within", ThresholdLabel: "Days"},
+	{Value: models.AlertRuleEarlyAssignment, Label: "Short call at risk of early assignment, ex-dividend within", ThresholdLabel: "Days"},
+}
+
+// alertsPageHandler serves the alert inbox and rule list
+func (s *Server) alertsPageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[ALERTS] Handling alerts page request")
+
+	symbols, err := s.symbolService.GetDistinctSymbols()
+	if err != nil {
+		log.Printf("[ALERTS] Error getting symbols: %v", err)
+		symbols = []string{}
+	}
+
+	alerts, err := s.alertService.GetAll()
+	if err != nil {
+		log.Printf("[ALERTS] Error getting alerts: %v", err)
+	}
+	rules, err := s.alertRuleService.GetAll()
+	if err != nil {
+		log.Printf("[ALERTS] Error getting alert rules: %v", err)
+	}
+
+	data := AlertsPageData{
+		AllSymbols: symbols,
+		CurrentDB:  s.getCurrentDatabaseName(),
+		ActivePage: "alerts",
+		Alerts:     alerts,
+		Rules:      rules,
+		RuleTypes:  alertRuleTypeOptions,
+	}
+
+	s.renderTemplate(w, "alerts.html", data)
+}
+
+// alertsAPIHandler serves the alert inbox and rules:
+// GET /api/alerts?state=new,snoozed lists alerts,
+// POST /api/alerts/{id}/acknowledge and POST /api/alerts/{id}/snooze {"hours": 24} change an alert's state,
+// DELETE /api/alerts/{id} dismisses an alert,
+// GET and POST /api/alerts/rules list and create rules,
+// PUT and DELETE /api/alerts/rules/{id} update and delete a rule.
+func (s *Server) alertsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/alerts"), "/"), "/")
+
+	switch {
+	case parts[0] == "":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.listAlerts(w, r)
+
+	case parts[0] == "rules":
+		if len(parts) == 1 {
+			s.alertRulesHandler(w, r)
+			return
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || len(parts) > 2 {
+			http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+			return
+		}
+		s.alertRuleHandler(w, r, id)
+
+	default:
+		id, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) > 2 {
+			http.Error(w, "Invalid alert ID", http.StatusBadRequest)
+			return
+		}
+		action := ""
+		if len(parts) == 2 {
+			action = parts[1]
+		}
+		s.alertActionHandler(w, r, id, action)
+	}
+}
+
+// listAlerts returns alerts, optionally only those in the comma-separated ?state= list
+func (s *Server) listAlerts(w http.ResponseWriter, r *http.Request) {
+	var states []string
+	for _, state := range strings.Split(r.URL.Query().Get("state"), ",") {
+		state = strings.TrimSpace(strings.ToLower(state))
+		switch state {
+		case "":
+		case models.AlertStateNew, models.AlertStateAcknowledged, models.AlertStateSnoozed:
+			states = append(states, state)
+		default:
+			http.Error(w, "Invalid state "+state, http.StatusBadRequest)
+			return
+		}
+	}
+
+	alerts, err := s.alertService.GetAll(states...)
+	if err != nil {
+		log.Printf("[ALERTS API] Error getting alerts: %v", err)
+		http.Error(w, "Failed to get alerts", http.StatusInternalServerError)
+		return
+	}
+	if alerts == nil {
+		alerts = []*models.Alert{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// alertActionHandler acknowledges, snoozes or dismisses one alert
+func (s *Server) alertActionHandler(w http.ResponseWriter, r *http.Request, id int, action string) {
+	var err error
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		err = s.alertService.Delete(id)
+		if err == nil {
+			log.Printf("[ALERTS API] Dismissed alert %d", id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+	case action == "acknowledge" && r.Method == http.MethodPost:
+		err = s.alertService.Acknowledge(id)
+
+	case action == "snooze" && r.Method == http.MethodPost:
+		var req struct {
+			Hours float64 `json:"hours"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Hours < 0 {
+			http.Error(w, "Snooze hours cannot be negative", http.StatusBadRequest)
+			return
+		}
+		snooze := defaultSnooze
+		if req.Hours > 0 {
+			snooze = time.Duration(req.Hours * float64(time.Hour))
+		}
+		err = s.alertService.Snooze(id, time.Now().Add(snooze))
+
+	case action == "" || action == "acknowledge" || action == "snooze":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			http.Error(w, "Alert not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("[ALERTS API] Error updating alert %d: %v", id, err)
+		http.Error(w, "Failed to update alert", http.StatusInternalServerError)
+		return
+	}
+
+	alert, err := s.alertService.GetByID(id)
+	if err != nil {
+		http.Error(w, "Alert not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("[ALERTS API] Alert %d is now %s", id, alert.State)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
+
+// alertRulesHandler lists (GET) or creates (POST) alert rules
+func (s *Server) alertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.alertRuleService.GetAll()
+		if err != nil {
+			log.Printf("[ALERTS API] Error getting alert rules: %v", err)
+			http.Error(w, "Failed to get alert rules", http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = []*models.AlertRule{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+
+	case http.MethodPost:
+		rule, ok := decodeAlertRule(w, r)
+		if !ok {
+			return
+		}
+		created, err := s.alertRuleService.Create(rule)
+		if err != nil {
+			s.writeAlertRuleError(w, err)
+			return
+		}
+		log.Printf("[ALERTS API] Created alert rule %d: %s", created.ID, created.Describe())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// alertRuleHandler updates (PUT) or deletes (DELETE) one alert rule
+func (s *Server) alertRuleHandler(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodPut:
+		rule, ok := decodeAlertRule(w, r)
+		if !ok {
+			return
+		}
+		rule.ID = id
+		updated, err := s.alertRuleService.Update(rule)
+		if err != nil {
+			s.writeAlertRuleError(w, err)
+			return
+		}
+		log.Printf("[ALERTS API] Updated alert rule %d: %s", id, updated.Describe())
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+
+	case http.MethodDelete:
+		if err := s.alertRuleService.Delete(id); err != nil {
+			s.writeAlertRuleError(w, err)
+			return
+		}
+		log.Printf("[ALERTS API] Deleted alert rule %d", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeAlertRule reads an AlertRuleRequest, writing a 400 response if it is not valid JSON
+func decodeAlertRule(w http.ResponseWriter, r *http.Request) (*models.AlertRule, bool) {
+	var req AlertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	return &models.AlertRule{
+		Name:      req.Name,
+		Type:      req.RuleType,
+		Symbol:    req.Symbol,
+		Threshold: req.Threshold,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	}, true
+}
+
+// writeAlertRuleError maps a rule service error to a response: validation errors are
+// a 400, a missing rule a 404 and anything else a 500
+func (s *Server) writeAlertRuleError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+	case strings.HasPrefix(message, "failed to"):
+		log.Printf("[ALERTS API] Error saving alert rule: %v", err)
+		http.Error(w, "Failed to save alert rule", http.StatusInternalServerError)
+	default:
+		http.Error(w, message, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"stonks/internal/database"
+)
+
+// Templates and static assets are built into the binary so Wheeler runs from any directory
+//
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static
+var staticFS embed.FS
+
+// devDir is the source checkout assets are read from in dev mode; blank uses the embedded copies
+var devDir string
+
+// SetDevDir turns on dev mode: templates, static files and the example SQL are read from the
+// source checkout at dir on every request, so edits show up without rebuilding. It must be
+// called before the server is created.
+func SetDevDir(dir string) {
+	devDir = dir
+}
+
+// assetFS returns the named directory of internal/web, from the checkout in dev mode
+func assetFS(name string) (fs.FS, error) {
+	if devDir != "" {
+		return os.DirFS(filepath.Join(devDir, "internal", "web", name)), nil
+	}
+	if name == "templates" {
+		return fs.Sub(templateFS, name)
+	}
+	return fs.Sub(staticFS, name)
+}
+
+// staticHandler serves the CSS and JavaScript under /static/
+func staticHandler() (http.Handler, error) {
+	static, err := assetFS("static")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open static assets: %w", err)
+	}
+	return http.FileServer(http.FS(static)), nil
+}
+
+// exampleSQL returns the example wheel strategy portfolio loaded by Generate Test Data
+func exampleSQL() ([]byte, error) {
+	if devDir != "" {
+		return os.ReadFile(filepath.Join(devDir, "internal", "database", "wheel_strategy_example_clean.sql"))
+	}
+	return []byte(database.ExampleSQL), nil
+}
+
+// templateSet holds the parsed page templates; in dev mode it parses them again for each
+// page so template edits show up on reload
+type templateSet struct {
+	funcs     template.FuncMap
+	templates *template.Template
+}
+
+func loadTemplates(funcs template.FuncMap) (*templateSet, error) {
+	templates, err := parseTemplates(funcs)
+	if err != nil {
+		return nil, err
+	}
+	return &templateSet{funcs: funcs, templates: templates}, nil
+}
+
+func parseTemplates(funcs template.FuncMap) (*template.Template, error) {
+	templates, err := assetFS("templates")
+	if err != nil {
+		return nil, err
+	}
+	return template.New("").Funcs(funcs).ParseFS(templates, "*.html")
+}
+
+// ExecuteTemplate renders the named template
+func (t *templateSet) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	templates := t.templates
+	if devDir != "" {
+		var err error
+		if templates, err = parseTemplates(t.funcs); err != nil {
+			return fmt.Errorf("failed to parse templates: %w", err)
+		}
+	}
+	return templates.ExecuteTemplate(w, name, data)
+}
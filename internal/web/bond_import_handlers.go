@@ -0,0 +1,313 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"stonks/internal/cusip"
+	"stonks/internal/models"
+	"time"
+)
+
+// bondLayout describes one of the bond-position CSV layouts accepted by the bond importers.
+// Columns are matched by header name, so the order of columns and extra columns don't matter.
+type bondLayout struct {
+	name       string // used in log tags and error messages
+	importType string
+}
+
+var (
+	treasuryDirectLayout = bondLayout{name: "TREASURYDIRECT_IMPORT", importType: models.ImportTypeTreasuryDirect}
+	brokerBondLayout     = bondLayout{name: "BOND_IMPORT", importType: models.ImportTypeBondPositions}
+)
+
+// bondColumnAliases maps each field to the normalized header names used by TreasuryDirect
+// and the common brokers (lower case, letters and digits only). Earlier aliases take
+// precedence, e.g. "Par Amount" over "Amount".
+var bondColumnAliases = map[string][]string{
+	"cusip":        {"cusip", "cuspid", "cusipnumber", "symbolcusip", "symbol", "securityid"},
+	"issued":       {"issuedate", "dateissued", "issued"},
+	"purchased":    {"purchasedate", "purchased", "tradedate", "dateacquired", "acquired", "acquisitiondate", "opendate"},
+	"maturity":     {"maturitydate", "maturity"},
+	"face":         {"paramount", "parvalue", "par", "facevalue", "faceamount", "face", "quantity", "qty", "amount"},
+	"cost":         {"purchaseprice", "purchaseamount", "amountpaid", "costbasis", "costbasistotal", "totalcost", "cost"},
+	"unitCost":     {"unitcost", "averagecost", "averageunitcost", "costper100", "purchasepriceper100"},
+	"coupon":       {"interestrate", "couponrate", "coupon", "rate"},
+	"yield":        {"yield", "yieldtomaturity", "ytm", "investmentrate", "highyield"},
+	"type":         {"securitytype", "producttype", "type", "description", "securitydescription", "securityterm", "name"},
+	"currentValue": {"currentvalue", "marketvalue", "value"},
+}
+
+// HandleTreasuryDirectImportUpload imports a TreasuryDirect holdings CSV export
+func (s *Server) HandleTreasuryDirectImportUpload(w http.ResponseWriter, r *http.Request) {
+	s.handleBondImportUpload(w, r, treasuryDirectLayout)
+}
+
+// HandleBondPositionsImportUpload imports a broker bond-position CSV export
+func (s *Server) HandleBondPositionsImportUpload(w http.ResponseWriter, r *http.Request) {
+	s.handleBondImportUpload(w, r, brokerBondLayout)
+}
+
+func (s *Server) handleBondImportUpload(w http.ResponseWriter, r *http.Request, layout bondLayout) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Printf("[%s] Starting bond-position CSV import", layout.name)
+	w.Header().Set("Content-Type", "application/json")
+
+	// Parse multipart form (10MB max)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		log.Printf("[%s] Error parsing multipart form: %v", layout.name, err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "Failed to parse form data",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("csvFile")
+	if err != nil {
+		log.Printf("[%s] Error getting form file: %v", layout.name, err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "No file provided or error reading file",
+			Details: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	batch, err := s.runImport(layout.importType, fileHeader.Filename, file, func(sess *importSession, file io.Reader) (int, int, error) {
+		return s.importBondPositionsFromCSV(sess, file, layout)
+	})
+	if err != nil {
+		log.Printf("[%s] Import failed: %v", layout.name, err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "Failed to import bond positions from CSV",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[%s] Import completed in batch %d: %d imported, %d skipped", layout.name, batch.ID, batch.ImportedCount, batch.SkippedCount)
+	json.NewEncoder(w).Encode(ImportResponse{
+		Success:       true,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
+	})
+}
+
+// importBondPositionsFromCSV finds the header row, then imports each position row as a treasury.
+// Blank and summary rows are ignored; holdings already tracked and non-Treasury CUSIPs are skipped.
+func (s *Server) importBondPositionsFromCSV(sess *importSession, file io.Reader, layout bondLayout) (importedCount int, skippedCount int, err error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Broker exports mix preamble, position and total rows
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	headerRow, columns := findBondHeader(records)
+	if headerRow < 0 {
+		return 0, 0, fmt.Errorf("could not find a header row with CUSIP and maturity date columns")
+	}
+	log.Printf("[%s] Found header on row %d with columns %v", layout.name, headerRow+1, columns)
+
+	for i := headerRow + 1; i < len(records); i++ {
+		rowNum := i + 1
+		row := csvRow{record: records[i], columns: columns}
+
+		// Only rows with both a CUSIP and a maturity are positions; the rest are totals or equities
+		if row.get("cusip") == "" || row.get("maturity") == "" {
+			continue
+		}
+
+		treasury, err := parseBondPosition(row)
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: %w", rowNum, err)
+		}
+
+		if !cusip.IsTreasury(treasury.CUSPID) {
+			skippedCount++
+			log.Printf("[%s] Row %d: Skipped non-Treasury CUSIP %s", layout.name, rowNum, treasury.CUSPID)
+			continue
+		}
+
+		if existing, err := sess.treasuryService.GetByCUSPID(treasury.CUSPID); err == nil && existing != nil {
+			skippedCount++
+			log.Printf("[%s] Row %d: Skipped %s, already tracked (purchased %s)",
+				layout.name, rowNum, treasury.CUSPID, existing.Purchased.Format("2006-01-02"))
+			continue
+		}
+
+		if _, err := sess.treasuryService.CreateImported(treasury, sess.batchID); err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: %w", rowNum, err)
+		}
+		importedCount++
+		log.Printf("[%s] Row %d: Created %s %s %.2f maturing %s", layout.name, rowNum,
+			treasury.GetSecurityType(), treasury.CUSPID, treasury.Amount, treasury.Maturity.Format("2006-01-02"))
+	}
+
+	if importedCount == 0 && skippedCount == 0 {
+		return 0, 0, fmt.Errorf("CSV file must contain position rows below the header")
+	}
+	return importedCount, skippedCount, nil
+}
+
+// findBondHeader returns the index of the first row naming both a CUSIP and a maturity column,
+// along with the column index of each recognized field
+func findBondHeader(records [][]string) (int, map[string]int) {
+	for i, record := range records {
+		columns := matchCSVColumns(record, bondColumnAliases)
+		_, hasCUSIP := columns["cusip"]
+		_, hasMaturity := columns["maturity"]
+		if hasCUSIP && hasMaturity {
+			return i, columns
+		}
+	}
+	return -1, nil
+}
+
+// parseBondPosition converts one position row into a treasury ready to insert
+func parseBondPosition(row csvRow) (*models.Treasury, error) {
+	id := cusip.Normalize(row.get("cusip"))
+	if err := cusip.Validate(id); err != nil {
+		return nil, err
+	}
+
+	maturity, err := parseCSVDate(row.get("maturity"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maturity date: %w", err)
+	}
+
+	var issued *time.Time
+	if value := row.get("issued"); value != "" {
+		date, err := parseCSVDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue date: %w", err)
+		}
+		issued = &date
+	}
+
+	var purchased time.Time
+	switch {
+	case row.get("purchased") != "":
+		purchased, err = parseCSVDate(row.get("purchased"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid purchase date: %w", err)
+		}
+	case issued != nil:
+		// TreasuryDirect purchases settle on the issue date, so it stands in for a missing purchase date
+		purchased = *issued
+	default:
+		return nil, fmt.Errorf("purchase or issue date is required for %s", id)
+	}
+	if !maturity.After(purchased) {
+		return nil, fmt.Errorf("maturity %s must be after purchase date %s",
+			maturity.Format("2006-01-02"), purchased.Format("2006-01-02"))
+	}
+
+	face, err := parseCSVNumber(row.get("face"))
+	if err != nil || face <= 0 {
+		return nil, fmt.Errorf("face amount must be a positive number, got '%s'", row.get("face"))
+	}
+
+	// Purchase price is either a total cost or a unit cost per 100 of face value. A bare
+	// "Price" column is the current market quote in broker exports, so it is never used.
+	var buyPrice float64
+	if value := row.get("cost"); value != "" {
+		buyPrice, err = parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid purchase price '%s'", value)
+		}
+	} else if value := row.get("unitCost"); value != "" {
+		per100, err := parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unit cost '%s'", value)
+		}
+		buyPrice = math.Round(face*per100) / 100
+	}
+	if buyPrice <= 0 {
+		return nil, fmt.Errorf("purchase price is required for %s (purchase price, cost basis or unit cost column)", id)
+	}
+
+	var coupon *float64
+	if value := row.get("coupon"); value != "" {
+		rate, err := parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interest rate '%s'", value)
+		}
+		coupon = &rate
+	}
+
+	var securityType *string
+	if t, ok := models.ParseTreasurySecurityType(row.get("type")); ok {
+		securityType = &t
+	} else if issued != nil && maturity.Sub(*issued) <= 366*24*time.Hour && (coupon == nil || *coupon == 0) {
+		// Bills are the only Treasuries issued for a year or less. The remaining term says
+		// nothing about this, since notes and bonds bought near maturity have short ones too.
+		t := models.TreasuryTypeBill
+		securityType = &t
+	}
+	if securityType != nil && *securityType == models.TreasuryTypeBill && coupon == nil {
+		zero := 0.0
+		coupon = &zero
+	}
+
+	yield := 0.0
+	if value := row.get("yield"); value != "" {
+		yield, err = parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid yield '%s'", value)
+		}
+	} else {
+		yield = approximateYieldToMaturity(face, buyPrice, coupon, purchased, maturity)
+	}
+
+	var currentValue *float64
+	if value := row.get("currentValue"); value != "" {
+		if v, err := parseCSVNumber(value); err == nil {
+			currentValue = &v
+		}
+	}
+
+	return &models.Treasury{
+		CUSPID:       id,
+		Purchased:    purchased,
+		Maturity:     maturity,
+		Amount:       face,
+		Yield:        yield,
+		BuyPrice:     buyPrice,
+		CurrentValue: currentValue,
+		CouponRate:   coupon,
+		SecurityType: securityType,
+		IssueDate:    issued,
+	}, nil
+}
+
+// approximateYieldToMaturity estimates the annual yield in percent from the discount and
+// coupon when the file doesn't report one
+func approximateYieldToMaturity(face, price float64, coupon *float64, purchased, maturity time.Time) float64 {
+	years := maturity.Sub(purchased).Hours() / 24 / 365
+	if years <= 0 || price <= 0 {
+		return 0
+	}
+	annualCoupon := 0.0
+	if coupon != nil {
+		annualCoupon = face * *coupon / 100
+	}
+	ytm := (annualCoupon + (face-price)/years) / ((face + price) / 2) * 100
+	return math.Round(ytm*1000) / 1000
+}
@@ -0,0 +1,189 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"stonks/internal/ical"
+	"strings"
+	"time"
+)
+
+// Calendar feed event types, used in the ?type= filter
+const (
+	calendarExpiration = "expiration" // open option expirations
+	calendarMaturity   = "maturity"   // treasury maturities
+	calendarExDividend = "ex_dividend"
+	calendarDividend   = "dividend" // recorded dividend payments
+)
+
+var calendarEventTypes = []string{calendarExpiration, calendarMaturity, calendarExDividend, calendarDividend}
+
+// calendarFeedHandler serves an iCalendar feed of option expirations, treasury maturities,
+// ex-dividend dates and dividend payments: GET /calendar.ics?type=expiration,ex_dividend&symbol=VZ,KO.
+// Both filters take comma-separated lists and default to everything; a symbol filter
+// leaves out treasuries, which have no symbol.
+func (s *Server) calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	types, err := parseCalendarTypes(r.URL.Query().Get("type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	symbols := make(map[string]bool)
+	for _, symbol := range strings.Split(r.URL.Query().Get("symbol"), ",") {
+		if symbol = strings.TrimSpace(strings.ToUpper(symbol)); symbol != "" {
+			symbols[symbol] = true
+		}
+	}
+
+	events, err := s.calendarEvents(types, symbols)
+	if err != nil {
+		log.Printf("[CALENDAR] Error building calendar feed: %v", err)
+		http.Error(w, "Failed to build calendar", http.StatusInternalServerError)
+		return
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+
+	log.Printf("[CALENDAR] Serving %d events", len(events))
+	cal := &ical.Calendar{Name: "Wheeler", Events: events}
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="wheeler.ics"`)
+	if err := cal.Write(w, time.Now()); err != nil {
+		log.Printf("[CALENDAR] Error writing calendar feed: %v", err)
+	}
+}
+
+// parseCalendarTypes reads the comma-separated ?type= filter; blank selects every type
+func parseCalendarTypes(value string) (map[string]bool, error) {
+	types := make(map[string]bool)
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(strings.ToLower(t))
+		if t == "" {
+			continue
+		}
+		valid := false
+		for _, known := range calendarEventTypes {
+			valid = valid || t == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid type %q: use %s", t, strings.Join(calendarEventTypes, ", "))
+		}
+		types[t] = true
+	}
+	if len(types) == 0 {
+		for _, t := range calendarEventTypes {
+			types[t] = true
+		}
+	}
+	return types, nil
+}
+
+// calendarEvents collects the events of the selected types for the given symbols, or for
+// everything when symbols is empty. UIDs name the record an event comes from, so edits
+// replace the earlier event.
+func (s *Server) calendarEvents(types, symbols map[string]bool) ([]ical.Event, error) {
+	wanted := func(symbol string) bool {
+		return len(symbols) == 0 || symbols[symbol]
+	}
+	var events []ical.Event
+
+	if types[calendarExpiration] {
+		options, err := s.optionService.GetOpen()
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if !wanted(option.Symbol) {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:     fmt.Sprintf("option-%d@wheeler", option.ID),
+				Date:    option.Expiration,
+				Summary: fmt.Sprintf("%s $%.2f %s expires", option.Symbol, option.Strike, option.Type),
+				Description: fmt.Sprintf("%d contract%s opened %s for $%.2f premium",
+					option.Contracts, plural(option.Contracts), option.Opened.Format("01/02/2006"), option.Premium),
+				Categories:   []string{"Expiration", option.Symbol},
+				LastModified: option.UpdatedAt,
+			})
+		}
+	}
+
+	if types[calendarMaturity] && len(symbols) == 0 {
+		treasuries, err := s.treasuryService.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, treasury := range treasuries {
+			// Sold treasuries will not mature in the portfolio
+			if treasury.ExitPrice != nil {
+				continue
+			}
+			security := "Treasury"
+			if treasury.SecurityType != nil && *treasury.SecurityType != "" {
+				security = "Treasury " + *treasury.SecurityType
+			}
+			events = append(events, ical.Event{
+				UID:          fmt.Sprintf("treasury-%s@wheeler", treasury.CUSPID),
+				Date:         treasury.Maturity,
+				Summary:      fmt.Sprintf("%s %s matures", security, treasury.CUSPID),
+				Description:  fmt.Sprintf("$%.2f face value at %.2f%% yield, bought %s", treasury.Amount, treasury.Yield, treasury.Purchased.Format("01/02/2006")),
+				Categories:   []string{"Maturity"},
+				LastModified: treasury.UpdatedAt,
+			})
+		}
+	}
+
+	if types[calendarExDividend] {
+		allSymbols, err := s.symbolService.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, symbol := range allSymbols {
+			if symbol.ExDividendDate == nil || !wanted(symbol.Symbol) {
+				continue
+			}
+			// One event per symbol: only the next ex-date is stored, so a new date moves it
+			events = append(events, ical.Event{
+				UID:          fmt.Sprintf("exdiv-%s@wheeler", symbol.Symbol),
+				Date:         *symbol.ExDividendDate,
+				Summary:      fmt.Sprintf("%s ex-dividend", symbol.Symbol),
+				Description:  fmt.Sprintf("$%.4f per share", symbol.Dividend),
+				Categories:   []string{"Ex-Dividend", symbol.Symbol},
+				LastModified: symbol.UpdatedAt,
+			})
+		}
+	}
+
+	if types[calendarDividend] {
+		dividends, err := s.dividendService.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, dividend := range dividends {
+			if !wanted(dividend.Symbol) {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:        fmt.Sprintf("dividend-%d@wheeler", dividend.ID),
+				Date:       dividend.Received,
+				Summary:    fmt.Sprintf("%s dividend paid $%.2f", dividend.Symbol, dividend.Amount),
+				Categories: []string{"Dividend", dividend.Symbol},
+			})
+		}
+	}
+
+	return events, nil
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Helpers shared by the importers that take CSV files as downloaded from a broker or data
+// site, where columns are found by header name rather than position.
+
+// csvDateFormats lists the date layouts found in broker, TreasuryDirect and price downloads
+var csvDateFormats = []string{
+	"2006-01-02",
+	"1/2/2006",
+	"01/02/2006",
+	"1/2/06",
+	"01/02/06",
+	"Jan 2, 2006",
+	"January 2, 2006",
+}
+
+// matchCSVColumns returns the column index of each field whose aliases name a header cell.
+// Aliases are normalized header names, and earlier aliases for a field take precedence.
+func matchCSVColumns(header []string, aliases map[string][]string) map[string]int {
+	columns := map[string]int{}
+	for field, names := range aliases {
+		for _, alias := range names {
+			if idx := indexOfHeader(header, alias); idx >= 0 {
+				columns[field] = idx
+				break
+			}
+		}
+	}
+	return columns
+}
+
+func indexOfHeader(record []string, alias string) int {
+	for i, cell := range record {
+		if normalizeHeader(cell) == alias {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeHeader lower-cases a header and drops everything but letters and digits
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// csvRow reads the fields of one record by the columns matched from its header
+type csvRow struct {
+	record  []string
+	columns map[string]int
+}
+
+func (r csvRow) get(field string) string {
+	idx, ok := r.columns[field]
+	if !ok || idx >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[idx])
+}
+
+func parseCSVDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, format := range csvDateFormats {
+		if date, err := time.Parse(format, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date '%s' (expected YYYY-MM-DD or MM/DD/YYYY)", value)
+}
+
+// parseCSVNumber accepts values such as "$9,786.12", "4.25%" and "(12.50)"
+func parseCSVNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	value = strings.NewReplacer("$", "", ",", "", "%", "", " ", "").Replace(value)
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		number = -number
+	}
+	return number, nil
+}
@@ -56,6 +56,19 @@ func (s *Server) buildDashboardData(symbols []string) (DashboardData, error) {
 	// Calculate totals
 	totals := s.calculateDashboardTotals(symbolSummaries, totalTreasuries)
 
+	// Open options held through an earnings announcement
+	var earningsRisks []*models.OpenPositionData
+	if openPositions, err := s.optionService.GetOpenPositionsWithDetails(); err != nil {
+		log.Printf("[DASHBOARD] Error getting open positions: %v", err)
+	} else {
+		earningsRisks = s.flagEarnings(openPositions)
+	}
+
+	newAlerts, err := s.alertService.CountNew()
+	if err != nil {
+		log.Printf("[DASHBOARD] Error counting new alerts: %v", err)
+	}
+
 	log.Printf("[DASHBOARD] Building dashboard data with %d symbols: %v", len(symbols), symbols)
 	log.Printf("[DASHBOARD] Built %d symbol summaries", len(symbolSummaries))
 
@@ -67,6 +80,8 @@ func (s *Server) buildDashboardData(symbols []string) (DashboardData, error) {
 		PutsByTicker:    putsByTicker,
 		TotalAllocation: totalAllocation,
 		Totals:          totals,
+		EarningsRisks:   earningsRisks,
+		NewAlerts:       newAlerts,
 		CurrentDB:       s.getCurrentDatabaseName(),
 		ActivePage:      "dashboard",
 	}, nil
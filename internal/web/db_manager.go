@@ -0,0 +1,183 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"stonks/internal/alerts"
+	"stonks/internal/database"
+	"stonks/internal/marketdata"
+	"stonks/internal/models"
+	"stonks/internal/notify"
+	"stonks/internal/polygon"
+	"strings"
+	"sync"
+	"time"
+)
+
+// switchDatabasePath is the route that switches databases; it takes the write lock itself
+const switchDatabasePath = "/database/set-current"
+
+// priceJobPathPrefix is the prefix of the price update job routes, which only read the
+// in-memory jobs. Their event stream stays open for as long as the job runs, and the job
+// holds the read lock itself, so holding it for the stream too could deadlock a switch.
+const priceJobPathPrefix = "/api/polygon/update-prices/"
+
+// Requests, scheduled jobs and price updates hold dbLock for reading while they use the
+// connection and services; switching databases holds it for writing, so it waits for them
+// to drain and nothing sees a half-rebuilt server. Nothing takes the read lock twice on the
+// same path, because a waiting writer blocks the second RLock.
+
+// Handler serves the registered routes, holding the database read lock for each request
+// so a database switch waits for in-flight requests to finish
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if holdsDatabaseLock(r.URL.Path) {
+			s.dbLock.RLock()
+			defer s.dbLock.RUnlock()
+		}
+		http.DefaultServeMux.ServeHTTP(w, r)
+	})
+}
+
+// switchSignal is a context that is cancelled when a database switch starts. Requests that
+// wait on a market data provider can hold the read lock for minutes, and a waiting writer
+// blocks every new request, so they give up instead of stalling the switch. The zero value
+// is ready to use.
+type switchSignal struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// context returns the context for the current database, cancelled by the next switch
+func (sig *switchSignal) context() context.Context {
+	sig.mu.Lock()
+	defer sig.mu.Unlock()
+	if sig.ctx == nil {
+		sig.ctx, sig.cancel = context.WithCancel(context.Background())
+	}
+	return sig.ctx
+}
+
+// fire cancels the current context. Requests that start before reset see it cancelled too.
+func (sig *switchSignal) fire() {
+	sig.mu.Lock()
+	defer sig.mu.Unlock()
+	if sig.ctx == nil {
+		sig.ctx, sig.cancel = context.WithCancel(context.Background())
+	}
+	sig.cancel()
+}
+
+// reset starts a new context once the switch holds the write lock
+func (sig *switchSignal) reset() {
+	sig.mu.Lock()
+	defer sig.mu.Unlock()
+	sig.ctx, sig.cancel = context.WithCancel(context.Background())
+}
+
+// providerContext derives the context for a request that calls a market data provider from
+// r, so it ends when the client goes away, when timeout passes (if non-zero) or when a
+// database switch starts
+func (s *Server) providerContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(r.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(r.Context())
+	}
+	stop := context.AfterFunc(s.switching.context(), cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// holdsDatabaseLock reports whether requests to path hold the database read lock
+func holdsDatabaseLock(path string) bool {
+	return path != switchDatabasePath && !strings.HasPrefix(path, priceJobPathPrefix)
+}
+
+// initServices binds the connection and every service to db
+func (s *Server) initServices(db *sql.DB) {
+	s.db = db
+	s.optionService = models.NewOptionService(db)
+	s.symbolService = models.NewSymbolService(db)
+	s.treasuryService = models.NewTreasuryService(db)
+	s.longPositionService = models.NewLongPositionService(db)
+	s.dividendService = models.NewDividendService(db)
+	s.settingService = models.NewSettingService(db)
+	s.configService = models.NewConfigService(db)
+	s.metricService = models.NewMetricService(db)
+	s.importBatchService = models.NewImportBatchService(db)
+	s.priceHistoryService = models.NewPriceHistoryService(db)
+	s.apiCacheService = models.NewAPICacheService(db)
+	s.polygonProvider = polygon.NewProvider(s.settingService, s.apiCacheService)
+	s.marketDataService = marketdata.NewService(s.symbolService, s.settingService, s.priceHistoryService, s.polygonProvider)
+	s.markService = marketdata.NewMarkService(s.marketDataService, s.optionService)
+	s.earningsService = models.NewEarningsService(db)
+	s.calendarService = marketdata.NewCalendarService(s.marketDataService, s.earningsService)
+	s.watchlistService = models.NewWatchlistService(db)
+	s.alertRuleService = models.NewAlertRuleService(db)
+	s.alertService = models.NewAlertService(db)
+	s.alertEngine = alerts.NewEngine(s.alertRuleService, s.alertService, s.optionService, s.symbolService)
+	s.channelService = models.NewNotificationChannelService(db)
+	s.deliveryService = models.NewNotificationDeliveryService(db)
+	s.notifier = notify.NewNotifier(s.channelService, s.deliveryService)
+	s.jobRunService = models.NewJobRunService(db)
+	if s.scheduler != nil {
+		s.scheduler.SetServices(s.configService, s.jobRunService)
+	}
+}
+
+// SwitchDatabase makes dbName in the data directory the current database without a
+// restart. The new database is opened and migrated first, so a failure leaves the current
+// one in place; then in-flight requests and jobs drain, the services are rebuilt on the new
+// connection and the old connection is closed.
+func (s *Server) SwitchDatabase(dbName string) error {
+	if dbName == "" || dbName != filepath.Base(dbName) {
+		return fmt.Errorf("invalid database name %q", dbName)
+	}
+	dbPath := filepath.Join(database.DataDir(), dbName)
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("failed to find database %s: %w", dbName, err)
+	}
+
+	log.Printf("[SET_DATABASE] Connecting to new database: %s", dbPath)
+	dbWrapper, err := database.NewDB(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to new database: %w", err)
+	}
+
+	// Bulk price updates and scheduled jobs write to the old database, and provider-bound
+	// requests can take minutes, so stop them rather than wait for them
+	s.priceJobs.cancelAll()
+	s.scheduler.CancelRunning()
+	s.switching.fire()
+
+	log.Printf("[SET_DATABASE] Waiting for in-flight requests and jobs to finish")
+	s.dbLock.Lock()
+	s.switching.reset()
+	s.scheduler.ResumeRunning()
+	if err := database.SetCurrentDatabase(dbName); err != nil {
+		s.dbLock.Unlock()
+		dbWrapper.Close()
+		return fmt.Errorf("failed to set current database: %w", err)
+	}
+	old := s.db
+	log.Printf("[SET_DATABASE] Reinitializing services with new database connection")
+	s.initServices(dbWrapper.DB)
+	s.dbLock.Unlock()
+
+	log.Printf("[SET_DATABASE] Closing previous database connection")
+	if err := old.Close(); err != nil {
+		log.Printf("[SET_DATABASE] Warning: Error closing previous database: %v", err)
+	}
+	return nil
+}
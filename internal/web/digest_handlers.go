@@ -0,0 +1,258 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"stonks/internal/models"
+	"stonks/internal/notify"
+	"strings"
+	"time"
+)
+
+// digestHandler serves the daily digest: GET /digest shows it as a page, ?format=text as
+// plain text and ?format=html as the standalone HTML that is emailed
+func (s *Server) digestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "html" {
+		http.Error(w, fmt.Sprintf("invalid format %q: use text or html", format), http.StatusBadRequest)
+		return
+	}
+
+	digest, err := s.buildDigest(time.Now())
+	if err != nil {
+		log.Printf("[DIGEST] Error building digest: %v", err)
+		http.Error(w, "Failed to build digest", http.StatusInternalServerError)
+		return
+	}
+
+	switch format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, digest.Text())
+
+	case "html":
+		html, err := s.renderDigestHTML(digest)
+		if err != nil {
+			log.Printf("[DIGEST] Error rendering digest: %v", err)
+			http.Error(w, "Failed to render digest", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, html)
+
+	default:
+		symbols, err := s.symbolService.GetDistinctSymbols()
+		if err != nil {
+			log.Printf("[DIGEST] Error getting symbols: %v", err)
+			symbols = []string{}
+		}
+		s.renderTemplate(w, "digest.html", DigestPageData{
+			AllSymbols: symbols,
+			CurrentDB:  s.getCurrentDatabaseName(),
+			ActivePage: "digest",
+			Digest:     digest,
+		})
+	}
+}
+
+// buildDigest summarizes the portfolio as of now from the same data as the dashboard and
+// monthly pages: premium is counted in the month an option was opened, as on the monthly page
+func (s *Server) buildDigest(now time.Time) (*Digest, error) {
+	symbols, err := s.symbolService.GetDistinctSymbols()
+	if err != nil {
+		return nil, err
+	}
+	dashboard, err := s.buildDashboardData(symbols)
+	if err != nil {
+		return nil, err
+	}
+	options, err := s.optionService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	dividends, err := s.dividendService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	longPositions, err := s.longPositionService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	today := now.Format("2006-01-02")
+	weekEnd := digestWeekEnd(now)
+	digest := &Digest{
+		Date:    now,
+		WeekEnd: weekEnd,
+		Totals:  dashboard.Totals,
+	}
+
+	for _, option := range options {
+		expiration := option.Expiration.Format("2006-01-02")
+		if option.IsOpen() && expiration >= today && expiration <= weekEnd.Format("2006-01-02") {
+			digest.ExpiringThisWeek = append(digest.ExpiringThisWeek, option)
+		}
+		if option.Opened.Format("2006-01-02") == today {
+			digest.OpenedToday = append(digest.OpenedToday, option)
+		}
+		if option.Closed != nil && option.Closed.Format("2006-01-02") == today {
+			digest.ClosedToday = append(digest.ClosedToday, option)
+		}
+	}
+	sort.SliceStable(digest.ExpiringThisWeek, func(i, j int) bool {
+		a, b := digest.ExpiringThisWeek[i], digest.ExpiringThisWeek[j]
+		if !a.Expiration.Equal(b.Expiration) {
+			return a.Expiration.Before(b.Expiration)
+		}
+		return a.Symbol < b.Symbol
+	})
+
+	// Premium by month, from January or last month, whichever is earlier
+	thisMonth := now.Format("2006-01")
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, now.Location()).Format("2006-01")
+	yearStart := now.Format("2006") + "-01"
+	fromMonth := yearStart
+	if lastMonth < fromMonth {
+		fromMonth = lastMonth
+	}
+	monthly := s.buildMonthlyData(symbols, options, dividends, longPositions, map[string]interface{}{}, fromMonth, thisMonth)
+	for _, byMonth := range [][]MonthlyChartData{monthly.PutsData.ByMonth, monthly.CallsData.ByMonth} {
+		for _, month := range byMonth {
+			switch {
+			case month.Month == thisMonth:
+				digest.PremiumMTD += month.Amount
+			case month.Month == lastMonth:
+				digest.PremiumLastMonth += month.Amount
+			}
+			if month.Month >= yearStart {
+				digest.PremiumYTD += month.Amount
+			}
+		}
+	}
+
+	digest.Deployed = dashboard.Totals.TotalPuts + dashboard.Totals.TotalLong
+	if dashboard.Totals.GrandTotal > 0 {
+		digest.Utilization = digest.Deployed / dashboard.Totals.GrandTotal * 100
+	}
+
+	alerts, err := s.alertService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, alert := range alerts {
+		if alert.TriggeredAt.In(now.Location()).Format("2006-01-02") == today {
+			digest.Alerts = append(digest.Alerts, alert)
+		}
+	}
+
+	log.Printf("[DIGEST] Built digest for %s: %d expiring, %d opened, %d closed, %d alerts",
+		today, len(digest.ExpiringThisWeek), len(digest.OpenedToday), len(digest.ClosedToday), len(digest.Alerts))
+	return digest, nil
+}
+
+// digestWeekEnd is the Friday whose expirations count as this week: today's week through
+// Friday, or next week's once the weekend starts
+func digestWeekEnd(now time.Time) time.Time {
+	days := (int(time.Friday) - int(now.Weekday()) + 7) % 7
+	return now.AddDate(0, 0, days)
+}
+
+// Subject is the digest's notification subject
+func (d *Digest) Subject() string {
+	return "Wheeler digest for " + d.Date.Format("Mon Jan 2, 2006")
+}
+
+// Text renders the digest as plain text
+func (d *Digest) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", d.Subject())
+
+	section := func(title string, options []*models.Option, describe func(*models.Option) string) {
+		fmt.Fprintf(&b, "\n%s\n", title)
+		if len(options) == 0 {
+			b.WriteString("- None\n")
+		}
+		for _, option := range options {
+			fmt.Fprintf(&b, "- %s $%.2f %s %s\n", option.Symbol, option.Strike, option.Type, describe(option))
+		}
+	}
+	section("Expiring through "+d.WeekEnd.Format("Mon Jan 2"), d.ExpiringThisWeek, func(o *models.Option) string {
+		return fmt.Sprintf("expires %s, %d contract%s", o.Expiration.Format("Mon Jan 2"), o.Contracts, plural(o.Contracts))
+	})
+	section("Opened today", d.OpenedToday, func(o *models.Option) string {
+		return fmt.Sprintf("expiring %s, %d contract%s for $%.2f premium", o.Expiration.Format("01/02/2006"), o.Contracts, plural(o.Contracts), o.Premium)
+	})
+	section("Closed today", d.ClosedToday, func(o *models.Option) string {
+		return fmt.Sprintf("for $%.2f profit", o.CalculateTotalProfit())
+	})
+
+	fmt.Fprintf(&b, "\nPremium\n")
+	fmt.Fprintf(&b, "- Month to date: $%.2f (last month $%.2f)\n", d.PremiumMTD, d.PremiumLastMonth)
+	fmt.Fprintf(&b, "- Year to date: $%.2f\n", d.PremiumYTD)
+
+	fmt.Fprintf(&b, "\nCollateral\n")
+	fmt.Fprintf(&b, "- Deployed: $%.2f of $%.2f (%.1f%%)\n", d.Deployed, d.Totals.GrandTotal, d.Utilization)
+	fmt.Fprintf(&b, "- Put exposure $%.2f, long stock $%.2f, treasuries $%.2f\n", d.Totals.TotalPuts, d.Totals.TotalLong, d.Totals.TotalTreasuries)
+
+	fmt.Fprintf(&b, "\nAlerts fired today\n")
+	if len(d.Alerts) == 0 {
+		b.WriteString("- None\n")
+	}
+	for _, alert := range d.Alerts {
+		fmt.Fprintf(&b, "- %s: %s\n", alert.RuleName, alert.Message)
+	}
+	return b.String()
+}
+
+// renderDigestHTML renders the digest as a standalone HTML document for email
+func (s *Server) renderDigestHTML(digest *Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, "digest_email.html", digest); err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// sendDigest builds today's digest and delivers it to the digest_channel named in config,
+// or to every enabled channel when none is named. It returns the deliveries made.
+func (s *Server) sendDigest(ctx context.Context, now time.Time) ([]*models.NotificationDelivery, error) {
+	digest, err := s.buildDigest(now)
+	if err != nil {
+		return nil, err
+	}
+	html, err := s.renderDigestHTML(digest)
+	if err != nil {
+		return nil, err
+	}
+	msg := notify.Message{Subject: digest.Subject(), Body: digest.Text(), HTML: html}
+
+	name := strings.TrimSpace(s.configService.GetValue("digest_channel", ""))
+	if name == "" {
+		return s.notifier.Broadcast(ctx, msg)
+	}
+
+	channels, err := s.channelService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if strings.EqualFold(channel.Name, name) {
+			delivery, err := s.notifier.Deliver(ctx, channel, msg)
+			if err != nil {
+				return nil, err
+			}
+			return []*models.NotificationDelivery{delivery}, nil
+		}
+	}
+	return nil, fmt.Errorf("digest_channel %q does not name a notification channel", name)
+}
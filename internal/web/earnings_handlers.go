@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"stonks/internal/marketdata"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// nextEarnings returns each symbol's next earnings date, or an empty map if they cannot be read
+func (s *Server) nextEarnings() map[string]*models.EarningsDate {
+	next, err := s.earningsService.GetNextBySymbol(time.Now())
+	if err != nil {
+		log.Printf("[EARNINGS] Error getting next earnings dates: %v", err)
+		return map[string]*models.EarningsDate{}
+	}
+	return next
+}
+
+// flagEarnings marks open positions whose underlying reports earnings before they expire
+// and returns those positions
+func (s *Server) flagEarnings(positions []*models.OpenPositionData) []*models.OpenPositionData {
+	next := s.nextEarnings()
+	var flagged []*models.OpenPositionData
+	for _, position := range positions {
+		earnings, ok := next[position.Symbol]
+		if ok && earnings.FallsBefore(position.Expiration) {
+			position.Earnings = earnings
+			flagged = append(flagged, position)
+		}
+	}
+	return flagged
+}
+
+// flagChainEarnings marks chain contracts whose underlying reports earnings before they expire
+func (s *Server) flagChainEarnings(contracts []*marketdata.ChainContract) {
+	marketdata.FlagEarnings(contracts, s.nextEarnings())
+}
+
+// earningsAPIHandler returns upcoming earnings dates: GET /api/earnings?symbol=VZ
+func (s *Server) earningsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("symbol")))
+	dates, err := s.earningsService.GetUpcoming(symbol, time.Now())
+	if err != nil {
+		log.Printf("[EARNINGS API] Error getting earnings dates: %v", err)
+		http.Error(w, "Failed to get earnings dates", http.StatusInternalServerError)
+		return
+	}
+	if dates == nil {
+		dates = []*models.EarningsDate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dates)
+}
+
+// updateSymbolEarnings applies the earnings date from the symbol modal. An empty date
+// clears the symbol's upcoming earnings; the current next date is left as it is.
+func (s *Server) updateSymbolEarnings(symbol, earningsDate string, timing *string) error {
+	now := time.Now()
+	current, err := s.earningsService.GetNext(symbol, now)
+	if err != nil {
+		return err
+	}
+
+	if earningsDate == "" {
+		if current == nil {
+			return nil
+		}
+		log.Printf("[EARNINGS] Clearing upcoming earnings for %s", symbol)
+		return s.earningsService.SetNext(symbol, nil, "", now)
+	}
+
+	date, err := time.Parse("2006-01-02", earningsDate)
+	if err != nil {
+		return err
+	}
+	newTiming := ""
+	if timing != nil {
+		newTiming = models.NormalizeEarningsTiming(*timing)
+	} else if current != nil {
+		newTiming = current.Timing
+	}
+	if current != nil && current.Date.Format("2006-01-02") == earningsDate && current.Timing == newTiming {
+		return nil
+	}
+
+	log.Printf("[EARNINGS] Setting next earnings for %s to %s", symbol, earningsDate)
+	return s.earningsService.SetNext(symbol, &date, newTiming, now)
+}
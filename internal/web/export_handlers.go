@@ -0,0 +1,78 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/export"
+	"strings"
+	"time"
+)
+
+// HandleExport downloads the current database as a zip of importer-compatible CSV files and a JSON document
+func (s *Server) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dbName := s.getCurrentDatabaseName()
+	log.Printf("[EXPORT] Exporting database %s", dbName)
+
+	// Build the archive in memory so a failure can still be reported as an error response
+	var buf bytes.Buffer
+	if _, err := export.NewExporter(s.db).WriteZip(&buf, dbName); err != nil {
+		log.Printf("[EXPORT] Error exporting database %s: %v", dbName, err)
+		http.Error(w, fmt.Sprintf("Failed to export database: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_export_%s.zip", strings.TrimSuffix(dbName, ".db"), time.Now().Format("2006-01-02_15-04-05"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", buf.Len()))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("[EXPORT] Error writing export response: %v", err)
+	}
+}
+
+// HandleLedgerExport downloads the current database as Ledger or Beancount transactions.
+// Account names come from the ledger_* keys on the Config page.
+func (s *Server) HandleLedgerExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format, err := export.ParseLedgerFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dbName := s.getCurrentDatabaseName()
+	log.Printf("[EXPORT] Exporting database %s as %s", dbName, format)
+
+	doc, err := export.NewExporter(s.db).Collect(dbName)
+	if err != nil {
+		log.Printf("[EXPORT] Error collecting database %s: %v", dbName, err)
+		http.Error(w, fmt.Sprintf("Failed to export database: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	accounts := export.LedgerAccountsFromConfig(s.configService.GetValue)
+	if err := export.WriteLedger(&buf, doc, format, accounts); err != nil {
+		log.Printf("[EXPORT] Error writing %s export: %v", format, err)
+		http.Error(w, fmt.Sprintf("Failed to export database: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", strings.TrimSuffix(dbName, ".db"), time.Now().Format("2006-01-02"), format.FileExtension())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("[EXPORT] Error writing ledger response: %v", err)
+	}
+}
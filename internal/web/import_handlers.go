@@ -1,17 +1,22 @@
 package web
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
 	"stonks/internal/database"
 	"stonks/internal/models"
+	"stonks/internal/occ"
 	"strconv"
 	"strings"
 	"time"
@@ -28,9 +33,16 @@ func (s *Server) HandleImport(w http.ResponseWriter, r *http.Request) {
 		symbols = []string{}
 	}
 
+	batches, err := s.importBatchService.GetAll()
+	if err != nil {
+		log.Printf("[IMPORT] Error getting import batches: %v", err)
+		batches = []*models.ImportBatch{}
+	}
+
 	data := ImportData{
 		Symbols:    symbols,
 		AllSymbols: symbols, // For navigation compatibility
+		Batches:    batches,
 		CurrentDB:  s.getCurrentDatabaseName(),
 		ActivePage: "import",
 	}
@@ -38,6 +50,68 @@ func (s *Server) HandleImport(w http.ResponseWriter, r *http.Request) {
 	s.renderTemplate(w, "import.html", data)
 }
 
+// HandleImportBatches returns the import history as JSON
+func (s *Server) HandleImportBatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	batches, err := s.importBatchService.GetAll()
+	if err != nil {
+		log.Printf("[IMPORT] Error getting import batches: %v", err)
+		http.Error(w, "Failed to get import batches", http.StatusInternalServerError)
+		return
+	}
+	if batches == nil {
+		batches = []*models.ImportBatch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batches)
+}
+
+// HandleImportBatchRevert deletes every row created by an import batch (DELETE /api/import/batches/{id})
+func (s *Server) HandleImportBatchRevert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/import/batches/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid import batch ID", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("[IMPORT] Reverting import batch %d", id)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	removed, err := s.importBatchService.Revert(id)
+	if err != nil {
+		log.Printf("[IMPORT] Error reverting import batch %d: %v", id, err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already reverted") {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"removed": removed,
+	})
+}
+
 // HandleBackup renders the backup page and lists available database files
 func (s *Server) HandleBackup(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
@@ -131,8 +205,8 @@ func (s *Server) HandleImportUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse CSV and import options
-	importedCount, skippedCount, err := s.importOptionsFromCSV(file)
+	// Parse CSV and import options in a single transaction
+	batch, err := s.runImport(models.ImportTypeOptions, fileHeader.Filename, file, s.importOptionsFromCSV)
 	if err != nil {
 		log.Printf("[IMPORT] Error importing options: %v", err)
 		response := ImportResponse{
@@ -144,11 +218,12 @@ func (s *Server) HandleImportUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("[IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	log.Printf("[IMPORT] Import completed in batch %d: %d imported, %d skipped", batch.ID, batch.ImportedCount, batch.SkippedCount)
 	response := ImportResponse{
 		Success:       true,
-		ImportedCount: importedCount,
-		SkippedCount:  skippedCount,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
 	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -175,7 +250,7 @@ func (s *Server) HandleStocksImportUpload(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	file, _, err := r.FormFile("csvFile")
+	file, fileHeader, err := r.FormFile("csvFile")
 	if err != nil {
 		log.Printf("[STOCKS_IMPORT] Error getting form file: %v", err)
 		response := ImportResponse{
@@ -190,7 +265,7 @@ func (s *Server) HandleStocksImportUpload(w http.ResponseWriter, r *http.Request
 	defer file.Close()
 
 	// Import stocks from CSV
-	importedCount, skippedCount, err := s.importStocksFromCSV(file)
+	batch, err := s.runImport(models.ImportTypeStocks, fileHeader.Filename, file, s.importStocksFromCSV)
 	if err != nil {
 		log.Printf("[STOCKS_IMPORT] Import failed: %v", err)
 		response := ImportResponse{
@@ -203,11 +278,12 @@ func (s *Server) HandleStocksImportUpload(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	log.Printf("[STOCKS_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	log.Printf("[STOCKS_IMPORT] Import completed in batch %d: %d imported, %d skipped", batch.ID, batch.ImportedCount, batch.SkippedCount)
 	response := ImportResponse{
 		Success:       true,
-		ImportedCount: importedCount,
-		SkippedCount:  skippedCount,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -236,7 +312,7 @@ func (s *Server) HandleDividendsImportUpload(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	file, _, err := r.FormFile("csvFile")
+	file, fileHeader, err := r.FormFile("csvFile")
 	if err != nil {
 		log.Printf("[DIVIDENDS_IMPORT] Error getting form file: %v", err)
 		response := ImportResponse{
@@ -251,7 +327,7 @@ func (s *Server) HandleDividendsImportUpload(w http.ResponseWriter, r *http.Requ
 	defer file.Close()
 
 	// Import dividends from CSV
-	importedCount, skippedCount, err := s.importDividendsFromCSV(file)
+	batch, err := s.runImport(models.ImportTypeDividends, fileHeader.Filename, file, s.importDividendsFromCSV)
 	if err != nil {
 		log.Printf("[DIVIDENDS_IMPORT] Import failed: %v", err)
 		response := ImportResponse{
@@ -264,11 +340,12 @@ func (s *Server) HandleDividendsImportUpload(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	log.Printf("[DIVIDENDS_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	log.Printf("[DIVIDENDS_IMPORT] Import completed in batch %d: %d imported, %d skipped", batch.ID, batch.ImportedCount, batch.SkippedCount)
 	response := ImportResponse{
 		Success:       true,
-		ImportedCount: importedCount,
-		SkippedCount:  skippedCount,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -297,7 +374,7 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	file, _, err := r.FormFile("csvFile")
+	file, fileHeader, err := r.FormFile("csvFile")
 	if err != nil {
 		log.Printf("[TREASURIES_IMPORT] Error getting form file: %v", err)
 		response := ImportResponse{
@@ -312,7 +389,7 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 	defer file.Close()
 
 	// Import treasuries from CSV
-	importedCount, skippedCount, err := s.importTreasuriesFromCSV(file)
+	batch, err := s.runImport(models.ImportTypeTreasuries, fileHeader.Filename, file, s.importTreasuriesFromCSV)
 	if err != nil {
 		log.Printf("[TREASURIES_IMPORT] Import failed: %v", err)
 		response := ImportResponse{
@@ -325,11 +402,12 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	log.Printf("[TREASURIES_IMPORT] Import completed: %d imported, %d skipped", importedCount, skippedCount)
+	log.Printf("[TREASURIES_IMPORT] Import completed in batch %d: %d imported, %d skipped", batch.ID, batch.ImportedCount, batch.SkippedCount)
 	response := ImportResponse{
 		Success:       true,
-		ImportedCount: importedCount,
-		SkippedCount:  skippedCount,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -337,7 +415,7 @@ func (s *Server) HandleTreasuriesImportUpload(w http.ResponseWriter, r *http.Req
 }
 
 // importOptionsFromCSV parses the CSV file and imports options
-func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
+func (s *Server) importOptionsFromCSV(sess *importSession, file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
 	reader.FieldsPerRecord = 10 // Expect exactly 10 fields
 
@@ -399,13 +477,13 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 		}
 
 		// Ensure symbol exists (create if it doesn't)
-		err = s.ensureSymbolExists(option.Symbol)
+		err = sess.ensureSymbolExists(option.Symbol)
 		if err != nil {
 			return importedCount, skippedCount, fmt.Errorf("error ensuring symbol exists for row %d: %w", rowNumber, err)
 		}
 
-		// Try to create the option (skip if duplicate) - use CreateWithCommission to set custom commission
-		_, err = s.optionService.CreateWithCommission(option.Symbol, option.Type, option.Opened, option.Strike, option.Expiration, option.Premium, option.Contracts, option.Commission)
+		// Create the option with its exit information in one insert (skip if duplicate)
+		_, err = sess.optionService.CreateImported(option, sess.batchID)
 		if err != nil {
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "duplicate") {
 				log.Printf("[IMPORT] Skipping duplicate option at row %d: %s %s %v", rowNumber, option.Symbol, option.Type, option.Opened)
@@ -415,27 +493,6 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 			return importedCount, skippedCount, fmt.Errorf("error creating option at row %d: %w", rowNumber, err)
 		}
 
-		// If the option was closed, update it with exit information
-		if option.Closed != nil {
-			// We need to get the created option to update it
-			options, err := s.optionService.GetBySymbol(option.Symbol)
-			if err == nil {
-				// Find the option we just created (match by key fields)
-				for _, opt := range options {
-					if opt.Symbol == option.Symbol && opt.Type == option.Type &&
-						opt.Opened.Equal(option.Opened) && opt.Strike == option.Strike &&
-						opt.Expiration.Equal(option.Expiration) && opt.Premium == option.Premium &&
-						opt.Contracts == option.Contracts {
-						_, updateErr := s.optionService.UpdateByID(opt.ID, opt.Symbol, opt.Type, opt.Opened, opt.Strike, opt.Expiration, opt.Premium, opt.Contracts, opt.Commission, option.Closed, option.ExitPrice)
-						if updateErr != nil {
-							log.Printf("[IMPORT] Warning: Failed to update option exit info for row %d: %v", rowNumber, updateErr)
-						}
-						break
-					}
-				}
-			}
-		}
-
 		importedCount++
 		if importedCount%10 == 0 {
 			log.Printf("[IMPORT] Progress: %d options imported so far", importedCount)
@@ -446,7 +503,7 @@ func (s *Server) importOptionsFromCSV(file io.Reader) (importedCount int, skippe
 }
 
 // importStocksFromCSV parses the CSV file and imports stock positions
-func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
+func (s *Server) importStocksFromCSV(sess *importSession, file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
 	reader.FieldsPerRecord = 6 // Expect exactly 6 fields
 
@@ -489,19 +546,13 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 		}
 
 		// Ensure symbol exists
-		if err := s.ensureSymbolExists(position.Symbol); err != nil {
+		if err := sess.ensureSymbolExists(position.Symbol); err != nil {
 			log.Printf("[STOCKS_IMPORT] Row %d: Failed to ensure symbol exists: %v", i+2, err)
 			return importedCount, skippedCount, fmt.Errorf("row %d: failed to create symbol: %w", i+2, err)
 		}
 
-		// Create long position
-		_, err = s.longPositionService.Create(
-			position.Symbol,
-			position.Opened,
-			position.Shares,
-			position.BuyPrice,
-		)
-
+		// Create long position with its exit data in one insert
+		_, err = sess.longPositionService.CreateImported(position, sess.batchID)
 		if err != nil {
 			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
 				log.Printf("[STOCKS_IMPORT] Row %d: Duplicate stock position skipped", i+2)
@@ -512,36 +563,6 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 			return importedCount, skippedCount, fmt.Errorf("row %d: failed to create position: %w", i+2, err)
 		}
 
-		// If position was closed, update with exit data
-		if position.Closed != nil && position.ExitPrice != nil {
-			// We need to find the position we just created and update it
-			positions, err := s.longPositionService.GetBySymbol(position.Symbol)
-			if err == nil && len(positions) > 0 {
-				// Find the most recent position (highest ID)
-				var latestPosition *models.LongPosition
-				for _, p := range positions {
-					if latestPosition == nil || p.ID > latestPosition.ID {
-						latestPosition = p
-					}
-				}
-
-				if latestPosition != nil {
-					_, updateErr := s.longPositionService.UpdateByID(
-						latestPosition.ID,
-						position.Symbol,
-						position.Opened,
-						position.Shares,
-						position.BuyPrice,
-						position.Closed,
-						position.ExitPrice,
-					)
-					if updateErr != nil {
-						log.Printf("[STOCKS_IMPORT] Row %d: Failed to update position with exit data: %v", i+2, updateErr)
-					}
-				}
-			}
-		}
-
 		importedCount++
 		log.Printf("[STOCKS_IMPORT] Row %d: Successfully imported %s position", i+2, position.Symbol)
 	}
@@ -550,7 +571,7 @@ func (s *Server) importStocksFromCSV(file io.Reader) (importedCount int, skipped
 }
 
 // importDividendsFromCSV parses the CSV file and imports dividend records
-func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
+func (s *Server) importDividendsFromCSV(sess *importSession, file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
 	reader.FieldsPerRecord = 3 // Expect exactly 3 fields: Symbol, Date Received, Amount
 
@@ -582,7 +603,7 @@ func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skip
 			Amount:       strings.TrimSpace(record[2]),
 		}
 
-		dividend, created, err := s.processDividendRecord(csvRecord, i+2)
+		dividend, created, err := s.processDividendRecord(sess, csvRecord, i+2)
 		if err != nil {
 			log.Printf("[DIVIDENDS_IMPORT] Row %d: %v", i+2, err)
 			return importedCount, skippedCount, err
@@ -603,9 +624,11 @@ func (s *Server) importDividendsFromCSV(file io.Reader) (importedCount int, skip
 }
 
 // importTreasuriesFromCSV parses the CSV file and imports treasury records
-func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, skippedCount int, err error) {
+func (s *Server) importTreasuriesFromCSV(sess *importSession, file io.Reader) (importedCount int, skippedCount int, err error) {
 	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = 8 // Expect exactly 8 fields: CUSPID, Purchased, Maturity, Amount, Yield, BuyPrice, CurrentValue, ExitPrice
+	// CUSPID, Purchased, Maturity, Amount, Yield, BuyPrice, CurrentValue, ExitPrice, then
+	// optionally CouponRate, SecurityType and IssueDate as exports write them
+	reader.FieldsPerRecord = -1
 
 	records, err := reader.ReadAll()
 	if err != nil {
@@ -624,9 +647,9 @@ func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, ski
 	log.Printf("[TREASURIES_IMPORT] Processing %d treasury records", len(records)-1)
 
 	for i, record := range records[1:] { // Skip header row
-		if len(record) != 8 {
-			log.Printf("[TREASURIES_IMPORT] Row %d: Invalid column count (expected 8, got %d)", i+2, len(record))
-			return importedCount, skippedCount, fmt.Errorf("row %d: expected 8 columns, got %d", i+2, len(record))
+		if len(record) != 8 && len(record) != 11 {
+			log.Printf("[TREASURIES_IMPORT] Row %d: Invalid column count (expected 8 or 11, got %d)", i+2, len(record))
+			return importedCount, skippedCount, fmt.Errorf("row %d: expected 8 or 11 columns, got %d", i+2, len(record))
 		}
 
 		csvRecord := CSVTreasuryRecord{
@@ -639,8 +662,13 @@ func (s *Server) importTreasuriesFromCSV(file io.Reader) (importedCount int, ski
 			CurrentValue: strings.TrimSpace(record[6]),
 			ExitPrice:    strings.TrimSpace(record[7]),
 		}
+		if len(record) == 11 {
+			csvRecord.CouponRate = strings.TrimSpace(record[8])
+			csvRecord.SecurityType = strings.TrimSpace(record[9])
+			csvRecord.IssueDate = strings.TrimSpace(record[10])
+		}
 
-		treasury, created, err := s.processTreasuryRecord(csvRecord, i+2)
+		treasury, created, err := s.processTreasuryRecord(sess, csvRecord, i+2)
 		if err != nil {
 			log.Printf("[TREASURIES_IMPORT] Row %d: %v", i+2, err)
 			return importedCount, skippedCount, err
@@ -666,6 +694,13 @@ func (s *Server) convertCSVRecordToOption(record CSVOptionRecord, rowNumber int)
 	if record.Symbol == "" {
 		return nil, fmt.Errorf("symbol is required")
 	}
+
+	// The symbol column may hold an OCC symbol (e.g. AAPL250117P00150000) instead of the underlying
+	if contract, err := occ.Parse(record.Symbol); err == nil {
+		if err := applyOCCContract(&record, contract); err != nil {
+			return nil, err
+		}
+	}
 	if record.Opened == "" {
 		return nil, fmt.Errorf("opened date is required")
 	}
@@ -795,7 +830,7 @@ func (s *Server) csvStockRecordToLongPosition(record CSVStockRecord) (*models.Lo
 	if err != nil {
 		return nil, fmt.Errorf("invalid shares format: %s", record.Shares)
 	}
-	shares := int(sharesFloat * 100) // Convert to actual shares count
+	shares := int(math.Round(sharesFloat * 100)) // Convert to actual shares count
 
 	// Parse buy price
 	buyPrice, err := strconv.ParseFloat(record.BuyPrice, 64)
@@ -841,7 +876,7 @@ func (s *Server) csvStockRecordToLongPosition(record CSVStockRecord) (*models.Lo
 }
 
 // processDividendRecord processes a single dividend record from CSV
-func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int) (*models.Dividend, bool, error) {
+func (s *Server) processDividendRecord(sess *importSession, csvRecord CSVDividendRecord, rowNum int) (*models.Dividend, bool, error) {
 	// Validate symbol
 	if csvRecord.Symbol == "" {
 		return nil, false, fmt.Errorf("symbol cannot be empty")
@@ -885,18 +920,18 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 	}
 
 	// Ensure symbol exists
-	symbol, err := s.symbolService.GetBySymbol(csvRecord.Symbol)
+	symbol, err := sess.symbolService.GetBySymbol(csvRecord.Symbol)
 	if err != nil {
 		// Create symbol if it doesn't exist
 		log.Printf("[DIVIDENDS_IMPORT] Creating new symbol: %s", csvRecord.Symbol)
-		symbol, err = s.symbolService.Create(csvRecord.Symbol)
+		symbol, err = sess.symbolService.Create(csvRecord.Symbol)
 		if err != nil {
 			return nil, false, fmt.Errorf("failed to create symbol '%s': %v", csvRecord.Symbol, err)
 		}
 	}
 
 	// Check if dividend already exists (to avoid duplicates)
-	existingDividends, err := s.dividendService.GetBySymbol(symbol.Symbol)
+	existingDividends, err := sess.dividendService.GetBySymbol(symbol.Symbol)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to check existing dividends: %v", err)
 	}
@@ -908,7 +943,7 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 	}
 
 	// Create the dividend
-	dividend, err := s.dividendService.Create(symbol.Symbol, receivedDate, amount)
+	dividend, err := sess.dividendService.CreateImported(symbol.Symbol, receivedDate, amount, sess.batchID)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create dividend: %v", err)
 	}
@@ -917,7 +952,7 @@ func (s *Server) processDividendRecord(csvRecord CSVDividendRecord, rowNum int)
 }
 
 // processTreasuryRecord processes a single treasury record from CSV
-func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, rowNum int) (*models.Treasury, bool, error) {
+func (s *Server) processTreasuryRecord(sess *importSession, csvRecord CSVTreasuryRecord, rowNum int) (*models.Treasury, bool, error) {
 	// Validate CUSPID
 	if csvRecord.CUSPID == "" {
 		return nil, false, fmt.Errorf("CUSPID cannot be empty")
@@ -1007,8 +1042,42 @@ func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, rowNum int)
 		exitPrice = &price
 	}
 
+	// Parse optional coupon rate, security type and issue date
+	var couponRate *float64
+	if csvRecord.CouponRate != "" {
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(csvRecord.CouponRate, "%"), 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid coupon rate '%s'", csvRecord.CouponRate)
+		}
+		couponRate = &rate
+	}
+
+	var securityType *string
+	if csvRecord.SecurityType != "" {
+		parsed, ok := models.ParseTreasurySecurityType(csvRecord.SecurityType)
+		if !ok {
+			return nil, false, fmt.Errorf("invalid security type '%s'", csvRecord.SecurityType)
+		}
+		securityType = &parsed
+	}
+
+	var issueDate *time.Time
+	if csvRecord.IssueDate != "" {
+		var parsed time.Time
+		for _, format := range dateFormats {
+			parsed, err = time.Parse(format, csvRecord.IssueDate)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid issue date format '%s' (expected YYYY-MM-DD, MM/DD/YYYY, M/D/YYYY, MM/DD/YY, or M/D/YY)", csvRecord.IssueDate)
+		}
+		issueDate = &parsed
+	}
+
 	// Check if treasury already exists (to avoid duplicates)
-	existingTreasury, err := s.treasuryService.GetByCUSPID(csvRecord.CUSPID)
+	existingTreasury, err := sess.treasuryService.GetByCUSPID(csvRecord.CUSPID)
 	if err == nil && existingTreasury != nil {
 		// Treasury already exists, check if it's the same one
 		if existingTreasury.Purchased.Equal(purchasedDate) && 
@@ -1018,30 +1087,113 @@ func (s *Server) processTreasuryRecord(csvRecord CSVTreasuryRecord, rowNum int)
 		}
 	}
 
-	// Create the treasury
-	treasury, err := s.treasuryService.Create(csvRecord.CUSPID, purchasedDate, maturityDate, amount, yield, buyPrice)
+	// Create the treasury with its optional fields in one insert
+	treasury, err := sess.treasuryService.CreateImported(&models.Treasury{
+		CUSPID:       csvRecord.CUSPID,
+		Purchased:    purchasedDate,
+		Maturity:     maturityDate,
+		Amount:       amount,
+		Yield:        yield,
+		BuyPrice:     buyPrice,
+		CurrentValue: currentValue,
+		ExitPrice:    exitPrice,
+		CouponRate:   couponRate,
+		SecurityType: securityType,
+		IssueDate:    issueDate,
+	}, sess.batchID)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create treasury: %v", err)
 	}
 
-	// Update with optional fields if provided
-	if currentValue != nil || exitPrice != nil {
-		_, err = s.treasuryService.Update(treasury.CUSPID, currentValue, exitPrice)
-		if err != nil {
-			log.Printf("[TREASURIES_IMPORT] Warning: Failed to update treasury with optional fields: %v", err)
-		}
+	return treasury, true, nil
+}
+
+// applyOCCContract fills the contract fields of an option record from its OCC symbol.
+// Type, strike and expiration may be left empty; if present they must agree with the OCC symbol.
+func applyOCCContract(record *CSVOptionRecord, contract *occ.Contract) error {
+	expiration := contract.Expiration.Format("2006-01-02")
+	strike := strconv.FormatFloat(contract.Strike, 'f', -1, 64)
+
+	if record.Type == "" {
+		record.Type = contract.Type
+	} else if record.Type != contract.Type {
+		return fmt.Errorf("type '%s' does not match OCC symbol %s", record.Type, record.Symbol)
 	}
 
-	return treasury, true, nil
+	if record.Expiration == "" {
+		record.Expiration = expiration
+	} else if record.Expiration != expiration {
+		return fmt.Errorf("expiration %s does not match OCC symbol %s", record.Expiration, record.Symbol)
+	}
+
+	if record.Strike == "" {
+		record.Strike = strike
+	} else if recordStrike, err := strconv.ParseFloat(record.Strike, 64); err != nil || math.Abs(recordStrike-contract.Strike) > 0.0005 {
+		return fmt.Errorf("strike %s does not match OCC symbol %s", record.Strike, record.Symbol)
+	}
+
+	record.Symbol = contract.Underlying
+	return nil
+}
+
+// importFunc parses one CSV file and writes its rows through the session's transaction-bound services
+type importFunc func(sess *importSession, file io.Reader) (importedCount int, skippedCount int, err error)
+
+// importSession holds services bound to the transaction of a single import batch
+type importSession struct {
+	batchID             int
+	symbolService       *models.SymbolService
+	optionService       *models.OptionService
+	longPositionService *models.LongPositionService
+	dividendService     *models.DividendService
+	treasuryService     *models.TreasuryService
+	priceHistoryService *models.PriceHistoryService
+}
+
+// runImport reads the uploaded file and imports it as one all-or-nothing import batch
+func (s *Server) runImport(importType, filename string, file io.Reader, importFn importFunc) (*models.ImportBatch, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	return s.importBatchService.Run(importType, filename, content, func(tx *sql.Tx, batchID int) (int, int, error) {
+		sess := &importSession{
+			batchID:             batchID,
+			symbolService:       s.symbolService.WithTx(tx),
+			optionService:       s.optionService.WithTx(tx),
+			longPositionService: s.longPositionService.WithTx(tx),
+			dividendService:     s.dividendService.WithTx(tx),
+			treasuryService:     s.treasuryService.WithTx(tx),
+			priceHistoryService: s.priceHistoryService.WithTx(tx),
+		}
+		return importFn(sess, bytes.NewReader(content))
+	})
+}
+
+// Import imports a CSV file of options, stocks, dividends or treasuries as one import
+// batch, the same as uploading it on the import page
+func (s *Server) Import(importType, filename string, file io.Reader) (*models.ImportBatch, error) {
+	importFns := map[string]importFunc{
+		models.ImportTypeOptions:    s.importOptionsFromCSV,
+		models.ImportTypeStocks:     s.importStocksFromCSV,
+		models.ImportTypeDividends:  s.importDividendsFromCSV,
+		models.ImportTypeTreasuries: s.importTreasuriesFromCSV,
+	}
+	importFn, ok := importFns[importType]
+	if !ok {
+		return nil, fmt.Errorf("invalid import type %q: use options, stocks, dividends or treasuries", importType)
+	}
+	return s.runImport(importType, filename, file, importFn)
 }
 
 // ensureSymbolExists creates a symbol if it doesn't exist
-func (s *Server) ensureSymbolExists(symbol string) error {
-	_, err := s.symbolService.GetBySymbol(symbol)
+func (sess *importSession) ensureSymbolExists(symbol string) error {
+	_, err := sess.symbolService.GetBySymbol(symbol)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			log.Printf("[IMPORT] Creating new symbol: %s", symbol)
-			_, createErr := s.symbolService.Create(symbol)
+			_, createErr := sess.symbolService.Create(symbol)
 			if createErr != nil {
 				return fmt.Errorf("failed to create symbol %s: %w", symbol, createErr)
 			}
@@ -1052,9 +1204,9 @@ func (s *Server) ensureSymbolExists(symbol string) error {
 	return nil
 }
 
-// getAvailableDbFiles returns a list of .db files in the ./data directory
+// getAvailableDbFiles returns a list of .db files in the data directory
 func (s *Server) getAvailableDbFiles() ([]string, error) {
-	dataDir := "./data"
+	dataDir := database.DataDir()
 
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -1082,9 +1234,9 @@ func (s *Server) getAvailableDbFiles() ([]string, error) {
 	return dbFiles, nil
 }
 
-// getBackupFiles returns a list of .db files in the ./backups directory
+// getBackupFiles returns a list of .db files in the backups directory
 func (s *Server) getBackupFiles() ([]string, error) {
-	backupDir := "./data/backups"
+	backupDir := backupDirectory()
 
 	// Ensure backup directory exists
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
@@ -1143,15 +1295,43 @@ func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Construct full path to database file in data directory
-	sourceFilePath := filepath.Join("./data", dbFileName)
-	
-	// Check if source file exists
-	if _, err := os.Stat(sourceFilePath); os.IsNotExist(err) {
-		log.Printf("[BACKUP] Source file does not exist: %s", sourceFilePath)
+	backupFileName, err := s.backupDatabase(dbFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("[BACKUP] Source file does not exist: %s", dbFileName)
 		http.Error(w, `{"success": false, "error": "Source file not found"}`, http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		log.Printf("[BACKUP] Error creating backup: %v", err)
+		http.Error(w, `{"success": false, "error": "Failed to create backup"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Return success response
+	response := map[string]interface{}{
+		"success":  true,
+		"message":  fmt.Sprintf("Backup created: %s", backupFileName),
+		"filename": backupFileName,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// backupDirectory is where database backups are kept
+func backupDirectory() string {
+	return database.BackupDir()
+}
+
+// backupDatabase copies a database in the data directory to a timestamped file in its backups directory and returns the backup name
+func (s *Server) backupDatabase(dbFileName string) (string, error) {
+	// Construct full path to database file in data directory
+	sourceFilePath := filepath.Join(database.DataDir(), dbFileName)
+
+	// Check if source file exists
+	if _, err := os.Stat(sourceFilePath); err != nil {
+		return "", fmt.Errorf("failed to find database %s: %w", dbFileName, err)
+	}
 
 	log.Printf("[BACKUP] Checkpointing WAL to ensure all data is committed")
 	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
@@ -1162,26 +1342,15 @@ func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
 	timestamp := time.Now().Format("2006-01-02-15-04-05")
 	baseName := strings.TrimSuffix(dbFileName, ".db")
 	backupFileName := fmt.Sprintf("%s.%s.db", baseName, timestamp)
-	backupPath := filepath.Join("./data/backups", backupFileName)
+	backupPath := filepath.Join(backupDirectory(), backupFileName)
 
 	// Create backup by copying the file
 	if err := s.copyFile(sourceFilePath, backupPath); err != nil {
-		log.Printf("[BACKUP] Error creating backup: %v", err)
-		http.Error(w, `{"success": false, "error": "Failed to create backup"}`, http.StatusInternalServerError)
-		return
+		return "", err
 	}
 
 	log.Printf("[BACKUP] Successfully created backup: %s -> %s", sourceFilePath, backupPath)
-
-	// Return success response
-	response := map[string]interface{}{
-		"success":  true,
-		"message":  fmt.Sprintf("Backup created: %s", backupFileName),
-		"filename": backupFileName,
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	return backupFileName, nil
 }
 
 // copyFile copies a file from src to dst
@@ -1261,7 +1430,7 @@ func (s *Server) handleDeleteBackup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Construct full backup file path
-	backupPath := filepath.Join("./data/backups", filename)
+	backupPath := filepath.Join(backupDirectory(), filename)
 
 	// Check if backup file exists
 	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
@@ -1289,7 +1458,7 @@ func (s *Server) handleDeleteBackup(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleSetCurrentDatabase sets the current active database and reconnects all services
+// handleSetCurrentDatabase switches the current database; it takes effect for the next request
 func (s *Server) handleSetCurrentDatabase(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -1316,46 +1485,20 @@ func (s *Server) handleSetCurrentDatabase(w http.ResponseWriter, r *http.Request
 	}
 
 	// Validate that the database exists
-	dbPath := filepath.Join("./data", dbName)
+	dbPath := filepath.Join(database.DataDir(), dbName)
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		log.Printf("[SET_DATABASE] Database does not exist: %s", dbPath)
 		http.Error(w, `{"success": false, "error": "Database does not exist"}`, http.StatusNotFound)
 		return
 	}
 
-	// Close existing database connection
-	log.Printf("[SET_DATABASE] Closing existing database connection")
-	if err := s.db.Close(); err != nil {
-		log.Printf("[SET_DATABASE] Warning: Error closing existing database: %v", err)
-	}
-
-	// Set the current database in the filesystem
-	if err := database.SetCurrentDatabase(dbName); err != nil {
-		log.Printf("[SET_DATABASE] Error setting current database: %v", err)
-		http.Error(w, `{"success": false, "error": "Failed to set current database"}`, http.StatusInternalServerError)
-		return
-	}
-
-	// Connect to the new database
-	log.Printf("[SET_DATABASE] Connecting to new database: %s", dbPath)
-	dbWrapper, err := database.NewDB(dbPath)
-	if err != nil {
-		log.Printf("[SET_DATABASE] Error connecting to new database: %v", err)
-		http.Error(w, `{"success": false, "error": "Failed to connect to new database"}`, http.StatusInternalServerError)
+	// Swap the connection and services once in-flight requests and jobs have finished
+	if err := s.SwitchDatabase(dbName); err != nil {
+		log.Printf("[SET_DATABASE] Error switching database: %v", err)
+		http.Error(w, `{"success": false, "error": "Failed to switch to the database"}`, http.StatusInternalServerError)
 		return
 	}
 
-	// Update server's database connection and reinitialize all services
-	log.Printf("[SET_DATABASE] Reinitializing services with new database connection")
-	s.db = dbWrapper.DB
-	s.optionService = models.NewOptionService(dbWrapper.DB)
-	s.symbolService = models.NewSymbolService(dbWrapper.DB)
-	s.treasuryService = models.NewTreasuryService(dbWrapper.DB)
-	s.longPositionService = models.NewLongPositionService(dbWrapper.DB)
-	s.dividendService = models.NewDividendService(dbWrapper.DB)
-	s.settingService = models.NewSettingService(dbWrapper.DB)
-	s.metricService = models.NewMetricService(dbWrapper.DB)
-
 	log.Printf("[SET_DATABASE] Successfully switched to database: %s", dbName)
 
 	// Return success response
@@ -1456,8 +1599,8 @@ func (s *Server) HandleGenerateTestData(w http.ResponseWriter, r *http.Request)
 	}
 	log.Printf("[GENERATE_TEST_DATA] Database connection OK, current symbols count: %d", testCount)
 
-	// Read the SQL file content from internal/database
-	sqlContent, err := os.ReadFile("internal/database/wheel_strategy_example_clean.sql")
+	// Read the example SQL built into the binary
+	sqlContent, err := exampleSQL()
 	if err != nil {
 		log.Printf("[GENERATE_TEST_DATA] ERROR: Failed to read SQL file: %v", err)
 		http.Error(w, "Failed to read test data file", http.StatusInternalServerError)
@@ -1577,7 +1720,7 @@ func (s *Server) handleDeleteDatabase(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Construct full database file path
-	dbPath := filepath.Join("./data", dbName)
+	dbPath := filepath.Join(database.DataDir(), dbName)
 
 	// Check if database file exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
@@ -0,0 +1,263 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"stonks/internal/notify"
+	"stonks/internal/scheduler"
+	"strings"
+	"time"
+)
+
+// Background jobs and the config keys holding their cron schedules
+const (
+	JobPriceRefresh    = "price_refresh"
+	JobMetricsSnapshot = "metrics_snapshot"
+	JobBackup          = "backup"
+	JobOptionMarks     = "option_marks"
+	JobEarningsRefresh = "earnings_refresh"
+	JobAlertCheck      = "alert_check"
+	JobDailyDigest     = "daily_digest"
+)
+
+// jobHistoryLimit is how many past runs of each job are shown
+const jobHistoryLimit = 10
+
+// registerJobs adds Wheeler's background jobs to the scheduler. Jobs read services
+// from the server when they run so they follow the current database.
+func (s *Server) registerJobs() {
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobPriceRefresh,
+		Title:       "Price Refresh",
+		Description: "Updates every symbol's price from the market data provider, open positions first",
+		ConfigKey:   "schedule_price_refresh",
+		Run: func(ctx context.Context) (string, error) {
+			if err := s.marketDataService.UpdateAllSymbolPrices(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Prices refreshed from %s", s.marketDataService.ProviderName()), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobMetricsSnapshot,
+		Title:       "Metrics Snapshot",
+		Description: "Records today's portfolio metrics for the metrics page",
+		ConfigKey:   "schedule_metrics_snapshot",
+		Run: func(ctx context.Context) (string, error) {
+			if err := s.metricService.ComprehensiveSnapshot(1); err != nil {
+				return "", err
+			}
+			return "Snapshot taken for " + time.Now().Format("2006-01-02"), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobOptionMarks,
+		Title:       "Option Marks",
+		Description: "Marks every open option to market so the options page can show percent of max profit",
+		ConfigKey:   "schedule_option_marks",
+		Run: func(ctx context.Context) (string, error) {
+			marked, failed, err := s.markService.MarkOpenOptions(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Marked %d open options, %d failed", marked, failed), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobEarningsRefresh,
+		Title:       "Earnings Refresh",
+		Description: "Updates upcoming earnings dates from the market data provider; manually entered dates are kept",
+		ConfigKey:   "schedule_earnings_refresh",
+		Run: func(ctx context.Context) (string, error) {
+			symbols, err := s.symbolService.GetDistinctSymbols()
+			if err != nil {
+				return "", err
+			}
+			updated, failed, err := s.calendarService.RefreshEarnings(ctx, symbols)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Earnings refreshed for %d symbols, %d failed", updated, failed), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobAlertCheck,
+		Title:       "Alert Check",
+		Description: "Evaluates alert rules against open positions and prices, adds matches to the alert inbox and sends new alerts to the notification channels",
+		ConfigKey:   "schedule_alert_check",
+		Run: func(ctx context.Context) (string, error) {
+			raised, err := s.alertEngine.Evaluate(time.Now())
+			if err != nil {
+				return "", err
+			}
+			if len(raised) == 0 {
+				return "Raised 0 new alerts", nil
+			}
+			deliveries, err := s.notifier.Broadcast(ctx, notify.AlertsMessage(raised))
+			if err != nil {
+				return "", err
+			}
+			if len(deliveries) == 0 {
+				return fmt.Sprintf("Raised %d new alerts", len(raised)), nil
+			}
+			sent := 0
+			for _, delivery := range deliveries {
+				if delivery.Status == models.DeliverySent {
+					sent++
+				}
+			}
+			return fmt.Sprintf("Raised %d new alerts, notified %d of %d channels", len(raised), sent, len(deliveries)), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobDailyDigest,
+		Title:       "Daily Digest",
+		Description: "Sends the daily digest of expirations, today's trades, premium, collateral and alerts to the notification channels",
+		ConfigKey:   "schedule_daily_digest",
+		Run: func(ctx context.Context) (string, error) {
+			deliveries, err := s.sendDigest(ctx, time.Now())
+			if err != nil {
+				return "", err
+			}
+			if len(deliveries) == 0 {
+				return "Digest built, no notification channels enabled", nil
+			}
+			sent := 0
+			for _, delivery := range deliveries {
+				if delivery.Status == models.DeliverySent {
+					sent++
+				}
+			}
+			return fmt.Sprintf("Digest sent to %d of %d channels", sent, len(deliveries)), nil
+		},
+	})
+
+	s.scheduler.Register(&scheduler.Job{
+		Name:        JobBackup,
+		Title:       "Database Backup",
+		Description: "Copies the current database to data/backups",
+		ConfigKey:   "schedule_backup",
+		Run: func(ctx context.Context) (string, error) {
+			backupFileName, err := s.backupDatabase(s.getCurrentDatabaseName())
+			if err != nil {
+				return "", err
+			}
+			return "Created " + backupFileName, nil
+		},
+	})
+}
+
+// RunJob runs a background job to completion and returns its message. The run is
+// recorded in the job history like one started from the jobs page.
+func (s *Server) RunJob(name string) (string, error) {
+	return s.scheduler.Run(name)
+}
+
+// StartScheduler starts running background jobs on their schedules
+func (s *Server) StartScheduler() {
+	s.scheduler.Start()
+}
+
+// jobsPageHandler serves the jobs admin page
+func (s *Server) jobsPageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[JOBS] Handling jobs page request")
+
+	symbols, err := s.symbolService.GetDistinctSymbols()
+	if err != nil {
+		log.Printf("[JOBS] Error getting symbols: %v", err)
+		symbols = []string{}
+	}
+
+	jobs, err := s.scheduler.Status(jobHistoryLimit)
+	if err != nil {
+		log.Printf("[JOBS] Error getting job status: %v", err)
+	}
+
+	data := JobsPageData{
+		AllSymbols: symbols,
+		CurrentDB:  s.getCurrentDatabaseName(),
+		ActivePage: "jobs",
+		Jobs:       jobs,
+		Timezone:   s.configService.GetValue(scheduler.ConfigTimezone, scheduler.DefaultTimezone),
+	}
+
+	s.renderTemplate(w, "jobs.html", data)
+}
+
+// jobsAPIHandler returns every job with its schedule and recent runs
+func (s *Server) jobsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses, err := s.scheduler.Status(jobHistoryLimit)
+	if err != nil {
+		log.Printf("[JOBS API] Error getting job status: %v", err)
+		http.Error(w, "Failed to get jobs", http.StatusInternalServerError)
+		return
+	}
+
+	jobs := make([]JobResponse, 0, len(statuses))
+	for _, status := range statuses {
+		jobs = append(jobs, JobResponse{
+			Name:          status.Job.Name,
+			Title:         status.Job.Title,
+			Description:   status.Job.Description,
+			ConfigKey:     status.Job.ConfigKey,
+			Schedule:      status.Schedule,
+			ScheduleError: status.ScheduleError,
+			Enabled:       status.Enabled(),
+			NextRun:       status.NextRun,
+			Running:       status.Running,
+			LastRun:       status.LastRun,
+			LastError:     status.LastError,
+			Recent:        status.Recent,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// jobRunAPIHandler starts a job immediately: POST /api/jobs/{name}/run
+func (s *Server) jobRunAPIHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "run" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := parts[0]
+	w.Header().Set("Content-Type", "application/json")
+	if err := s.scheduler.RunNow(name); err != nil {
+		log.Printf("[JOBS API] Could not start job %s: %v", name, err)
+		status := http.StatusConflict
+		if !s.scheduler.HasJob(name) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": err.Error()})
+		return
+	}
+
+	log.Printf("[JOBS API] Started job %s", name)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Job %s started", name),
+	})
+}
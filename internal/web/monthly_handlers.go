@@ -13,6 +13,12 @@ import (
 
 // monthlyHandler serves the monthly performance view
 func (s *Server) monthlyHandler(w http.ResponseWriter, r *http.Request) {
+	data := s.loadMonthlyData(r)
+	s.renderTemplate(w, "monthly.html", data)
+}
+
+// loadMonthlyData builds the monthly view for the from/to months in the request, defaulting to the last 12 months
+func (s *Server) loadMonthlyData(r *http.Request) MonthlyData {
 	// Parse query parameters for month filtering (YYYY-MM format)
 	fromMonth := r.URL.Query().Get("from")
 	toMonth := r.URL.Query().Get("to")
@@ -25,6 +31,11 @@ func (s *Server) monthlyHandler(w http.ResponseWriter, r *http.Request) {
 		fromMonth = fmt.Sprintf("%04d-%02d", elevenMonthsAgo.Year(), elevenMonthsAgo.Month())
 	}
 	
+	return s.MonthlyReport(fromMonth, toMonth)
+}
+
+// MonthlyReport builds the monthly view for fromMonth through toMonth (YYYY-MM)
+func (s *Server) MonthlyReport(fromMonth, toMonth string) MonthlyData {
 	symbols, err := s.symbolService.GetDistinctSymbols()
 	if err != nil {
 		symbols = []string{}
@@ -55,9 +66,7 @@ func (s *Server) monthlyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build monthly data with month filtering
-	data := s.buildMonthlyData(symbols, options, dividends, longPositions, optionsIndex, fromMonth, toMonth)
-
-	s.renderTemplate(w, "monthly.html", data)
+	return s.buildMonthlyData(symbols, options, dividends, longPositions, optionsIndex, fromMonth, toMonth)
 }
 
 // calculateMaxCollateral finds the peak simultaneous collateral during a given month.
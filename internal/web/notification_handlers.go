@@ -0,0 +1,211 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"stonks/internal/notify"
+	"strconv"
+	"strings"
+)
+
+// notificationDeliveryLimit is how many recent deliveries the settings page and API show
+const notificationDeliveryLimit = 50
+
+// redactChannels returns copies of the channels without their SMTP passwords
+func redactChannels(channels []*models.NotificationChannel) []*models.NotificationChannel {
+	redacted := make([]*models.NotificationChannel, len(channels))
+	for i, channel := range channels {
+		redacted[i] = channel.Redacted()
+	}
+	return redacted
+}
+
+// notificationsAPIHandler serves notification channels and the delivery log:
+// GET and POST /api/notifications/channels list and create channels,
+// PUT and DELETE /api/notifications/channels/{id} update and delete a channel,
+// POST /api/notifications/channels/{id}/test sends a test message,
+// GET /api/notifications/deliveries lists recent deliveries.
+func (s *Server) notificationsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notifications"), "/"), "/")
+
+	switch {
+	case parts[0] == "deliveries" && len(parts) == 1:
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.listNotificationDeliveries(w, r)
+
+	case parts[0] == "channels" && len(parts) == 1:
+		s.notificationChannelsHandler(w, r)
+
+	case parts[0] == "channels" && len(parts) <= 3:
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+			return
+		}
+		if len(parts) == 3 {
+			if parts[2] != "test" {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			s.testNotificationChannel(w, r, id)
+			return
+		}
+		s.notificationChannelHandler(w, r, id)
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listNotificationDeliveries returns the most recent deliveries, newest first
+func (s *Server) listNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := s.deliveryService.GetRecent(notificationDeliveryLimit)
+	if err != nil {
+		log.Printf("[NOTIFICATIONS API] Error getting deliveries: %v", err)
+		http.Error(w, "Failed to get notification deliveries", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.NotificationDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// notificationChannelsHandler lists (GET) or creates (POST) notification channels
+func (s *Server) notificationChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		channels, err := s.channelService.GetAll()
+		if err != nil {
+			log.Printf("[NOTIFICATIONS API] Error getting channels: %v", err)
+			http.Error(w, "Failed to get notification channels", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(redactChannels(channels))
+
+	case http.MethodPost:
+		channel, ok := decodeNotificationChannel(w, r)
+		if !ok {
+			return
+		}
+		created, err := s.channelService.Create(channel)
+		if err != nil {
+			s.writeNotificationChannelError(w, err)
+			return
+		}
+		log.Printf("[NOTIFICATIONS API] Created %s channel %d: %s", created.Type, created.ID, created.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(created.Redacted())
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// notificationChannelHandler updates (PUT) or deletes (DELETE) one channel
+func (s *Server) notificationChannelHandler(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodPut:
+		channel, ok := decodeNotificationChannel(w, r)
+		if !ok {
+			return
+		}
+		existing, err := s.channelService.GetByID(id)
+		if err != nil {
+			s.writeNotificationChannelError(w, err)
+			return
+		}
+		if channel.Config["password"] == "" && existing.Config["password"] != "" {
+			channel.Config["password"] = existing.Config["password"]
+		}
+		channel.ID = id
+		updated, err := s.channelService.Update(channel)
+		if err != nil {
+			s.writeNotificationChannelError(w, err)
+			return
+		}
+		log.Printf("[NOTIFICATIONS API] Updated channel %d: %s", id, updated.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated.Redacted())
+
+	case http.MethodDelete:
+		if err := s.channelService.Delete(id); err != nil {
+			s.writeNotificationChannelError(w, err)
+			return
+		}
+		log.Printf("[NOTIFICATIONS API] Deleted channel %d", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// testNotificationChannel sends a test message to a channel, enabled or not, and returns
+// the delivery, which records whether it was sent
+func (s *Server) testNotificationChannel(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	channel, err := s.channelService.GetByID(id)
+	if err != nil {
+		s.writeNotificationChannelError(w, err)
+		return
+	}
+
+	log.Printf("[NOTIFICATIONS API] Sending test message to channel %d: %s", id, channel.Name)
+	delivery, err := s.notifier.Deliver(r.Context(), channel, notify.TestMessage(channel))
+	if err != nil {
+		log.Printf("[NOTIFICATIONS API] Error recording test delivery: %v", err)
+		http.Error(w, "Failed to record test delivery", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// decodeNotificationChannel reads a NotificationChannelRequest, writing a 400 response if it is not valid JSON
+func decodeNotificationChannel(w http.ResponseWriter, r *http.Request) (*models.NotificationChannel, bool) {
+	var req NotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+	return &models.NotificationChannel{
+		Name:    req.Name,
+		Type:    req.ChannelType,
+		Config:  req.Config,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}, true
+}
+
+// writeNotificationChannelError maps a channel service error to a response: validation
+// errors are a 400, a missing channel a 404 and anything else a 500
+func (s *Server) writeNotificationChannelError(w http.ResponseWriter, err error) {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+	case strings.HasPrefix(message, "failed to"):
+		log.Printf("[NOTIFICATIONS API] Error saving notification channel: %v", err)
+		http.Error(w, "Failed to save notification channel", http.StatusInternalServerError)
+	default:
+		http.Error(w, message, http.StatusBadRequest)
+	}
+}
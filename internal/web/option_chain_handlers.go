@@ -0,0 +1,269 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"stonks/internal/marketdata"
+	"stonks/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Screener result limits
+const (
+	defaultScreenLimit = 100
+	maxScreenLimit     = 1000
+)
+
+// OptionChainResponse is one symbol's option chain
+type OptionChainResponse struct {
+	Symbol          string                      `json:"symbol"`
+	UnderlyingPrice float64                     `json:"underlying_price"`
+	Contracts       []*marketdata.ChainContract `json:"contracts"`
+}
+
+// OptionScreenResponse is the best contracts found across several symbols
+type OptionScreenResponse struct {
+	Symbols   []string                    `json:"symbols"`
+	Matches   int                         `json:"matches"`
+	Contracts []*marketdata.ChainContract `json:"contracts"`
+	Failures  map[string]string           `json:"failures,omitempty"`
+}
+
+// chainQuery holds the filters and ordering shared by the chain and screener APIs
+type chainQuery struct {
+	contractType string
+	criteria     marketdata.ScreenCriteria
+	strikeMin    float64
+	strikeMax    float64
+	sort         string
+	descending   bool
+	limit        int
+}
+
+// parseChainQuery reads ?type=put|call|all, the screen criteria (min_dte, max_dte,
+// min_aroi, min_delta, max_delta, min_oi, min_otm, max_otm, min_bid), min_strike,
+// max_strike, sort, order=asc|desc and limit
+func parseChainQuery(values url.Values, defaultType, defaultSort string) (*chainQuery, error) {
+	q := &chainQuery{contractType: defaultType, sort: defaultSort}
+
+	switch contractType := strings.ToLower(values.Get("type")); contractType {
+	case "":
+	case "all":
+		q.contractType = ""
+	case "put", "call":
+		q.contractType = contractType
+	default:
+		return nil, fmt.Errorf("invalid type %q, expected put, call or all", contractType)
+	}
+
+	for param, dest := range map[string]*int{
+		"min_dte": &q.criteria.MinDTE,
+		"max_dte": &q.criteria.MaxDTE,
+		"limit":   &q.limit,
+	} {
+		if value := values.Get(param); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("invalid %s %q", param, value)
+			}
+			*dest = parsed
+		}
+	}
+
+	for param, dest := range map[string]*float64{
+		"min_aroi":   &q.criteria.MinAROI,
+		"min_delta":  &q.criteria.MinDelta,
+		"max_delta":  &q.criteria.MaxDelta,
+		"min_oi":     &q.criteria.MinOpenInterest,
+		"min_otm":    &q.criteria.MinOTM,
+		"max_otm":    &q.criteria.MaxOTM,
+		"min_bid":    &q.criteria.MinBid,
+		"min_strike": &q.strikeMin,
+		"max_strike": &q.strikeMax,
+	} {
+		if value := values.Get(param); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", param, value)
+			}
+			*dest = parsed
+		}
+	}
+
+	if sortKey := strings.ToLower(values.Get("sort")); sortKey != "" {
+		q.sort = sortKey
+	}
+	// Rankings read best first; chains read in listing order
+	switch strings.ToLower(values.Get("order")) {
+	case "asc":
+	case "desc":
+		q.descending = true
+	case "":
+		q.descending = q.sort != marketdata.SortByExpiration && q.sort != marketdata.SortByStrike &&
+			q.sort != marketdata.SortBySymbol && q.sort != marketdata.SortByDTE
+	default:
+		return nil, fmt.Errorf("invalid order %q, expected asc or desc", values.Get("order"))
+	}
+	return q, nil
+}
+
+// optionChainPageHandler serves the option chain browser and put screener
+func (s *Server) optionChainPageHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[OPTION CHAIN] Handling option chain page request")
+
+	symbols, err := s.symbolService.GetDistinctSymbols()
+	if err != nil {
+		log.Printf("[OPTION CHAIN] Error getting symbols: %v", err)
+		symbols = []string{}
+	}
+
+	data := OptionChainPageData{
+		AllSymbols:     symbols,
+		CurrentDB:      s.getCurrentDatabaseName(),
+		ActivePage:     "option-chain",
+		SelectedSymbol: strings.ToUpper(r.URL.Query().Get("symbol")),
+		Provider:       s.marketDataService.ProviderName(),
+	}
+
+	s.renderTemplate(w, "option-chain.html", data)
+}
+
+// optionChainAPIHandler returns a symbol's chain: GET /api/options/chain/{symbol}
+func (s *Server) optionChainAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := strings.ToUpper(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/options/chain/"), "/"))
+	if symbol == "" || strings.Contains(symbol, "/") {
+		http.Error(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	query, err := parseChainQuery(r.URL.Query(), "", marketdata.SortByExpiration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := query.criteria.ChainFilter(query.contractType, time.Now())
+	filter.StrikeMin = query.strikeMin
+	filter.StrikeMax = query.strikeMax
+
+	ctx, cancel := s.providerContext(r, 0)
+	defer cancel()
+
+	contracts, err := s.marketDataService.FetchOptionChain(ctx, symbol, filter)
+	if err != nil {
+		log.Printf("[OPTION CHAIN] Error fetching chain for %s: %v", symbol, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": err.Error()})
+		return
+	}
+
+	response := OptionChainResponse{Symbol: symbol, Contracts: []*marketdata.ChainContract{}}
+	for _, contract := range contracts {
+		if query.criteria.Matches(contract) {
+			response.Contracts = append(response.Contracts, contract)
+		}
+		if response.UnderlyingPrice == 0 {
+			response.UnderlyingPrice = contract.UnderlyingPrice
+		}
+	}
+	marketdata.SortChainContracts(response.Contracts, query.sort, query.descending)
+	s.flagChainEarnings(response.Contracts)
+
+	log.Printf("[OPTION CHAIN] Returning %d of %d contracts for %s", len(response.Contracts), len(contracts), symbol)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[OPTION CHAIN] Error encoding chain: %v", err)
+	}
+}
+
+// optionScreenerAPIHandler ranks contracts across symbols: GET /api/options/screener.
+// ?symbols=VZ,KO limits the screen; otherwise ?scope=watchlist screens the watchlist and
+// ?scope=all, the default, every symbol. Puts are screened unless ?type= says otherwise,
+// best AROI first.
+func (s *Server) optionScreenerAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := parseChainQuery(r.URL.Query(), "put", marketdata.SortByAROI)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.limit <= 0 {
+		query.limit = defaultScreenLimit
+	}
+	if query.limit > maxScreenLimit {
+		query.limit = maxScreenLimit
+	}
+
+	var symbols []string
+	for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		switch scope := r.URL.Query().Get("scope"); scope {
+		case "", "all":
+			symbols, err = s.symbolService.GetDistinctSymbols()
+		case "watchlist":
+			var items []*models.WatchlistItem
+			items, err = s.watchlistService.GetAll()
+			symbols = []string{}
+			for _, item := range items {
+				symbols = append(symbols, item.Symbol)
+			}
+		default:
+			http.Error(w, fmt.Sprintf("Invalid scope %q: use all or watchlist", scope), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("[OPTION CHAIN] Error getting symbols: %v", err)
+			http.Error(w, "Failed to get symbols", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	log.Printf("[OPTION CHAIN] Screening %d symbols for %s contracts", len(symbols), query.contractType)
+	ctx, cancel := s.providerContext(r, 0)
+	defer cancel()
+
+	contracts, failures, err := s.marketDataService.ScreenOptionChains(ctx, symbols, query.contractType, query.criteria)
+	if err != nil {
+		log.Printf("[OPTION CHAIN] Screen cancelled: %v", err)
+		return
+	}
+
+	marketdata.SortChainContracts(contracts, query.sort, query.descending)
+	response := OptionScreenResponse{
+		Symbols:   symbols,
+		Matches:   len(contracts),
+		Contracts: contracts,
+		Failures:  failures,
+	}
+	if len(response.Contracts) > query.limit {
+		response.Contracts = response.Contracts[:query.limit]
+	}
+	if response.Contracts == nil {
+		response.Contracts = []*marketdata.ChainContract{}
+	}
+	s.flagChainEarnings(response.Contracts)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("[OPTION CHAIN] Error encoding screen results: %v", err)
+	}
+}
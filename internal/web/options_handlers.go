@@ -44,6 +44,10 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		log.Printf("[OPTIONS PAGE] Retrieved %d open positions", len(openPositions))
 	}
+	throughEarnings := s.flagEarnings(openPositions)
+	log.Printf("[OPTIONS PAGE] %d open positions expire after an earnings date", len(throughEarnings))
+	atRisk := s.flagAssignmentRisk(openPositions)
+	log.Printf("[OPTIONS PAGE] %d open calls are at risk of early assignment before an ex-dividend date", len(atRisk))
 
 	// Get summary totals
 	log.Printf("[OPTIONS PAGE] Calculating summary totals")
@@ -61,6 +65,7 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 		OptionsSummary: optionsSummary,
 		OpenPositions:  openPositions,
 		SummaryTotals:  summaryTotals,
+		ProfitTarget:   s.profitTargetPercent(),
 		CurrentDB:      s.getCurrentDatabaseName(),
 		ActivePage:     "options",
 	}
@@ -70,6 +75,42 @@ func (s *Server) optionsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[OPTIONS PAGE] Successfully completed options page request")
 }
 
+// profitTargetPercent returns the configured percent of max profit at which open options
+// are flagged to consider closing
+func (s *Server) profitTargetPercent() float64 {
+	value := s.configService.GetValue("profit_target_percent", "50")
+	target, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("[OPTIONS PAGE] WARNING: Invalid profit_target_percent %q, using 50", value)
+		return 50
+	}
+	return target
+}
+
+// flagAssignmentRisk marks open short calls likely to be assigned early ahead of an
+// ex-dividend date and returns those positions
+func (s *Server) flagAssignmentRisk(positions []*models.OpenPositionData) []*models.OpenPositionData {
+	symbols, err := s.symbolService.GetAll()
+	if err != nil {
+		log.Printf("[OPTIONS PAGE] WARNING: Failed to get symbols for assignment risk: %v", err)
+		return nil
+	}
+	bySymbol := make(map[string]*models.Symbol, len(symbols))
+	for _, symbol := range symbols {
+		bySymbol[symbol.Symbol] = symbol
+	}
+
+	now := time.Now()
+	var flagged []*models.OpenPositionData
+	for _, position := range positions {
+		if risk := position.EarlyAssignmentRisk(bySymbol[position.Symbol], now); risk != nil {
+			position.AssignmentRisk = risk
+			flagged = append(flagged, position)
+		}
+	}
+	return flagged
+}
+
 // allOptionsHandler serves the all options view with complete sortable table
 func (s *Server) allOptionsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[ALL OPTIONS PAGE] %s %s - Start processing all options page request", r.Method, r.URL.Path)
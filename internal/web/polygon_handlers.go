@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,11 +20,11 @@ func (s *Server) polygonTestHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("[POLYGON API] Testing API connection")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := s.providerContext(r, 10*time.Second)
 	defer cancel()
 
-	// Test the connection
-	err := s.polygonService.TestConnection(ctx)
+	// Test the connection of the selected market data provider
+	err := s.marketDataService.TestConnection(ctx)
 	
 	response := map[string]interface{}{
 		"success": err == nil,
@@ -43,8 +44,15 @@ func (s *Server) polygonTestHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// polygonUpdatePricesHandler triggers price updates for symbols
+// polygonUpdatePricesHandler starts a background price update for the requested symbols,
+// or every symbol when none are given. Only one update runs at a time; asking again while
+// one is running returns the running job.
 func (s *Server) polygonUpdatePricesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.priceJobs.list())
+		return
+	}
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -63,76 +71,142 @@ func (s *Server) polygonUpdatePricesHandler(w http.ResponseWriter, r *http.Reque
 		request.All = true
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	var updated, failed int
-	var errors []string
-
-	if request.All || len(request.Symbols) == 0 {
+	symbols := request.Symbols
+	if request.All || len(symbols) == 0 {
 		// Update all symbols (prioritized: active positions first)
-		symbols, err := s.symbolService.GetPrioritizedSymbols()
+		var err error
+		symbols, err = s.symbolService.GetPrioritizedSymbols()
 		if err != nil {
 			log.Printf("[POLYGON API] Error getting prioritized symbols: %v", err)
 			http.Error(w, "Failed to get symbols", http.StatusInternalServerError)
 			return
 		}
+	}
 
-		log.Printf("[POLYGON API] Updating prices for %d symbols (prioritized order)", len(symbols))
+	job, started := s.priceJobs.start(len(symbols), func(ctx context.Context, job *PriceUpdateJob) error {
+		// The job outlives this request, so it holds the database lock itself, and stops if
+		// a database switch started before it got the lock
+		s.dbLock.RLock()
+		defer s.dbLock.RUnlock()
+		stop := context.AfterFunc(s.switching.context(), job.Cancel)
+		defer stop()
+		_, _, err := s.marketDataService.UpdateSymbolPrices(ctx, symbols, job.record)
+		return err
+	})
+
+	status := job.Status()
+	if started {
+		log.Printf("[POLYGON API] Started price update job %s for %d symbols", job.ID(), len(symbols))
+	} else {
+		log.Printf("[POLYGON API] Price update job %s already running", job.ID())
+	}
 
-		for _, symbol := range symbols {
-			if err := s.polygonService.UpdateSymbolPrice(ctx, symbol); err != nil {
-				log.Printf("[POLYGON API] Failed to update %s: %v", symbol, err)
-				errors = append(errors, symbol+": "+err.Error())
-				failed++
-			} else {
-				updated++
-			}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"existing":   !started,
+		"job_id":     status.ID,
+		"total":      status.Total,
+		"status_url": status.StatusURL,
+		"events_url": status.EventsURL,
+	}); err != nil {
+		log.Printf("[POLYGON API] Error encoding update response: %v", err)
+	}
+}
 
-			// Rate limiting for free tier (5 requests per minute)
-			time.Sleep(12 * time.Second)
-		}
-	} else {
-		// Update specific symbols
-		log.Printf("[POLYGON API] Updating prices for specific symbols: %v", request.Symbols)
+// polygonPriceJobHandler serves a bulk price update job:
+// GET /api/polygon/update-prices/{id} returns its progress,
+// GET /api/polygon/update-prices/{id}/events streams it as Server-Sent Events,
+// DELETE /api/polygon/update-prices/{id} cancels it.
+func (s *Server) polygonPriceJobHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/polygon/update-prices/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "events") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
 
-		for _, symbol := range request.Symbols {
-			if err := s.polygonService.UpdateSymbolPrice(ctx, symbol); err != nil {
-				log.Printf("[POLYGON API] Failed to update %s: %v", symbol, err)
-				errors = append(errors, symbol+": "+err.Error())
-				failed++
-			} else {
-				updated++
-			}
+	job := s.priceJobs.get(parts[0])
+	if job == nil {
+		http.Error(w, "Price update job not found", http.StatusNotFound)
+		return
+	}
 
-			// Rate limiting
-			if len(request.Symbols) > 1 {
-				time.Sleep(12 * time.Second)
-			}
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
 		}
+		s.streamPriceJobEvents(w, r, job)
+		return
 	}
 
-	response := map[string]interface{}{
-		"success": updated > 0,
-		"updated": updated,
-		"failed":  failed,
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		log.Printf("[POLYGON API] Cancelling price update job %s", job.ID())
+		job.Cancel()
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if len(errors) > 0 {
-		response["errors"] = errors
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job.Status()); err != nil {
+		log.Printf("[POLYGON API] Error encoding job status: %v", err)
 	}
+}
 
-	if updated > 0 {
-		response["message"] = "Price update completed"
-	} else {
-		response["message"] = "No prices were updated"
+// priceJobKeepAlive is how often an idle event stream sends a comment so proxies keep it open
+const priceJobKeepAlive = 15 * time.Second
+
+// streamPriceJobEvents writes the job's events as Server-Sent Events until it finishes or
+// the client goes away. Event IDs are indexes, so a reconnecting EventSource resumes where
+// it left off via Last-Event-ID.
+func (s *Server) streamPriceJobEvents(w http.ResponseWriter, r *http.Request, job *PriceUpdateJob) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
 	}
 
-	log.Printf("[POLYGON API] Price update completed: %d updated, %d failed", updated, failed)
+	next := 0
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && lastID >= 0 {
+		next = lastID + 1
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("[POLYGON API] Error encoding update response: %v", err)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(priceJobKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		events, finished, changed := job.eventsSince(next)
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("[POLYGON API] Error encoding job event: %v", err)
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			next = event.ID + 1
+		}
+		flusher.Flush()
+		if finished {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
 	}
 }
 
@@ -153,11 +227,11 @@ func (s *Server) polygonSymbolInfoHandler(w http.ResponseWriter, r *http.Request
 	symbol := strings.ToUpper(path)
 	log.Printf("[POLYGON API] Getting symbol info for: %s", symbol)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := s.providerContext(r, 30*time.Second)
 	defer cancel()
 
 	// Get symbol info from Polygon
-	info, err := s.polygonService.FetchSymbolDetails(ctx, symbol)
+	info, err := s.marketDataService.FetchSymbolDetails(ctx, symbol)
 	if err != nil {
 		log.Printf("[POLYGON API] Error getting symbol info for %s: %v", symbol, err)
 		response := map[string]interface{}{
@@ -171,7 +245,7 @@ func (s *Server) polygonSymbolInfoHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Get dividend history (optional)
-	dividends, err := s.polygonService.FetchDividendHistory(ctx, symbol, 5)
+	dividends, err := s.marketDataService.FetchDividendHistory(ctx, symbol, 5)
 	if err != nil {
 		log.Printf("[POLYGON API] Warning: failed to get dividend history for %s: %v", symbol, err)
 		// Continue without dividends
@@ -201,14 +275,14 @@ func (s *Server) polygonStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status := s.polygonService.GetAPIKeyStatus()
+	status := s.polygonProvider.GetAPIKeyStatus()
 
 	// Test connection if API key is configured
 	if status.Configured {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := s.providerContext(r, 10*time.Second)
 		defer cancel()
 
-		if err := s.polygonService.TestConnection(ctx); err != nil {
+		if err := s.polygonProvider.TestConnection(ctx); err != nil {
 			status.Valid = false
 			status.Error = err.Error()
 		} else {
@@ -247,7 +321,7 @@ func (s *Server) polygonFetchDividendsHandler(w http.ResponseWriter, r *http.Req
 		request.Limit = 10 // Default to 10 recent dividends
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := s.providerContext(r, 5*time.Minute)
 	defer cancel()
 
 	var processed int
@@ -266,7 +340,7 @@ func (s *Server) polygonFetchDividendsHandler(w http.ResponseWriter, r *http.Req
 		log.Printf("[POLYGON API] Fetching dividends for %d symbols (prioritized order)", len(symbols))
 
 		for _, symbol := range symbols {
-			dividends, err := s.polygonService.FetchDividendHistory(ctx, symbol, request.Limit)
+			dividends, err := s.marketDataService.FetchDividendHistory(ctx, symbol, request.Limit)
 			processed++
 			
 			if err != nil {
@@ -279,16 +353,13 @@ func (s *Server) polygonFetchDividendsHandler(w http.ResponseWriter, r *http.Req
 					"count":     len(dividends),
 				})
 			}
-
-			// Rate limiting for free tier (5 requests per minute)
-			time.Sleep(12 * time.Second)
 		}
 	} else {
 		// Fetch dividends for specific symbols
 		log.Printf("[POLYGON API] Fetching dividends for specific symbols: %v", request.Symbols)
 
 		for _, symbol := range request.Symbols {
-			dividends, err := s.polygonService.FetchDividendHistory(ctx, symbol, request.Limit)
+			dividends, err := s.marketDataService.FetchDividendHistory(ctx, symbol, request.Limit)
 			processed++
 			
 			if err != nil {
@@ -301,11 +372,6 @@ func (s *Server) polygonFetchDividendsHandler(w http.ResponseWriter, r *http.Req
 					"count":     len(dividends),
 				})
 			}
-
-			// Rate limiting
-			if len(request.Symbols) > 1 {
-				time.Sleep(12 * time.Second)
-			}
 		}
 	}
 
@@ -334,4 +400,36 @@ func (s *Server) polygonFetchDividendsHandler(w http.ResponseWriter, r *http.Req
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("[POLYGON API] Error encoding dividend fetch response: %v", err)
 	}
-}
\ No newline at end of file
+}
+// polygonCacheHandler reports on (GET) or empties (DELETE) the market data response cache
+func (s *Server) polygonCacheHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		stats, err := s.apiCacheService.Stats()
+		if err != nil {
+			log.Printf("[POLYGON API] Error getting cache stats: %v", err)
+			http.Error(w, "Failed to get cache stats", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(stats)
+
+	case http.MethodDelete:
+		cleared, err := s.apiCacheService.Clear()
+		if err != nil {
+			log.Printf("[POLYGON API] Error clearing cache: %v", err)
+			http.Error(w, "Failed to clear cache", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[POLYGON API] Cleared %d cached responses", cleared)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"cleared": cleared,
+			"message": fmt.Sprintf("Cleared %d cached responses", cleared),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"stonks/internal/database"
+	"sync"
+	"time"
+)
+
+// portfoliosHandler serves the consolidated view of several portfolio databases:
+// GET /portfolios?db=a.db&db=b.db&from=2025-01&to=2025-12. Every database is included when
+// none is picked, and the months default to the last 12 as on the monthly page.
+func (s *Server) portfoliosHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	databases, data, err := s.loadConsolidatedData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.renderTemplate(w, "portfolios.html", PortfoliosPageData{
+		AllSymbols:   s.getAllSymbolsList(),
+		CurrentDB:    s.getCurrentDatabaseName(),
+		ActivePage:   "portfolios",
+		Databases:    databases,
+		Consolidated: data,
+	})
+}
+
+// portfoliosAPIHandler returns the consolidated view as JSON, taking the same parameters
+// as the portfolios page
+func (s *Server) portfoliosAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	_, data, err := s.loadConsolidatedData(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[PORTFOLIOS] Error encoding consolidated data: %v", err)
+	}
+}
+
+// loadConsolidatedData reads the picked databases and month range from the request and
+// builds the consolidated view, returning the picker options as well
+func (s *Server) loadConsolidatedData(r *http.Request) ([]PortfolioOption, ConsolidatedData, error) {
+	all, err := database.ListDatabases()
+	if err != nil {
+		return nil, ConsolidatedData{}, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	picked := make(map[string]bool)
+	for _, name := range r.URL.Query()["db"] {
+		picked[name] = true
+	}
+	current := s.getCurrentDatabaseName()
+	var options []PortfolioOption
+	var names []string
+	for _, name := range all {
+		selected := len(picked) == 0 || picked[name]
+		options = append(options, PortfolioOption{Name: name, Selected: selected})
+		if !selected {
+			continue
+		}
+		// The current portfolio leads the breakdown
+		if name == current {
+			names = append([]string{name}, names...)
+		} else {
+			names = append(names, name)
+		}
+		delete(picked, name)
+	}
+	for name := range picked {
+		return nil, ConsolidatedData{}, fmt.Errorf("unknown database %q", name)
+	}
+
+	now := time.Now()
+	fromMonth := r.URL.Query().Get("from")
+	toMonth := r.URL.Query().Get("to")
+	if fromMonth == "" || toMonth == "" {
+		fromMonth = now.AddDate(0, -11, 0).Format("2006-01")
+		toMonth = now.Format("2006-01")
+	}
+	for _, month := range []string{fromMonth, toMonth} {
+		if _, err := time.Parse("2006-01", month); err != nil {
+			return nil, ConsolidatedData{}, fmt.Errorf("invalid month %q: use YYYY-MM", month)
+		}
+	}
+
+	return options, s.buildConsolidatedData(names, current, fromMonth, toMonth), nil
+}
+
+// buildConsolidatedData summarizes each named database in parallel and combines them.
+// The current database is read through the server's own services; the others are opened
+// read-only for the duration of the request.
+func (s *Server) buildConsolidatedData(names []string, current, fromMonth, toMonth string) ConsolidatedData {
+	portfolios := make([]*PortfolioSummary, len(names))
+	summaries := make([][]SymbolSummary, len(names))
+	incomes := make([]map[string]float64, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			portfolios[i], summaries[i], incomes[i] = s.summarizePortfolio(name, name == current, fromMonth, toMonth)
+		}()
+	}
+	wg.Wait()
+
+	data := ConsolidatedData{FromMonth: fromMonth, ToMonth: toMonth, Portfolios: portfolios}
+
+	// Totals are recalculated from every symbol summary so the ratios are portfolio-weighted
+	var combined []SymbolSummary
+	treasuries := 0.0
+	monthSet := make(map[string]bool)
+	for i, portfolio := range portfolios {
+		combined = append(combined, summaries[i]...)
+		treasuries += portfolio.Totals.TotalTreasuries
+		for month := range incomes[i] {
+			monthSet[month] = true
+		}
+	}
+	data.Totals = s.calculateDashboardTotals(combined, treasuries)
+
+	for month := range monthSet {
+		data.Months = append(data.Months, month)
+	}
+	sort.Strings(data.Months)
+	data.Income = make([]float64, len(data.Months))
+	for j, month := range data.Months {
+		label := month
+		if t, err := time.Parse("2006-01", month); err == nil {
+			label = t.Format("2006 Jan")
+		}
+		data.MonthLabels = append(data.MonthLabels, label)
+		for i, portfolio := range portfolios {
+			amount := incomes[i][month]
+			portfolio.Income = append(portfolio.Income, amount)
+			portfolio.TotalIncome += amount
+			data.Income[j] += amount
+			data.TotalIncome += amount
+		}
+	}
+
+	exposure := make(map[string]*ExposureRow)
+	for i := range portfolios {
+		for _, summary := range summaries[i] {
+			amount := summary.LongAmount + summary.PutExposed
+			if amount == 0 {
+				continue
+			}
+			row, ok := exposure[summary.Ticker]
+			if !ok {
+				row = &ExposureRow{Ticker: summary.Ticker, ByPortfolio: make([]float64, len(portfolios))}
+				exposure[summary.Ticker] = row
+			}
+			row.ByPortfolio[i] += amount
+			row.Total += amount
+		}
+	}
+	for _, row := range exposure {
+		data.Exposure = append(data.Exposure, *row)
+	}
+	sort.Slice(data.Exposure, func(i, j int) bool {
+		if data.Exposure[i].Total != data.Exposure[j].Total {
+			return data.Exposure[i].Total > data.Exposure[j].Total
+		}
+		return data.Exposure[i].Ticker < data.Exposure[j].Ticker
+	})
+
+	return data
+}
+
+// summarizePortfolio builds one database's dashboard totals, symbol summaries and income by
+// month, using the same calculations as the dashboard and monthly pages
+func (s *Server) summarizePortfolio(name string, current bool, fromMonth, toMonth string) (*PortfolioSummary, []SymbolSummary, map[string]float64) {
+	portfolio := &PortfolioSummary{Name: name, Current: current}
+
+	source := s
+	if !current {
+		db, err := database.OpenReadOnly(filepath.Join(database.DataDir(), name))
+		if err != nil {
+			log.Printf("[PORTFOLIOS] Error opening %s: %v", name, err)
+			portfolio.Error = err.Error()
+			return portfolio, nil, nil
+		}
+		defer db.Close()
+		source = &Server{}
+		source.initServices(db.DB)
+	}
+
+	symbols, err := source.symbolService.GetDistinctSymbols()
+	if err != nil {
+		log.Printf("[PORTFOLIOS] Error reading symbols of %s: %v", name, err)
+		portfolio.Error = fmt.Sprintf("failed to read symbols: %v", err)
+		return portfolio, nil, nil
+	}
+	dashboard, err := source.buildDashboardData(symbols)
+	if err != nil {
+		log.Printf("[PORTFOLIOS] Error building dashboard of %s: %v", name, err)
+		portfolio.Error = err.Error()
+		return portfolio, nil, nil
+	}
+	portfolio.Totals = dashboard.Totals
+
+	income := make(map[string]float64)
+	for _, total := range source.MonthlyReport(fromMonth, toMonth).TotalsByMonth {
+		income[total.Month] += total.Amount
+	}
+	return portfolio, dashboard.SymbolSummaries, income
+}
@@ -0,0 +1,450 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"stonks/internal/models"
+	"strings"
+	"time"
+)
+
+// priceColumnAliases maps each price-history field to accepted normalized header names.
+// The Close column wins over Adj Close so stored bars match the prices trades were made at.
+var priceColumnAliases = map[string][]string{
+	"symbol": {"symbol", "ticker"},
+	"date":   {"date", "day", "tradedate"},
+	"open":   {"open"},
+	"high":   {"high"},
+	"low":    {"low"},
+	"close":  {"close", "closelast", "price", "adjclose"},
+	"volume": {"volume"},
+}
+
+// assignmentWindow is how far from expiration (or an early close) a stock trade
+// at the strike is still treated as the assignment of that option
+const assignmentWindow = 4 * 24 * time.Hour
+
+// PriceChartData is the price history of a symbol with the symbol's trades overlaid
+type PriceChartData struct {
+	Symbol      string            `json:"symbol"`
+	Prices      []PriceChartPoint `json:"prices"`
+	Strikes     []StrikeSegment   `json:"strikes"`
+	Trades      []TradeMarker     `json:"trades"`
+	Assignments []TradeMarker     `json:"assignments"`
+}
+
+// PriceChartPoint is one daily close
+type PriceChartPoint struct {
+	Date  string  `json:"date"`
+	Close float64 `json:"close"`
+}
+
+// StrikeSegment draws an option's strike from the day it was opened until it was closed or expired
+type StrikeSegment struct {
+	OptionID  int     `json:"option_id"`
+	Type      string  `json:"type"`
+	Strike    float64 `json:"strike"`
+	Contracts int     `json:"contracts"`
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	Open      bool    `json:"open"`
+	Assigned  bool    `json:"assigned"`
+}
+
+// TradeMarker is a point on the chart for a stock trade or assignment
+type TradeMarker struct {
+	Date   string  `json:"date"`
+	Price  float64 `json:"price"`
+	Kind   string  `json:"kind"` // buy, sell, put_assigned or called_away
+	Shares int     `json:"shares"`
+	Label  string  `json:"label"`
+}
+
+// symbolPriceHistoryHandler returns chart data for a symbol, optionally limited with ?from= and ?to=
+func (s *Server) symbolPriceHistoryHandler(w http.ResponseWriter, r *http.Request, symbol string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol = strings.ToUpper(symbol)
+	var from, to time.Time
+	for param, dest := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := r.URL.Query().Get(param); value != "" {
+			parsed, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s date, expected YYYY-MM-DD", param), http.StatusBadRequest)
+				return
+			}
+			*dest = parsed
+		}
+	}
+
+	data, err := s.buildPriceChartData(symbol, from, to)
+	if err != nil {
+		log.Printf("[PRICE HISTORY] Error building chart data for %s: %v", symbol, err)
+		http.Error(w, "Failed to get price history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("[PRICE HISTORY] Error encoding chart data: %v", err)
+	}
+}
+
+// symbolFetchPriceHistoryHandler backfills daily bars from the market data provider.
+// The range defaults to a month before the first trade in the symbol through today.
+func (s *Server) symbolFetchPriceHistoryHandler(w http.ResponseWriter, r *http.Request, symbol string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol = strings.ToUpper(symbol)
+	var request struct {
+		From string `json:"from,omitempty"`
+		To   string `json:"to,omitempty"`
+	}
+	if r.Body != nil {
+		// An empty body means the default range
+		json.NewDecoder(r.Body).Decode(&request)
+	}
+
+	to := time.Now()
+	from := s.firstTradeDate(symbol).AddDate(0, -1, 0)
+	if request.From != "" {
+		parsed, err := time.Parse("2006-01-02", request.From)
+		if err != nil {
+			http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if request.To != "" {
+		parsed, err := time.Parse("2006-01-02", request.To)
+		if err != nil {
+			http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	ctx, cancel := s.providerContext(r, time.Minute)
+	defer cancel()
+
+	saved, err := s.marketDataService.BackfillPriceHistory(ctx, symbol, from, to)
+
+	response := map[string]interface{}{
+		"success": err == nil,
+		"symbol":  symbol,
+		"saved":   saved,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		log.Printf("[PRICE HISTORY] Failed to fetch price history for %s: %v", symbol, err)
+		response["error"] = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		response["message"] = fmt.Sprintf("Saved %d daily prices", saved)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// firstTradeDate returns the earliest option or stock trade in a symbol, or a year ago if there are none
+func (s *Server) firstTradeDate(symbol string) time.Time {
+	first := time.Now().AddDate(-1, 0, 0)
+	if options, err := s.optionService.GetBySymbol(symbol); err == nil {
+		for _, option := range options {
+			if option.Opened.Before(first) {
+				first = option.Opened
+			}
+		}
+	}
+	if positions, err := s.longPositionService.GetBySymbol(symbol); err == nil {
+		for _, position := range positions {
+			if position.Opened.Before(first) {
+				first = position.Opened
+			}
+		}
+	}
+	return first
+}
+
+// buildPriceChartData combines stored daily closes with the symbol's options and stock trades
+func (s *Server) buildPriceChartData(symbol string, from, to time.Time) (*PriceChartData, error) {
+	bars, err := s.priceHistoryService.GetBySymbol(symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	options, err := s.optionService.GetBySymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options: %w", err)
+	}
+	positions, err := s.longPositionService.GetBySymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get long positions: %w", err)
+	}
+
+	data := &PriceChartData{
+		Symbol:      symbol,
+		Prices:      []PriceChartPoint{},
+		Strikes:     []StrikeSegment{},
+		Trades:      []TradeMarker{},
+		Assignments: []TradeMarker{},
+	}
+	for _, bar := range bars {
+		data.Prices = append(data.Prices, PriceChartPoint{Date: bar.Date.Format("2006-01-02"), Close: bar.Close})
+	}
+
+	for _, position := range positions {
+		data.Trades = append(data.Trades, TradeMarker{
+			Date:   position.Opened.Format("2006-01-02"),
+			Price:  position.BuyPrice,
+			Kind:   "buy",
+			Shares: position.Shares,
+			Label:  fmt.Sprintf("Bought %d @ $%.2f", position.Shares, position.BuyPrice),
+		})
+		if position.Closed != nil && position.ExitPrice != nil {
+			data.Trades = append(data.Trades, TradeMarker{
+				Date:   position.Closed.Format("2006-01-02"),
+				Price:  *position.ExitPrice,
+				Kind:   "sell",
+				Shares: position.Shares,
+				Label:  fmt.Sprintf("Sold %d @ $%.2f", position.Shares, *position.ExitPrice),
+			})
+		}
+	}
+
+	for _, option := range options {
+		end := option.Expiration
+		if option.Closed != nil {
+			end = *option.Closed
+		}
+		segment := StrikeSegment{
+			OptionID:  option.ID,
+			Type:      option.Type,
+			Strike:    option.Strike,
+			Contracts: option.Contracts,
+			Start:     option.Opened.Format("2006-01-02"),
+			End:       end.Format("2006-01-02"),
+			Open:      option.IsOpen(),
+		}
+		if marker := findAssignment(option, positions); marker != nil {
+			segment.Assigned = true
+			data.Assignments = append(data.Assignments, *marker)
+		}
+		data.Strikes = append(data.Strikes, segment)
+	}
+
+	return data, nil
+}
+
+// findAssignment matches an option to the stock trade its assignment created: a put to shares
+// bought at the strike, a call to shares sold at the strike, around expiration or the close date
+func findAssignment(option *models.Option, positions []*models.LongPosition) *TradeMarker {
+	if option.IsOpen() && time.Now().Before(option.Expiration) {
+		return nil
+	}
+	when := option.Expiration
+	if option.Closed != nil {
+		when = *option.Closed
+	}
+	near := func(t time.Time) bool {
+		d := t.Sub(when)
+		return d > -assignmentWindow && d < assignmentWindow
+	}
+	atStrike := func(price float64) bool {
+		return math.Abs(price-option.Strike) < 0.005
+	}
+
+	for _, position := range positions {
+		switch option.Type {
+		case "Put":
+			if near(position.Opened) && atStrike(position.BuyPrice) {
+				return &TradeMarker{
+					Date:   position.Opened.Format("2006-01-02"),
+					Price:  option.Strike,
+					Kind:   "put_assigned",
+					Shares: position.Shares,
+					Label:  fmt.Sprintf("Assigned %d shares @ $%.2f put", position.Shares, option.Strike),
+				}
+			}
+		case "Call":
+			if position.Closed != nil && position.ExitPrice != nil && near(*position.Closed) && atStrike(*position.ExitPrice) {
+				return &TradeMarker{
+					Date:   position.Closed.Format("2006-01-02"),
+					Price:  option.Strike,
+					Kind:   "called_away",
+					Shares: position.Shares,
+					Label:  fmt.Sprintf("Called away %d shares @ $%.2f call", position.Shares, option.Strike),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// HandlePriceHistoryImportUpload imports daily prices from a CSV. Files without a symbol
+// column, such as a single-ticker download, take the symbol from the "symbol" form field.
+func (s *Server) HandlePriceHistoryImportUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Printf("[PRICE_IMPORT] Starting price history CSV import")
+	w.Header().Set("Content-Type", "application/json")
+
+	// Parse multipart form (10MB max)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		log.Printf("[PRICE_IMPORT] Error parsing multipart form: %v", err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "Failed to parse form data",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("csvFile")
+	if err != nil {
+		log.Printf("[PRICE_IMPORT] Error getting form file: %v", err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "No file provided or error reading file",
+			Details: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	defaultSymbol := strings.ToUpper(strings.TrimSpace(r.FormValue("symbol")))
+	batch, err := s.runImport(models.ImportTypePriceHistory, fileHeader.Filename, file, func(sess *importSession, file io.Reader) (int, int, error) {
+		return s.importPriceHistoryFromCSV(sess, file, defaultSymbol)
+	})
+	if err != nil {
+		log.Printf("[PRICE_IMPORT] Import failed: %v", err)
+		json.NewEncoder(w).Encode(ImportResponse{
+			Success: false,
+			Error:   "Failed to import price history from CSV",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[PRICE_IMPORT] Import completed in batch %d: %d imported, %d skipped", batch.ID, batch.ImportedCount, batch.SkippedCount)
+	json.NewEncoder(w).Encode(ImportResponse{
+		Success:       true,
+		ImportedCount: batch.ImportedCount,
+		SkippedCount:  batch.SkippedCount,
+		BatchID:       batch.ID,
+	})
+}
+
+// importPriceHistoryFromCSV stores one bar per row; rows without a usable close and days
+// that already have a price are skipped
+func (s *Server) importPriceHistoryFromCSV(sess *importSession, file io.Reader, defaultSymbol string) (importedCount int, skippedCount int, err error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := matchCSVColumns(header, priceColumnAliases)
+	if _, ok := columns["date"]; !ok {
+		return 0, 0, fmt.Errorf("CSV must have a date column")
+	}
+	if _, ok := columns["close"]; !ok {
+		return 0, 0, fmt.Errorf("CSV must have a close column")
+	}
+	if _, ok := columns["symbol"]; !ok && defaultSymbol == "" {
+		return 0, 0, fmt.Errorf("CSV has no symbol column and no symbol was given")
+	}
+
+	optional := func(row csvRow, field string, rowNum int) (*float64, error) {
+		value := row.get(field)
+		if value == "" || strings.EqualFold(value, "null") {
+			return nil, nil
+		}
+		number, err := parseCSVNumber(value)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid %s '%s'", rowNum, field, value)
+		}
+		return &number, nil
+	}
+
+	knownSymbols := map[string]bool{}
+	rowNum := 1
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		rowNum++
+		if readErr != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: %w", rowNum, readErr)
+		}
+
+		row := csvRow{record: record, columns: columns}
+		symbol := strings.ToUpper(row.get("symbol"))
+		if symbol == "" {
+			symbol = defaultSymbol
+		}
+		closeValue := row.get("close")
+		if row.get("date") == "" || closeValue == "" || strings.EqualFold(closeValue, "null") {
+			skippedCount++
+			continue
+		}
+
+		date, err := parseCSVDate(row.get("date"))
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: %w", rowNum, err)
+		}
+		closePrice, err := parseCSVNumber(closeValue)
+		if err != nil || closePrice <= 0 {
+			return importedCount, skippedCount, fmt.Errorf("row %d: invalid close '%s'", rowNum, closeValue)
+		}
+
+		bar := &models.PriceBar{Symbol: symbol, Date: date, Close: closePrice, Source: models.PriceSourceImport}
+		if bar.Open, err = optional(row, "open", rowNum); err != nil {
+			return importedCount, skippedCount, err
+		}
+		if bar.High, err = optional(row, "high", rowNum); err != nil {
+			return importedCount, skippedCount, err
+		}
+		if bar.Low, err = optional(row, "low", rowNum); err != nil {
+			return importedCount, skippedCount, err
+		}
+		if bar.Volume, err = optional(row, "volume", rowNum); err != nil {
+			return importedCount, skippedCount, err
+		}
+
+		if !knownSymbols[symbol] {
+			if err := sess.ensureSymbolExists(symbol); err != nil {
+				return importedCount, skippedCount, err
+			}
+			knownSymbols[symbol] = true
+		}
+		created, err := sess.priceHistoryService.CreateImported(bar, sess.batchID)
+		if err != nil {
+			return importedCount, skippedCount, fmt.Errorf("row %d: %w", rowNum, err)
+		}
+		if !created {
+			skippedCount++
+			log.Printf("[PRICE_IMPORT] Row %d: Skipped %s on %s, a price is already stored", rowNum, symbol, date.Format("2006-01-02"))
+			continue
+		}
+		importedCount++
+	}
+
+	return importedCount, skippedCount, nil
+}
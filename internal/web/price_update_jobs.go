@@ -0,0 +1,260 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Bulk price update job statuses
+const (
+	PriceJobRunning   = "running"
+	PriceJobCompleted = "completed"
+	PriceJobCancelled = "cancelled"
+	PriceJobFailed    = "failed"
+)
+
+// Event types streamed for a bulk price update
+const (
+	PriceEventProgress = "progress"
+	PriceEventDone     = "done"
+)
+
+// maxFinishedPriceJobs is how many finished jobs are kept for status lookups
+const maxFinishedPriceJobs = 10
+
+// PriceUpdateEvent is one step of a bulk price update
+type PriceUpdateEvent struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Symbol    string `json:"symbol,omitempty"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Done      int    `json:"done"`
+	Remaining int    `json:"remaining"`
+	Total     int    `json:"total"`
+	Updated   int    `json:"updated"`
+	Failed    int    `json:"failed"`
+	Status    string `json:"status"`
+}
+
+// PriceUpdateJobStatus is a snapshot of a bulk price update for the polling endpoint
+type PriceUpdateJobStatus struct {
+	ID         string     `json:"job_id"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Done       int        `json:"done"`
+	Remaining  int        `json:"remaining"`
+	Updated    int        `json:"updated"`
+	Failed     int        `json:"failed"`
+	Errors     []string   `json:"errors,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	StatusURL  string     `json:"status_url"`
+	EventsURL  string     `json:"events_url"`
+}
+
+// PriceUpdateJob is a bulk price update running in the background. Every step is kept
+// as an event so clients that connect late, or reconnect, can replay what they missed.
+type PriceUpdateJob struct {
+	mu         sync.Mutex
+	id         string
+	total      int
+	status     string
+	updated    int
+	failed     int
+	errors     []string
+	err        string
+	startedAt  time.Time
+	finishedAt time.Time
+	events     []PriceUpdateEvent
+	changed    chan struct{}
+	cancel     context.CancelFunc
+}
+
+// ID returns the job ID
+func (j *PriceUpdateJob) ID() string {
+	return j.id
+}
+
+// Cancel stops the job after the symbol in progress
+func (j *PriceUpdateJob) Cancel() {
+	j.cancel()
+}
+
+// record adds the outcome for one symbol
+func (j *PriceUpdateJob) record(symbol string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	event := PriceUpdateEvent{Type: PriceEventProgress, Symbol: symbol, Success: err == nil}
+	if err != nil {
+		j.failed++
+		event.Error = err.Error()
+		j.errors = append(j.errors, symbol+": "+err.Error())
+	} else {
+		j.updated++
+	}
+	j.append(event)
+}
+
+// finish marks the job as done; a cancelled context means the user stopped it
+func (j *PriceUpdateJob) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.finishedAt = time.Now()
+	switch {
+	case err == nil:
+		j.status = PriceJobCompleted
+	case errors.Is(err, context.Canceled):
+		j.status = PriceJobCancelled
+	default:
+		j.status = PriceJobFailed
+		j.err = err.Error()
+	}
+	j.append(PriceUpdateEvent{Type: PriceEventDone, Success: err == nil, Error: j.err})
+}
+
+// append fills in the running totals, stores the event and wakes waiting streams; j.mu must be held
+func (j *PriceUpdateJob) append(event PriceUpdateEvent) {
+	event.ID = len(j.events)
+	event.Done = j.updated + j.failed
+	event.Remaining = j.total - event.Done
+	event.Total = j.total
+	event.Updated = j.updated
+	event.Failed = j.failed
+	event.Status = j.status
+	j.events = append(j.events, event)
+
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// eventsSince returns events from index next on, whether the job has finished, and
+// a channel that is closed when more events arrive
+func (j *PriceUpdateJob) eventsSince(next int) ([]PriceUpdateEvent, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var events []PriceUpdateEvent
+	if next < len(j.events) {
+		events = append(events, j.events[next:]...)
+	}
+	return events, j.status != PriceJobRunning, j.changed
+}
+
+// Status returns a snapshot of the job
+func (j *PriceUpdateJob) Status() *PriceUpdateJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := &PriceUpdateJobStatus{
+		ID:        j.id,
+		Status:    j.status,
+		Total:     j.total,
+		Done:      j.updated + j.failed,
+		Remaining: j.total - j.updated - j.failed,
+		Updated:   j.updated,
+		Failed:    j.failed,
+		Errors:    append([]string(nil), j.errors...),
+		Error:     j.err,
+		StartedAt: j.startedAt,
+		StatusURL: "/api/polygon/update-prices/" + j.id,
+		EventsURL: "/api/polygon/update-prices/" + j.id + "/events",
+	}
+	if !j.finishedAt.IsZero() {
+		finished := j.finishedAt
+		status.FinishedAt = &finished
+	}
+	return status
+}
+
+// priceUpdateJobs tracks bulk price updates; only one runs at a time since they share the provider's quota
+type priceUpdateJobs struct {
+	mu    sync.Mutex
+	jobs  map[string]*PriceUpdateJob
+	order []string
+}
+
+func newPriceUpdateJobs() *priceUpdateJobs {
+	return &priceUpdateJobs{jobs: make(map[string]*PriceUpdateJob)}
+}
+
+// start runs update in the background for total symbols. If a job is already
+// running it is returned instead, with started false.
+func (m *priceUpdateJobs) start(total int, update func(ctx context.Context, job *PriceUpdateJob) error) (job *PriceUpdateJob, started bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range m.order {
+		if existing := m.jobs[id]; existing.Status().Status == PriceJobRunning {
+			return existing, false
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job = &PriceUpdateJob{
+		id:        newJobID(),
+		total:     total,
+		status:    PriceJobRunning,
+		startedAt: time.Now(),
+		changed:   make(chan struct{}),
+		cancel:    cancel,
+	}
+	m.jobs[job.id] = job
+	m.order = append(m.order, job.id)
+
+	// Forget the oldest finished jobs
+	for len(m.order) > maxFinishedPriceJobs+1 {
+		delete(m.jobs, m.order[0])
+		m.order = m.order[1:]
+	}
+
+	go func() {
+		defer cancel()
+		job.finish(update(ctx, job))
+	}()
+	return job, true
+}
+
+// get returns a job by ID, or nil
+func (m *priceUpdateJobs) get(id string) *PriceUpdateJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id]
+}
+
+// list returns snapshots of known jobs, newest first
+func (m *priceUpdateJobs) list() []*PriceUpdateJobStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]*PriceUpdateJobStatus, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		statuses = append(statuses, m.jobs[m.order[i]].Status())
+	}
+	return statuses
+}
+
+// cancelAll stops every running job, for example before the database is closed
+func (m *priceUpdateJobs) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		job.Cancel()
+	}
+}
+
+// newJobID returns a random hex identifier
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
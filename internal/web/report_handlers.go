@@ -0,0 +1,216 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/xlsx"
+	"strconv"
+	"time"
+)
+
+// monthlyExportXLSXHandler downloads the monthly view for the requested from/to months as a spreadsheet
+func (s *Server) monthlyExportXLSXHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := s.loadMonthlyData(r)
+	log.Printf("[REPORT] Exporting monthly report %s to %s", data.SelectedFromDate, data.SelectedToDate)
+
+	filename := fmt.Sprintf("monthly_%s_to_%s.xlsx", data.SelectedFromDate, data.SelectedToDate)
+	s.writeWorkbook(w, buildMonthlyWorkbook(data), filename)
+}
+
+// symbolExportXLSXHandler downloads the symbol page's summary and trade history as a spreadsheet
+func (s *Server) symbolExportXLSXHandler(w http.ResponseWriter, r *http.Request, symbol string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data := s.buildSymbolData(symbol)
+	log.Printf("[REPORT] Exporting symbol report for %s", symbol)
+
+	filename := fmt.Sprintf("%s_%s.xlsx", symbol, time.Now().Format("2006-01-02"))
+	s.writeWorkbook(w, buildSymbolWorkbook(data), filename)
+}
+
+// writeWorkbook encodes the workbook in memory so a failure can still be reported as an error response
+func (s *Server) writeWorkbook(w http.ResponseWriter, wb *xlsx.Workbook, filename string) {
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		log.Printf("[REPORT] Error writing %s: %v", filename, err)
+		http.Error(w, fmt.Sprintf("Failed to build spreadsheet: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", xlsx.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", buf.Len()))
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("[REPORT] Error writing report response: %v", err)
+	}
+}
+
+// buildMonthlyWorkbook lays out the monthly view with one sheet per section
+func buildMonthlyWorkbook(data MonthlyData) *xlsx.Workbook {
+	wb := xlsx.New()
+
+	// Premiums by ticker (rows) and month (columns), matching the table on the page
+	premiums := wb.AddSheet("Premiums")
+	premiums.AddHeader(append(append([]string{"Ticker"}, data.TableMonthLabels...), "Total")...)
+	widths := []float64{12}
+	for range data.TableYearMonths {
+		widths = append(widths, 13)
+	}
+	premiums.SetColumnWidths(append(widths, 14)...)
+	for _, row := range data.TableData {
+		cells := []xlsx.Cell{xlsx.Text(row.Ticker)}
+		for _, ym := range data.TableYearMonths {
+			cells = append(cells, xlsx.Currency(row.MonthValues[ym]))
+		}
+		premiums.AddRow(append(cells, xlsx.BoldCurrency(row.Total))...)
+	}
+	totals := []xlsx.Cell{xlsx.Bold("Total")}
+	for _, ym := range data.TableYearMonths {
+		totals = append(totals, xlsx.BoldCurrency(data.TableTotalsByMonth[ym]))
+	}
+	premiums.AddRow(append(totals, xlsx.BoldCurrency(data.GrandTotal))...)
+
+	addMonthlySectionSheets(wb, "Puts", data.PutsData.ByMonth, data.PutsData.ByTicker)
+	addMonthlySectionSheets(wb, "Calls", data.CallsData.ByMonth, data.CallsData.ByTicker)
+	addMonthlySectionSheets(wb, "Dividends", data.DividendsData.ByMonth, data.DividendsData.ByTicker)
+	addMonthlySectionSheets(wb, "Long Gains", data.CapGainsData.ByMonth, data.CapGainsData.ByTicker)
+
+	return wb
+}
+
+// addMonthlySectionSheets adds the by-month and by-ticker breakdowns of one monthly chart section
+func addMonthlySectionSheets(wb *xlsx.Workbook, section string, byMonth []MonthlyChartData, byTicker []TickerChartData) {
+	monthSheet := wb.AddSheet(section + " by Month")
+	monthSheet.SetColumnWidths(14, 14)
+	monthSheet.AddHeader("Month", "Amount")
+	var total float64
+	for _, m := range byMonth {
+		monthSheet.AddRow(xlsx.Text(m.Month), xlsx.Currency(m.Amount))
+		total += m.Amount
+	}
+	monthSheet.AddRow(xlsx.Bold("Total"), xlsx.BoldCurrency(total))
+
+	tickerSheet := wb.AddSheet(section + " by Ticker")
+	tickerSheet.SetColumnWidths(12, 14)
+	tickerSheet.AddHeader("Ticker", "Amount")
+	total = 0
+	for _, t := range byTicker {
+		tickerSheet.AddRow(xlsx.Text(t.Ticker), xlsx.Currency(t.Amount))
+		total += t.Amount
+	}
+	tickerSheet.AddRow(xlsx.Bold("Total"), xlsx.BoldCurrency(total))
+}
+
+// buildSymbolWorkbook lays out the symbol page with a summary and one sheet per trade history section
+func buildSymbolWorkbook(data SymbolData) *xlsx.Workbook {
+	wb := xlsx.New()
+
+	summary := wb.AddSheet("Summary")
+	summary.SetColumnWidths(18, 16)
+	summary.AddHeader("Symbol", data.Symbol)
+	summary.AddRow(xlsx.Text("Price"), xlsx.Currency(data.Price))
+	summary.AddRow(xlsx.Text("Dividend"), xlsx.Currency(data.Dividend))
+	summary.AddRow(xlsx.Text("Yield"), xlsx.Percent(data.Yield))
+	summary.AddRow(xlsx.Text("Ex-Dividend Date"), xlsx.OptionalDate(data.ExDividendDate))
+	if data.HasPERatio {
+		summary.AddRow(xlsx.Text("P/E Ratio"), xlsx.Number(data.PERatioValue))
+	} else {
+		summary.AddRow(xlsx.Text("P/E Ratio"))
+	}
+	summary.AddRow(xlsx.Text("Options Gains"), xlsx.Currency(parseAmount(data.OptionsGains)))
+	summary.AddRow(xlsx.Text("Cap Gains"), xlsx.Currency(parseAmount(data.CapGains)))
+	summary.AddRow(xlsx.Text("Dividends"), xlsx.Currency(data.DividendsTotal))
+	summary.AddRow(xlsx.Bold("Total Profits"), xlsx.BoldCurrency(parseAmount(data.TotalProfits)))
+	summary.AddRow(xlsx.Text("Cash on Cash"), xlsx.Percent(parseAmount(data.CashOnCash)))
+
+	options := wb.AddSheet("Options")
+	options.SetColumnWidths(8, 12, 12, 12, 10, 12, 10, 10, 10, 11, 12, 22)
+	options.AddHeader("Type", "Opened", "Closed", "Expiration", "Strike", "Premium", "Contracts",
+		"Exit Price", "Commission", "Profit", "AROI", "OCC Symbol")
+	var optionsTotal float64
+	for _, o := range data.OptionsList {
+		profit := o.CalculateTotalProfit()
+		optionsTotal += profit
+		options.AddRow(
+			xlsx.Text(o.Type),
+			xlsx.Date(o.Opened),
+			xlsx.OptionalDate(o.Closed),
+			xlsx.Date(o.Expiration),
+			xlsx.Currency(o.Strike),
+			xlsx.Currency(o.Premium),
+			xlsx.Integer(o.Contracts),
+			xlsx.OptionalCurrency(o.ExitPrice),
+			xlsx.Currency(o.Commission),
+			xlsx.Currency(profit),
+			xlsx.Percent(o.CalculateAROI()),
+			xlsx.Text(o.GetOCCSymbol()),
+		)
+	}
+	options.AddRow(xlsx.Bold("Total"), xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{},
+		xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.BoldCurrency(optionsTotal))
+
+	positions := wb.AddSheet("Stock Positions")
+	positions.SetColumnWidths(12, 12, 10, 12, 12, 14, 14)
+	positions.AddHeader("Opened", "Closed", "Shares", "Buy Price", "Exit Price", "Invested", "Gain/Loss")
+	var invested, gains float64
+	for _, lp := range data.LongPositionsList {
+		// Open positions are valued at the current price, as on the symbol page
+		gain := lp.CalculateProfitLoss(data.Price)
+		invested += lp.CalculateTotalInvested()
+		gains += gain
+		positions.AddRow(
+			xlsx.Date(lp.Opened),
+			xlsx.OptionalDate(lp.Closed),
+			xlsx.Integer(lp.Shares),
+			xlsx.Currency(lp.BuyPrice),
+			xlsx.OptionalCurrency(lp.ExitPrice),
+			xlsx.Currency(lp.CalculateTotalInvested()),
+			xlsx.Currency(gain),
+		)
+	}
+	positions.AddRow(xlsx.Bold("Total"), xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{},
+		xlsx.BoldCurrency(invested), xlsx.BoldCurrency(gains))
+
+	dividends := wb.AddSheet("Dividends")
+	dividends.SetColumnWidths(14, 14)
+	dividends.AddHeader("Received", "Amount")
+	for _, d := range data.DividendsList {
+		dividends.AddRow(xlsx.Date(d.Received), xlsx.Currency(d.Amount))
+	}
+	dividends.AddRow(xlsx.Bold("Total"), xlsx.BoldCurrency(data.DividendsTotal))
+
+	monthly := wb.AddSheet("Monthly Results")
+	monthly.SetColumnWidths(12, 8, 12, 8, 12, 14)
+	monthly.AddHeader("Month", "Puts", "Puts Total", "Calls", "Calls Total", "Total")
+	var monthlyTotal float64
+	for _, m := range data.MonthlyResults {
+		monthlyTotal += m.Total
+		monthly.AddRow(
+			xlsx.Text(m.Month),
+			xlsx.Integer(m.PutsCount),
+			xlsx.Currency(m.PutsTotal),
+			xlsx.Integer(m.CallsCount),
+			xlsx.Currency(m.CallsTotal),
+			xlsx.Currency(m.Total),
+		)
+	}
+	monthly.AddRow(xlsx.Bold("Total"), xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.Cell{}, xlsx.BoldCurrency(monthlyTotal))
+
+	return wb
+}
+
+// parseAmount reads back a figure that SymbolData carries pre-formatted for the template
+func parseAmount(formatted string) float64 {
+	value, _ := strconv.ParseFloat(formatted, 64)
+	return value
+}
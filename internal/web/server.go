@@ -7,19 +7,25 @@ import (
 	"html/template"
 	"log"
 	"net/http"
-	"path/filepath"
 	"sort"
 	"strconv"
+	"stonks/internal/alerts"
 	"stonks/internal/database"
+	"stonks/internal/marketdata"
 	"stonks/internal/models"
+	"stonks/internal/notify"
 	"stonks/internal/polygon"
+	"stonks/internal/scheduler"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Server struct {
+	dbLock              sync.RWMutex // Held for writing while the current database is switched
+	switching           switchSignal // Cancels provider-bound requests when a switch starts
 	db                  *sql.DB
 	optionService       *models.OptionService
 	symbolService       *models.SymbolService
@@ -29,8 +35,25 @@ type Server struct {
 	settingService      *models.SettingService
 	configService       *models.ConfigService
 	metricService       *models.MetricService
-	polygonService      *polygon.Service
-	templates           *template.Template
+	importBatchService  *models.ImportBatchService
+	priceHistoryService *models.PriceHistoryService
+	apiCacheService     *models.APICacheService
+	polygonProvider     *polygon.Provider
+	marketDataService   *marketdata.Service
+	markService         *marketdata.MarkService
+	earningsService     *models.EarningsService
+	calendarService     *marketdata.CalendarService
+	watchlistService    *models.WatchlistService
+	alertRuleService    *models.AlertRuleService
+	alertService        *models.AlertService
+	alertEngine         *alerts.Engine
+	channelService      *models.NotificationChannelService
+	deliveryService     *models.NotificationDeliveryService
+	notifier            *notify.Notifier
+	jobRunService       *models.JobRunService
+	scheduler           *scheduler.Scheduler
+	priceJobs           *priceUpdateJobs
+	templates           *templateSet
 }
 
 func NewServer() (*Server, error) {
@@ -50,8 +73,11 @@ func NewServer() (*Server, error) {
 	log.Printf("[SERVER] Database connection established successfully")
 
 	// Load templates with custom functions
-	templatePath := filepath.Join("internal", "web", "templates", "*.html")
-	log.Printf("[SERVER] Loading HTML templates from: %s", templatePath)
+	if devDir != "" {
+		log.Printf("[SERVER] Dev mode: loading templates and static assets from %s", devDir)
+	} else {
+		log.Printf("[SERVER] Loading embedded HTML templates")
+	}
 	
 	// Create template with custom functions
 	funcMap := template.FuncMap{
@@ -156,6 +182,11 @@ func NewServer() (*Server, error) {
 			switch v := value.(type) {
 			case float64:
 				floatVal = v
+			case *float64:
+				if v == nil {
+					return "$0.00"
+				}
+				floatVal = *v
 			case int:
 				floatVal = float64(v)
 			case string:
@@ -238,7 +269,7 @@ func NewServer() (*Server, error) {
 		},
 	}
 	
-	templates, err := template.New("").Funcs(funcMap).ParseGlob(templatePath)
+	templates, err := loadTemplates(funcMap)
 	if err != nil {
 		log.Printf("[SERVER] ERROR: Failed to parse templates: %v", err)
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
@@ -247,23 +278,12 @@ func NewServer() (*Server, error) {
 
 	log.Printf("[SERVER] Initializing service layers")
 	
-	// Initialize core services
-	symbolService := models.NewSymbolService(dbWrapper.DB)
-	settingService := models.NewSettingService(dbWrapper.DB)
-
-	server := &Server{
-		db:                  dbWrapper.DB,
-		optionService:       models.NewOptionService(dbWrapper.DB),
-		symbolService:       symbolService,
-		treasuryService:     models.NewTreasuryService(dbWrapper.DB),
-		longPositionService: models.NewLongPositionService(dbWrapper.DB),
-		dividendService:     models.NewDividendService(dbWrapper.DB),
-		settingService:      settingService,
-		configService:       models.NewConfigService(dbWrapper.DB),
-		metricService:       models.NewMetricService(dbWrapper.DB),
-		polygonService:      polygon.NewService(symbolService, settingService),
-		templates:           templates,
-	}
+	server := &Server{templates: templates}
+	server.initServices(dbWrapper.DB)
+	server.scheduler = scheduler.New(server.configService, server.jobRunService)
+	server.scheduler.SetGuard(server.dbLock.RLocker())
+	server.registerJobs()
+	server.priceJobs = newPriceUpdateJobs()
 
 	log.Printf("[SERVER] All services initialized successfully")
 	log.Printf("[SERVER] Server creation completed")
@@ -271,8 +291,10 @@ func NewServer() (*Server, error) {
 	return server, nil
 }
 
-// Close closes the database connection
+// Close stops background jobs and closes the database connection
 func (s *Server) Close() error {
+	s.scheduler.Stop()
+	s.priceJobs.cancelAll()
 	if s.db != nil {
 		log.Printf("[SERVER] Closing database connection")
 		return s.db.Close()
@@ -284,7 +306,12 @@ func (s *Server) setupRoutes() {
 	log.Printf("[SERVER] Setting up HTTP routes")
 
 	// Serve static files (CSS, JS, images)
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("internal/web/static"))))
+	static, err := staticHandler()
+	if err != nil {
+		log.Printf("[SERVER] ERROR: %v", err)
+	} else {
+		http.Handle("/static/", http.StripPrefix("/static/", static))
+	}
 	log.Printf("[SERVER] Route registered: /static/ -> file server")
 
 	http.HandleFunc("/", s.dashboardHandler)
@@ -292,6 +319,8 @@ func (s *Server) setupRoutes() {
 
 	http.HandleFunc("/monthly", s.monthlyHandler)
 	log.Printf("[SERVER] Route registered: /monthly -> monthlyHandler")
+	http.HandleFunc("/monthly/export.xlsx", s.monthlyExportXLSXHandler)
+	log.Printf("[SERVER] Route registered: /monthly/export.xlsx -> monthlyExportXLSXHandler")
 
 	http.HandleFunc("/options", s.optionsHandler)
 	log.Printf("[SERVER] Route registered: /options -> optionsHandler")
@@ -299,6 +328,24 @@ func (s *Server) setupRoutes() {
 	http.HandleFunc("/all-options", s.allOptionsHandler)
 	log.Printf("[SERVER] Route registered: /all-options -> allOptionsHandler")
 
+	http.HandleFunc("/option-chain", s.optionChainPageHandler)
+	log.Printf("[SERVER] Route registered: /option-chain -> optionChainPageHandler")
+
+	http.HandleFunc("/watchlist", s.watchlistHandler)
+	log.Printf("[SERVER] Route registered: /watchlist -> watchlistHandler")
+
+	http.HandleFunc("/alerts", s.alertsPageHandler)
+	log.Printf("[SERVER] Route registered: /alerts -> alertsPageHandler")
+
+	http.HandleFunc("/digest", s.digestHandler)
+	log.Printf("[SERVER] Route registered: /digest -> digestHandler")
+
+	http.HandleFunc("/portfolios", s.portfoliosHandler)
+	log.Printf("[SERVER] Route registered: /portfolios -> portfoliosHandler")
+
+	http.HandleFunc("/api/portfolios", s.portfoliosAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/portfolios -> portfoliosAPIHandler")
+
 	http.HandleFunc("/treasuries", s.treasuriesHandler)
 	log.Printf("[SERVER] Route registered: /treasuries -> treasuriesHandler")
 
@@ -323,6 +370,33 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/api/options/filter", s.optionsFilterHandler)
 	log.Printf("[SERVER] Route registered: /api/options/filter -> optionsFilterHandler")
 
+	http.HandleFunc("/api/options/chain/", s.optionChainAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/options/chain/ -> optionChainAPIHandler")
+
+	http.HandleFunc("/api/options/screener", s.optionScreenerAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/options/screener -> optionScreenerAPIHandler")
+
+	http.HandleFunc("/api/earnings", s.earningsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/earnings -> earningsAPIHandler")
+
+	http.HandleFunc("/api/watchlist", s.watchlistAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/watchlist -> watchlistAPIHandler")
+
+	http.HandleFunc("/api/watchlist/", s.watchlistItemAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/watchlist/ -> watchlistItemAPIHandler")
+
+	http.HandleFunc("/api/alerts", s.alertsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts -> alertsAPIHandler")
+
+	http.HandleFunc("/api/alerts/", s.alertsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/alerts/ -> alertsAPIHandler")
+
+	http.HandleFunc("/api/notifications/", s.notificationsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/notifications/ -> notificationsAPIHandler")
+
+	http.HandleFunc("/calendar.ics", s.calendarFeedHandler)
+	log.Printf("[SERVER] Route registered: /calendar.ics -> calendarFeedHandler")
+
 	http.HandleFunc("/api/symbols/", s.symbolAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/symbols/ -> symbolAPIHandler")
 
@@ -383,10 +457,15 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/backup", s.HandleBackup)
 	log.Printf("[SERVER] Route registered: /backup -> HandleBackup")
 
+	http.HandleFunc("/export", s.HandleExport)
+	log.Printf("[SERVER] Route registered: /export -> HandleExport")
+	http.HandleFunc("/export/ledger", s.HandleLedgerExport)
+	log.Printf("[SERVER] Route registered: /export/ledger -> HandleLedgerExport")
+
 	http.HandleFunc("/backup/", s.HandleBackupFile)
 	log.Printf("[SERVER] Route registered: /backup/ -> HandleBackupFile")
 
-	http.HandleFunc("/database/set-current", s.handleSetCurrentDatabase)
+	http.HandleFunc(switchDatabasePath, s.handleSetCurrentDatabase)
 	log.Printf("[SERVER] Route registered: /database/set-current -> handleSetCurrentDatabase")
 
 	http.HandleFunc("/database/create", s.handleCreateDatabase)
@@ -395,7 +474,7 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/database/delete/", s.handleDeleteDatabase)
 	log.Printf("[SERVER] Route registered: /database/delete/ -> handleDeleteDatabase")
 
-	http.Handle("/backups/", http.StripPrefix("/backups/", http.FileServer(http.Dir("./data/backups"))))
+	http.Handle("/backups/", http.StripPrefix("/backups/", http.FileServer(http.Dir(backupDirectory()))))
 	log.Printf("[SERVER] Route registered: /backups/ -> file server for backup directory")
 
 	http.HandleFunc("/import/upload", s.HandleImportUpload)
@@ -409,6 +488,18 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 
 	http.HandleFunc("/import/upload/treasuries", s.HandleTreasuriesImportUpload)
 	log.Printf("[SERVER] Route registered: /import/upload/treasuries -> HandleTreasuriesImportUpload")
+	http.HandleFunc("/import/upload/treasurydirect", s.HandleTreasuryDirectImportUpload)
+	log.Printf("[SERVER] Route registered: /import/upload/treasurydirect -> HandleTreasuryDirectImportUpload")
+	http.HandleFunc("/import/upload/bonds", s.HandleBondPositionsImportUpload)
+	log.Printf("[SERVER] Route registered: /import/upload/bonds -> HandleBondPositionsImportUpload")
+	http.HandleFunc("/import/upload/prices", s.HandlePriceHistoryImportUpload)
+	log.Printf("[SERVER] Route registered: /import/upload/prices -> HandlePriceHistoryImportUpload")
+
+	http.HandleFunc("/api/import/batches", s.HandleImportBatches)
+	log.Printf("[SERVER] Route registered: /api/import/batches -> HandleImportBatches")
+
+	http.HandleFunc("/api/import/batches/", s.HandleImportBatchRevert)
+	log.Printf("[SERVER] Route registered: /api/import/batches/ -> HandleImportBatchRevert")
 
 	http.HandleFunc("/api/generate-test-data", s.HandleGenerateTestData)
 	log.Printf("[SERVER] Route registered: /api/generate-test-data -> HandleGenerateTestData")
@@ -431,6 +522,15 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/api/config", s.configAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/config -> configAPIHandler")
 
+	http.HandleFunc("/jobs", s.jobsPageHandler)
+	log.Printf("[SERVER] Route registered: /jobs -> jobsPageHandler")
+
+	http.HandleFunc("/api/jobs", s.jobsAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/jobs -> jobsAPIHandler")
+
+	http.HandleFunc("/api/jobs/", s.jobRunAPIHandler)
+	log.Printf("[SERVER] Route registered: /api/jobs/{name}/run -> jobRunAPIHandler")
+
 	http.HandleFunc("/api/settings/", s.individualSettingAPIHandler)
 	log.Printf("[SERVER] Route registered: /api/settings/ -> individualSettingAPIHandler")
 
@@ -440,6 +540,9 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/api/polygon/update-prices", s.polygonUpdatePricesHandler)
 	log.Printf("[SERVER] Route registered: /api/polygon/update-prices -> polygonUpdatePricesHandler")
 
+	http.HandleFunc("/api/polygon/update-prices/", s.polygonPriceJobHandler)
+	log.Printf("[SERVER] Route registered: /api/polygon/update-prices/ -> polygonPriceJobHandler")
+
 	http.HandleFunc("/api/polygon/symbol-info/", s.polygonSymbolInfoHandler)
 	log.Printf("[SERVER] Route registered: /api/polygon/symbol-info/ -> polygonSymbolInfoHandler")
 
@@ -449,6 +552,9 @@ http.HandleFunc("/symbol/", s.symbolHandler)
 	http.HandleFunc("/api/polygon/fetch-dividends", s.polygonFetchDividendsHandler)
 	log.Printf("[SERVER] Route registered: /api/polygon/fetch-dividends -> polygonFetchDividendsHandler")
 
+	http.HandleFunc("/api/polygon/cache", s.polygonCacheHandler)
+	log.Printf("[SERVER] Route registered: /api/polygon/cache -> polygonCacheHandler")
+
 	log.Printf("[SERVER] All routes registered successfully")
 }
 
@@ -462,7 +568,7 @@ func (s *Server) Start(port string) error {
 	fmt.Printf("🚀 Wheeler web application starting on http://localhost:%s\n", port)
 	fmt.Printf("   📈 Dashboard:    http://localhost:%s/\n", port)
 
-	return http.ListenAndServe(":"+port, nil)
+	return http.ListenAndServe(":"+port, s.Handler())
 }
 
 // SetupTestRoutes sets up routes for testing purposes
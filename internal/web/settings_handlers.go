@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 	"stonks/internal/models"
+	"stonks/internal/polygon"
 )
 
 // SettingsData holds data for the settings template
@@ -16,6 +17,19 @@ type SettingsData struct {
 	CurrentDB  string            `json:"currentDB"`
 	ApiKey     string            `json:"apiKey"`
 	ActivePage string            `json:"activePage"`
+
+	MarketDataProvider  string   `json:"marketDataProvider"`
+	MarketDataProviders []string `json:"marketDataProviders"`
+	MarketDataDir       string   `json:"marketDataDir"`
+
+	PolygonPlan              string                `json:"polygonPlan"`
+	PolygonRequestsPerMinute string                `json:"polygonRequestsPerMinute"`
+	EffectiveRequestsPerMin  int                   `json:"effectiveRequestsPerMin"`
+	CacheStats               *models.APICacheStats `json:"cacheStats"`
+
+	NotificationChannels   []*models.NotificationChannel  `json:"notificationChannels"`
+	NotificationDeliveries []*models.NotificationDelivery `json:"notificationDeliveries"`
+	NotificationTypes      []string                       `json:"notificationTypes"`
 }
 
 // SchwabData holds data for the Schwab settings template
@@ -78,7 +92,31 @@ func (s *Server) settingsHandler(w http.ResponseWriter, r *http.Request) {
 		CurrentDB:  s.getCurrentDatabaseName(),
 		ApiKey:     apiKey,
 		ActivePage: "settings",
+
+		MarketDataProvider:  s.marketDataService.ProviderName(),
+		MarketDataProviders: s.marketDataService.ProviderNames(),
+		MarketDataDir:       s.marketDataService.Directory(),
+
+		PolygonPlan:              s.settingService.GetValueWithDefault(polygon.SettingPlan, polygon.PlanFree),
+		PolygonRequestsPerMinute: s.settingService.GetValue(polygon.SettingRequestsPerMinute),
+		EffectiveRequestsPerMin:  s.polygonProvider.RequestsPerMinute(),
+	}
+
+	if stats, err := s.apiCacheService.Stats(); err != nil {
+		log.Printf("[SETTINGS] Error getting cache stats: %v", err)
+	} else {
+		data.CacheStats = stats
+	}
+
+	channels, err := s.channelService.GetAll()
+	if err != nil {
+		log.Printf("[SETTINGS] Error getting notification channels: %v", err)
+	}
+	data.NotificationChannels = redactChannels(channels)
+	if data.NotificationDeliveries, err = s.deliveryService.GetRecent(notificationDeliveryLimit); err != nil {
+		log.Printf("[SETTINGS] Error getting notification deliveries: %v", err)
 	}
+	data.NotificationTypes = models.NotificationChannelTypes
 
 	s.renderTemplate(w, "settings.html", data)
 }
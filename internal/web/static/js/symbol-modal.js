@@ -79,6 +79,7 @@ class SymbolModal {
             const dividendInput = document.getElementById('dividendInput');
             const exDividendDateInput = document.getElementById('exDividendDateInput');
             const peRatioInput = document.getElementById('peRatioInput');
+            const earningsDateInput = document.getElementById('earningsDateInput');
             
             if (symbolInput) {
                 symbolInput.value = symbolData.symbol;
@@ -88,6 +89,7 @@ class SymbolModal {
             if (dividendInput) dividendInput.value = symbolData.dividend || '';
             if (exDividendDateInput) exDividendDateInput.value = symbolData.ex_dividend_date || '';
             if (peRatioInput) peRatioInput.value = symbolData.pe_ratio || '';
+            if (earningsDateInput) earningsDateInput.value = symbolData.earnings_date || '';
         } else {
             if (this.symbolForm) {
                 this.symbolForm.reset();
@@ -121,6 +123,7 @@ class SymbolModal {
         const dividendInput = document.getElementById('dividendInput');
         const exDividendDateInput = document.getElementById('exDividendDateInput');
         const peRatioInput = document.getElementById('peRatioInput');
+        const earningsDateInput = document.getElementById('earningsDateInput');
         
         if (!symbolInput) {
             console.error('Symbol input not found');
@@ -132,7 +135,9 @@ class SymbolModal {
             price: parseFloat(priceInput?.value) || 0,
             dividend: parseFloat(dividendInput?.value) || 0,
             ex_dividend_date: exDividendDateInput?.value || null,
-            pe_ratio: parseFloat(peRatioInput?.value) || null
+            pe_ratio: parseFloat(peRatioInput?.value) || null,
+            // Only sent when given so saving a symbol never clears dates it did not show
+            earnings_date: earningsDateInput?.value || null
         };
         
         const url = `/api/symbols/${symbolData.symbol}`;
@@ -145,7 +150,8 @@ class SymbolModal {
                 price: symbolData.price,
                 dividend: symbolData.dividend,
                 ex_dividend_date: symbolData.ex_dividend_date,
-                pe_ratio: symbolData.pe_ratio
+                pe_ratio: symbolData.pe_ratio,
+                earnings_date: symbolData.earnings_date
             })
         })
         .then(response => {
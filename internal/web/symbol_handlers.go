@@ -2,7 +2,6 @@ package web
 
 import (
 	"encoding/json"
-	"context"
 	"log"
 	"net/http"
 	"sort"
@@ -25,6 +24,15 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("[SYMBOL] ===== Starting symbol handler for: %s =====", symbol)
 
+	data := s.buildSymbolData(symbol)
+
+	log.Printf("[SYMBOL] Step 12: Rendering template for %s", symbol)
+	s.renderTemplate(w, "symbol.html", data)
+	log.Printf("[SYMBOL] ===== Completed symbol handler for: %s =====", symbol)
+}
+
+// buildSymbolData loads the positions, totals and monthly results shown on the symbol page
+func (s *Server) buildSymbolData(symbol string) SymbolData {
 	log.Printf("[SYMBOL] Step 1: Getting all symbols from database")
 	symbols, err := s.symbolService.GetDistinctSymbols()
 	if err != nil {
@@ -193,6 +201,11 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 	monthlyResults := s.buildSymbolMonthlyResults(optionsList)
 	log.Printf("[SYMBOL] Built %d monthly results for %s", len(monthlyResults), symbol)
 
+	nextEarnings, err := s.earningsService.GetNext(symbol, time.Now())
+	if err != nil {
+		log.Printf("[SYMBOL] Error getting next earnings for %s: %v", symbol, err)
+	}
+
 	log.Printf("[SYMBOL] Step 10: Creating template data for %s", symbol)
 	data := SymbolData{
 		Symbol:            symbol,
@@ -203,6 +216,7 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 		Price:             price,
 		Dividend:          dividend,
 		ExDividendDate:    exDividendDate,
+		NextEarnings:      nextEarnings,
 		PERatio:           peRatio,
 		PERatioValue:      peRatioValue,
 		HasPERatio:        hasPERatio,
@@ -229,9 +243,7 @@ func (s *Server) symbolHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[SYMBOL] Data counts: %d dividends, %d options, %d long positions, %d monthly results",
 		len(data.DividendsList), len(data.OptionsList), len(data.LongPositionsList), len(data.MonthlyResults))
 
-	log.Printf("[SYMBOL] Step 12: Rendering template for %s", symbol)
-	s.renderTemplate(w, "symbol.html", data)
-	log.Printf("[SYMBOL] ===== Completed symbol handler for: %s =====", symbol)
+	return data
 }
 
 // buildSymbolMonthlyResults creates monthly aggregation for a specific symbol's options based on opened date
@@ -371,6 +383,12 @@ func (s *Server) updateSymbolHandler(w http.ResponseWriter, r *http.Request) {
 	if updateReq.PERatio != nil {
 		peRatio = updateReq.PERatio
 	}
+	if updateReq.EarningsDate != nil && *updateReq.EarningsDate != "" {
+		if _, err := time.Parse("2006-01-02", *updateReq.EarningsDate); err != nil {
+			http.Error(w, "Invalid earnings date format", http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Update the symbol
 	updatedSymbol, err := s.symbolService.Update(symbol, price, dividend, exDividendDate, peRatio)
@@ -379,6 +397,14 @@ func (s *Server) updateSymbolHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updateReq.EarningsDate != nil {
+		if err := s.updateSymbolEarnings(symbol, *updateReq.EarningsDate, updateReq.EarningsTiming); err != nil {
+			log.Printf("[SYMBOL] Error saving earnings date for %s: %v", symbol, err)
+			http.Error(w, "Failed to save earnings date", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedSymbol)
 }
@@ -444,6 +470,22 @@ func (s *Server) symbolAPIHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check if this is a spreadsheet export request
+	if len(pathSegments) > 1 && pathSegments[1] == "export.xlsx" {
+		s.symbolExportXLSXHandler(w, r, symbol)
+		return
+	}
+
+	// Check if this is a price history request (chart data or provider backfill)
+	if len(pathSegments) > 1 && pathSegments[1] == "price-history" {
+		if len(pathSegments) > 2 && pathSegments[2] == "fetch" {
+			s.symbolFetchPriceHistoryHandler(w, r, symbol)
+		} else {
+			s.symbolPriceHistoryHandler(w, r, symbol)
+		}
+		return
+	}
+
 	// Check if this is a dividend data fetch request
 	if len(pathSegments) > 1 && pathSegments[1] == "fetch-dividends" {
 		s.symbolFetchDividendsHandler(w, r, symbol)
@@ -482,11 +524,11 @@ func (s *Server) symbolUpdatePriceHandler(w http.ResponseWriter, r *http.Request
 
 	log.Printf("[SYMBOL API] Updating price for symbol: %s", symbol)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := s.providerContext(r, 30*time.Second)
 	defer cancel()
 
-	// Update symbol price using Polygon service
-	err := s.polygonService.UpdateSymbolPrice(ctx, symbol)
+	// Update symbol price using the configured market data provider
+	err := s.marketDataService.UpdateSymbolPrice(ctx, symbol)
 	
 	response := map[string]interface{}{
 		"success": err == nil,
@@ -517,11 +559,11 @@ func (s *Server) symbolFetchDividendsHandler(w http.ResponseWriter, r *http.Requ
 
 	log.Printf("[SYMBOL API] Fetching dividends for symbol: %s", symbol)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := s.providerContext(r, 30*time.Second)
 	defer cancel()
 
-	// Fetch dividend data using Polygon service
-	dividends, err := s.polygonService.FetchDividendHistory(ctx, symbol, 10)
+	// Fetch dividend data using the configured market data provider
+	dividends, err := s.marketDataService.FetchDividendHistory(ctx, symbol, 10)
 	
 	response := map[string]interface{}{
 		"success": err == nil,
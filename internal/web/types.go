@@ -3,6 +3,7 @@ package web
 import (
 	"html/template"
 	"stonks/internal/models"
+	"stonks/internal/scheduler"
 	"time"
 )
 
@@ -18,6 +19,8 @@ type SymbolUpdateRequest struct {
 	Dividend       *float64 `json:"dividend,omitempty"`
 	ExDividendDate *string  `json:"ex_dividend_date,omitempty"`
 	PERatio        *float64 `json:"pe_ratio,omitempty"`
+	EarningsDate   *string  `json:"earnings_date,omitempty"`   // Next earnings, YYYY-MM-DD; empty clears
+	EarningsTiming *string  `json:"earnings_timing,omitempty"` // bmo, amc or empty
 }
 
 type TreasuryUpdateRequest struct {
@@ -34,6 +37,7 @@ type ImportResponse struct {
 	Success       bool   `json:"success"`
 	ImportedCount int    `json:"imported_count"`
 	SkippedCount  int    `json:"skipped_count"`
+	BatchID       int    `json:"batch_id,omitempty"`
 	Error         string `json:"error,omitempty"`
 	Details       string `json:"details,omitempty"`
 }
@@ -75,19 +79,25 @@ type CSVTreasuryRecord struct {
 	BuyPrice     string
 	CurrentValue string
 	ExitPrice    string
+	CouponRate   string
+	SecurityType string
+	IssueDate    string
 }
 
 // DashboardData holds data for the dashboard template
+
 type DashboardData struct {
-	Symbols         []string        `json:"symbols"`
-	AllSymbols      []string        `json:"allSymbols"`     // For navigation compatibility
-	SymbolSummaries []SymbolSummary `json:"symbolSummaries"`
-	LongByTicker    []ChartData     `json:"longByTicker"`
-	PutsByTicker    []ChartData     `json:"putsByTicker"`
-	TotalAllocation []ChartData     `json:"totalAllocation"`
-	Totals          DashboardTotals `json:"totals"`
-	CurrentDB       string          `json:"currentDB"`
-	ActivePage      string          `json:"activePage"`
+	Symbols         []string                   `json:"symbols"`
+	AllSymbols      []string                   `json:"allSymbols"` // For navigation compatibility
+	SymbolSummaries []SymbolSummary            `json:"symbolSummaries"`
+	LongByTicker    []ChartData                `json:"longByTicker"`
+	PutsByTicker    []ChartData                `json:"putsByTicker"`
+	TotalAllocation []ChartData                `json:"totalAllocation"`
+	Totals          DashboardTotals            `json:"totals"`
+	EarningsRisks   []*models.OpenPositionData `json:"earningsRisks"` // Open options expiring after an earnings date
+	NewAlerts       int                        `json:"newAlerts"`     // Alerts not yet acknowledged or snoozed
+	CurrentDB       string                     `json:"currentDB"`
+	ActivePage      string                     `json:"activePage"`
 }
 
 type SymbolSummary struct {
@@ -220,6 +230,7 @@ type OptionsData struct {
 	OptionsSummary []*models.OptionSummary    `json:"options_summary"`
 	OpenPositions  []*models.OpenPositionData `json:"open_positions"`
 	SummaryTotals  *models.OptionSummary      `json:"summary_totals"`
+	ProfitTarget   float64                    `json:"profit_target"` // Percent of max profit at which to consider closing
 	CurrentDB      string                     `json:"currentDB"`
 	ActivePage     string                     `json:"activePage"`
 }
@@ -263,6 +274,7 @@ type SymbolData struct {
 	Price             float64                `json:"price"`
 	Dividend          float64                `json:"dividend"`
 	ExDividendDate    *time.Time             `json:"exDividendDate"`
+	NextEarnings      *models.EarningsDate   `json:"nextEarnings"`
 	PERatio           *float64               `json:"peRatio"`
 	PERatioValue      float64                `json:"peRatioValue"`
 	HasPERatio        bool                   `json:"hasPERatio"`
@@ -405,10 +417,11 @@ type HelpData struct {
 
 // ImportData holds data for the import template
 type ImportData struct {
-	Symbols    []string `json:"symbols"`
-	AllSymbols []string `json:"allSymbols"` // For navigation compatibility
-	CurrentDB  string   `json:"currentDB"`
-	ActivePage string   `json:"activePage"`
+	Symbols    []string              `json:"symbols"`
+	AllSymbols []string              `json:"allSymbols"` // For navigation compatibility
+	Batches    []*models.ImportBatch `json:"batches"`
+	CurrentDB  string                `json:"currentDB"`
+	ActivePage string                `json:"activePage"`
 }
 
 // BackupData holds data for the backup template
@@ -473,6 +486,160 @@ type ConfigPageData struct {
 	Config     []*models.ConfigSetting `json:"config"`
 }
 
+// JobsPageData holds data for the scheduled jobs admin page template
+type JobsPageData struct {
+	AllSymbols []string               `json:"allSymbols"`
+	CurrentDB  string                 `json:"currentDB"`
+	ActivePage string                 `json:"activePage"`
+	Jobs       []*scheduler.JobStatus `json:"jobs"`
+	Timezone   string                 `json:"timezone"`
+}
+
+// OptionChainPageData holds data for the option chain and screener page template
+type OptionChainPageData struct {
+	AllSymbols     []string `json:"allSymbols"`
+	CurrentDB      string   `json:"currentDB"`
+	ActivePage     string   `json:"activePage"`
+	SelectedSymbol string   `json:"selectedSymbol"`
+	Provider       string   `json:"provider"`
+}
+
+// WatchlistPageData holds data for the watchlist template
+type WatchlistPageData struct {
+	AllSymbols []string                 `json:"allSymbols"`
+	CurrentDB  string                   `json:"currentDB"`
+	ActivePage string                   `json:"activePage"`
+	Items      []*WatchlistItemResponse `json:"items"`
+}
+
+// WatchlistRequest adds or updates a watchlist entry; blank targets are cleared
+type WatchlistRequest struct {
+	Symbol      string   `json:"symbol"`
+	TargetPrice *float64 `json:"target_price"`
+	TargetYield *float64 `json:"target_yield"`
+	Notes       string   `json:"notes"`
+}
+
+// AlertsPageData holds data for the alert inbox template
+type AlertsPageData struct {
+	AllSymbols []string              `json:"allSymbols"`
+	CurrentDB  string                `json:"currentDB"`
+	ActivePage string                `json:"activePage"`
+	Alerts     []*models.Alert       `json:"alerts"`
+	Rules      []*models.AlertRule   `json:"rules"`
+	RuleTypes  []AlertRuleTypeOption `json:"ruleTypes"`
+}
+
+// AlertRuleTypeOption is a rule type offered on the alerts page
+type AlertRuleTypeOption struct {
+	Value          string `json:"value"`
+	Label          string `json:"label"`
+	ThresholdLabel string `json:"thresholdLabel"`
+}
+
+// AlertRuleRequest creates or updates an alert rule; rules are enabled unless enabled is false
+type AlertRuleRequest struct {
+	Name      string  `json:"name"`
+	RuleType  string  `json:"rule_type"`
+	Symbol    string  `json:"symbol"`
+	Threshold float64 `json:"threshold"`
+	Enabled   *bool   `json:"enabled"`
+}
+
+// NotificationChannelRequest creates or updates a notification channel; channels are enabled
+// unless enabled is false. A blank password on update keeps the stored one.
+type NotificationChannelRequest struct {
+	Name        string            `json:"name"`
+	ChannelType string            `json:"channel_type"`
+	Config      map[string]string `json:"config"`
+	Enabled     *bool             `json:"enabled"`
+}
+
+// Digest is the daily portfolio summary, rendered as a page, as HTML email and as plain text
+type Digest struct {
+	Date             time.Time        `json:"date"`
+	WeekEnd          time.Time        `json:"weekEnd"` // Last expiration date counted as this week
+	ExpiringThisWeek []*models.Option `json:"expiringThisWeek"`
+	OpenedToday      []*models.Option `json:"openedToday"`
+	ClosedToday      []*models.Option `json:"closedToday"`
+	PremiumMTD       float64          `json:"premiumMTD"`
+	PremiumLastMonth float64          `json:"premiumLastMonth"` // The whole previous month
+	PremiumYTD       float64          `json:"premiumYTD"`
+	Totals           DashboardTotals  `json:"totals"`
+	Deployed         float64          `json:"deployed"`    // Put exposure plus long stock
+	Utilization      float64          `json:"utilization"` // Deployed as a percent of deployed plus treasuries
+	Alerts           []*models.Alert  `json:"alerts"`      // Alerts triggered today
+}
+
+// DigestPageData holds data for the digest template
+type DigestPageData struct {
+	AllSymbols []string `json:"allSymbols"`
+	CurrentDB  string   `json:"currentDB"`
+	ActivePage string   `json:"activePage"`
+	Digest     *Digest  `json:"digest"`
+}
+
+// ConsolidatedData combines several portfolio databases for the portfolios page
+type ConsolidatedData struct {
+	FromMonth   string              `json:"fromMonth"`
+	ToMonth     string              `json:"toMonth"`
+	Portfolios  []*PortfolioSummary `json:"portfolios"`
+	Totals      DashboardTotals     `json:"totals"`      // Dashboard totals across the portfolios that loaded
+	Months      []string            `json:"months"`      // yyyy-mm months with income in any portfolio
+	MonthLabels []string            `json:"monthLabels"` // Formatted labels ("2025 Jan", etc.)
+	Income      []float64           `json:"income"`      // Combined income for each of Months
+	TotalIncome float64             `json:"totalIncome"`
+	Exposure    []ExposureRow       `json:"exposure"` // Largest combined exposure first
+}
+
+// PortfolioSummary is one database's share of the consolidated view
+type PortfolioSummary struct {
+	Name        string          `json:"name"`
+	Current     bool            `json:"current"`
+	Error       string          `json:"error,omitempty"` // Why the portfolio could not be read
+	Totals      DashboardTotals `json:"totals"`
+	Income      []float64       `json:"income"` // Income for each of ConsolidatedData.Months
+	TotalIncome float64         `json:"totalIncome"`
+}
+
+// ExposureRow is a symbol's long plus put exposure in each portfolio
+type ExposureRow struct {
+	Ticker      string    `json:"ticker"`
+	ByPortfolio []float64 `json:"byPortfolio"` // In ConsolidatedData.Portfolios order
+	Total       float64   `json:"total"`
+}
+
+// PortfolioOption is a database in the portfolios page picker
+type PortfolioOption struct {
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+}
+
+// PortfoliosPageData holds data for the portfolios template
+type PortfoliosPageData struct {
+	AllSymbols   []string          `json:"allSymbols"`
+	CurrentDB    string            `json:"currentDB"`
+	ActivePage   string            `json:"activePage"`
+	Databases    []PortfolioOption `json:"databases"`
+	Consolidated ConsolidatedData  `json:"consolidated"`
+}
+
+// JobResponse represents a scheduled job in the jobs API
+type JobResponse struct {
+	Name          string           `json:"name"`
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	ConfigKey     string           `json:"config_key"`
+	Schedule      string           `json:"schedule"`
+	ScheduleError string           `json:"schedule_error,omitempty"`
+	Enabled       bool             `json:"enabled"`
+	NextRun       *time.Time       `json:"next_run"`
+	Running       bool             `json:"running"`
+	LastRun       *models.JobRun   `json:"last_run"`
+	LastError     *models.JobRun   `json:"last_error"`
+	Recent        []*models.JobRun `json:"recent"`
+}
+
 // PageData holds common data for all page templates
 type PageData struct {
 	Title      string   `json:"title"`
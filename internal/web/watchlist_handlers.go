@@ -0,0 +1,181 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"stonks/internal/models"
+	"strings"
+)
+
+// WatchlistItemResponse is a watchlist entry with its yield and distance to target
+type WatchlistItemResponse struct {
+	*models.WatchlistItem
+	Yield            float64  `json:"yield"`
+	EntryPrice       *float64 `json:"entry_price"`
+	DistanceToTarget *float64 `json:"distance_to_target"`
+	AtTarget         bool     `json:"at_target"`
+}
+
+func newWatchlistItemResponse(item *models.WatchlistItem) *WatchlistItemResponse {
+	return &WatchlistItemResponse{
+		WatchlistItem:    item,
+		Yield:            item.Yield(),
+		EntryPrice:       item.EntryPrice(),
+		DistanceToTarget: item.DistanceToTarget(),
+		AtTarget:         item.AtTarget(),
+	}
+}
+
+func newWatchlistResponse(items []*models.WatchlistItem) []*WatchlistItemResponse {
+	response := make([]*WatchlistItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newWatchlistItemResponse(item))
+	}
+	return response
+}
+
+// watchlistHandler serves the watchlist page
+func (s *Server) watchlistHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[WATCHLIST] Handling watchlist page request")
+
+	symbols, err := s.symbolService.GetDistinctSymbols()
+	if err != nil {
+		log.Printf("[WATCHLIST] Error getting symbols: %v", err)
+		symbols = []string{}
+	}
+
+	items, err := s.watchlistService.GetAll()
+	if err != nil {
+		log.Printf("[WATCHLIST] Error getting watchlist: %v", err)
+	}
+
+	data := WatchlistPageData{
+		AllSymbols: symbols,
+		CurrentDB:  s.getCurrentDatabaseName(),
+		ActivePage: "watchlist",
+		Items:      newWatchlistResponse(items),
+	}
+
+	s.renderTemplate(w, "watchlist.html", data)
+}
+
+// watchlistAPIHandler lists the watchlist (GET /api/watchlist) or adds to it (POST /api/watchlist)
+func (s *Server) watchlistAPIHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.watchlistService.GetAll()
+		if err != nil {
+			log.Printf("[WATCHLIST API] Error getting watchlist: %v", err)
+			http.Error(w, "Failed to get watchlist", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newWatchlistResponse(items))
+
+	case http.MethodPost:
+		var req WatchlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := validateWatchlistRequest(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Symbol) == "" {
+			http.Error(w, "Symbol is required", http.StatusBadRequest)
+			return
+		}
+
+		item, err := s.watchlistService.Add(req.Symbol, req.TargetPrice, req.TargetYield, req.Notes)
+		if err != nil {
+			log.Printf("[WATCHLIST API] Error adding %s: %v", req.Symbol, err)
+			http.Error(w, "Failed to add to watchlist", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[WATCHLIST API] Added %s to the watchlist", item.Symbol)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(newWatchlistItemResponse(item))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// watchlistItemAPIHandler serves one watchlist entry:
+// GET, PUT and DELETE /api/watchlist/{symbol}
+func (s *Server) watchlistItemAPIHandler(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/watchlist/"), "/"))
+	if symbol == "" || strings.Contains(symbol, "/") {
+		http.Error(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		item, err := s.watchlistService.GetBySymbol(symbol)
+		if err != nil {
+			http.Error(w, "Watchlist entry not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newWatchlistItemResponse(item))
+
+	case http.MethodPut:
+		var req WatchlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := validateWatchlistRequest(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		item, err := s.watchlistService.Update(symbol, req.TargetPrice, req.TargetYield, req.Notes)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				http.Error(w, "Watchlist entry not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("[WATCHLIST API] Error updating %s: %v", symbol, err)
+			http.Error(w, "Failed to update watchlist entry", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[WATCHLIST API] Updated %s on the watchlist", symbol)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newWatchlistItemResponse(item))
+
+	case http.MethodDelete:
+		if err := s.watchlistService.Delete(symbol); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				http.Error(w, "Watchlist entry not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("[WATCHLIST API] Error removing %s: %v", symbol, err)
+			http.Error(w, "Failed to remove from watchlist", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[WATCHLIST API] Removed %s from the watchlist", symbol)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validateWatchlistRequest rejects targets that are not positive
+func validateWatchlistRequest(req *WatchlistRequest) error {
+	if req.TargetPrice != nil && *req.TargetPrice <= 0 {
+		return fmt.Errorf("target price must be greater than zero")
+	}
+	if req.TargetYield != nil && *req.TargetYield <= 0 {
+		return fmt.Errorf("target yield must be greater than zero")
+	}
+	return nil
+}
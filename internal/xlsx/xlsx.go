@@ -0,0 +1,367 @@
+// Package xlsx writes simple Office Open XML spreadsheets using only the standard library.
+//
+// It supports what Wheeler's reports need: multiple sheets, text, numbers, dates,
+// currency and percent formats, bold header and total rows, column widths and a
+// frozen header row. Strings are written inline, so no shared string table is needed.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Style selects one of the predefined cell formats in the workbook stylesheet
+type Style int
+
+const (
+	StyleDefault Style = iota
+	StyleBold
+	StyleCurrency
+	StyleBoldCurrency
+	StylePercent
+	StyleDate
+	StyleInteger
+)
+
+// ContentType is the MIME type of an XLSX workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const (
+	maxSheetNameLength = 31
+	invalidSheetChars  = `[]:*?/\`
+)
+
+// excelEpoch is day zero of the 1900 date system as used by Excel for all modern dates
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Cell is a single spreadsheet value with its display style
+type Cell struct {
+	Value interface{} // string, float64, int or time.Time; nil leaves the cell empty
+	Style Style
+}
+
+// Text returns a text cell
+func Text(s string) Cell { return Cell{Value: s} }
+
+// Bold returns a bold text cell
+func Bold(s string) Cell { return Cell{Value: s, Style: StyleBold} }
+
+// Number returns a general number cell
+func Number(f float64) Cell { return Cell{Value: f} }
+
+// Integer returns a whole number cell
+func Integer(n int) Cell { return Cell{Value: n, Style: StyleInteger} }
+
+// Currency returns a dollar-formatted cell
+func Currency(f float64) Cell { return Cell{Value: f, Style: StyleCurrency} }
+
+// BoldCurrency returns a bold dollar-formatted cell for totals
+func BoldCurrency(f float64) Cell { return Cell{Value: f, Style: StyleBoldCurrency} }
+
+// Percent returns a percent cell from a value in percentage points (12.5 displays as 12.50%)
+func Percent(points float64) Cell { return Cell{Value: points / 100, Style: StylePercent} }
+
+// Date returns a date cell
+func Date(t time.Time) Cell { return Cell{Value: t, Style: StyleDate} }
+
+// OptionalDate returns a date cell, or an empty cell when t is nil
+func OptionalDate(t *time.Time) Cell {
+	if t == nil {
+		return Cell{}
+	}
+	return Date(*t)
+}
+
+// OptionalCurrency returns a currency cell, or an empty cell when f is nil
+func OptionalCurrency(f *float64) Cell {
+	if f == nil {
+		return Cell{}
+	}
+	return Currency(*f)
+}
+
+// Workbook is an in-memory spreadsheet made of one or more sheets
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// Sheet is a single worksheet
+type Sheet struct {
+	name         string
+	rows         [][]Cell
+	widths       []float64
+	freezeHeader bool
+}
+
+// New creates an empty workbook
+func New() *Workbook {
+	return &Workbook{}
+}
+
+// AddSheet appends a sheet, adjusting the name to Excel's rules (31 characters, no []:*?/\, unique)
+func (wb *Workbook) AddSheet(name string) *Sheet {
+	sheet := &Sheet{name: wb.uniqueSheetName(sanitizeSheetName(name))}
+	wb.sheets = append(wb.sheets, sheet)
+	return sheet
+}
+
+// Sheets returns the sheets in workbook order
+func (wb *Workbook) Sheets() []*Sheet {
+	return wb.sheets
+}
+
+// Name returns the sheet name as it appears in the workbook
+func (s *Sheet) Name() string {
+	return s.name
+}
+
+// Rows returns the cells added to the sheet
+func (s *Sheet) Rows() [][]Cell {
+	return s.rows
+}
+
+// AddRow appends a row of cells
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+// AddHeader appends a bold row of column titles and freezes it when it is the first row
+func (s *Sheet) AddHeader(titles ...string) {
+	cells := make([]Cell, len(titles))
+	for i, title := range titles {
+		cells[i] = Bold(title)
+	}
+	if len(s.rows) == 0 {
+		s.freezeHeader = true
+	}
+	s.AddRow(cells...)
+}
+
+// SetColumnWidths sets column widths in characters, starting at column A
+func (s *Sheet) SetColumnWidths(widths ...float64) {
+	s.widths = widths
+}
+
+// Write encodes the workbook as an XLSX (zip) document
+func (wb *Workbook) Write(w io.Writer) error {
+	if len(wb.sheets) == 0 {
+		wb.AddSheet("Sheet1")
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", wb.contentTypesXML()},
+		{"_rels/.rels", []byte(rootRelsXML)},
+		{"xl/workbook.xml", wb.workbookXML()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRelsXML()},
+		{"xl/styles.xml", []byte(stylesXML)},
+	}
+	for i, sheet := range wb.sheets {
+		parts = append(parts, struct {
+			name    string
+			content []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := fw.Write(part.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish workbook: %w", err)
+	}
+	return nil
+}
+
+func (wb *Workbook) uniqueSheetName(name string) string {
+	taken := func(candidate string) bool {
+		for _, sheet := range wb.sheets {
+			if strings.EqualFold(sheet.name, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+
+	candidate := name
+	for n := 2; taken(candidate); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		base := name
+		if len(base)+len(suffix) > maxSheetNameLength {
+			base = base[:maxSheetNameLength-len(suffix)]
+		}
+		candidate = base + suffix
+	}
+	return candidate
+}
+
+func sanitizeSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidSheetChars, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Sheet"
+	}
+	if len(name) > maxSheetNameLength {
+		name = name[:maxSheetNameLength]
+	}
+	return name
+}
+
+// ColumnName converts a zero-based column index to its letter name (0 = A, 26 = AA)
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// DateSerial converts a time to an Excel serial date number
+func DateSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(excelEpoch).Hours() / 24
+}
+
+func (wb *Workbook) contentTypesXML() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.Bytes()
+}
+
+func (wb *Workbook) workbookXML() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range wb.sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(sheet.name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.Bytes()
+}
+
+func (wb *Workbook) workbookRelsXML() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+func (s *Sheet) xml() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if s.freezeHeader {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			writeCell(&b, fmt.Sprintf("%s%d", ColumnName(c), r+1), cell)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes()
+}
+
+func writeCell(b *bytes.Buffer, ref string, cell Cell) {
+	style := ""
+	if cell.Style != StyleDefault {
+		style = fmt.Sprintf(` s="%d"`, cell.Style)
+	}
+
+	switch v := cell.Value.(type) {
+	case nil:
+		if style != "" {
+			fmt.Fprintf(b, `<c r="%s"%s/>`, ref, style)
+		}
+	case string:
+		fmt.Fprintf(b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		xml.EscapeText(b, []byte(v))
+		b.WriteString(`</t></is></c>`)
+	case float64:
+		fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+	case int:
+		fmt.Fprintf(b, `<c r="%s"%s><v>%d</v></c>`, ref, style, v)
+	case time.Time:
+		fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(DateSerial(v), 'f', -1, 64))
+	default:
+		fmt.Fprintf(b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		xml.EscapeText(b, []byte(fmt.Sprint(v)))
+		b.WriteString(`</t></is></c>`)
+	}
+}
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// stylesXML defines the cellXfs in the same order as the Style constants
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2">` +
+	`<numFmt numFmtId="164" formatCode="&quot;$&quot;#,##0.00;[Red]\-&quot;$&quot;#,##0.00"/>` +
+	`<numFmt numFmtId="165" formatCode="yyyy\-mm\-dd"/>` +
+	`</numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="7">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+	`<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
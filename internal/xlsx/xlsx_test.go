@@ -0,0 +1,135 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for index, want := range tests {
+		if got := ColumnName(index); got != want {
+			t.Errorf("ColumnName(%d) = %s, want %s", index, got, want)
+		}
+	}
+}
+
+func TestDateSerial(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want float64
+	}{
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 61},
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 45658},
+		{time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), 45658.5},
+		// Wall-clock date is kept regardless of location
+		{time.Date(2025, 1, 1, 0, 0, 0, 0, time.FixedZone("EST", -5*3600)), 45658},
+	}
+	for _, tt := range tests {
+		if got := DateSerial(tt.date); got != tt.want {
+			t.Errorf("DateSerial(%v) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestAddSheetNames(t *testing.T) {
+	wb := New()
+	names := []string{
+		wb.AddSheet("Premiums").Name(),
+		wb.AddSheet("premiums").Name(),
+		wb.AddSheet("Puts/Calls [2025]").Name(),
+		wb.AddSheet(strings.Repeat("x", 40)).Name(),
+		wb.AddSheet(strings.Repeat("x", 40)).Name(),
+		wb.AddSheet("  ").Name(),
+	}
+	want := []string{
+		"Premiums",
+		"premiums (2)",
+		"Puts-Calls -2025-",
+		strings.Repeat("x", 31),
+		strings.Repeat("x", 27) + " (2)",
+		"Sheet",
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("sheet %d named %q, want %q", i, names[i], want[i])
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	wb := New()
+	sheet := wb.AddSheet("Report")
+	sheet.SetColumnWidths(12, 14)
+	sheet.AddHeader("Ticker", "Amount")
+	sheet.AddRow(Text("AT&T <T>"), Currency(-12.5))
+	sheet.AddRow(Date(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)), Percent(12.5))
+	sheet.AddRow(Cell{}, Integer(3))
+	wb.AddSheet("Empty")
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Workbook is not a valid zip: %v", err)
+	}
+
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+
+		// Every part must be well-formed XML
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Workbook is missing %s", name)
+		}
+	}
+
+	sheetXML := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<col min="2" max="2" width="14" customWidth="1"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Ticker</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">AT&amp;T &lt;T&gt;</t></is></c>`,
+		`<c r="B2" s="2"><v>-12.5</v></c>`,
+		`<c r="A3" s="5"><v>45658</v></c>`,
+		`<c r="B3" s="4"><v>0.125</v></c>`,
+		`<row r="4"><c r="B4" s="6"><v>3</v></c></row>`,
+	} {
+		if !strings.Contains(sheetXML, want) {
+			t.Errorf("sheet1.xml missing %s", want)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Empty" sheetId="2" r:id="rId2"/>`) {
+		t.Errorf("workbook.xml does not list the second sheet: %s", parts["xl/workbook.xml"])
+	}
+	if !strings.Contains(parts["xl/_rels/workbook.xml.rels"], `Id="rId3"`) {
+		t.Errorf("styles relationship should follow the sheet relationships")
+	}
+}
@@ -1,36 +1,175 @@
+// Wheeler tracks a wheel strategy portfolio. Run without a command it starts the web
+// application; the other commands do one task from the command line so cron jobs and
+// scripts can drive Wheeler without a browser. Run "wheeler help" for the list.
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"stonks/internal/config"
 	"stonks/internal/web"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// command is a wheeler subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+// commands is filled in by init to let the help command list it
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "serve [--addr :8080] [--data-dir ./data] [--config wheeler.toml] [--dev-dir .]", "Start the web application (the default)", serveCommand},
+		{"import", "import [flags] options|stocks|dividends|treasuries <file>", "Import a CSV file as one import batch", importCommand},
+		{"export", "export [--format zip|ledger|beancount] [--out file]", "Export the current database", exportCommand},
+		{"backup", "backup", "Back up the current database to the backups directory", backupCommand},
+		{"migrate", "migrate [--all]", "Apply pending schema migrations", migrateCommand},
+		{"snapshot", "snapshot", "Record today's metrics snapshot", snapshotCommand},
+		{"prices", "prices update", "Refresh every symbol's price from the market data provider", pricesCommand},
+		{"report", "report monthly [--from YYYY-MM] [--to YYYY-MM] [--format text|csv]", "Print the monthly income report", reportCommand},
+		{"help", "help", "Show this help", helpCommand},
+	}
+}
+
+// errUsage reports bad arguments after the command's usage has been printed
+var errUsage = errors.New("invalid arguments")
+
 func main() {
+	if err := run(os.Args[1:]); err != nil && err != flag.ErrHelp {
+		if err != errUsage {
+			fmt.Fprintf(os.Stderr, "wheeler: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serveCommand(args)
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	helpCommand(nil)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func helpCommand(args []string) error {
+	fmt.Fprintf(os.Stderr, "Usage: wheeler <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nEvery command accepts --config, --data-dir, --backup-dir, --database, --log-level and --verbose,\n"+
+		"which can also be set with WHEELER_* environment variables or a config file. Run \"wheeler <command> -h\" for its flags.\n")
+	return nil
+}
+
+// options holds the flags every command accepts
+type options struct {
+	flags    *config.Flags
+	verbose  bool
+	defaults config.Config
+	config   *config.Config
+}
+
+// newFlagSet creates a command's flag set with the common flags. Commands other than serve
+// log nothing unless asked to, so their output is just their result.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts := &options{flags: config.AddFlags(fs), defaults: config.Default()}
+	opts.defaults.LogLevel = config.LogOff
+	fs.BoolVar(&opts.verbose, "verbose", false, "show the server log (same as --log-level debug)")
+	fs.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(fs.Output(), "Usage: wheeler %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.summary)
+			}
+		}
+		fs.PrintDefaults()
+	}
+	return fs, opts
+}
+
+// parse parses a command's flags, checks it got between min and max arguments, then loads
+// and applies the configuration
+func (o *options) parse(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		return errUsage
+	}
+	cfg, err := o.flags.Load(o.defaults)
+	if err != nil {
+		return err
+	}
+	if o.verbose {
+		cfg.LogLevel = config.LogDebug
+	}
+	cfg.Apply()
+	o.config = cfg
+	return nil
+}
+
+func serveCommand(args []string) error {
+	fs, opts := newFlagSet("serve")
+	opts.flags.AddServerFlags()
+	opts.defaults.LogLevel = config.LogInfo
+	if err := opts.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	addr := opts.config.Addr
+	if opts.config.File != "" {
+		log.Printf("[CONFIG] Loaded settings from %s", opts.config.File)
+	}
+	web.SetDevDir(opts.config.DevDir)
+
 	// Create web server (it will handle database initialization)
 	server, err := web.NewServer()
 	if err != nil {
-		log.Fatalf("Failed to create web server: %v", err)
+		return fmt.Errorf("failed to create web server: %w", err)
 	}
 
 	// Setup routes
 	server.SetupTestRoutes()
 
+	// Run price refreshes, metric snapshots and backups on their configured schedules
+	server.StartScheduler()
+
 	// Create HTTP server
 	httpServer := &http.Server{
-		Addr:    ":8080",
-		Handler: http.DefaultServeMux,
+		Addr:    addr,
+		Handler: server.Handler(),
+	}
+
+	url := "http://" + addr
+	if strings.HasPrefix(addr, ":") {
+		url = "http://localhost" + addr
 	}
 
 	// Start server in background goroutine
 	go func() {
-		log.Printf("🚀 Wheeler web application starting on http://localhost:8080")
-		log.Printf("   📈 Dashboard:    http://localhost:8080/")
+		log.Printf("🚀 Wheeler web application starting on %s", url)
+		log.Printf("   📈 Dashboard:    %s/", url)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start web server: %v", err)
 		}
@@ -54,11 +193,12 @@ func main() {
 	} else {
 		log.Println("Server gracefully shut down")
 	}
-	
+
 	// Close database connection
 	if err := server.Close(); err != nil {
 		log.Printf("Error closing database: %v", err)
 	} else {
 		log.Println("Database connection closed")
 	}
+	return nil
 }
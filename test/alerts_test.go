@@ -0,0 +1,183 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+type jobRunResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// runServerJob runs a background job now and waits for its run to finish
+func runServerJob(t *testing.T, name string) *jobRunResult {
+	t.Helper()
+	started := time.Now()
+
+	resp, err := http.Post("http://localhost:8081/api/jobs/"+name+"/run", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to start %s: %v", name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected 202 starting %s, got %d", name, resp.StatusCode)
+	}
+
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		resp, err := http.Get("http://localhost:8081/api/jobs")
+		if err != nil {
+			t.Fatalf("Failed to get jobs: %v", err)
+		}
+		var jobs []struct {
+			Name    string `json:"name"`
+			Running bool   `json:"running"`
+			LastRun *struct {
+				jobRunResult
+				StartedAt time.Time `json:"started_at"`
+			} `json:"last_run"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&jobs)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to decode jobs: %v", err)
+		}
+		for _, job := range jobs {
+			if job.Name == name && !job.Running && job.LastRun != nil && !job.LastRun.StartedAt.Before(started.Truncate(time.Second)) {
+				return &job.LastRun.jobRunResult
+			}
+		}
+	}
+	t.Fatalf("Timed out waiting for %s", name)
+	return nil
+}
+
+// TestAlertRules raises an alert for an option near expiration and walks it through the inbox states
+func TestAlertRules(t *testing.T) {
+	db := openServerDatabase(t, "alerts_test.db")
+	models.NewSymbolService(db.DB).Create("VZ")
+	expires := time.Now().AddDate(0, 0, 3)
+	if _, err := models.NewOptionService(db.DB).Create("VZ", "Put", time.Now().AddDate(0, 0, -20), 40, expires, 0.80, 1); err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	resp, err := http.Post("http://localhost:8081/api/alerts/rules", "application/json",
+		strings.NewReader(`{"name": "Expiring", "rule_type": "dte_below", "threshold": 7}`))
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+	var rule struct {
+		ID      int  `json:"id"`
+		Enabled bool `json:"enabled"`
+	}
+	json.NewDecoder(resp.Body).Decode(&rule)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || !rule.Enabled {
+		t.Fatalf("Expected an enabled rule with status 201, got %d %+v", resp.StatusCode, rule)
+	}
+
+	resp, err = http.Post("http://localhost:8081/api/alerts/rules", "application/json",
+		strings.NewReader(`{"name": "Breakout", "rule_type": "price_above", "threshold": 45}`))
+	if err != nil {
+		t.Fatalf("Failed to post invalid rule: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a price rule without a symbol, got %d", resp.StatusCode)
+	}
+
+	if run := runServerJob(t, "alert_check"); run.Status != "success" || run.Message != "Raised 1 new alerts" {
+		t.Fatalf("Unexpected alert check run %+v", run)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/alerts?state=new")
+	if err != nil {
+		t.Fatalf("Failed to get alerts: %v", err)
+	}
+	var alerts []struct {
+		ID       int    `json:"id"`
+		RuleName string `json:"rule_name"`
+		Symbol   string `json:"symbol"`
+		Message  string `json:"message"`
+		State    string `json:"state"`
+	}
+	json.NewDecoder(resp.Body).Decode(&alerts)
+	resp.Body.Close()
+	if len(alerts) != 1 || alerts[0].Symbol != "VZ" || !strings.Contains(alerts[0].Message, "has 3 days left") {
+		t.Fatalf("Expected one VZ expiration alert, got %+v", alerts)
+	}
+	alertID := alerts[0].ID
+
+	resp, err = http.Get("http://localhost:8081/")
+	if err != nil {
+		t.Fatalf("Failed to get dashboard: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "1 new alert") {
+		t.Errorf("Expected the dashboard to show 1 new alert")
+	}
+
+	// Running the check again while the option is still near expiration raises nothing
+	if run := runServerJob(t, "alert_check"); run.Message != "Raised 0 new alerts" {
+		t.Errorf("Expected no new alerts on the second check, got %+v", run)
+	}
+
+	for _, step := range []struct{ action, state string }{
+		{"acknowledge", "acknowledged"},
+		{"snooze", "snoozed"},
+	} {
+		resp, err = http.Post(fmt.Sprintf("http://localhost:8081/api/alerts/%d/%s", alertID, step.action), "application/json", nil)
+		if err != nil {
+			t.Fatalf("Failed to %s alert: %v", step.action, err)
+		}
+		var alert struct {
+			State string `json:"state"`
+		}
+		json.NewDecoder(resp.Body).Decode(&alert)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || alert.State != step.state {
+			t.Errorf("Expected %s to leave the alert %s, got %d %q", step.action, step.state, resp.StatusCode, alert.State)
+		}
+	}
+
+	resp, err = http.Get("http://localhost:8081/alerts")
+	if err != nil {
+		t.Fatalf("Failed to get alerts page: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	for _, want := range []string{"has 3 days left", "snoozed", "DTE at or below 7"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected alerts page to contain %q", want)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:8081/api/alerts/rules/%d", rule.ID), nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete rule: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting the rule, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(fmt.Sprintf("http://localhost:8081/api/alerts/%d/acknowledge", alertID), "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to acknowledge deleted alert: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the rule's alerts to be deleted with it, got %d", resp.StatusCode)
+	}
+}
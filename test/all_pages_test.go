@@ -25,6 +25,12 @@ func TestAllPageRendering(t *testing.T) {
 		{"Settings", "http://localhost:8081/settings"},
 		{"Import", "http://localhost:8081/import"},
 		{"Backup", "http://localhost:8081/backup"},
+		{"Jobs", "http://localhost:8081/jobs"},
+		{"Option Chain", "http://localhost:8081/option-chain"},
+		{"Watchlist", "http://localhost:8081/watchlist"},
+		{"Alerts", "http://localhost:8081/alerts"},
+		{"Digest", "http://localhost:8081/digest"},
+		{"Portfolios", "http://localhost:8081/portfolios"},
 	}
 
 	// Test each main page
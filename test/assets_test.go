@@ -0,0 +1,41 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestEmbeddedAssets checks static files and the example SQL come from the binary rather
+// than the source tree, by requesting them from outside the checkout
+func TestEmbeddedAssets(t *testing.T) {
+	useServerDatabase(t, "assets_test.db")
+	t.Chdir(t.TempDir())
+
+	resp, err := http.Get("http://localhost:8081/static/css/styles.css")
+	if err != nil {
+		t.Fatalf("Failed to get stylesheet: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/css") || len(body) == 0 {
+		t.Errorf("Expected the embedded stylesheet, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if resp, err := http.Get("http://localhost:8081/static/js/missing.js"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing static file, got %v (%v)", resp, err)
+	}
+
+	resp, err = http.Post("http://localhost:8081/api/generate-test-data", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to generate test data: %v", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || resp.StatusCode != http.StatusOK || !result.Success {
+		t.Errorf("Expected test data from the embedded example SQL, got %d (%v)", resp.StatusCode, err)
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestEarlyAssignmentRisk flags an in the money covered call ahead of an ex-dividend date on
+// the options page and through the ex-dividend alert rule
+func TestEarlyAssignmentRisk(t *testing.T) {
+	db := openServerDatabase(t, "assignment_risk_test.db")
+
+	symbols := models.NewSymbolService(db.DB)
+	options := models.NewOptionService(db.DB)
+	symbols.Create("VZ")
+	exDate := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 3)
+	symbols.Update("VZ", 42, 0.6775, &exDate, nil)
+
+	// $2.00 in the money with a $2.15 mark leaves $0.15 of extrinsic value; the $45 call is out of the money
+	atRisk, err := options.Create("VZ", "Call", time.Now().AddDate(0, 0, -20), 40, time.Now().AddDate(0, 0, 10), 1.10, 1)
+	if err == nil {
+		err = options.UpdateCurrentPrice(atRisk.ID, 2.15, time.Now())
+	}
+	if err == nil {
+		_, err = options.Create("VZ", "Call", time.Now().AddDate(0, 0, -20), 45, time.Now().AddDate(0, 0, 10), 0.30, 1)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create calls: %v", err)
+	}
+
+	resp, err := http.Get("http://localhost:8081/options")
+	if err != nil {
+		t.Fatalf("Failed to get options page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if count := strings.Count(string(body), ">Early assignment risk</span>"); count != 1 {
+		t.Errorf("Expected one call flagged for early assignment on the options page, got %d", count)
+	}
+	if !strings.Contains(string(body), "$0.15 of extrinsic value left, less than the $0.68 dividend") {
+		t.Errorf("Expected the flag to explain the extrinsic value and dividend")
+	}
+
+	resp, err = http.Post("http://localhost:8081/api/alerts/rules", "application/json",
+		strings.NewReader(`{"name": "Early assignment", "rule_type": "early_assignment", "threshold": 5}`))
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating rule, got %d", resp.StatusCode)
+	}
+	if run := runServerJob(t, "alert_check"); run.Status != "success" {
+		t.Fatalf("Unexpected alert check run %+v", run)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/alerts?state=new")
+	if err != nil {
+		t.Fatalf("Failed to get alerts: %v", err)
+	}
+	var alerts []struct {
+		Subject string `json:"subject"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&alerts)
+	resp.Body.Close()
+	var assignment []string
+	for _, alert := range alerts {
+		if strings.HasPrefix(alert.Subject, "assignment:") {
+			assignment = append(assignment, alert.Message)
+		}
+	}
+	if len(assignment) != 1 || !strings.Contains(assignment[0], "VZ $40.00 Call") || !strings.Contains(assignment[0], "likely to be assigned early") {
+		t.Errorf("Expected one early assignment alert for the $40 call, got %v", assignment)
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"stonks/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBondPositionImports uploads a TreasuryDirect holdings export and a broker positions file
+func TestBondPositionImports(t *testing.T) {
+	useServerDatabase(t, "bond_import_test.db")
+
+	treasuryDirect := "CUSIP,Security Type,Issue Date,Maturity Date,Interest Rate,Par Amount,Purchase Price\n" +
+		"912797RT6,26-Week Bill,01/02/2025,07/03/2025,0.000%,\"$10,000.00\",\"$9,786.12\"\n" +
+		"91282CJL6,Note,11/30/2023,11/30/2030,4.375%,\"$5,000.00\",\"$4,968.75\"\n" +
+		"912797LB1,,10/03/2024,04/03/2025,,\"$1,000.00\",\"$977.50\"\n"
+	result := uploadImportCSV(t, "/import/upload/treasurydirect", "holdings.csv", []byte(treasuryDirect))
+	if result.ImportedCount != 3 || result.SkippedCount != 0 {
+		t.Errorf("TreasuryDirect import: expected 3 imported, got %d imported %d skipped", result.ImportedCount, result.SkippedCount)
+	}
+
+	// Broker export with a preamble, a stock, a corporate bond, an already imported note and a totals row
+	broker := "\"Positions for account Brokerage ...123 as of 10/01/2025\"\n" +
+		"\n" +
+		"Symbol,Description,Quantity,Price,Unit Cost,Coupon Rate,Maturity Date,Trade Date,Market Value\n" +
+		"912810TM0,US TREASURY BOND 4.000% 11/15/52,2000,89.50,87.25,4.000%,11/15/2052,03/04/2025,\"$1,790.00\"\n" +
+		"AAPL,APPLE INC,10,225.01,190.00,,,03/04/2025,\"$2,250.10\"\n" +
+		"037833AK6,APPLE INC 2.400% 05/03/23,1000,99.10,99.10,2.400%,05/03/2023,03/04/2021,\"$1,000.00\"\n" +
+		"91282CJL6,US TREASURY NOTE 4.375% 11/30/30,5000,100.20,99.375,4.375%,11/30/2030,12/01/2023,\"$5,010.00\"\n" +
+		"91282CAZ4,US TREASURY 11/30/25,1000,99.95,99.80,,11/30/2025,09/02/2025,\"$999.50\"\n" +
+		"Account Total,,,,,,,,\"$10,050.10\"\n"
+	result = uploadImportCSV(t, "/import/upload/bonds", "positions.csv", []byte(broker))
+	if result.ImportedCount != 2 || result.SkippedCount != 2 {
+		t.Errorf("Broker import: expected 2 imported and 2 skipped, got %d imported %d skipped", result.ImportedCount, result.SkippedCount)
+	}
+
+	tests := []struct {
+		cusip        string
+		securityType string
+		coupon       float64
+		buyPrice     float64
+		purchased    string
+		issued       string
+	}{
+		{"912797RT6", models.TreasuryTypeBill, 0, 9786.12, "2025-01-02", "2025-01-02"},
+		{"91282CJL6", models.TreasuryTypeNote, 4.375, 4968.75, "2023-11-30", "2023-11-30"},
+		// No security type, but issued for 26 weeks without a coupon
+		{"912797LB1", models.TreasuryTypeBill, 0, 977.50, "2024-10-03", "2024-10-03"},
+		// The unit cost, not the market price, is the purchase price
+		{"912810TM0", models.TreasuryTypeBond, 4, 1745, "2025-03-04", ""},
+	}
+	for _, tt := range tests {
+		treasury := getTreasury(t, tt.cusip)
+
+		if treasury.GetSecurityType() != tt.securityType || !treasury.HasCouponRate() || treasury.GetCouponRate() != tt.coupon {
+			t.Errorf("%s: expected %s at %.3f%%, got %s at %v", tt.cusip, tt.securityType, tt.coupon, treasury.GetSecurityType(), treasury.CouponRate)
+		}
+		if treasury.BuyPrice != tt.buyPrice || treasury.Purchased.Format("2006-01-02") != tt.purchased {
+			t.Errorf("%s: expected buy price %.2f on %s, got %.2f on %s", tt.cusip, tt.buyPrice, tt.purchased,
+				treasury.BuyPrice, treasury.Purchased.Format("2006-01-02"))
+		}
+		if issued := formatOptionalDate(treasury.IssueDate); issued != tt.issued {
+			t.Errorf("%s: expected issue date %q, got %q", tt.cusip, tt.issued, issued)
+		}
+		if treasury.Yield <= 0 {
+			t.Errorf("%s: expected an estimated yield, got %.3f", tt.cusip, treasury.Yield)
+		}
+	}
+
+	// A note bought three months before maturity is not taken for a bill without an issue date
+	if treasury := getTreasury(t, "91282CAZ4"); treasury.SecurityType != nil || treasury.HasCouponRate() {
+		t.Errorf("91282CAZ4: expected an unknown type and coupon, got %s at %v", treasury.GetSecurityType(), treasury.CouponRate)
+	}
+}
+
+func getTreasury(t *testing.T, cusip string) models.Treasury {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8081/api/treasuries/" + cusip)
+	if err != nil {
+		t.Fatalf("Failed to get treasury %s: %v", cusip, err)
+	}
+	defer resp.Body.Close()
+	var treasury models.Treasury
+	if err := json.NewDecoder(resp.Body).Decode(&treasury); err != nil {
+		t.Fatalf("Failed to decode treasury %s: %v", cusip, err)
+	}
+	return treasury
+}
+
+func formatOptionalDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// TestBondPositionImportRejectsBadCheckDigit checks that the whole file is rejected for an invalid CUSIP
+func TestBondPositionImportRejectsBadCheckDigit(t *testing.T) {
+	useServerDatabase(t, "bond_import_invalid_test.db")
+
+	result := postImportCSV(t, "/import/upload/treasurydirect", "holdings.csv", []byte("CUSIP,Issue Date,Maturity Date,Par Amount,Purchase Price\n"+
+		"912797RT6,01/02/2025,07/03/2025,10000,9786.12\n"+
+		"912797RT1,01/02/2025,07/03/2025,10000,9786.12\n"), nil)
+	if result.Success || !strings.Contains(result.Details, "check digit") {
+		t.Errorf("Expected check digit failure, got success=%v details=%q", result.Success, result.Details)
+	}
+
+	resp, err := http.Get("http://localhost:8081/api/treasuries/912797RT6")
+	if err != nil {
+		t.Fatalf("Failed to get treasury: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Error("Expected the valid row to be rolled back with the rejected file")
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestCalendarFeed checks the iCalendar feed's events, filters and stable UIDs
+func TestCalendarFeed(t *testing.T) {
+	db := openServerDatabase(t, "calendar_feed_test.db")
+
+	symbols := models.NewSymbolService(db.DB)
+	options := models.NewOptionService(db.DB)
+	for _, symbol := range []string{"VZ", "KO"} {
+		symbols.Create(symbol)
+	}
+	exDate := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	symbols.Update("VZ", 42, 0.6775, &exDate, nil)
+	opened := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	put, err := options.Create("VZ", "Put", opened, 40, time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), 0.80, 1)
+	if err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	options.Create("KO", "Call", opened, 65, time.Date(2025, 3, 28, 0, 0, 0, 0, time.UTC), 0.50, 2)
+	models.NewTreasuryService(db.DB).Create("912797KX4", opened, time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), 10000, 4.2, 9800)
+	dividend, err := models.NewDividendService(db.DB).Create("VZ", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 67.75)
+	if err != nil {
+		t.Fatalf("Failed to create dividend: %v", err)
+	}
+
+	getFeed := func(query string) (int, string) {
+		resp, err := http.Get("http://localhost:8081/calendar.ics" + query)
+		if err != nil {
+			t.Fatalf("Failed to get calendar: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusOK && !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/calendar") {
+			t.Errorf("Expected a text/calendar response, got %q", resp.Header.Get("Content-Type"))
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	status, feed := getFeed("")
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if count := strings.Count(feed, "BEGIN:VEVENT"); count != 5 {
+		t.Errorf("Expected 5 events, got %d", count)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("UID:option-%d@wheeler\r\n", put.ID),
+		"SUMMARY:VZ $40.00 Put expires\r\n",
+		"DTSTART;VALUE=DATE:20250321\r\n",
+		"UID:treasury-912797KX4@wheeler\r\n",
+		"DTSTART;VALUE=DATE:20250605\r\n",
+		"UID:exdiv-VZ@wheeler\r\n",
+		"SUMMARY:VZ ex-dividend\r\n",
+		fmt.Sprintf("UID:dividend-%d@wheeler\r\n", dividend.ID),
+		"SUMMARY:VZ dividend paid $67.75\r\n",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected feed to contain %q", want)
+		}
+	}
+
+	// Filters combine, and a symbol filter leaves out treasuries
+	_, feed = getFeed("?type=expiration,maturity&symbol=vz")
+	if strings.Count(feed, "BEGIN:VEVENT") != 1 || !strings.Contains(feed, "SUMMARY:VZ $40.00 Put expires") {
+		t.Errorf("Expected only the VZ put, got:\n%s", feed)
+	}
+	if status, _ := getFeed("?type=earnings"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown type, got %d", status)
+	}
+
+	// Rolling the put keeps its UID, so calendars move the event instead of adding one
+	_, err = options.UpdateByID(put.ID, "VZ", "Put", opened, 40, time.Date(2025, 4, 17, 0, 0, 0, 0, time.UTC), 0.80, 1, put.Commission, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to update put: %v", err)
+	}
+	_, feed = getFeed("?type=expiration&symbol=VZ")
+	if !strings.Contains(feed, fmt.Sprintf("UID:option-%d@wheeler", put.ID)) || !strings.Contains(feed, "DTSTART;VALUE=DATE:20250417") {
+		t.Errorf("Expected the rolled put under the same UID, got:\n%s", feed)
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"stonks/internal/database"
+	"stonks/internal/models"
+)
+
+// TestSwitchDatabaseUnderLoad switches databases while requests are in flight; every
+// request must succeed and the new database must be served as soon as the switch returns
+func TestSwitchDatabaseUnderLoad(t *testing.T) {
+	createTestDatabase(t, "switch_b_test.db")
+	if _, err := models.NewSymbolService(openTestDatabase(t, "switch_b_test.db").DB).Create("SWB"); err != nil {
+		t.Fatalf("Failed to create SWB: %v", err)
+	}
+	if _, err := models.NewSymbolService(openServerDatabase(t, "switch_a_test.db").DB).Create("SWA"); err != nil {
+		t.Fatalf("Failed to create SWA: %v", err)
+	}
+
+	dashboard := func() (int, string, error) {
+		resp, err := http.Get("http://localhost:8081/")
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	stop := make(chan struct{})
+	errs := make(chan error, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if status, _, err := dashboard(); err != nil || status != http.StatusOK {
+					errs <- fmt.Errorf("dashboard returned %d (%v)", status, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		for _, name := range []string{"switch_b_test.db", "switch_a_test.db"} {
+			setServerDatabase(t, name)
+		}
+	}
+	setServerDatabase(t, "switch_b_test.db")
+	_, body, err := dashboard()
+	close(stop)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Request failed during database switches: %v", err)
+	}
+	if err != nil || !strings.Contains(body, "SWB") || strings.Contains(body, "SWA") {
+		t.Errorf("Expected the dashboard to show switch_b_test.db right after switching (%v)", err)
+	}
+	if current, _ := database.GetCurrentDatabase(); current != "switch_b_test.db" {
+		t.Errorf("Expected currentdb to name switch_b_test.db, got %q", current)
+	}
+}
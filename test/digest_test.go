@@ -0,0 +1,125 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestDailyDigest checks the digest page and its text and HTML renderings, then sends it
+// through a file channel with the daily_digest job
+func TestDailyDigest(t *testing.T) {
+	db := openServerDatabase(t, "digest_test.db")
+
+	now := time.Now()
+	// Expirations through Friday count as this week, or through next Friday at the weekend
+	weekEnd := now.AddDate(0, 0, (int(time.Friday)-int(now.Weekday())+7)%7)
+	lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.Local)
+
+	models.NewSymbolService(db.DB).Create("VZ")
+	models.NewSymbolService(db.DB).Create("KO")
+	options := models.NewOptionService(db.DB)
+	// $200 of put premium and $30 of call profit this month, $100 last month, less
+	// the commission charged on closing
+	if _, err := options.CreateWithCommission("VZ", "Put", now, 40, weekEnd, 1.00, 2, 0); err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	call, err := options.CreateWithCommission("KO", "Call", now, 65, now.AddDate(0, 0, 30), 0.50, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to create call: %v", err)
+	}
+	if err := options.CloseByID(call.ID, now, 0.20); err != nil {
+		t.Fatalf("Failed to close call: %v", err)
+	}
+	old, err := options.CreateWithCommission("KO", "Put", lastMonth, 60, lastMonth.AddDate(0, 0, 7), 1.00, 1, 0)
+	if err != nil {
+		t.Fatalf("Failed to create last month's put: %v", err)
+	}
+	options.CloseByID(old.ID, lastMonth.AddDate(0, 0, 7), 0)
+
+	resp, err := http.Post("http://localhost:8081/api/alerts/rules", "application/json",
+		strings.NewReader(`{"name": "Expiring", "rule_type": "dte_below", "threshold": 8}`))
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+	resp.Body.Close()
+	if run := runServerJob(t, "alert_check"); run.Message != "Raised 1 new alerts" {
+		t.Fatalf("Unexpected alert check run %+v", run)
+	}
+
+	get := func(url string) (int, string, string) {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", url, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	ytd := "$328.70"
+	if lastMonth.Year() != now.Year() {
+		ytd = "$229.35"
+	}
+	status, contentType, text := get("http://localhost:8081/digest?format=text")
+	if status != http.StatusOK || !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("Expected a plain text digest, got %d %s", status, contentType)
+	}
+	for _, want := range []string{
+		"Wheeler digest for " + now.Format("Mon Jan 2, 2006") + "\n",
+		"\nExpiring through " + weekEnd.Format("Mon Jan 2") + "\n- VZ $40.00 Put expires " + weekEnd.Format("Mon Jan 2") + ", 2 contracts\n",
+		"\nOpened today\n",
+		"- VZ $40.00 Put expiring " + weekEnd.Format("01/02/2006") + ", 2 contracts for $1.00 premium\n",
+		"- KO $65.00 Call expiring",
+		"\nClosed today\n- KO $65.00 Call for $29.35 profit\n",
+		"- Month to date: $229.35 (last month $99.35)\n",
+		"- Year to date: " + ytd + "\n",
+		"- Deployed: $8000.00 of $8000.00 (100.0%)\n",
+		"\nAlerts fired today\n- Expiring: ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the text digest to contain %q, got:\n%s", want, text)
+		}
+	}
+
+	status, _, html := get("http://localhost:8081/digest?format=html")
+	if status != http.StatusOK || !strings.Contains(html, "<!DOCTYPE html>") || !strings.Contains(html, "$229.35") {
+		t.Errorf("Expected a standalone HTML digest, got %d:\n%s", status, html)
+	}
+	if status, _, _ := get("http://localhost:8081/digest?format=pdf"); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", status)
+	}
+
+	path := filepath.Join(t.TempDir(), "digest.log")
+	resp, err = http.Post("http://localhost:8081/api/notifications/channels", "application/json",
+		strings.NewReader(`{"name": "Digest log", "channel_type": "file", "config": {"path": "`+path+`"}}`))
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	resp.Body.Close()
+
+	if run := runServerJob(t, "daily_digest"); run.Status != "success" || run.Message != "Digest sent to 1 of 1 channels" {
+		t.Fatalf("Unexpected digest run %+v", run)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read digest log: %v", err)
+	}
+	var entry struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Expected one JSON line in the digest log, got %q", data)
+	}
+	if entry.Subject != "Wheeler digest for "+now.Format("Mon Jan 2, 2006") || !strings.Contains(entry.Body, "Closed today\n- KO") {
+		t.Errorf("Unexpected digest delivery %+v", entry)
+	}
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestEarningsFlags enters an earnings date on the symbol modal and checks open options
+// and chain contracts expiring after it are flagged
+func TestEarningsFlags(t *testing.T) {
+	earnings := time.Now().AddDate(0, 0, 10).Format("2006-01-02")
+	expiration := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	db := useFileMarketData(t, "earnings_test.db", map[string]string{
+		"options.csv": "underlying,contract_type,strike,expiration,bid,ask,underlying_price\n" +
+			"VZ,put,40," + expiration + ",0.95,1.05,42\n",
+	}, "VZ")
+
+	expires, _ := time.Parse("2006-01-02", expiration)
+	_, err := models.NewOptionService(db.DB).Create("VZ", "Put", time.Now().AddDate(0, 0, -7), 40, expires, 1.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/api/symbols/VZ", strings.NewReader(`{"earnings_date": "`+earnings+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to save earnings date: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 saving earnings date, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/earnings?symbol=vz")
+	if err != nil {
+		t.Fatalf("Failed to get earnings: %v", err)
+	}
+	var dates []struct {
+		Symbol string    `json:"symbol"`
+		Date   time.Time `json:"date"`
+		Source string    `json:"source"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&dates)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode earnings: %v", err)
+	}
+	if len(dates) != 1 || dates[0].Date.Format("2006-01-02") != earnings || dates[0].Source != "manual" {
+		t.Fatalf("Expected the manual earnings date, got %+v", dates)
+	}
+
+	earningsBadge := "Earnings " + dates[0].Date.Format("01/02")
+	for _, page := range []string{"/options", "/"} {
+		resp, err = http.Get("http://localhost:8081" + page)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", page, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(strings.ToLower(string(body)), strings.ToLower(earningsBadge)) {
+			t.Errorf("Expected %s to flag the open put with %q", page, earningsBadge)
+		}
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/options/chain/VZ")
+	if err != nil {
+		t.Fatalf("Failed to get chain: %v", err)
+	}
+	var chain struct {
+		Contracts []struct {
+			Earnings *struct {
+				Date time.Time `json:"date"`
+			} `json:"earnings"`
+		} `json:"contracts"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&chain)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode chain: %v", err)
+	}
+	if len(chain.Contracts) != 1 || chain.Contracts[0].Earnings == nil {
+		t.Errorf("Expected the chain contract to be flagged for earnings, got %+v", chain)
+	}
+
+	// Clearing the date on the modal removes the flags
+	req, _ = http.NewRequest(http.MethodPut, "http://localhost:8081/api/symbols/VZ", strings.NewReader(`{"earnings_date": ""}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to clear earnings date: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get("http://localhost:8081/options")
+	if err != nil {
+		t.Fatalf("Failed to get options page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(body), earningsBadge) {
+		t.Errorf("Expected no earnings flag after clearing the date")
+	}
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"stonks/internal/export"
+	"strings"
+	"testing"
+)
+
+// exportRoundTripFixtures are uploaded through the importers before the first export
+var exportRoundTripFixtures = []struct {
+	endpoint string
+	filename string
+	content  string
+}{
+	{"/import/upload", export.OptionsFileName, "symbol,opened,closed,type,strike,expiration,premium,contracts,exit_price,commission\n" +
+		"RTRP,2025-01-06,2025-01-24,Put,42.5,2025-02-21,1.15,2,0.2,2.6\n" +
+		"RTRP,2025-02-03,,Call,47,2025-03-21,0.85,1,,0.65\n"},
+	{"/import/upload/stocks", export.StocksFileName, "Symbol,Purchased,Closed,Shares,BuyPrice,ExitPrice\n" +
+		"RTRP,1/24/2025,,2.29,42.5,\n" +
+		"RTRP,6/2/2024,12/20/2024,1,38.125,44\n"},
+	{"/import/upload/dividends", export.DividendsFileName, "Symbol,Date Received,Amount\n" +
+		"RTRP,3/14/2025,$152.33\n"},
+	{"/import/upload/treasuries", export.TreasuriesFileName, "CUSPID,Purchased,Maturity,Amount,Yield,BuyPrice,CurrentValue,ExitPrice,CouponRate,SecurityType,IssueDate\n" +
+		"912797RT1,2025-01-02,2025-07-03,10000,4.31,9786.12,9901.5,,,Bill,2025-01-02\n" +
+		"91282CLR0,2025-01-10,2034-11-15,5000,4.62,4890.5,,,4.25,Note,2024-11-15\n"},
+}
+
+// TestExportRoundTrip exports the database, reverts the original imports, re-imports the
+// exported CSV files and checks that a second export is identical to the first
+func TestExportRoundTrip(t *testing.T) {
+	// Other tests recreate the shared integration database file underneath the server,
+	// so run against a dedicated database and switch back afterwards
+	useServerDatabase(t, "export_roundtrip_test.db")
+
+	var batchIDs []int
+	for _, fixture := range exportRoundTripFixtures {
+		result := uploadImportCSV(t, fixture.endpoint, fixture.filename, []byte(fixture.content))
+		if result.ImportedCount == 0 {
+			t.Fatalf("Expected fixture %s to import rows", fixture.filename)
+		}
+		batchIDs = append(batchIDs, result.BatchID)
+	}
+
+	// Tables without an importer are exported too, with channel passwords left out
+	for _, setup := range []struct{ path, body string }{
+		{"/api/watchlist", `{"symbol": "RTRP", "target_price": 40, "notes": "Wheel under 40"}`},
+		{"/api/alerts/rules", `{"name": "Expiring", "rule_type": "dte_below", "threshold": 7}`},
+		{"/api/notifications/channels", `{"name": "Email", "channel_type": "smtp", "config": {"host": "mail.example.com", ` +
+			`"from": "wheeler@example.com", "to": "me@example.com", "username": "wheeler", "password": "export-secret"}}`},
+	} {
+		resp, err := http.Post("http://localhost:8081"+setup.path, "application/json", strings.NewReader(setup.body))
+		if err != nil {
+			t.Fatalf("Failed to post %s: %v", setup.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected 201 from %s, got %d", setup.path, resp.StatusCode)
+		}
+	}
+
+	first := downloadExport(t)
+
+	doc, err := export.ReadDocument(first.raw)
+	if err != nil {
+		t.Fatalf("Failed to read export document: %v", err)
+	}
+	if doc.Version != export.DocumentVersion || len(doc.Options) != 2 || len(doc.LongPositions) != 2 ||
+		len(doc.Dividends) != 1 || len(doc.Treasuries) != 2 {
+		t.Fatalf("Unexpected export document: version=%d options=%d positions=%d dividends=%d treasuries=%d",
+			doc.Version, len(doc.Options), len(doc.LongPositions), len(doc.Dividends), len(doc.Treasuries))
+	}
+	if len(doc.Config) == 0 || len(doc.ImportBatches) != len(batchIDs) {
+		t.Errorf("Expected the config and import batch tables, got %d config settings and %d batches", len(doc.Config), len(doc.ImportBatches))
+	}
+	if len(doc.Watchlist) != 1 || len(doc.AlertRules) != 1 || len(doc.NotificationChannels) != 1 {
+		t.Errorf("Expected the watchlist, alert rule and channel, got %d, %d and %d",
+			len(doc.Watchlist), len(doc.AlertRules), len(doc.NotificationChannels))
+	} else if doc.NotificationChannels[0].Config["username"] != "wheeler" {
+		t.Errorf("Expected the channel config without its password, got %v", doc.NotificationChannels[0].Config)
+	}
+	for _, name := range []string{export.ConfigFileName, export.ImportBatchesFileName, export.PriceHistoryFileName,
+		export.EarningsFileName, export.WatchlistFileName, export.AlertRulesFileName, export.AlertsFileName, export.ChannelsFileName} {
+		if _, ok := first.files[name]; !ok {
+			t.Errorf("Expected %s in the export bundle", name)
+		}
+	}
+	for name, content := range first.files {
+		if strings.Contains(string(content), "export-secret") {
+			t.Errorf("Expected no channel password in %s", name)
+		}
+	}
+
+	client := &http.Client{}
+	for _, id := range batchIDs {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:8081/api/import/batches/%d", id), nil)
+		if err != nil {
+			t.Fatalf("Failed to create revert request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to revert batch %d: %v", id, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Reverting batch %d returned status %d", id, resp.StatusCode)
+		}
+	}
+
+	for _, fixture := range exportRoundTripFixtures {
+		result := uploadImportCSV(t, fixture.endpoint, fixture.filename, first.files[fixture.filename])
+		if result.SkippedCount != 0 {
+			t.Errorf("Re-importing %s skipped %d rows, expected none after revert", fixture.filename, result.SkippedCount)
+		}
+	}
+
+	second := downloadExport(t)
+	for _, fixture := range exportRoundTripFixtures {
+		if !bytes.Equal(first.files[fixture.filename], second.files[fixture.filename]) {
+			t.Errorf("%s changed after round trip:\nfirst:\n%s\nsecond:\n%s",
+				fixture.filename, first.files[fixture.filename], second.files[fixture.filename])
+		}
+	}
+
+	// The bond details survive the round trip, not just the importer's required columns
+	roundTripped, err := export.ReadDocument(second.raw)
+	if err != nil {
+		t.Fatalf("Failed to read second export document: %v", err)
+	}
+	for _, treasury := range roundTripped.Treasuries {
+		if treasury.CUSPID != "91282CLR0" {
+			continue
+		}
+		if treasury.CouponRate == nil || *treasury.CouponRate != 4.25 || treasury.GetSecurityType() != "Note" ||
+			treasury.IssueDate == nil || treasury.IssueDate.Format("2006-01-02") != "2024-11-15" {
+			t.Errorf("Expected coupon 4.25, type Note and issue date 2024-11-15 after round trip, got %+v", treasury)
+		}
+	}
+}
@@ -1,6 +1,13 @@
 package test
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
 	"testing"
 
@@ -42,3 +49,171 @@ func getSharedTestDB(t *testing.T) *database.DB {
 	
 	return db
 }
+
+
+// removeTestDatabase deletes a database in the data directory together with its WAL and
+// shared-memory files
+func removeTestDatabase(dbName string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove("./data/" + dbName + suffix)
+	}
+}
+
+// createTestDatabase creates a fresh database in the data directory and removes it when the
+// test ends
+func createTestDatabase(t *testing.T, dbName string) {
+	t.Helper()
+
+	removeTestDatabase(dbName)
+	if err := database.CreateNewDatabase(dbName); err != nil {
+		t.Fatalf("Failed to create database %s: %v", dbName, err)
+	}
+	t.Cleanup(func() { removeTestDatabase(dbName) })
+}
+
+// useServerDatabase creates a fresh database and makes it the test server's current database for the test
+func useServerDatabase(t *testing.T, dbName string) {
+	t.Helper()
+
+	createTestDatabase(t, dbName)
+	setServerDatabase(t, dbName)
+
+	t.Cleanup(func() {
+		if _, err := os.Stat(getTestDBPath()); os.IsNotExist(err) {
+			database.CreateNewDatabase(testDBName)
+		}
+		setServerDatabase(t, testDBName)
+	})
+}
+
+// openTestDatabase opens a database in the data directory for the test to seed data; the
+// connection is closed when the test ends
+func openTestDatabase(t *testing.T, dbName string) *database.DB {
+	t.Helper()
+
+	db, err := database.NewDB("./data/" + dbName)
+	if err != nil {
+		t.Fatalf("Failed to open database %s: %v", dbName, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// openServerDatabase makes a fresh database the test server's current database and opens
+// it for the test to seed data
+func openServerDatabase(t *testing.T, dbName string) *database.DB {
+	t.Helper()
+
+	useServerDatabase(t, dbName)
+	return openTestDatabase(t, dbName)
+}
+
+func setServerDatabase(t *testing.T, dbName string) {
+	resp, err := http.PostForm("http://localhost:8081/database/set-current", url.Values{"database": {dbName}})
+	if err != nil {
+		t.Fatalf("Failed to switch server database to %s: %v", dbName, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Switching server database to %s returned status %d", dbName, resp.StatusCode)
+	}
+}
+
+type importResult struct {
+	Success       bool   `json:"success"`
+	ImportedCount int    `json:"imported_count"`
+	SkippedCount  int    `json:"skipped_count"`
+	BatchID       int    `json:"batch_id"`
+	Error         string `json:"error"`
+	Details       string `json:"details"`
+}
+
+// postImportCSV uploads content as the csvFile form field of an import endpoint, along with
+// any extra form fields, and decodes the response whether or not the import succeeded
+func postImportCSV(t *testing.T, endpoint, filename string, content []byte, fields url.Values) importResult {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("csvFile", filename)
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write(content)
+	for key, values := range fields {
+		for _, value := range values {
+			writer.WriteField(key, value)
+		}
+	}
+	writer.Close()
+
+	resp, err := http.Post("http://localhost:8081"+endpoint, writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("Failed to upload %s: %v", filename, err)
+	}
+	defer resp.Body.Close()
+
+	var result importResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode import response for %s: %v", filename, err)
+	}
+	return result
+}
+
+// uploadImportCSV uploads content to an import endpoint and fails the test unless it succeeds
+func uploadImportCSV(t *testing.T, endpoint, filename string, content []byte) importResult {
+	t.Helper()
+
+	result := postImportCSV(t, endpoint, filename, content, nil)
+	if !result.Success {
+		t.Fatalf("Import of %s failed: %s %s", filename, result.Error, result.Details)
+	}
+	return result
+}
+
+type exportBundle struct {
+	raw   []byte
+	files map[string][]byte
+}
+
+func downloadExport(t *testing.T) exportBundle {
+	t.Helper()
+
+	resp, err := http.Get("http://localhost:8081/export")
+	if err != nil {
+		t.Fatalf("Failed to request export: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Export returned status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Expected application/zip, got %s", ct)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		t.Fatalf("Export is not a valid zip: %v", err)
+	}
+
+	bundle := exportBundle{raw: raw, files: map[string][]byte{}}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s in export: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s in export: %v", f.Name, err)
+		}
+		bundle.files[f.Name] = content
+	}
+	return bundle
+}
@@ -76,7 +76,7 @@ func startTestServer() error {
 	// Create HTTP server on test port
 	testServer = &http.Server{
 		Addr:    ":8081",
-		Handler: http.DefaultServeMux,
+		Handler: server.Handler(),
 	}
 
 	// Start server in background
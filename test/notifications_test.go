@@ -0,0 +1,139 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestNotificationChannels sends a test message and a new alert to a webhook stand-in and
+// checks the delivery log
+func TestNotificationChannels(t *testing.T) {
+	db := openServerDatabase(t, "notifications_test.db")
+
+	var mu sync.Mutex
+	var subjects []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Subject string `json:"subject"`
+			Text    string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		subjects = append(subjects, payload.Subject)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	postChannel := func(body string) (*http.Response, map[string]interface{}) {
+		resp, err := http.Post("http://localhost:8081/api/notifications/channels", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+		defer resp.Body.Close()
+		var channel map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&channel)
+		return resp, channel
+	}
+
+	resp, hook := postChannel(`{"name": "Slack", "channel_type": "webhook", "config": {"url": "` + webhook.URL + `"}}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating webhook channel, got %d", resp.StatusCode)
+	}
+	if resp, _ := postChannel(`{"name": "Email", "channel_type": "smtp", "config": {"from": "a@example.com", "to": "b@example.com"}}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an SMTP channel without a host, got %d", resp.StatusCode)
+	}
+
+	// Passwords are never returned, and a blank password on update keeps the stored one
+	resp, email := postChannel(`{"name": "Email", "channel_type": "smtp", "config": {"host": "127.0.0.1", "port": "1", "from": "a@example.com", "to": "b@example.com", "username": "me", "password": "secret"}}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating SMTP channel, got %d", resp.StatusCode)
+	}
+	if _, ok := email["config"].(map[string]interface{})["password"]; ok {
+		t.Errorf("Expected the SMTP password to be left out of the response")
+	}
+	emailID := int(email["id"].(float64))
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:8081/api/notifications/channels/%d", emailID),
+		strings.NewReader(`{"name": "Email", "channel_type": "smtp", "config": {"host": "127.0.0.1", "port": "1", "from": "a@example.com", "to": "b@example.com", "username": "me"}, "enabled": false}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to update channel: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 disabling SMTP channel, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(fmt.Sprintf("http://localhost:8081/api/notifications/channels/%d/test", int(hook["id"].(float64))), "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to test channel: %v", err)
+	}
+	var delivery models.NotificationDelivery
+	json.NewDecoder(resp.Body).Decode(&delivery)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || delivery.Status != models.DeliverySent || delivery.Attempts != 1 {
+		t.Errorf("Expected the test message to be sent, got %d %+v", resp.StatusCode, delivery)
+	}
+
+	stored, err := models.NewNotificationChannelService(db.DB).GetByID(emailID)
+	if err != nil || stored.Config["password"] != "secret" || stored.Enabled {
+		t.Errorf("Expected the disabled SMTP channel to keep its password, got %+v (%v)", stored, err)
+	}
+	models.NewSymbolService(db.DB).Create("VZ")
+	_, err = models.NewOptionService(db.DB).Create("VZ", "Put", time.Now().AddDate(0, 0, -20), 40, time.Now().AddDate(0, 0, 3), 0.80, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	resp, err = http.Post("http://localhost:8081/api/alerts/rules", "application/json",
+		strings.NewReader(`{"name": "Expiring", "rule_type": "dte_below", "threshold": 7}`))
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+	resp.Body.Close()
+
+	// Only the enabled webhook is notified
+	if run := runServerJob(t, "alert_check"); run.Message != "Raised 1 new alerts, notified 1 of 1 channels" {
+		t.Errorf("Unexpected alert check run %+v", run)
+	}
+	mu.Lock()
+	got := strings.Join(subjects, "|")
+	mu.Unlock()
+	if got != "Wheeler test notification|Wheeler alert: Expiring" {
+		t.Errorf("Expected the webhook to receive the test and the alert, got %q", got)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/notifications/deliveries")
+	if err != nil {
+		t.Fatalf("Failed to get deliveries: %v", err)
+	}
+	var deliveries []models.NotificationDelivery
+	json.NewDecoder(resp.Body).Decode(&deliveries)
+	resp.Body.Close()
+	if len(deliveries) != 2 || deliveries[0].Subject != "Wheeler alert: Expiring" || deliveries[0].ChannelName != "Slack" {
+		t.Errorf("Expected the alert delivery first in the log, got %+v", deliveries)
+	}
+
+	resp, err = http.Get("http://localhost:8081/settings")
+	if err != nil {
+		t.Fatalf("Failed to get settings page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{"Delivery Log", "POST to " + webhook.URL, "Email to b@example.com via 127.0.0.1", "Wheeler alert: Expiring"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected settings page to contain %q", want)
+		}
+	}
+	if strings.Contains(string(body), "secret") {
+		t.Errorf("Expected the settings page not to include the SMTP password")
+	}
+}
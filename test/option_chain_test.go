@@ -0,0 +1,120 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chainContract struct {
+	Underlying string  `json:"underlying"`
+	OCCSymbol  string  `json:"occ_symbol"`
+	Strike     float64 `json:"strike"`
+	DTE        int     `json:"dte"`
+	AROI       float64 `json:"aroi"`
+	PercentOTM float64 `json:"percent_otm"`
+}
+
+// TestOptionChainAndScreener reads chains from the drop folder and ranks puts across symbols
+func TestOptionChainAndScreener(t *testing.T) {
+	// Expirations relative to today so DTE filters keep working
+	soon := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	later := time.Now().AddDate(0, 0, 90).Format("2006-01-02")
+	useFileMarketData(t, "option_chain_test.db", map[string]string{
+		"options.csv": "underlying,contract_type,strike,expiration,bid,ask,delta,implied_volatility,open_interest,underlying_price\n" +
+			"VZ,put,40," + soon + ",0.95,1.05,-0.22,0.21,500,42\n" +
+			"VZ,put,38," + soon + ",0.40,0.50,-0.12,0.23,300,42\n" +
+			"VZ,put,40," + later + ",1.80,1.90,-0.28,0.20,150,42\n" +
+			"VZ,call,44," + soon + ",0.45,0.55,0.20,0.19,250,42\n" +
+			"KO,put,60," + soon + ",1.95,2.05,-0.25,0.18,900,63\n" +
+			"KO,put,62," + soon + ",2.90,3.10,-0.45,0.17,50,63\n",
+	}, "VZ", "KO", "T")
+
+	resp, err := http.Get("http://localhost:8081/api/options/chain/vz?type=put")
+	if err != nil {
+		t.Fatalf("Failed to get chain: %v", err)
+	}
+	var chain struct {
+		Symbol          string          `json:"symbol"`
+		UnderlyingPrice float64         `json:"underlying_price"`
+		Contracts       []chainContract `json:"contracts"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&chain)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode chain: %v", err)
+	}
+	if chain.Symbol != "VZ" || chain.UnderlyingPrice != 42 || len(chain.Contracts) != 3 {
+		t.Fatalf("Expected 3 VZ puts at 42, got %+v", chain)
+	}
+	// Listed by expiration then strike
+	if first := chain.Contracts[0]; first.Strike != 38 || first.DTE != 30 {
+		t.Errorf("Expected the nearest, lowest strike first, got %+v", first)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/options/screener?max_dte=45&max_delta=0.3&min_oi=100")
+	if err != nil {
+		t.Fatalf("Failed to run screener: %v", err)
+	}
+	var screen struct {
+		Symbols   []string          `json:"symbols"`
+		Matches   int               `json:"matches"`
+		Contracts []chainContract   `json:"contracts"`
+		Failures  map[string]string `json:"failures"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&screen)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode screen: %v", err)
+	}
+	if len(screen.Symbols) != 3 || screen.Matches != 3 {
+		t.Fatalf("Expected 3 puts matched across 3 symbols, got %+v", screen)
+	}
+	// KO 60 put: $200 on $6,000 over 30 days beats both VZ puts
+	if best := screen.Contracts[0]; best.Underlying != "KO" || best.Strike != 60 || best.AROI < 40 || best.AROI > 41 {
+		t.Errorf("Expected the KO 60 put ranked first at about 40.6%% AROI, got %+v", best)
+	}
+	if _, ok := screen.Failures["T"]; !ok {
+		t.Errorf("Expected T to be reported as having no chain, got %v", screen.Failures)
+	}
+
+	// The watchlist scope screens only watched symbols
+	resp, err = http.Post("http://localhost:8081/api/watchlist", "application/json", strings.NewReader(`{"symbol": "KO"}`))
+	if err != nil {
+		t.Fatalf("Failed to add to watchlist: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get("http://localhost:8081/api/options/screener?scope=watchlist&max_dte=45&max_delta=0.3&min_oi=100")
+	if err != nil {
+		t.Fatalf("Failed to run screener: %v", err)
+	}
+	screen.Contracts = nil
+	err = json.NewDecoder(resp.Body).Decode(&screen)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode screen: %v", err)
+	}
+	if len(screen.Symbols) != 1 || screen.Symbols[0] != "KO" || len(screen.Contracts) != 1 || screen.Contracts[0].Underlying != "KO" {
+		t.Errorf("Expected only the watched KO put, got %+v", screen)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/options/screener?scope=portfolio")
+	if err != nil {
+		t.Fatalf("Failed to run screener: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid scope, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/options/screener?type=straddle")
+	if err != nil {
+		t.Fatalf("Failed to run screener: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid type, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestOptionMarksJob marks an open put from the drop folder and flags it on the options page
+func TestOptionMarksJob(t *testing.T) {
+	expiration := time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	db := useFileMarketData(t, "option_marks_test.db", map[string]string{
+		"options.csv": "underlying,contract_type,strike,expiration,bid,ask\n" +
+			"VZ,put,40," + expiration + ",0.20,0.30\n",
+	}, "VZ")
+
+	expires, _ := time.Parse("2006-01-02", expiration)
+	_, err := models.NewOptionService(db.DB).Create("VZ", "Put", time.Now().AddDate(0, 0, -7), 40, expires, 1.00, 1)
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	resp, err := http.Post("http://localhost:8081/api/jobs/option_marks/run", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to start option marks: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected 202 starting option marks, got %d", resp.StatusCode)
+	}
+
+	var job struct {
+		Name    string `json:"name"`
+		Running bool   `json:"running"`
+		LastRun *struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"last_run"`
+	}
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		resp, err := http.Get("http://localhost:8081/api/jobs")
+		if err != nil {
+			t.Fatalf("Failed to get jobs: %v", err)
+		}
+		var jobs []json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&jobs)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Failed to decode jobs: %v", err)
+		}
+		for _, raw := range jobs {
+			json.Unmarshal(raw, &job)
+			if job.Name == "option_marks" {
+				break
+			}
+		}
+		if !job.Running && job.LastRun != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for option marks")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if job.LastRun.Status != "success" || job.LastRun.Message != "Marked 1 open options, 0 failed" {
+		t.Fatalf("Unexpected option marks run %+v", job.LastRun)
+	}
+
+	resp, err = http.Get("http://localhost:8081/options")
+	if err != nil {
+		t.Fatalf("Failed to get options page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	// Sold at 1.00 and marked at 0.25: $75 of $100 less $0.65 commission
+	for _, want := range []string{"$0.25", "74%", "Consider closing"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected options page to contain %q", want)
+		}
+	}
+}
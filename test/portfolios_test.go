@@ -0,0 +1,109 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"stonks/internal/models"
+)
+
+// TestConsolidatedPortfolios combines the current database with a second one opened
+// read-only and checks totals, monthly income and exposure per portfolio and combined
+func TestConsolidatedPortfolios(t *testing.T) {
+	now := time.Now()
+	expiration := now.AddDate(0, 0, 30)
+
+	// $4,000 of VZ put collateral and $100 premium in the current database
+	db := openServerDatabase(t, "portfolios_a_test.db")
+	models.NewSymbolService(db.DB).Create("VZ")
+	if _, err := models.NewOptionService(db.DB).CreateWithCommission("VZ", "Put", now, 40, expiration, 1.00, 1, 0); err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+
+	// $10,000 of VZ put collateral, $100 premium and $6,000 of KO stock in the other
+	createTestDatabase(t, "portfolios_b_test.db")
+	other := openTestDatabase(t, "portfolios_b_test.db")
+	models.NewSymbolService(other.DB).Create("VZ")
+	models.NewSymbolService(other.DB).Create("KO")
+	if _, err := models.NewOptionService(other.DB).CreateWithCommission("VZ", "Put", now, 50, expiration, 0.50, 2, 0); err != nil {
+		t.Fatalf("Failed to create put: %v", err)
+	}
+	if _, err := models.NewLongPositionService(other.DB).Create("KO", now, 100, 60); err != nil {
+		t.Fatalf("Failed to create long position: %v", err)
+	}
+	other.Close()
+	before, err := os.Stat("./data/portfolios_b_test.db")
+	if err != nil {
+		t.Fatalf("Failed to stat second database: %v", err)
+	}
+
+	resp, err := http.Get("http://localhost:8081/api/portfolios?db=portfolios_b_test.db&db=portfolios_a_test.db")
+	if err != nil {
+		t.Fatalf("Failed to get consolidated view: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	var data struct {
+		Portfolios []struct {
+			Name        string  `json:"name"`
+			Current     bool    `json:"current"`
+			Error       string  `json:"error"`
+			TotalIncome float64 `json:"totalIncome"`
+			Totals      struct {
+				TotalLong float64 `json:"totalLong"`
+				TotalPuts float64 `json:"totalPuts"`
+			} `json:"totals"`
+		} `json:"portfolios"`
+		Totals struct {
+			TotalLong        float64 `json:"totalLong"`
+			TotalPuts        float64 `json:"totalPuts"`
+			TotalPutPremiums float64 `json:"totalPutPremiums"`
+		} `json:"totals"`
+		Months   []string  `json:"months"`
+		Income   []float64 `json:"income"`
+		Exposure []struct {
+			Ticker      string    `json:"ticker"`
+			ByPortfolio []float64 `json:"byPortfolio"`
+			Total       float64   `json:"total"`
+		} `json:"exposure"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatalf("Failed to decode consolidated view: %v", err)
+	}
+
+	if len(data.Portfolios) != 2 || data.Portfolios[0].Name != "portfolios_a_test.db" || !data.Portfolios[0].Current ||
+		data.Portfolios[1].Name != "portfolios_b_test.db" || data.Portfolios[1].Error != "" {
+		t.Fatalf("Expected the current database first, then the other, got %+v", data.Portfolios)
+	}
+	if a, b := data.Portfolios[0].Totals, data.Portfolios[1].Totals; a.TotalPuts != 4000 || b.TotalPuts != 10000 || b.TotalLong != 6000 {
+		t.Errorf("Unexpected per-portfolio totals %+v and %+v", a, b)
+	}
+	if data.Totals.TotalPuts != 14000 || data.Totals.TotalLong != 6000 || data.Totals.TotalPutPremiums != 200 {
+		t.Errorf("Unexpected combined totals %+v", data.Totals)
+	}
+	if len(data.Months) != 1 || data.Months[0] != now.Format("2006-01") || data.Income[0] != 200 ||
+		data.Portfolios[0].TotalIncome != 100 || data.Portfolios[1].TotalIncome != 100 {
+		t.Errorf("Expected $100 of income in each portfolio this month, got %v %v %+v", data.Months, data.Income, data.Portfolios)
+	}
+	if len(data.Exposure) != 2 || data.Exposure[0].Ticker != "VZ" || data.Exposure[0].Total != 14000 ||
+		data.Exposure[0].ByPortfolio[0] != 4000 || data.Exposure[1].Ticker != "KO" || data.Exposure[1].ByPortfolio[1] != 6000 {
+		t.Errorf("Unexpected exposure %+v", data.Exposure)
+	}
+
+	// The other database is only read
+	if after, err := os.Stat("./data/portfolios_b_test.db"); err != nil || !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("Expected the other database to be left untouched")
+	}
+
+	if resp, err := http.Get("http://localhost:8081/api/portfolios?db=missing.db"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown database, got %v (%v)", resp, err)
+	}
+	if resp, err := http.Get("http://localhost:8081/portfolios?from=2025-13&to=2025-12"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid month, got %v (%v)", resp, err)
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestPriceHistoryImport uploads a single-ticker download and reads it back as chart data
+func TestPriceHistoryImport(t *testing.T) {
+	useServerDatabase(t, "price_history_test.db")
+
+	// Yahoo-style export: no symbol column, Adj Close alongside Close and a missing day
+	prices := "Date,Open,High,Low,Close,Adj Close,Volume\n" +
+		"2025-03-03,40.00,40.50,39.80,40.10,39.60,1200000\n" +
+		"2025-03-04,40.10,40.70,40.00,40.55,40.05,1100000\n" +
+		"2025-03-05,null,null,null,null,null,null\n" +
+		"2025-03-06,40.60,41.20,40.40,41.02,40.51,1300000\n"
+
+	result := postImportCSV(t, "/import/upload/prices", "VZ.csv", []byte(prices), url.Values{"symbol": {"vz"}})
+	if !result.Success || result.ImportedCount != 3 || result.SkippedCount != 1 {
+		t.Fatalf("Expected 3 imported and 1 skipped, got %+v", result)
+	}
+
+	resp, err := http.Get("http://localhost:8081/api/symbols/VZ/price-history?from=2025-03-04")
+	if err != nil {
+		t.Fatalf("Failed to get price history: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var chart struct {
+		Symbol string `json:"symbol"`
+		Prices []struct {
+			Date  string  `json:"date"`
+			Close float64 `json:"close"`
+		} `json:"prices"`
+		Strikes []interface{} `json:"strikes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&chart); err != nil {
+		t.Fatalf("Failed to decode chart data: %v", err)
+	}
+	if chart.Symbol != "VZ" || len(chart.Prices) != 2 {
+		t.Fatalf("Expected 2 VZ prices from March 4, got %s with %d", chart.Symbol, len(chart.Prices))
+	}
+	if chart.Prices[0].Date != "2025-03-04" || chart.Prices[0].Close != 40.55 {
+		t.Errorf("Expected the Close column rather than Adj Close, got %+v", chart.Prices[0])
+	}
+	if chart.Strikes == nil {
+		t.Errorf("Expected an empty strikes list rather than null")
+	}
+}
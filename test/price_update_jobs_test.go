@@ -0,0 +1,143 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stonks/internal/database"
+	"stonks/internal/models"
+)
+
+type priceUpdateEvent struct {
+	Type      string `json:"type"`
+	Symbol    string `json:"symbol"`
+	Success   bool   `json:"success"`
+	Done      int    `json:"done"`
+	Remaining int    `json:"remaining"`
+	Total     int    `json:"total"`
+	Updated   int    `json:"updated"`
+	Failed    int    `json:"failed"`
+	Status    string `json:"status"`
+}
+
+// useFileMarketData switches the server to a new database reading market data from a
+// drop folder holding files, with the given symbols already created, and returns a
+// connection to it for seeding more data
+func useFileMarketData(t *testing.T, dbName string, files map[string]string, symbols ...string) *database.DB {
+	t.Helper()
+	db := openServerDatabase(t, dbName)
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	settingService := models.NewSettingService(db.DB)
+	symbolService := models.NewSymbolService(db.DB)
+	settingService.SetValue("MARKET_DATA_PROVIDER", "file", "")
+	settingService.SetValue("MARKET_DATA_DIR", dir, "")
+	for _, symbol := range symbols {
+		if _, err := symbolService.Create(symbol); err != nil {
+			t.Fatalf("Failed to create %s: %v", symbol, err)
+		}
+	}
+	return db
+}
+
+// TestBulkPriceUpdateJob starts a background price update from the drop folder and follows its event stream
+func TestBulkPriceUpdateJob(t *testing.T) {
+	useFileMarketData(t, "price_update_jobs_test.db", map[string]string{
+		"quotes.csv": "symbol,price\nVZ,41.37\nT,27.10\n",
+	}, "VZ", "T", "KO")
+
+	resp, err := http.Post("http://localhost:8081/api/polygon/update-prices", "application/json", strings.NewReader(`{"all": true}`))
+	if err != nil {
+		t.Fatalf("Failed to start price update: %v", err)
+	}
+	var started struct {
+		Success   bool   `json:"success"`
+		JobID     string `json:"job_id"`
+		Total     int    `json:"total"`
+		StatusURL string `json:"status_url"`
+		EventsURL string `json:"events_url"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&started)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode start response: %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted || !started.Success || started.JobID == "" || started.Total != 3 {
+		t.Fatalf("Expected a job for 3 symbols with status 202, got %d %+v", resp.StatusCode, started)
+	}
+
+	// The stream replays every event from the start and closes after the done event
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err = client.Get("http://localhost:8081" + started.EventsURL)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %q", ct)
+	}
+	var events []priceUpdateEvent
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event priceUpdateEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	resp.Body.Close()
+
+	if len(events) != 4 {
+		t.Fatalf("Expected 3 progress events and a done event, got %+v", events)
+	}
+	if first := events[0]; first.Type != "progress" || first.Done != 1 || first.Remaining != 2 {
+		t.Errorf("Unexpected first event %+v", first)
+	}
+	last := events[3]
+	if last.Type != "done" || last.Status != "completed" || last.Updated != 2 || last.Failed != 1 || last.Remaining != 0 {
+		t.Errorf("Expected completed with 2 updated and 1 failed, got %+v", last)
+	}
+
+	resp, err = http.Get("http://localhost:8081" + started.StatusURL)
+	if err != nil {
+		t.Fatalf("Failed to get job status: %v", err)
+	}
+	var status struct {
+		Status  string   `json:"status"`
+		Updated int      `json:"updated"`
+		Failed  int      `json:"failed"`
+		Errors  []string `json:"errors"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode job status: %v", err)
+	}
+	if status.Status != "completed" || status.Updated != 2 || status.Failed != 1 || len(status.Errors) != 1 || !strings.HasPrefix(status.Errors[0], "KO:") {
+		t.Errorf("Unexpected job status %+v", status)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/polygon/update-prices/unknown")
+	if err != nil {
+		t.Fatalf("Failed to get unknown job: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", resp.StatusCode)
+	}
+}
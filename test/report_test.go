@@ -0,0 +1,61 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"stonks/internal/xlsx"
+	"strings"
+	"testing"
+)
+
+// TestXLSXReports downloads the monthly and symbol spreadsheets and checks their sheets
+func TestXLSXReports(t *testing.T) {
+	useServerDatabase(t, "xlsx_report_test.db")
+
+	for _, fixture := range exportRoundTripFixtures[:3] {
+		uploadImportCSV(t, fixture.endpoint, fixture.filename, []byte(fixture.content))
+	}
+
+	tests := []struct {
+		url    string
+		sheets []string
+	}{
+		{"/monthly/export.xlsx?from=2024-06&to=2025-05", []string{"Premiums", "Puts by Month", "Dividends by Ticker", "Long Gains by Month"}},
+		{"/api/symbols/RTRP/export.xlsx", []string{"Summary", "Options", "Stock Positions", "Dividends", "Monthly Results"}},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get("http://localhost:8081" + tt.url)
+		if err != nil {
+			t.Fatalf("Failed to request %s: %v", tt.url, err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s returned status %d: %s", tt.url, resp.StatusCode, raw)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != xlsx.ContentType {
+			t.Errorf("%s: expected %s, got %s", tt.url, xlsx.ContentType, ct)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+		if err != nil {
+			t.Fatalf("%s is not a valid workbook: %v", tt.url, err)
+		}
+		workbook, err := zr.Open("xl/workbook.xml")
+		if err != nil {
+			t.Fatalf("%s has no workbook part: %v", tt.url, err)
+		}
+		content, _ := io.ReadAll(workbook)
+		workbook.Close()
+
+		for _, sheet := range tt.sheets {
+			if !strings.Contains(string(content), `name="`+sheet+`"`) {
+				t.Errorf("%s is missing sheet %q", tt.url, sheet)
+			}
+		}
+	}
+}
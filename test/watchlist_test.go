@@ -0,0 +1,137 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type watchlistEntry struct {
+	Symbol           string   `json:"symbol"`
+	Price            float64  `json:"price"`
+	TargetPrice      *float64 `json:"target_price"`
+	Notes            string   `json:"notes"`
+	Yield            float64  `json:"yield"`
+	EntryPrice       *float64 `json:"entry_price"`
+	DistanceToTarget *float64 `json:"distance_to_target"`
+	AtTarget         bool     `json:"at_target"`
+}
+
+// TestWatchlist adds a ticker with no position, prices it with the bulk price update
+// and checks its distance to target
+func TestWatchlist(t *testing.T) {
+	useFileMarketData(t, "watchlist_test.db", map[string]string{
+		"quotes.csv": "symbol,price\nKO,62.50\n",
+	})
+
+	resp, err := http.Post("http://localhost:8081/api/watchlist", "application/json",
+		strings.NewReader(`{"symbol": "ko", "target_price": 60, "notes": "Wheel under 60"}`))
+	if err != nil {
+		t.Fatalf("Failed to add to watchlist: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 adding to watchlist, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post("http://localhost:8081/api/watchlist", "application/json", strings.NewReader(`{"symbol": "T", "target_yield": -1}`))
+	if err != nil {
+		t.Fatalf("Failed to post invalid entry: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative target yield, got %d", resp.StatusCode)
+	}
+
+	// A bulk update of every symbol includes watchlist tickers
+	resp, err = http.Post("http://localhost:8081/api/polygon/update-prices", "application/json", strings.NewReader(`{"all": true}`))
+	if err != nil {
+		t.Fatalf("Failed to start price update: %v", err)
+	}
+	var started struct {
+		Total     int    `json:"total"`
+		StatusURL string `json:"status_url"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&started)
+	resp.Body.Close()
+	if err != nil || started.Total != 1 {
+		t.Fatalf("Expected a price update for KO, got %+v (%v)", started, err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		resp, err := http.Get("http://localhost:8081" + started.StatusURL)
+		if err != nil {
+			t.Fatalf("Failed to get job status: %v", err)
+		}
+		var status struct {
+			Status string `json:"status"`
+		}
+		json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if status.Status != "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Price update did not finish")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/watchlist/KO")
+	if err != nil {
+		t.Fatalf("Failed to get watchlist entry: %v", err)
+	}
+	var entry watchlistEntry
+	err = json.NewDecoder(resp.Body).Decode(&entry)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode watchlist entry: %v", err)
+	}
+	if entry.Price != 62.50 || entry.DistanceToTarget == nil || *entry.DistanceToTarget != 4 || entry.AtTarget {
+		t.Errorf("Expected KO at $62.50, 4%% above target, got %+v", entry)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/api/watchlist/KO", strings.NewReader(`{"target_price": 65, "notes": "Sell puts"}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to update watchlist entry: %v", err)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&entry)
+	resp.Body.Close()
+	if err != nil || !entry.AtTarget || entry.Notes != "Sell puts" {
+		t.Errorf("Expected KO at target after raising it, got %+v (%v)", entry, err)
+	}
+
+	resp, err = http.Get("http://localhost:8081/watchlist")
+	if err != nil {
+		t.Fatalf("Failed to get watchlist page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Sell puts") {
+		t.Errorf("Expected the watchlist page to list KO, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, "http://localhost:8081/api/watchlist/KO", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to remove watchlist entry: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 removing KO, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://localhost:8081/api/watchlist")
+	if err != nil {
+		t.Fatalf("Failed to list watchlist: %v", err)
+	}
+	var entries []watchlistEntry
+	err = json.NewDecoder(resp.Body).Decode(&entries)
+	resp.Body.Close()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected an empty watchlist, got %+v (%v)", entries, err)
+	}
+}